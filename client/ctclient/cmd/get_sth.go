@@ -18,7 +18,6 @@ import (
 	"context"
 	"fmt"
 
-	ct "github.com/OlegBabkin/certificate-transparency-go"
 	"github.com/spf13/cobra"
 )
 
@@ -42,7 +41,7 @@ func runGetSTH(ctx context.Context) {
 		exitWithDetails(err)
 	}
 	// Display the STH.
-	when := ct.TimestampToTime(sth.Timestamp)
+	when := sth.TimestampTime()
 	fmt.Printf("%v (timestamp %d): Got STH for %v log (size=%d) at %v, hash %x\n", when, sth.Timestamp, sth.Version, sth.TreeSize, logClient.BaseURI(), sth.SHA256RootHash)
 	fmt.Printf("%v\n", signatureToString(&sth.TreeHeadSignature))
 }
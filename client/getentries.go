@@ -17,19 +17,77 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
+	"iter"
+	"net/http"
 	"strconv"
+	"strings"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 )
 
+// EntriesNotAvailableError is returned by the get-entries methods when the
+// log rejects the request because the start of the requested range is beyond
+// its currently sequenced tree size, e.g. because the entries have been
+// submitted but not integrated yet. Other failures are reported as RspError.
+type EntriesNotAvailableError struct {
+	Start, End int64
+	RspError
+}
+
+// Error formats the EntriesNotAvailableError.
+func (e EntriesNotAvailableError) Error() string {
+	return fmt.Sprintf("entries [%d, %d] not available yet: %v", e.Start, e.End, e.RspError)
+}
+
+// Unwrap returns the underlying RspError.
+func (e EntriesNotAvailableError) Unwrap() error {
+	return e.RspError
+}
+
+// entriesNotAvailableMarkers are fragments of get-entries error responses
+// which indicate that the requested range is beyond the tree size: the first
+// is returned by CTFE itself, the second when it passes through an OutOfRange
+// error from the Trillian backend.
+var entriesNotAvailableMarkers = []string{
+	"need tree size",
+	"code = OutOfRange",
+}
+
+// checkEntriesNotAvailable converts a get-entries RspError to an
+// EntriesNotAvailableError if it indicates that the [start, end] range has
+// not been sequenced yet, and returns any other error unchanged.
+func checkEntriesNotAvailable(err error, start, end int64) error {
+	rspErr, ok := err.(RspError)
+	if !ok || rspErr.StatusCode != http.StatusBadRequest {
+		return err
+	}
+	body := string(rspErr.Body)
+	for _, marker := range entriesNotAvailableMarkers {
+		if strings.Contains(body, marker) {
+			return EntriesNotAvailableError{Start: start, End: end, RspError: rspErr}
+		}
+	}
+	return err
+}
+
 // GetRawEntries exposes the /ct/v1/get-entries result with only the JSON parsing done.
+// If the log reports that the requested range is beyond its tree size, the
+// returned error is an EntriesNotAvailableError.
 func (c *LogClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	resp, _, err := c.GetRawEntriesWithSize(ctx, start, end)
+	return resp, err
+}
+
+// GetRawEntriesWithSize is the same as GetRawEntries, but also returns the
+// size of the get-entries response body in bytes.
+func (c *LogClient) GetRawEntriesWithSize(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, int, error) {
 	if end < 0 {
-		return nil, errors.New("end should be >= 0")
+		return nil, 0, errors.New("end should be >= 0")
 	}
 	if end < start {
-		return nil, errors.New("start should be <= end")
+		return nil, 0, errors.New("start should be <= end")
 	}
 
 	params := map[string]string{
@@ -38,11 +96,12 @@ func (c *LogClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.Ge
 	}
 
 	var resp ct.GetEntriesResponse
-	if _, _, err := c.GetAndParse(ctx, ct.GetEntriesPath, params, &resp); err != nil {
-		return nil, err
+	_, body, err := c.GetAndParse(ctx, ct.GetEntriesPath, params, &resp)
+	if err != nil {
+		return nil, len(body), checkEntriesNotAvailable(err, start, end)
 	}
 
-	return &resp, nil
+	return &resp, len(body), nil
 }
 
 // GetEntries attempts to retrieve the entries in the sequence [start, end] from the CT log server
@@ -66,3 +125,49 @@ func (c *LogClient) GetEntries(ctx context.Context, start, end int64) ([]ct.LogE
 	}
 	return entries, nil
 }
+
+// entriesBatchSize is the number of entries that Entries requests in one go.
+// Logs may return fewer entries than requested, which Entries handles.
+const entriesBatchSize = 1000
+
+// Entries returns an iterator over the entries in the sequence [start, end] of
+// the CT log, parsed as [pre-]certificates. The entries are fetched lazily in
+// batches, re-requesting the remainder if the log returns fewer entries than
+// requested.
+//
+// A failure to parse an individual entry is yielded as an error for that entry,
+// after which the iteration continues. A failure to fetch entries is yielded
+// as the final error of the iteration.
+func (c *LogClient) Entries(ctx context.Context, start, end int64) iter.Seq2[*ct.LogEntry, error] {
+	return func(yield func(*ct.LogEntry, error) bool) {
+		for start <= end {
+			batchEnd := min(end, start+entriesBatchSize-1)
+			resp, err := c.GetRawEntries(ctx, start, batchEnd)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(resp.Entries) == 0 {
+				yield(nil, fmt.Errorf("no entries returned for range [%d, %d]", start, batchEnd))
+				return
+			}
+			if maxCount := batchEnd - start + 1; int64(len(resp.Entries)) > maxCount {
+				resp.Entries = resp.Entries[:maxCount]
+			}
+			for i := range resp.Entries {
+				index := start + int64(i)
+				logEntry, err := ct.LogEntryFromLeaf(index, &resp.Entries[i])
+				if x509.IsFatal(err) {
+					err = fmt.Errorf("failed to parse entry %d: %w", index, err)
+					logEntry = nil
+				} else {
+					err = nil
+				}
+				if !yield(logEntry, err) {
+					return
+				}
+			}
+			start += int64(len(resp.Entries))
+		}
+	}
+}
@@ -20,18 +20,32 @@ package client
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
 	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
 )
 
 // LogClient represents a client for a given CT Log instance
 type LogClient struct {
 	jsonclient.JSONClient
+	roots *rootsCache
+}
+
+// rootsCache holds the last get-roots response, along with the validators
+// needed to make a conditional request for it.
+type rootsCache struct {
+	mu           sync.Mutex
+	etag         string
+	lastModified string
+	roots        []ct.ASN1Cert
 }
 
 // CheckLogClient is an interface that allows (just) checking of various log contents.
@@ -53,7 +67,7 @@ func New(uri string, hc *http.Client, opts jsonclient.Options) (*LogClient, erro
 	if err != nil {
 		return nil, err
 	}
-	return &LogClient{*logClient}, err
+	return &LogClient{JSONClient: *logClient, roots: &rootsCache{}}, err
 }
 
 // RspError represents a server error including HTTP information.
@@ -94,8 +108,18 @@ func (c *LogClient) addChainWithRetry(ctx context.Context, ctype ct.LogEntryType
 		}
 	}
 
+	// The log ID is not covered by the SCT signature, so a response without one
+	// is accepted, but one that is present must be well-formed.
 	var logID ct.LogID
-	copy(logID.KeyID[:], resp.ID)
+	if len(resp.ID) > 0 {
+		if err := logID.FromBytes(resp.ID); err != nil {
+			return nil, RspError{
+				Err:        fmt.Errorf("id is %v", err),
+				StatusCode: httpRsp.StatusCode,
+				Body:       body,
+			}
+		}
+	}
 	sct := &ct.SignedCertificateTimestamp{
 		SCTVersion: resp.SCTVersion,
 		LogID:      logID,
@@ -140,6 +164,16 @@ func (c *LogClient) GetSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
 	return sth, nil
 }
 
+// GetVerifiedSTH retrieves the current STH from the log, as for GetSTH, but
+// fails if the client has no public key for the log, so that the STH
+// signature can't be left unchecked by mistake.
+func (c *LogClient) GetVerifiedSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
+	if c.Verifier == nil {
+		return nil, errors.New("no public key configured for log, can't verify STH signature")
+	}
+	return c.GetSTH(ctx)
+}
+
 // VerifySTHSignature checks the signature in sth, returning any error encountered or nil if verification is
 // successful.
 func (c *LogClient) VerifySTHSignature(sth ct.SignedTreeHead) error {
@@ -179,6 +213,20 @@ func (c *LogClient) GetSTHConsistency(ctx context.Context, first, second uint64)
 	return resp.Consistency, nil
 }
 
+// GetVerifiedConsistencyProof retrieves the consistency proof between two
+// snapshots, and checks it against the given root hashes of the snapshots.
+// Returns an error if the proof can't be retrieved or doesn't verify.
+func (c *LogClient) GetVerifiedConsistencyProof(ctx context.Context, first, second uint64, firstRoot, secondRoot []byte) ([][]byte, error) {
+	pf, err := c.GetSTHConsistency(ctx, first, second)
+	if err != nil {
+		return nil, err
+	}
+	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, first, second, pf, firstRoot, secondRoot); err != nil {
+		return nil, fmt.Errorf("failed to verify consistency proof (%d => %d): %v", first, second, err)
+	}
+	return pf, nil
+}
+
 // GetProofByHash returns an audit path for the hash of an SCT.
 func (c *LogClient) GetProofByHash(ctx context.Context, hash []byte, treeSize uint64) (*ct.GetProofByHashResponse, error) {
 	b64Hash := base64.StdEncoding.EncodeToString(hash)
@@ -195,10 +243,34 @@ func (c *LogClient) GetProofByHash(ctx context.Context, hash []byte, treeSize ui
 }
 
 // GetAcceptedRoots retrieves the set of acceptable root certificates for a log.
+// If the log gave an ETag or Last-Modified header with the previous response,
+// the request is made conditional on the roots having changed, and the
+// previous roots are returned if they have not.
 func (c *LogClient) GetAcceptedRoots(ctx context.Context) ([]ct.ASN1Cert, error) {
+	var hdr http.Header
+	var cached []ct.ASN1Cert
+	if c.roots != nil {
+		c.roots.mu.Lock()
+		if c.roots.roots != nil {
+			hdr = make(http.Header)
+			if c.roots.etag != "" {
+				hdr.Set("If-None-Match", c.roots.etag)
+			}
+			if c.roots.lastModified != "" {
+				hdr.Set("If-Modified-Since", c.roots.lastModified)
+			}
+			cached = c.roots.roots
+		}
+		c.roots.mu.Unlock()
+	}
+
 	var resp ct.GetRootsResponse
-	httpRsp, body, err := c.GetAndParse(ctx, ct.GetRootsPath, nil, &resp)
+	httpRsp, body, err := c.GetAndParseWithHeader(ctx, ct.GetRootsPath, nil, hdr, &resp)
 	if err != nil {
+		var rspErr RspError
+		if cached != nil && errors.As(err, &rspErr) && rspErr.StatusCode == http.StatusNotModified {
+			return append([]ct.ASN1Cert(nil), cached...), nil
+		}
 		return nil, err
 	}
 	var roots []ct.ASN1Cert
@@ -209,9 +281,32 @@ func (c *LogClient) GetAcceptedRoots(ctx context.Context) ([]ct.ASN1Cert, error)
 		}
 		roots = append(roots, ct.ASN1Cert{Data: cert})
 	}
+
+	if c.roots != nil {
+		etag, lastModified := httpRsp.Header.Get("ETag"), httpRsp.Header.Get("Last-Modified")
+		c.roots.mu.Lock()
+		if etag != "" || lastModified != "" {
+			c.roots.etag, c.roots.lastModified = etag, lastModified
+			c.roots.roots = append([]ct.ASN1Cert{}, roots...)
+		} else {
+			c.roots.etag, c.roots.lastModified, c.roots.roots = "", "", nil
+		}
+		c.roots.mu.Unlock()
+	}
 	return roots, nil
 }
 
+// RefreshAcceptedRoots retrieves the set of acceptable root certificates for a
+// log, without making the request conditional on any previous response.
+func (c *LogClient) RefreshAcceptedRoots(ctx context.Context) ([]ct.ASN1Cert, error) {
+	if c.roots != nil {
+		c.roots.mu.Lock()
+		c.roots.etag, c.roots.lastModified, c.roots.roots = "", "", nil
+		c.roots.mu.Unlock()
+	}
+	return c.GetAcceptedRoots(ctx)
+}
+
 // GetEntryAndProof returns a log entry and audit path for the index of a leaf.
 func (c *LogClient) GetEntryAndProof(ctx context.Context, index, treeSize uint64) (*ct.GetEntryAndProofResponse, error) {
 	base10 := 10
@@ -17,9 +17,13 @@ package client_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -38,6 +42,8 @@ import (
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
 )
 
 func dh(s string) []byte {
@@ -183,6 +189,90 @@ func TestGetEntries(t *testing.T) {
 	}
 }
 
+func TestGetRawEntriesWithSize(t *testing.T) {
+	body := fmt.Sprintf(`{"entries":[{"leaf_input": "%s","extra_data": "%s"}]}`, CertEntryB64, CertEntryExtraDataB64)
+	ts := serveRspAt(t, "/ct/v1/get-entries", body)
+	defer ts.Close()
+	lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	rsp, size, err := lc.GetRawEntriesWithSize(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatalf("GetRawEntriesWithSize(0,0)=_,_,%v; want nil", err)
+	}
+	if got, want := len(rsp.Entries), 1; got != want {
+		t.Errorf("GetRawEntriesWithSize(0,0) returned %d entries, want %d", got, want)
+	}
+	if got, want := size, len(body); got != want {
+		t.Errorf("GetRawEntriesWithSize(0,0) returned size %d, want %d", got, want)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	var requests []string
+	// The log returns two entries regardless of the requested range.
+	ts := serveHandlerAt(t, "/ct/v1/get-entries", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		requests = append(requests, q.Get("start")+"-"+q.Get("end"))
+		_, err := fmt.Fprintf(w, `{"entries":[{"leaf_input": "%s","extra_data": "%s"},{"leaf_input": "%s","extra_data": "%s"}]}`,
+			PrecertEntryB64,
+			PrecertEntryExtraDataB64,
+			CertEntryB64,
+			CertEntryExtraDataB64)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	defer ts.Close()
+	lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var indices []int64
+	for entry, err := range lc.Entries(context.Background(), 10, 14) {
+		if err != nil {
+			t.Fatalf("Entries(10,14) yielded error: %v", err)
+		}
+		indices = append(indices, entry.Index)
+	}
+	if got, want := indices, []int64{10, 11, 12, 13, 14}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries(10,14) yielded indices %v; want %v", got, want)
+	}
+	if got, want := requests, []string{"10-14", "12-14", "14-14"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries(10,14) made requests %v; want %v", got, want)
+	}
+
+	// Breaking out of the loop stops fetching.
+	requests = nil
+	for range lc.Entries(context.Background(), 0, 100) {
+		break
+	}
+	if got, want := len(requests), 1; got != want {
+		t.Errorf("Entries(0,100) made %d requests after break; want %d", got, want)
+	}
+}
+
+func TestEntriesErrors(t *testing.T) {
+	ts := serveRspAt(t, "/ct/v1/get-entries", `{"entries":[]}`)
+	defer ts.Close()
+	lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	var errs int
+	for entry, err := range lc.Entries(context.Background(), 0, 10) {
+		if entry != nil || err == nil {
+			t.Errorf("Entries(0,10) yielded (%v, %v); want (nil, error)", entry, err)
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("Entries(0,10) yielded %d errors; want 1", errs)
+	}
+}
+
 func TestGetEntriesErrors(t *testing.T) {
 	ctx := context.Background()
 	var tests = []struct {
@@ -266,6 +356,79 @@ func TestGetRawEntriesErrors(t *testing.T) {
 	}
 }
 
+func TestGetRawEntriesNotAvailable(t *testing.T) {
+	ctx := context.Background()
+	var tests = []struct {
+		desc        string
+		status      int
+		body        string
+		wantTyped   bool
+		wantRspCode int
+	}{
+		{
+			desc:      "ctfe-tree-too-small",
+			status:    http.StatusBadRequest,
+			body:      "Bad Request\nneed tree size: 11 to get leaves but only got: 10",
+			wantTyped: true,
+		},
+		{
+			desc:      "backend-out-of-range",
+			status:    http.StatusBadRequest,
+			body:      "Bad Request\nbackend GetLeavesByRange request failed: rpc error: code = OutOfRange desc = start index beyond tree size",
+			wantTyped: true,
+		},
+		{
+			desc:        "other-bad-request",
+			status:      http.StatusBadRequest,
+			body:        "Bad Request\nbad range",
+			wantRspCode: http.StatusBadRequest,
+		},
+		{
+			desc:        "server-error",
+			status:      http.StatusInternalServerError,
+			body:        "Internal Server Error\nneed tree size: 11 to get leaves but only got: 10",
+			wantRspCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ts := serveHandlerAt(t, "/ct/v1/get-entries", func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, test.body, test.status)
+			})
+			defer ts.Close()
+			lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			_, err = lc.GetRawEntries(ctx, 10, 20)
+			if err == nil {
+				t.Fatal("GetRawEntries()=_, nil; want error")
+			}
+
+			naErr, ok := err.(client.EntriesNotAvailableError)
+			if ok != test.wantTyped {
+				t.Fatalf("GetRawEntries()=_, %T; want EntriesNotAvailableError: %v", err, test.wantTyped)
+			}
+			if ok {
+				if naErr.Start != 10 || naErr.End != 20 {
+					t.Errorf("EntriesNotAvailableError range=[%d, %d]; want [10, 20]", naErr.Start, naErr.End)
+				}
+				var rspErr client.RspError
+				if !errors.As(err, &rspErr) || rspErr.StatusCode != test.status {
+					t.Errorf("errors.As(RspError)=%v with status %d; want true with status %d", errors.As(err, &rspErr), rspErr.StatusCode, test.status)
+				}
+				return
+			}
+			if rspErr, ok := err.(client.RspError); !ok {
+				t.Errorf("GetRawEntries()=_, %T; want RspError", err)
+			} else if rspErr.StatusCode != test.wantRspCode {
+				t.Errorf("RspError.StatusCode=%d; want %d", rspErr.StatusCode, test.wantRspCode)
+			}
+		})
+	}
+}
+
 func TestGetSTH(t *testing.T) {
 	ts := serveRspAt(t, "/ct/v1/get-sth",
 		fmt.Sprintf(`{"tree_size": %d, "timestamp": %d, "sha256_root_hash": "%s", "tree_head_signature": "%s"}`,
@@ -310,6 +473,73 @@ func TestGetSTH(t *testing.T) {
 	}
 }
 
+func TestGetVerifiedSTH(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey(): %v", err)
+	}
+	sth := ct.SignedTreeHead{Version: ct.V1, TreeSize: 10, Timestamp: 1500000000000}
+	sth.SHA256RootHash[0] = 0x42
+	input, err := ct.SerializeSTHSignatureInput(sth)
+	if err != nil {
+		t.Fatalf("SerializeSTHSignatureInput(): %v", err)
+	}
+	sig, err := tls.CreateSignature(*key, tls.SHA256, input)
+	if err != nil {
+		t.Fatalf("CreateSignature(): %v", err)
+	}
+	rawSig, err := tls.Marshal(sig)
+	if err != nil {
+		t.Fatalf("tls.Marshal(): %v", err)
+	}
+
+	for _, test := range []struct {
+		desc     string
+		treeSize uint64
+		keyDER   []byte
+		wantErr  string
+	}{
+		{desc: "valid", treeSize: sth.TreeSize, keyDER: keyDER},
+		{desc: "tampered", treeSize: sth.TreeSize + 1, keyDER: keyDER, wantErr: "failed to verify"},
+		{desc: "no key", treeSize: sth.TreeSize, wantErr: "no public key"},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			rsp, err := json.Marshal(ct.GetSTHResponse{
+				TreeSize:          test.treeSize,
+				Timestamp:         sth.Timestamp,
+				SHA256RootHash:    sth.SHA256RootHash[:],
+				TreeHeadSignature: rawSig,
+			})
+			if err != nil {
+				t.Fatalf("json.Marshal(): %v", err)
+			}
+			ts := serveRspAt(t, "/ct/v1/get-sth", string(rsp))
+			defer ts.Close()
+			lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{PublicKeyDER: test.keyDER})
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			got, err := lc.GetVerifiedSTH(context.Background())
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("GetVerifiedSTH()=%+v,%v; want nil, error containing %q", got, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetVerifiedSTH()=nil,%v; want _,nil", err)
+			}
+			if got.TreeSize != sth.TreeSize {
+				t.Errorf("GetVerifiedSTH().TreeSize=%d; want %d", got.TreeSize, sth.TreeSize)
+			}
+		})
+	}
+}
+
 func TestGetSTHErrors(t *testing.T) {
 	ctx := context.Background()
 	var tests = []struct {
@@ -602,6 +832,58 @@ func TestGetSTHConsistencyErrors(t *testing.T) {
 	}
 }
 
+func TestGetVerifiedConsistencyProof(t *testing.T) {
+	tree := testonly.New(rfc6962.DefaultHasher)
+	for i := 0; i < 7; i++ {
+		tree.AppendData([]byte(fmt.Sprintf("leaf %d", i)))
+	}
+	const first, second = 3, 7
+	pf, err := tree.ConsistencyProof(first, second)
+	if err != nil {
+		t.Fatalf("ConsistencyProof(): %v", err)
+	}
+	tampered := make([][]byte, len(pf))
+	for i := range pf {
+		tampered[i] = append([]byte(nil), pf[i]...)
+	}
+	tampered[0][0] ^= 0x01
+	firstRoot, secondRoot := tree.HashAt(first), tree.HashAt(second)
+
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc       string
+		proof      [][]byte
+		firstRoot  []byte
+		secondRoot []byte
+		wantErr    bool
+	}{
+		{desc: "valid", proof: pf, firstRoot: firstRoot, secondRoot: secondRoot},
+		{desc: "tampered proof", proof: tampered, firstRoot: firstRoot, secondRoot: secondRoot, wantErr: true},
+		{desc: "wrong root", proof: pf, firstRoot: firstRoot, secondRoot: tree.HashAt(second - 1), wantErr: true},
+		{desc: "empty proof", proof: nil, firstRoot: firstRoot, secondRoot: secondRoot, wantErr: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			rsp, err := json.Marshal(ct.GetSTHConsistencyResponse{Consistency: test.proof})
+			if err != nil {
+				t.Fatalf("json.Marshal(): %v", err)
+			}
+			ts := serveRspAt(t, "/ct/v1/get-sth-consistency", string(rsp))
+			defer ts.Close()
+			lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			got, err := lc.GetVerifiedConsistencyProof(ctx, first, second, test.firstRoot, test.secondRoot)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("GetVerifiedConsistencyProof()=_,%v; want err=%v", err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(got, test.proof) {
+				t.Errorf("GetVerifiedConsistencyProof()=%x,nil; want %x,nil", got, test.proof)
+			}
+		})
+	}
+}
+
 func TestGetProofByHash(t *testing.T) {
 	hs := serveRspAt(t, "/ct/v1/get-proof-by-hash", ProofByHashResp)
 	defer hs.Close()
@@ -729,6 +1011,89 @@ func TestGetAcceptedRootsErrors(t *testing.T) {
 	}
 }
 
+func TestGetAcceptedRootsCached(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc      string
+		header    string // header set on full responses
+		value     string
+		condition string // header expected on conditional requests
+	}{
+		{desc: "etag", header: "ETag", value: `"v1"`, condition: "If-None-Match"},
+		{desc: "last-modified", header: "Last-Modified", value: "Mon, 02 Jan 2006 15:04:05 GMT", condition: "If-Modified-Since"},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			var full, notModified int
+			ts := serveHandlerAt(t, "/ct/v1/get-roots", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(test.condition) == test.value {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full++
+				w.Header().Set(test.header, test.value)
+				if _, err := fmt.Fprint(w, GetRootsResp); err != nil {
+					t.Error(err)
+				}
+			})
+			defer ts.Close()
+			lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			want, err := lc.GetAcceptedRoots(ctx)
+			if err != nil {
+				t.Fatalf("GetAcceptedRoots()=nil,%v; want roots,nil", err)
+			}
+			got, err := lc.GetAcceptedRoots(ctx)
+			if err != nil {
+				t.Fatalf("GetAcceptedRoots()=nil,%v; want roots,nil", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetAcceptedRoots()=%v after 304, want cached %v", got, want)
+			}
+			if full != 1 || notModified != 1 {
+				t.Errorf("server sent %d full and %d not-modified responses, want 1 and 1", full, notModified)
+			}
+
+			if _, err := lc.RefreshAcceptedRoots(ctx); err != nil {
+				t.Fatalf("RefreshAcceptedRoots()=nil,%v; want roots,nil", err)
+			}
+			if full != 2 {
+				t.Errorf("server sent %d full responses after refresh, want 2", full)
+			}
+		})
+	}
+}
+
+func TestGetAcceptedRootsNotCached(t *testing.T) {
+	// Without validators in the response, a 304 is never expected, and is an
+	// error like any other non-OK status.
+	var calls int
+	ts := serveHandlerAt(t, "/ct/v1/get-roots", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if _, err := fmt.Fprint(w, GetRootsResp); err != nil {
+			t.Error(err)
+		}
+	})
+	defer ts.Close()
+	lc, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := lc.GetAcceptedRoots(context.Background()); err != nil {
+		t.Fatalf("GetAcceptedRoots()=nil,%v; want roots,nil", err)
+	}
+	if got, err := lc.GetAcceptedRoots(context.Background()); err == nil {
+		t.Errorf("GetAcceptedRoots()=%v,nil; want nil,error", got)
+	}
+}
+
 func TestGetEntryAndProof(t *testing.T) {
 	hs := serveRspAt(t, "/ct/v1/get-entry-and-proof", GetEntryAndProofResp)
 	defer hs.Close()
@@ -15,17 +15,42 @@
 package ctpolicy
 
 import (
+	"fmt"
+
 	"github.com/OlegBabkin/certificate-transparency-go/loglist3"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 )
 
+// appleMinOperators is the number of distinct log operators which must have
+// issued the SCTs of a certificate under Apple's CT log policy.
+const appleMinOperators = 2
+
+// appleOperatorGroupPrefix prefixes the names of the groups which encode the
+// distinct operators requirement of Apple's CT log policy.
+const appleOperatorGroupPrefix = "Not-operated-by-"
+
 // AppleCTPolicy implements logic for complying with Apple's CT log policy.
 type AppleCTPolicy struct{}
 
 // LogsByGroup describes submission requirements for embedded SCTs according to
 // https://support.apple.com/en-us/HT205280. Returns an error if it's not
-// possible to satisfy the policy with the provided loglist.
+// possible to satisfy the policy with the provided loglist, including when
+// its logs are run by fewer distinct operators than the policy requires.
+//
+// The distinct operators requirement is encoded as one group per operator,
+// holding the logs of all the other operators, from which one SCT is needed.
+// SCTs which satisfy all these groups can't all come from the same operator.
 func (appleP AppleCTPolicy) LogsByGroup(cert *x509.Certificate, approved *loglist3.LogList) (LogPolicyData, error) {
+	operators := 0
+	for _, op := range approved.Operators {
+		if len(op.Logs) > 0 {
+			operators++
+		}
+	}
+	if operators < appleMinOperators {
+		return nil, fmt.Errorf("only %d log operators are available while %d distinct operators are required", operators, appleMinOperators)
+	}
+
 	var incCount int
 	switch m := lifetimeInMonths(cert); {
 	case m < 15:
@@ -42,6 +67,17 @@ func (appleP AppleCTPolicy) LogsByGroup(cert *x509.Certificate, approved *loglis
 		return nil, err
 	}
 	groups := LogPolicyData{baseGroup.Name: baseGroup}
+	for _, op := range approved.Operators {
+		if len(op.Logs) == 0 {
+			continue
+		}
+		group := LogGroupInfo{Name: appleOperatorGroupPrefix + op.Name, IsBase: false}
+		group.populate(approved, func(other *loglist3.Operator) bool { return other != op })
+		if err := group.setMinInclusions(1); err != nil {
+			return nil, err
+		}
+		groups[group.Name] = &group
+	}
 	return groups, nil
 }
 
@@ -44,10 +44,42 @@ func wantedAppleGroups(count int) LogPolicyData {
 				"https://log.bob.io":                        1.0,
 			},
 		},
+		"Not-operated-by-Google": {
+			Name:          "Not-operated-by-Google",
+			LogURLs:       map[string]bool{"https://log.bob.io": true},
+			MinInclusions: 1,
+			LogWeights:    map[string]float32{"https://log.bob.io": 1.0},
+		},
+		"Not-operated-by-Bob's CT Log Shop": {
+			Name: "Not-operated-by-Bob's CT Log Shop",
+			LogURLs: map[string]bool{
+				"https://ct.googleapis.com/aviator/":        true,
+				"https://ct.googleapis.com/icarus/":         true,
+				"https://ct.googleapis.com/rocketeer/":      true,
+				"https://ct.googleapis.com/racketeer/":      true,
+				"https://ct.googleapis.com/logs/argon2020/": true,
+			},
+			MinInclusions: 1,
+			LogWeights: map[string]float32{
+				"https://ct.googleapis.com/aviator/":        1.0,
+				"https://ct.googleapis.com/icarus/":         1.0,
+				"https://ct.googleapis.com/rocketeer/":      1.0,
+				"https://ct.googleapis.com/racketeer/":      1.0,
+				"https://ct.googleapis.com/logs/argon2020/": 1.0,
+			},
+		},
 	}
 	return gi
 }
 
+// getTestCertWithLifetime returns a certificate valid for the given number of
+// months.
+func getTestCertWithLifetime(months int) *x509.Certificate {
+	cert := getTestCertPEMLongOriginal()
+	cert.NotAfter = cert.NotBefore.AddDate(0, months, 0)
+	return cert
+}
+
 func TestCheckApplePolicy(t *testing.T) {
 	tests := []struct {
 		name string
@@ -74,6 +106,36 @@ func TestCheckApplePolicy(t *testing.T) {
 			cert: getTestCertPEMLongOriginal(),
 			want: wantedAppleGroups(5),
 		},
+		{
+			name: "14-months",
+			cert: getTestCertWithLifetime(14),
+			want: wantedAppleGroups(2),
+		},
+		{
+			name: "15-months",
+			cert: getTestCertWithLifetime(15),
+			want: wantedAppleGroups(3),
+		},
+		{
+			name: "27-months",
+			cert: getTestCertWithLifetime(27),
+			want: wantedAppleGroups(3),
+		},
+		{
+			name: "28-months",
+			cert: getTestCertWithLifetime(28),
+			want: wantedAppleGroups(4),
+		},
+		{
+			name: "39-months",
+			cert: getTestCertWithLifetime(39),
+			want: wantedAppleGroups(4),
+		},
+		{
+			name: "40-months",
+			cert: getTestCertWithLifetime(40),
+			want: wantedAppleGroups(5),
+		},
 	}
 
 	var policy AppleCTPolicy
@@ -91,3 +153,23 @@ func TestCheckApplePolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckApplePolicyWarnings(t *testing.T) {
+	var policy AppleCTPolicy
+	sampleLogList := sampleLogList(t)
+	// Removing Bob-log, so all the logs have the same operator.
+	sampleLogList.Operators = sampleLogList.Operators[:1]
+
+	want := "only 1 log operators are available while 2 distinct operators are required"
+	for _, cert := range []*x509.Certificate{getTestCertPEMShort(), getTestCertPEMLongOriginal()} {
+		got, err := policy.LogsByGroup(cert, sampleLogList)
+		if err == nil {
+			t.Errorf("LogsByGroup returned no error when expected")
+		} else if err.Error() != want {
+			t.Errorf("LogsByGroup returned error message %q while expected %q", err.Error(), want)
+		}
+		if got != nil {
+			t.Errorf("LogsByGroup returned groups %v along with the error", got)
+		}
+	}
+}
@@ -19,46 +19,32 @@ import (
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 )
 
-// ChromeCTPolicy implements logic for complying with Chrome's CT log policy
+// ChromeCTPolicy implements logic for complying with Chrome's CT log policy.
+// It is a preset of ConfigurableCTPolicy, see ChromeCTPolicyConfig.
 type ChromeCTPolicy struct {
 }
 
+// ChromeCTPolicyConfig returns the ConfigurableCTPolicy with the thresholds of
+// Chrome's CT log policy, e.g. as a starting point for custom policies.
+func ChromeCTPolicyConfig() ConfigurableCTPolicy {
+	return ConfigurableCTPolicy{
+		PolicyName: "Chrome",
+		LifetimeBuckets: []LifetimeBucket{
+			{MaxMonths: 14, MinInclusions: 2},
+			{MaxMonths: 27, MinInclusions: 3},
+			{MaxMonths: 39, MinInclusions: 4},
+		},
+		LongLifetimeInclusions: 5,
+		GoogleMinInclusions:    1,
+		NonGoogleMinInclusions: 1,
+	}
+}
+
 // LogsByGroup describes submission requirements for embedded SCTs according to
 // https://github.com/chromium/ct-policy/blob/master/ct_policy.md#qualifying-certificate.
 // Returns an error if it's not possible to satisfy the policy with the provided loglist.
 func (chromeP ChromeCTPolicy) LogsByGroup(cert *x509.Certificate, approved *loglist3.LogList) (LogPolicyData, error) {
-	googGroup := LogGroupInfo{Name: "Google-operated", IsBase: false}
-	googGroup.populate(approved, func(op *loglist3.Operator) bool { return op.GoogleOperated() })
-	if err := googGroup.setMinInclusions(1); err != nil {
-		return nil, err
-	}
-
-	nonGoogGroup := LogGroupInfo{Name: "Non-Google-operated", IsBase: false}
-	nonGoogGroup.populate(approved, func(op *loglist3.Operator) bool { return !op.GoogleOperated() })
-	if err := nonGoogGroup.setMinInclusions(1); err != nil {
-		return nil, err
-	}
-	var incCount int
-	switch m := lifetimeInMonths(cert); {
-	case m < 15:
-		incCount = 2
-	case m <= 27:
-		incCount = 3
-	case m <= 39:
-		incCount = 4
-	default:
-		incCount = 5
-	}
-	baseGroup, err := BaseGroupFor(approved, incCount)
-	if err != nil {
-		return nil, err
-	}
-	groups := LogPolicyData{
-		googGroup.Name:    &googGroup,
-		nonGoogGroup.Name: &nonGoogGroup,
-		baseGroup.Name:    baseGroup,
-	}
-	return groups, nil
+	return ChromeCTPolicyConfig().LogsByGroup(cert, approved)
 }
 
 // Name returns label for the submission policy.
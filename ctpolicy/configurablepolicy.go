@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctpolicy
+
+import (
+	"github.com/OlegBabkin/certificate-transparency-go/loglist3"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+)
+
+// LifetimeBucket gives the number of SCTs required for certificates whose
+// lifetime is within a given bound.
+type LifetimeBucket struct {
+	// MaxMonths is the longest lifetime, in whole months, of the certificates
+	// covered by the bucket.
+	MaxMonths int
+	// MinInclusions is the number of SCTs required for such certificates.
+	MinInclusions int
+}
+
+// ConfigurableCTPolicy is a CT log policy whose requirements are described by
+// its fields, which allows experimenting with thresholds other than those of
+// the predefined policies.
+type ConfigurableCTPolicy struct {
+	// PolicyName is returned by Name.
+	PolicyName string
+	// LifetimeBuckets maps certificate lifetimes to the number of SCTs
+	// required from all logs. The buckets must be sorted by MaxMonths, and the
+	// first bucket covering the certificate's lifetime applies.
+	LifetimeBuckets []LifetimeBucket
+	// LongLifetimeInclusions is the number of SCTs required for certificates
+	// whose lifetime exceeds all the buckets.
+	LongLifetimeInclusions int
+	// GoogleMinInclusions and NonGoogleMinInclusions are the numbers of SCTs
+	// required from Google-operated and non-Google-operated logs. The
+	// corresponding group is omitted if zero.
+	GoogleMinInclusions, NonGoogleMinInclusions int
+}
+
+// LogsByGroup describes submission requirements for embedded SCTs according to
+// the policy's fields. Returns an error if it's not possible to satisfy the
+// policy with the provided loglist.
+func (p ConfigurableCTPolicy) LogsByGroup(cert *x509.Certificate, approved *loglist3.LogList) (LogPolicyData, error) {
+	groups := LogPolicyData{}
+	if p.GoogleMinInclusions > 0 {
+		googGroup := LogGroupInfo{Name: "Google-operated", IsBase: false}
+		googGroup.populate(approved, func(op *loglist3.Operator) bool { return op.GoogleOperated() })
+		if err := googGroup.setMinInclusions(p.GoogleMinInclusions); err != nil {
+			return nil, err
+		}
+		groups[googGroup.Name] = &googGroup
+	}
+	if p.NonGoogleMinInclusions > 0 {
+		nonGoogGroup := LogGroupInfo{Name: "Non-Google-operated", IsBase: false}
+		nonGoogGroup.populate(approved, func(op *loglist3.Operator) bool { return !op.GoogleOperated() })
+		if err := nonGoogGroup.setMinInclusions(p.NonGoogleMinInclusions); err != nil {
+			return nil, err
+		}
+		groups[nonGoogGroup.Name] = &nonGoogGroup
+	}
+
+	baseGroup, err := BaseGroupFor(approved, p.inclusionsFor(lifetimeInMonths(cert)))
+	if err != nil {
+		return nil, err
+	}
+	groups[baseGroup.Name] = baseGroup
+	return groups, nil
+}
+
+// inclusionsFor returns the number of SCTs required for a certificate with
+// the given lifetime in months.
+func (p ConfigurableCTPolicy) inclusionsFor(months int) int {
+	for _, b := range p.LifetimeBuckets {
+		if months <= b.MaxMonths {
+			return b.MinInclusions
+		}
+	}
+	return p.LongLifetimeInclusions
+}
+
+// Name returns label for the submission policy.
+func (p ConfigurableCTPolicy) Name() string {
+	return p.PolicyName
+}
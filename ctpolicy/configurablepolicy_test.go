@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctpolicy
+
+import (
+	"testing"
+
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestCheckConfigurablePolicy(t *testing.T) {
+	policy := ConfigurableCTPolicy{
+		PolicyName:             "Custom",
+		LifetimeBuckets:        []LifetimeBucket{{MaxMonths: 27, MinInclusions: 1}},
+		LongLifetimeInclusions: 6,
+		NonGoogleMinInclusions: 1,
+	}
+	// No Google-operated group is required.
+	wantedCustomGroups := func(base int) LogPolicyData {
+		gi := wantedGroups(0, 1, base, false)
+		delete(gi, "Google-operated")
+		return gi
+	}
+
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		want LogPolicyData
+	}{
+		{
+			name: "Short",
+			cert: getTestCertPEMShort(),
+			want: wantedCustomGroups(1),
+		},
+		{
+			name: "2-year",
+			cert: getTestCertPEM2Years(),
+			want: wantedCustomGroups(1),
+		},
+		{
+			name: "3-year",
+			cert: getTestCertPEM3Years(),
+			want: wantedCustomGroups(6),
+		},
+		{
+			name: "Long",
+			cert: getTestCertPEMLongOriginal(),
+			want: wantedCustomGroups(6),
+		},
+	}
+
+	sampleLogList := sampleLogList(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := policy.LogsByGroup(test.cert, sampleLogList)
+			if err != nil {
+				t.Fatalf("LogsByGroup returned an error when not expected: %v", err)
+			}
+			if diff := pretty.Compare(test.want, got); diff != "" {
+				t.Errorf("LogsByGroup: (-want +got)\n%s", diff)
+			}
+		})
+	}
+	if got, want := policy.Name(), "Custom"; got != want {
+		t.Errorf("Name()=%q, want %q", got, want)
+	}
+}
+
+func TestCheckConfigurablePolicyErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		policy ConfigurableCTPolicy
+		want   string
+	}{
+		{
+			name:   "non-Google",
+			policy: ConfigurableCTPolicy{NonGoogleMinInclusions: 2, LongLifetimeInclusions: 1},
+			want:   "trying to assign 2 minimal inclusion number while only 1 logs are part of group \"Non-Google-operated\"",
+		},
+		{
+			name:   "base",
+			policy: ConfigurableCTPolicy{LongLifetimeInclusions: 7},
+			want:   "trying to assign 7 minimal inclusion number while only 6 logs are part of group \"All-logs\"",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.policy.LogsByGroup(getTestCertPEMLongOriginal(), sampleLogList(t))
+			if err == nil {
+				t.Fatal("LogsByGroup returned no error when expected")
+			}
+			if err.Error() != test.want {
+				t.Errorf("LogsByGroup returned error message %q while expected %q", err.Error(), test.want)
+			}
+		})
+	}
+}
+
+func TestChromeCTPolicyConfig(t *testing.T) {
+	var chrome ChromeCTPolicy
+	config := ChromeCTPolicyConfig()
+	if got, want := config.Name(), chrome.Name(); got != want {
+		t.Errorf("ChromeCTPolicyConfig().Name()=%q, want %q", got, want)
+	}
+	for _, cert := range []*x509.Certificate{getTestCertPEMShort(), getTestCertPEM2Years(), getTestCertPEM3Years(), getTestCertPEMLongOriginal()} {
+		want, err := chrome.LogsByGroup(cert, sampleLogList(t))
+		if err != nil {
+			t.Fatalf("ChromeCTPolicy.LogsByGroup(): %v", err)
+		}
+		got, err := config.LogsByGroup(cert, sampleLogList(t))
+		if err != nil {
+			t.Fatalf("ChromeCTPolicyConfig().LogsByGroup(): %v", err)
+		}
+		if diff := pretty.Compare(want, got); diff != "" {
+			t.Errorf("LogsByGroup: (-want +got)\n%s", diff)
+		}
+	}
+}
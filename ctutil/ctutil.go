@@ -24,6 +24,7 @@ import (
 	"fmt"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 )
@@ -209,3 +210,46 @@ func ContainsSCT(cert *x509.Certificate, sct *ct.SignedCertificateTimestamp) (bo
 	}
 	return false, nil
 }
+
+// PrecertMatchesCert checks whether finalCert is the certificate issued for
+// the precertificate whose DER-encoded TBSCertificate is precertTBS, i.e.
+// whether their TBSCertificates are identical apart from the CT poison
+// extension of the precertificate and the embedded SCT list extension of the
+// final certificate.
+//
+// The precertTBS would normally be taken from a precert log entry, where the
+// poison extension is already removed and the issuance information is
+// adjusted if a pre-issuer was used; the poison is removed here if present.
+func PrecertMatchesCert(precertTBS []byte, finalCert *x509.Certificate) (bool, error) {
+	if finalCert == nil {
+		return false, errors.New("final certificate is nil")
+	}
+
+	precert, err := x509.ParseTBSCertificate(precertTBS)
+	if x509.IsFatal(err) {
+		return false, fmt.Errorf("error parsing precert TBSCertificate: %s", err)
+	}
+	if hasExtension(precert, x509.OIDExtensionCTPoison) {
+		if precertTBS, err = x509.RemoveCTPoison(precertTBS); err != nil {
+			return false, fmt.Errorf("error removing CT poison from precert: %s", err)
+		}
+	}
+
+	finalTBS := finalCert.RawTBSCertificate
+	if hasExtension(finalCert, x509.OIDExtensionCTSCT) {
+		if finalTBS, err = x509.RemoveSCTList(finalTBS); err != nil {
+			return false, fmt.Errorf("error removing SCT list from final certificate: %s", err)
+		}
+	}
+
+	return bytes.Equal(precertTBS, finalTBS), nil
+}
+
+func hasExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
@@ -21,6 +21,7 @@ import (
 	ct "github.com/OlegBabkin/certificate-transparency-go"
 	"github.com/OlegBabkin/certificate-transparency-go/testdata"
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
 	"github.com/OlegBabkin/certificate-transparency-go/x509util"
 )
 
@@ -359,3 +360,72 @@ func TestContainsSCT(t *testing.T) {
 		})
 	}
 }
+
+func TestPrecertMatchesCert(t *testing.T) {
+	precert, err := x509util.CertificateFromPEM([]byte(testdata.TestPreCertPEM))
+	if x509.IsFatal(err) {
+		t.Fatalf("error parsing precert: %s", err)
+	}
+	logTBS, err := x509.RemoveCTPoison(precert.RawTBSCertificate)
+	if err != nil {
+		t.Fatalf("error removing CT poison: %s", err)
+	}
+
+	tests := []struct {
+		desc     string
+		tbs      []byte
+		certPEM  string
+		want     bool
+		wantErr  bool
+		nilFinal bool
+	}{
+		{
+			desc:    "logged precert TBS matches final cert",
+			tbs:     logTBS,
+			certPEM: testdata.TestEmbeddedCertPEM,
+			want:    true,
+		},
+		{
+			desc:    "poisoned precert TBS matches final cert",
+			tbs:     precert.RawTBSCertificate,
+			certPEM: testdata.TestEmbeddedCertPEM,
+			want:    true,
+		},
+		{
+			desc:    "unrelated cert",
+			tbs:     logTBS,
+			certPEM: testdata.TestCertPEM,
+			want:    false,
+		},
+		{
+			desc:    "invalid precert TBS",
+			tbs:     []byte{0x30, 0x01},
+			certPEM: testdata.TestEmbeddedCertPEM,
+			wantErr: true,
+		},
+		{
+			desc:     "nil final cert",
+			tbs:      logTBS,
+			nilFinal: true,
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var cert *x509.Certificate
+			if !test.nilFinal {
+				if cert, err = x509util.CertificateFromPEM([]byte(test.certPEM)); x509.IsFatal(err) {
+					t.Fatalf("error parsing certificate: %s", err)
+				}
+			}
+			got, err := PrecertMatchesCert(test.tbs, cert)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("PrecertMatchesCert(_,_) = _, %v, want error: %t", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("PrecertMatchesCert(_,_) = %t, _, want %t", got, test.want)
+			}
+		})
+	}
+}
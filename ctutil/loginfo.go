@@ -17,6 +17,7 @@ package ctutil
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -40,9 +41,26 @@ type LogInfo struct {
 	MMD         time.Duration
 	Verifier    *ct.SignatureVerifier
 	PublicKey   []byte
+	// RootsRefreshInterval is how long the log's accepted roots are cached
+	// for by Roots. If zero, DefaultRootsRefreshInterval is used.
+	RootsRefreshInterval time.Duration
 
 	mu      sync.RWMutex
 	lastSTH *ct.SignedTreeHead
+
+	rootsMu      sync.Mutex
+	roots        *x509.CertPool
+	rootsFetched time.Time
+}
+
+// DefaultRootsRefreshInterval is how long the accepted roots of a log are
+// cached for, unless LogInfo.RootsRefreshInterval says otherwise.
+const DefaultRootsRefreshInterval = time.Hour
+
+// rootsClient is implemented by log clients that can retrieve the log's
+// accepted roots, such as client.LogClient.
+type rootsClient interface {
+	GetAcceptedRoots(ctx context.Context) ([]ct.ASN1Cert, error)
 }
 
 // NewLogInfo builds a LogInfo object based on a log list entry.
@@ -100,6 +118,26 @@ func logInfoByKeyHash(ll *loglist3.LogList, hc *http.Client, infoFactory func(*l
 	return result, nil
 }
 
+// LogInfoByURL builds a map of LogInfo objects indexed by their log URLs, with
+// any trailing slashes removed.
+func LogInfoByURL(ll *loglist3.LogList, hc *http.Client) (map[string]*LogInfo, error) {
+	return logInfoByURL(ll, hc, NewLogInfo)
+}
+
+func logInfoByURL(ll *loglist3.LogList, hc *http.Client, infoFactory func(*loglist3.Log, *http.Client) (*LogInfo, error)) (map[string]*LogInfo, error) {
+	result := make(map[string]*LogInfo)
+	for _, operator := range ll.Operators {
+		for _, log := range operator.Logs {
+			li, err := infoFactory(log, hc)
+			if err != nil {
+				return nil, err
+			}
+			result[strings.TrimRight(log.URL, "/")] = li
+		}
+	}
+	return result, nil
+}
+
 // LastSTH returns the last STH known for the log.
 func (li *LogInfo) LastSTH() *ct.SignedTreeHead {
 	li.mu.RLock()
@@ -114,6 +152,40 @@ func (li *LogInfo) SetSTH(sth *ct.SignedTreeHead) {
 	li.lastSTH = sth
 }
 
+// Roots returns the roots accepted by the log. They are retrieved from the log
+// on first use, and cached for RootsRefreshInterval.
+func (li *LogInfo) Roots(ctx context.Context) (*x509.CertPool, error) {
+	li.rootsMu.Lock()
+	defer li.rootsMu.Unlock()
+	interval := li.RootsRefreshInterval
+	if interval <= 0 {
+		interval = DefaultRootsRefreshInterval
+	}
+	if li.roots != nil && time.Since(li.rootsFetched) < interval {
+		return li.roots, nil
+	}
+
+	rc, ok := li.Client.(rootsClient)
+	if !ok {
+		return nil, fmt.Errorf("client for log %q can't retrieve accepted roots", li.Description)
+	}
+	certs, err := rc.GetAcceptedRoots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accepted roots for %q log: %v", li.Description, err)
+	}
+	pool := x509.NewCertPool()
+	for i, c := range certs {
+		root, err := x509.ParseCertificate(c.Data)
+		if x509.IsFatal(err) {
+			return nil, fmt.Errorf("failed to parse root %d of %q log: %v", i, li.Description, err)
+		}
+		pool.AddCert(root)
+	}
+	li.roots = pool
+	li.rootsFetched = time.Now()
+	return pool, nil
+}
+
 // VerifySCTSignature checks the signature in the SCT matches the given leaf (adjusted for the
 // timestamp in the SCT) and log.
 func (li *LogInfo) VerifySCTSignature(sct ct.SignedCertificateTimestamp, leaf ct.MerkleTreeLeaf) error {
@@ -152,6 +224,16 @@ func (li *LogInfo) VerifyInclusion(ctx context.Context, leaf ct.MerkleTreeLeaf,
 	return li.VerifyInclusionAt(ctx, leaf, timestamp, sth.TreeSize, sth.SHA256RootHash[:])
 }
 
+// VerifyInclusionBySTH checks that the given Merkle tree leaf is present in the tree described
+// by the given STH, which the caller is expected to have verified already.  The STH is not fetched
+// nor recorded as the latest one for the log.  On success, returns the index of the leaf in the log.
+func (li *LogInfo) VerifyInclusionBySTH(ctx context.Context, leaf ct.MerkleTreeLeaf, sth *ct.SignedTreeHead) (int64, error) {
+	if sth == nil {
+		return -1, errors.New("no STH provided")
+	}
+	return li.VerifyInclusionAt(ctx, leaf, leaf.TimestampedEntry.Timestamp, sth.TreeSize, sth.SHA256RootHash[:])
+}
+
 // VerifyInclusionAt checks that the given Merkle tree leaf, adjusted for the provided timestamp,
 // is present in the given tree size & root hash of the log. On success, returns the index of the
 // leaf in the log.
@@ -172,3 +254,33 @@ func (li *LogInfo) VerifyInclusionAt(ctx context.Context, leaf ct.MerkleTreeLeaf
 	}
 	return rsp.LeafIndex, nil
 }
+
+// InclusionResult holds the outcome of checking the inclusion of one leaf in
+// a batch.
+type InclusionResult struct {
+	// Index is the index of the leaf in the log, or -1 if Err is set.
+	Index int64
+	Err   error
+}
+
+// VerifyInclusionBatch checks that each of the given Merkle tree leaves, adjusted for the
+// corresponding timestamp, is present in the current tree size of the log.  A single STH is
+// fetched and all of the leaves are verified against it, so this is cheaper than calling
+// VerifyInclusion for each leaf.  Returns one result per leaf, in order; an error is only
+// returned if the STH can't be retrieved.
+func (li *LogInfo) VerifyInclusionBatch(ctx context.Context, leaves []ct.MerkleTreeLeaf, timestamps []uint64) ([]InclusionResult, error) {
+	if len(leaves) != len(timestamps) {
+		return nil, fmt.Errorf("got %d leaves but %d timestamps", len(leaves), len(timestamps))
+	}
+	sth, err := li.Client.GetSTH(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current STH for %q log: %v", li.Description, err)
+	}
+	li.SetSTH(sth)
+	results := make([]InclusionResult, len(leaves))
+	for i, leaf := range leaves {
+		index, err := li.VerifyInclusionAt(ctx, leaf, timestamps[i], sth.TreeSize, sth.SHA256RootHash[:])
+		results[i] = InclusionResult{Index: index, Err: err}
+	}
+	return results, nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctutil
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/loglist3"
+	"github.com/OlegBabkin/certificate-transparency-go/testdata"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+)
+
+// fakeLogClient is a client.CheckLogClient serving STHs and inclusion proofs
+// from an in-memory tree.
+type fakeLogClient struct {
+	tree       *testonly.Tree
+	index      map[[32]byte]int64
+	roots      []ct.ASN1Cert
+	sthCalls   int
+	rootsCalls int
+}
+
+func newFakeLogClient(t *testing.T, leaves []ct.MerkleTreeLeaf) *fakeLogClient {
+	t.Helper()
+	f := &fakeLogClient{tree: testonly.New(rfc6962.DefaultHasher), index: make(map[[32]byte]int64)}
+	for i := range leaves {
+		hash, err := ct.LeafHashForLeaf(&leaves[i])
+		if err != nil {
+			t.Fatalf("LeafHashForLeaf(): %v", err)
+		}
+		f.index[hash] = int64(i)
+		f.tree.Append(hash[:])
+	}
+	return f
+}
+
+func (f *fakeLogClient) BaseURI() string { return "https://ct.example.com" }
+
+func (f *fakeLogClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	f.sthCalls++
+	sth := &ct.SignedTreeHead{TreeSize: f.tree.Size()}
+	copy(sth.SHA256RootHash[:], f.tree.Hash())
+	return sth, nil
+}
+
+func (f *fakeLogClient) GetSTHConsistency(ctx context.Context, first, second uint64) ([][]byte, error) {
+	return f.tree.ConsistencyProof(first, second)
+}
+
+func (f *fakeLogClient) GetProofByHash(ctx context.Context, hash []byte, treeSize uint64) (*ct.GetProofByHashResponse, error) {
+	var key [32]byte
+	copy(key[:], hash)
+	index, ok := f.index[key]
+	if !ok {
+		return nil, errors.New("leaf not found")
+	}
+	path, err := f.tree.InclusionProof(uint64(index), treeSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ct.GetProofByHashResponse{LeafIndex: index, AuditPath: path}, nil
+}
+
+func (f *fakeLogClient) GetAcceptedRoots(context.Context) ([]ct.ASN1Cert, error) {
+	f.rootsCalls++
+	return f.roots, nil
+}
+
+func TestRoots(t *testing.T) {
+	block, _ := pem.Decode([]byte(testdata.CACertPEM))
+	if block == nil {
+		t.Fatal("failed to decode CA cert PEM")
+	}
+	fake := newFakeLogClient(t, nil)
+	fake.roots = []ct.ASN1Cert{{Data: block.Bytes}}
+	li := &LogInfo{Description: "fake", Client: fake, RootsRefreshInterval: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		pool, err := li.Roots(ctx)
+		if err != nil {
+			t.Fatalf("Roots()=_,%v; want _,nil", err)
+		}
+		if got := len(pool.Subjects()); got != 1 {
+			t.Errorf("Roots() returned %d roots; want 1", got)
+		}
+	}
+	if fake.rootsCalls != 1 {
+		t.Errorf("Roots() within refresh interval fetched roots %d times; want 1", fake.rootsCalls)
+	}
+
+	// Pretend the roots were fetched longer ago than the refresh interval.
+	li.rootsFetched = li.rootsFetched.Add(-2 * time.Minute)
+	if _, err := li.Roots(ctx); err != nil {
+		t.Fatalf("Roots()=_,%v; want _,nil", err)
+	}
+	if fake.rootsCalls != 2 {
+		t.Errorf("Roots() after refresh interval fetched roots %d times; want 2", fake.rootsCalls)
+	}
+}
+
+func TestVerifyInclusionBatch(t *testing.T) {
+	const logSize = 7
+	var logged []ct.MerkleTreeLeaf
+	for i := 0; i < logSize; i++ {
+		leaf := ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: []byte(fmt.Sprintf("cert %d", i))}, uint64(1000+i))
+		logged = append(logged, *leaf)
+	}
+	fake := newFakeLogClient(t, logged)
+	li := &LogInfo{Description: "fake", Client: fake}
+
+	// The timestamps are passed separately, so clear them in the leaves.
+	leaf := func(i int) ct.MerkleTreeLeaf {
+		l := logged[i]
+		l.TimestampedEntry.Timestamp = 0
+		return l
+	}
+	unlogged := *ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: []byte("other cert")}, 2000)
+	leaves := []ct.MerkleTreeLeaf{leaf(0), leaf(3), leaf(6), unlogged, leaf(2)}
+	timestamps := []uint64{1000, 1003, 1006, 2000, 9999}
+
+	got, err := li.VerifyInclusionBatch(context.Background(), leaves, timestamps)
+	if err != nil {
+		t.Fatalf("VerifyInclusionBatch()=_,%v; want _,nil", err)
+	}
+	if len(got) != len(leaves) {
+		t.Fatalf("VerifyInclusionBatch() returned %d results; want %d", len(got), len(leaves))
+	}
+	for i, want := range []int64{0, 3, 6, -1, -1} {
+		if got[i].Index != want {
+			t.Errorf("result[%d].Index=%d; want %d", i, got[i].Index, want)
+		}
+		if gotErr, wantErr := got[i].Err != nil, want < 0; gotErr != wantErr {
+			t.Errorf("result[%d].Err=%v; want err=%v", i, got[i].Err, wantErr)
+		}
+	}
+	if fake.sthCalls != 1 {
+		t.Errorf("VerifyInclusionBatch() fetched %d STHs; want 1", fake.sthCalls)
+	}
+	if sth := li.LastSTH(); sth == nil || sth.TreeSize != logSize {
+		t.Errorf("LastSTH()=%+v; want tree size %d", sth, logSize)
+	}
+
+	if _, err := li.VerifyInclusionBatch(context.Background(), leaves, timestamps[1:]); err == nil {
+		t.Error("VerifyInclusionBatch(mismatched lengths)=_,nil; want _,non-nil")
+	}
+}
+
+func TestVerifyInclusionBySTH(t *testing.T) {
+	var logged []ct.MerkleTreeLeaf
+	for i := 0; i < 5; i++ {
+		leaf := ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: []byte(fmt.Sprintf("cert %d", i))}, uint64(1000+i))
+		logged = append(logged, *leaf)
+	}
+	fake := newFakeLogClient(t, logged)
+	li := &LogInfo{Description: "fake", Client: fake}
+	ctx := context.Background()
+
+	sth, err := fake.GetSTH(ctx)
+	if err != nil {
+		t.Fatalf("GetSTH()=_,%v; want _,nil", err)
+	}
+	fake.sthCalls = 0
+	mismatched := *sth
+	mismatched.SHA256RootHash[0] ^= 0xff
+	smaller := &ct.SignedTreeHead{TreeSize: 3}
+	copy(smaller.SHA256RootHash[:], fake.tree.HashAt(3))
+
+	for _, tc := range []struct {
+		desc    string
+		leaf    int
+		sth     *ct.SignedTreeHead
+		want    int64
+		wantErr bool
+	}{
+		{desc: "matching", leaf: 2, sth: sth, want: 2},
+		{desc: "smaller-tree", leaf: 1, sth: smaller, want: 1},
+		{desc: "mismatched-root", leaf: 2, sth: &mismatched, wantErr: true},
+		{desc: "beyond-tree", leaf: 4, sth: smaller, wantErr: true},
+		{desc: "nil-sth", leaf: 0, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := li.VerifyInclusionBySTH(ctx, logged[tc.leaf], tc.sth)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("VerifyInclusionBySTH()=%d,%v; want err=%v", got, err, tc.wantErr)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("VerifyInclusionBySTH()=%d,nil; want %d,nil", got, tc.want)
+			}
+		})
+	}
+	if fake.sthCalls != 0 {
+		t.Errorf("VerifyInclusionBySTH() fetched %d STHs; want 0", fake.sthCalls)
+	}
+	if li.LastSTH() != nil {
+		t.Errorf("LastSTH()=%+v; want nil", li.LastSTH())
+	}
+}
+
+func TestLogInfoByURL(t *testing.T) {
+	ll, err := loglist3.NewFromJSON([]byte(testdata.SampleLogList3))
+	if err != nil {
+		t.Fatalf("NewFromJSON()=_,%v; want _,nil", err)
+	}
+	factory := func(log *loglist3.Log, _ *http.Client) (*LogInfo, error) {
+		return &LogInfo{Description: log.Description}, nil
+	}
+	byURL, err := logInfoByURL(ll, nil, factory)
+	if err != nil {
+		t.Fatalf("logInfoByURL()=_,%v; want _,nil", err)
+	}
+	if got, want := len(byURL), 6; got != want {
+		t.Errorf("logInfoByURL() returned %d logs; want %d", got, want)
+	}
+	for url, want := range map[string]string{
+		"https://ct.googleapis.com/icarus":         "Google 'Icarus' log",
+		"https://ct.googleapis.com/logs/argon2020": "Google 'Argon2020' log",
+		"https://log.bob.io":                       "Bob's Dubious Log",
+	} {
+		li := byURL[url]
+		if li == nil {
+			t.Errorf("logInfoByURL()[%q] missing", url)
+			continue
+		}
+		if li.Description != want {
+			t.Errorf("logInfoByURL()[%q].Description=%q; want %q", url, li.Description, want)
+		}
+	}
+
+	failing := func(*loglist3.Log, *http.Client) (*LogInfo, error) {
+		return nil, errors.New("bad log")
+	}
+	if _, err := logInfoByURL(ll, nil, failing); err == nil {
+		t.Error("logInfoByURL(failing factory)=_,nil; want _,non-nil")
+	}
+}
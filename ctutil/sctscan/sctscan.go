@@ -19,8 +19,13 @@ package main
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"sync/atomic"
 	"time"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
@@ -43,6 +48,9 @@ var (
 	numWorkers    = flag.Int("num_workers", 2, "Number of concurrent matchers")
 	parallelFetch = flag.Int("parallel_fetch", 2, "Number of concurrent GetEntries fetches")
 	startIndex    = flag.Int64("start_index", 0, "Log index to start scanning at")
+	sctClockSkew  = flag.Duration("sct_clock_skew", 5*time.Minute, "How far ahead of the local clock an SCT timestamp may be before it is flagged")
+	logGenesis    = flag.String("log_genesis", "", "If set, RFC 3339 time before which SCT timestamps are flagged, e.g. the start of the log")
+	summaryJSON   = flag.Bool("summary_json", false, "Whether to print the end of scan summary as JSON")
 )
 
 func main() {
@@ -75,6 +83,12 @@ func main() {
 	if err != nil {
 		klog.Exitf("Failed to parse log list: %v", err)
 	}
+	bounds := sctTimestampBounds{Skew: *sctClockSkew}
+	if *logGenesis != "" {
+		if bounds.Genesis, err = time.Parse(time.RFC3339, *logGenesis); err != nil {
+			klog.Exitf("Failed to parse --log_genesis: %v", err)
+		}
+	}
 	klog.Warning("Performing validations via direct log queries")
 	logsByHash, err := ctutil.LogInfoByKeyHash(ll, hc)
 	if err != nil {
@@ -92,17 +106,61 @@ func main() {
 	}
 	s := scanner.NewScanner(logClient, scanOpts)
 
-	if err := s.Scan(ctx,
+	var stats scanStats
+	err = s.Scan(ctx,
 		func(entry *ct.RawLogEntry) {
-			checkCertWithEmbeddedSCT(ctx, logsByHash, *inclusion, entry)
+			checkCertWithEmbeddedSCT(ctx, logsByHash, bounds, *inclusion, &stats, entry)
 		},
 		func(entry *ct.RawLogEntry) {
 			klog.Errorf("Internal error: found pre-cert! %+v", entry)
-		}); err != nil {
+		})
+	if werr := stats.summary().write(os.Stdout, *summaryJSON); werr != nil {
+		klog.Errorf("Failed to write summary: %v", werr)
+	}
+	if err != nil {
 		klog.Exitf("Scan failed: %v", err)
 	}
 }
 
+// scanStats counts the outcomes of checking certificates. It is updated
+// concurrently by the scanner's workers.
+type scanStats struct {
+	certs             atomic.Int64
+	scts              atomic.Int64
+	sigFailures       atomic.Int64
+	inclusionFailures atomic.Int64
+	unknownLogSCTs    atomic.Int64
+}
+
+// scanSummary is a snapshot of scanStats, for reporting at the end of a scan.
+type scanSummary struct {
+	CertsScanned      int64 `json:"certs_scanned"`
+	SCTsChecked       int64 `json:"scts_checked"`
+	SignatureFailures int64 `json:"signature_failures"`
+	InclusionFailures int64 `json:"inclusion_failures"`
+	UnknownLogSCTs    int64 `json:"unknown_log_scts"`
+}
+
+func (s *scanStats) summary() scanSummary {
+	return scanSummary{
+		CertsScanned:      s.certs.Load(),
+		SCTsChecked:       s.scts.Load(),
+		SignatureFailures: s.sigFailures.Load(),
+		InclusionFailures: s.inclusionFailures.Load(),
+		UnknownLogSCTs:    s.unknownLogSCTs.Load(),
+	}
+}
+
+// write prints the summary to w, either as JSON or as one line per counter.
+func (s scanSummary) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(s)
+	}
+	_, err := fmt.Fprintf(w, "Certs scanned:      %d\nSCTs checked:       %d\nSignature failures: %d\nInclusion failures: %d\nUnknown log SCTs:   %d\n",
+		s.CertsScanned, s.SCTsChecked, s.SignatureFailures, s.InclusionFailures, s.UnknownLogSCTs)
+	return err
+}
+
 // EmbeddedSCTMatcher implements the scanner.Matcher interface by matching just certificates
 // that have embedded SCTs.
 type EmbeddedSCTMatcher struct{}
@@ -118,9 +176,30 @@ func (e EmbeddedSCTMatcher) PrecertificateMatches(*ct.Precertificate) bool {
 	return false
 }
 
+// sctTimestampBounds describes the range of plausible SCT timestamps.
+type sctTimestampBounds struct {
+	// Skew is how far ahead of the local clock an SCT timestamp may be.
+	Skew time.Duration
+	// Genesis is the earliest plausible SCT timestamp, if non-zero.
+	Genesis time.Time
+}
+
+// check returns an error if the given SCT timestamp is later than now plus the
+// allowed clock skew, or earlier than the genesis time.
+func (b sctTimestampBounds) check(timestamp uint64, now time.Time) error {
+	when := ct.TimestampToTime(timestamp)
+	if limit := now.Add(b.Skew); when.After(limit) {
+		return fmt.Errorf("timestamp %d (%v) is in the future, after %v", timestamp, when, limit)
+	}
+	if !b.Genesis.IsZero() && when.Before(b.Genesis) {
+		return fmt.Errorf("timestamp %d (%v) is before log genesis at %v", timestamp, when, b.Genesis)
+	}
+	return nil
+}
+
 // checkCertWithEmbeddedSCT is the callback that the scanner invokes for each cert found by the matcher.
 // Here, we only expect to get certificates that have embedded SCT lists.
-func checkCertWithEmbeddedSCT(ctx context.Context, logsByKey map[[sha256.Size]byte]*ctutil.LogInfo, checkInclusion bool, rawEntry *ct.RawLogEntry) {
+func checkCertWithEmbeddedSCT(ctx context.Context, logsByKey map[[sha256.Size]byte]*ctutil.LogInfo, bounds sctTimestampBounds, checkInclusion bool, stats *scanStats, rawEntry *ct.RawLogEntry) {
 	entry, err := rawEntry.ToLogEntry()
 	if x509.IsFatal(err) {
 		klog.Errorf("[%d] Internal error: failed to parse cert in entry: %v", rawEntry.Index, err)
@@ -132,6 +211,7 @@ func checkCertWithEmbeddedSCT(ctx context.Context, logsByKey map[[sha256.Size]by
 		klog.Errorf("[%d] Internal error: no cert in entry", entry.Index)
 		return
 	}
+	stats.certs.Add(1)
 	if len(entry.Chain) == 0 {
 		klog.Errorf("[%d] No issuance chain found", entry.Index)
 		return
@@ -155,14 +235,20 @@ func checkCertWithEmbeddedSCT(ctx context.Context, logsByKey map[[sha256.Size]by
 			klog.Errorf("[%d] Failed to deserialize SCT[%d] data: %v", entry.Index, i, err)
 			continue
 		}
+		stats.scts.Add(1)
+		if err := bounds.check(sct.Timestamp, time.Now()); err != nil {
+			klog.Warningf("[%d] Implausible SCT[%d] timestamp: %v", entry.Index, i, err)
+		}
 		logInfo := logsByKey[sct.LogID.KeyID]
 		if logInfo == nil {
 			klog.Infof("[%d] SCT[%d] for unknown logID: %x, cannot validate SCT", entry.Index, i, sct.LogID)
+			stats.unknownLogSCTs.Add(1)
 			continue
 		}
 
 		if err := logInfo.VerifySCTSignature(*sct, *merkleLeaf); err != nil {
 			klog.Errorf("[%d] Failed to verify SCT[%d] signature from log %q: %v", entry.Index, i, logInfo.Description, err)
+			stats.sigFailures.Add(1)
 		} else {
 			klog.V(1).Infof("[%d] Verified SCT[%d] against log %q", entry.Index, i, logInfo.Description)
 		}
@@ -175,13 +261,14 @@ func checkCertWithEmbeddedSCT(ctx context.Context, logsByKey map[[sha256.Size]by
 			// Inclusion failure may be OK if the SCT is within the Log's MMD
 			sth := logInfo.LastSTH()
 			if sth != nil {
-				delta := time.Duration(sth.Timestamp-sct.Timestamp) * time.Millisecond
+				delta := sth.TimestampTime().Sub(ct.TimestampToTime(sct.Timestamp))
 				if delta < logInfo.MMD {
 					klog.Warningf("[%d] Failed to verify SCT[%d] inclusion proof (%v), but Log's MMD has not passed %d -> %d < %v", entry.Index, i, err, sct.Timestamp, sth.Timestamp, logInfo.MMD)
 					continue
 				}
 			}
 			klog.Errorf("[%d] Failed to verify SCT[%d] inclusion proof: %v", entry.Index, i, err)
+			stats.inclusionFailures.Add(1)
 		} else {
 			klog.V(1).Infof("[%d] Checked SCT[%d] inclusion against log %q, at index %d", entry.Index, i, logInfo.Description, index)
 		}
@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/ctutil"
+	"github.com/OlegBabkin/certificate-transparency-go/testdata"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+)
+
+func TestSCTTimestampBoundsCheck(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	genesis := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := func(t time.Time) uint64 { return uint64(t.UnixMilli()) }
+
+	for _, test := range []struct {
+		desc    string
+		bounds  sctTimestampBounds
+		ts      uint64
+		wantErr bool
+	}{
+		{desc: "now", bounds: sctTimestampBounds{Skew: time.Minute, Genesis: genesis}, ts: ms(now)},
+		{desc: "within-skew", bounds: sctTimestampBounds{Skew: time.Minute}, ts: ms(now.Add(30 * time.Second))},
+		{desc: "future-dated", bounds: sctTimestampBounds{Skew: time.Minute}, ts: ms(now.Add(2 * time.Minute)), wantErr: true},
+		{desc: "future-no-skew", bounds: sctTimestampBounds{}, ts: ms(now.Add(time.Millisecond)), wantErr: true},
+		{desc: "at-genesis", bounds: sctTimestampBounds{Genesis: genesis}, ts: ms(genesis)},
+		{desc: "pre-genesis", bounds: sctTimestampBounds{Genesis: genesis}, ts: ms(genesis.Add(-time.Hour)), wantErr: true},
+		{desc: "no-genesis", bounds: sctTimestampBounds{}, ts: 1},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.bounds.check(test.ts, now)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("check(%d)=%v; want err=%v", test.ts, err, test.wantErr)
+			}
+		})
+	}
+}
+
+// noProofLogClient is a client.CheckLogClient which serves an STH but can't
+// find any leaves, so that all inclusion checks fail.
+type noProofLogClient struct{}
+
+func (noProofLogClient) BaseURI() string { return "https://ct.example.com" }
+
+func (noProofLogClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	return &ct.SignedTreeHead{TreeSize: 10, Timestamp: uint64(time.Now().UnixMilli())}, nil
+}
+
+func (noProofLogClient) GetSTHConsistency(context.Context, uint64, uint64) ([][]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (noProofLogClient) GetProofByHash(context.Context, []byte, uint64) (*ct.GetProofByHashResponse, error) {
+	return nil, errors.New("leaf not found")
+}
+
+func TestScanStats(t *testing.T) {
+	keyDER, err := base64.StdEncoding.DecodeString(testdata.LogPublicKeyB64)
+	if err != nil {
+		t.Fatalf("failed to decode log key: %v", err)
+	}
+	pk, err := ct.PublicKeyFromB64(testdata.LogPublicKeyB64)
+	if err != nil {
+		t.Fatalf("PublicKeyFromB64(): %v", err)
+	}
+	verifier, err := ct.NewSignatureVerifier(pk)
+	if err != nil {
+		t.Fatalf("NewSignatureVerifier(): %v", err)
+	}
+	known := map[[sha256.Size]byte]*ctutil.LogInfo{
+		sha256.Sum256(keyDER): {Description: "test log", Client: noProofLogClient{}, Verifier: verifier},
+	}
+	unknown := map[[sha256.Size]byte]*ctutil.LogInfo{}
+
+	entry := func(index int64, certPEM string) *ct.RawLogEntry {
+		t.Helper()
+		chain, err := x509util.CertificatesFromPEM([]byte(certPEM + testdata.CACertPEM))
+		if err != nil {
+			t.Fatalf("CertificatesFromPEM(): %v", err)
+		}
+		cert := ct.ASN1Cert{Data: chain[0].Raw}
+		return &ct.RawLogEntry{
+			Index: index,
+			Leaf:  *ct.CreateX509MerkleTreeLeaf(cert, 1000),
+			Cert:  cert,
+			Chain: []ct.ASN1Cert{{Data: chain[1].Raw}},
+		}
+	}
+
+	var stats scanStats
+	bounds := sctTimestampBounds{Skew: time.Minute}
+	ctx := context.Background()
+	for _, e := range []struct {
+		logs  map[[sha256.Size]byte]*ctutil.LogInfo
+		entry *ct.RawLogEntry
+	}{
+		{logs: known, entry: entry(0, testdata.TestEmbeddedCertPEM)},
+		{logs: known, entry: entry(1, testdata.TestInvalidEmbeddedCertPEM)},
+		{logs: unknown, entry: entry(2, testdata.TestEmbeddedCertPEM)},
+	} {
+		checkCertWithEmbeddedSCT(ctx, e.logs, bounds, true, &stats, e.entry)
+	}
+
+	want := scanSummary{
+		CertsScanned:      3,
+		SCTsChecked:       3,
+		SignatureFailures: 1,
+		InclusionFailures: 2,
+		UnknownLogSCTs:    1,
+	}
+	got := stats.summary()
+	if got != want {
+		t.Errorf("summary()=%+v; want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := got.write(&buf, true); err != nil {
+		t.Fatalf("write(json)=%v", err)
+	}
+	var parsed scanSummary
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("failed to parse JSON summary %q: %v", buf.String(), err)
+	}
+	if parsed != want {
+		t.Errorf("JSON summary=%+v; want %+v", parsed, want)
+	}
+}
@@ -20,22 +20,49 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/OlegBabkin/certificate-transparency-go/client"
 	"github.com/OlegBabkin/certificate-transparency-go/fixchain"
 	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
 	"golang.org/x/time/rate"
 )
 
+var inputFormat = flag.String("input_format", "json", "Format of the chains input: json for a file of JSON-encoded DER chains, or pem for a file, or a directory of files, each holding one chain as concatenated PEM certificates")
+
+// chainQueuer is the part of fixchain.FixAndLog that processChains uses.
+type chainQueuer interface {
+	QueueAllCertsInChain(chain []*x509.Certificate)
+}
+
+// processChains queues all the chains read from the given path, which is in
+// the given input format.
+func processChains(path, format string, fl chainQueuer) {
+	switch format {
+	case "json":
+		processJSONChains(path, fl)
+	case "pem":
+		if err := processPEMChains(path, fl); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("Unknown input format %q", format)
+	}
+}
+
 // Assumes chains to be stores in a file in JSON encoded with the certificates
 // in DER format.
-func processChains(file string, fl *fixchain.FixAndLog) {
+func processJSONChains(file string, fl chainQueuer) {
 	f, err := os.Open(file)
 	if err != nil {
 		log.Fatalf("Can't open %q: %s", file, err)
@@ -71,6 +98,57 @@ func processChains(file string, fl *fixchain.FixAndLog) {
 	}
 }
 
+// processPEMChains queues the chain held in the file at path, or if path is
+// a directory the chains held in each of the files in it, in name order.
+// Each file holds one chain as concatenated PEM certificates, leaf first.
+func processPEMChains(path string, fl chainQueuer) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		files = nil
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	for _, file := range files {
+		chain, err := readPEMChain(file)
+		if err != nil {
+			return err
+		}
+		fl.QueueAllCertsInChain(chain)
+	}
+	return nil
+}
+
+// readPEMChain parses the chain of concatenated PEM certificates in file.
+func readPEMChain(file string) ([]*x509.Certificate, error) {
+	ders, err := x509util.ReadPossiblePEMFile(file, "CERTIFICATE")
+	if err != nil {
+		return nil, err
+	}
+	if len(ders) == 0 {
+		return nil, fmt.Errorf("%s: no certificates found", file)
+	}
+	chain := make([]*x509.Certificate, 0, len(ders))
+	for _, der := range ders {
+		cert, err := x509.ParseCertificate(der)
+		if x509.IsFatal(err) {
+			return nil, fmt.Errorf("%s: can't parse certificate: %s", file, err)
+		}
+		chain = append(chain, cert)
+	}
+	return chain, nil
+}
+
 // A simple function to save the FixErrors that are spat out by the FixAndLog to
 // a directory.  contentStore() is the function to alter to store the errors
 // wherever/however they need to be stored.  Both logStringErrors() and
@@ -105,11 +183,30 @@ func logStringErrors(wg *sync.WaitGroup, errors chan *fixchain.FixError, baseDir
 	}
 }
 
+// logStats logs the progress of fl every second until ctx is cancelled.
+func logStats(ctx context.Context, fl *fixchain.FixAndLog) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		fs, ls := fl.Stats()
+		log.Printf("fixers: %d active, %d reconstructed, %d not reconstructed, %d fixed, %d not fixed, %d valid chains produced, %d valid chains sent",
+			fs.Active, fs.Reconstructed, fs.NotReconstructed, fs.Fixed, fs.NotFixed, fs.ValidChainsProduced, fs.ValidChainsOut)
+		log.Printf("posters: %d active, %d posted, %d queued, %d certs requeued, %d chains requeued",
+			ls.Active, ls.Posted, ls.Queued, ls.Reposted, ls.ChainReposted)
+	}
+}
+
 func main() {
+	flag.Parse()
 	ctx := context.Background()
-	logURL := os.Args[1]
-	chainsFile := os.Args[2]
-	errDir := os.Args[3]
+	logURL := flag.Arg(0)
+	chainsFile := flag.Arg(1)
+	errDir := flag.Arg(2)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -124,12 +221,16 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to create log client: %v", err)
 	}
-	fl := fixchain.NewFixAndLog(ctx, 100, 100, errors, c, logClient, limiter, true)
+	fl := fixchain.NewFixAndLog(ctx, 100, 100, errors, c, logClient, limiter, false)
+
+	statsCtx, stopStats := context.WithCancel(ctx)
+	go logStats(statsCtx, fl)
 
-	processChains(chainsFile, fl)
+	processChains(chainsFile, *inputFormat, fl)
 
 	log.Printf("Wait for fixers and loggers")
 	fl.Wait()
+	stopStats()
 	close(errors)
 	log.Printf("Wait for errors")
 	wg.Wait()
@@ -0,0 +1,114 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OlegBabkin/certificate-transparency-go/testdata"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+)
+
+// recordingQueuer records the chains queued to it.
+type recordingQueuer struct {
+	chains [][]*x509.Certificate
+}
+
+func (q *recordingQueuer) QueueAllCertsInChain(chain []*x509.Certificate) {
+	q.chains = append(q.chains, chain)
+}
+
+func TestProcessPEMChainsDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.pem": testdata.TestCertPEM + testdata.CACertPEM,
+		"b.pem": testdata.TestPreCertPEM + testdata.CACertPEM,
+		"c.pem": testdata.CACertPEM,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatalf("WriteFile(%s): %v", name, err)
+		}
+	}
+	// Subdirectories are not descended into.
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("Mkdir(): %v", err)
+	}
+
+	var q recordingQueuer
+	if err := processPEMChains(dir, &q); err != nil {
+		t.Fatalf("processPEMChains()=%v, want nil", err)
+	}
+
+	want := [][]string{
+		{testdata.TestCertPEM, testdata.CACertPEM},
+		{testdata.TestPreCertPEM, testdata.CACertPEM},
+		{testdata.CACertPEM},
+	}
+	if got := len(q.chains); got != len(want) {
+		t.Fatalf("processPEMChains() queued %d chains, want %d", got, len(want))
+	}
+	for i, wantChain := range want {
+		gotChain := q.chains[i]
+		if len(gotChain) != len(wantChain) {
+			t.Errorf("chain %d: got %d certs, want %d", i, len(gotChain), len(wantChain))
+			continue
+		}
+		for j, pemData := range wantChain {
+			wantCert, err := x509util.CertificateFromPEM([]byte(pemData))
+			if x509.IsFatal(err) {
+				t.Fatalf("CertificateFromPEM(): %v", err)
+			}
+			if !gotChain[j].Equal(wantCert) {
+				t.Errorf("chain %d: cert %d is %q, want %q", i, j, gotChain[j].Subject, wantCert.Subject)
+			}
+		}
+	}
+}
+
+func TestProcessPEMChainsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "chain.pem")
+	if err := os.WriteFile(file, []byte(testdata.TestCertPEM+testdata.CACertPEM), 0o644); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+
+	var q recordingQueuer
+	if err := processPEMChains(file, &q); err != nil {
+		t.Fatalf("processPEMChains()=%v, want nil", err)
+	}
+	if got, want := len(q.chains), 1; got != want {
+		t.Fatalf("processPEMChains() queued %d chains, want %d", got, want)
+	}
+	if got, want := len(q.chains[0]), 2; got != want {
+		t.Errorf("processPEMChains() queued chain of %d certs, want %d", got, want)
+	}
+}
+
+func TestProcessPEMChainsErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing.pem"), empty} {
+		var q recordingQueuer
+		if err := processPEMChains(path, &q); err == nil {
+			t.Errorf("processPEMChains(%q)=nil, want error", path)
+		}
+	}
+}
@@ -16,6 +16,7 @@
 package fixchain
 
 import (
+	"context"
 	"encoding/pem"
 	"net/http"
 
@@ -28,11 +29,13 @@ import (
 // presence of FixErrors does not mean the fix was unsuccessful.  Callers should
 // check for returned chains to determine success.
 func Fix(cert *x509.Certificate, chain []*x509.Certificate, roots *x509.CertPool, client *http.Client) ([][]*x509.Certificate, []*FixError) {
+	ctx := context.Background()
 	fix := &toFix{
+		ctx:   ctx,
 		cert:  cert,
 		chain: newDedupedChain(chain),
 		roots: roots,
-		cache: newURLCache(client, false),
+		cache: newURLCache(ctx, client, false),
 	}
 	return fix.handleChain()
 }
@@ -40,11 +43,15 @@ func Fix(cert *x509.Certificate, chain []*x509.Certificate, roots *x509.CertPool
 const maxChainLength = 20
 
 type toFix struct {
+	ctx   context.Context
 	cert  *x509.Certificate
 	chain *dedupedChain
 	roots *x509.CertPool
 	opts  *x509.VerifyOptions
 	cache *urlCache
+	// extra holds candidate intermediates which are tried, along with the
+	// chain, before fetching any certificate from the network.
+	extra []*x509.Certificate
 }
 
 func (fix *toFix) handleChain() ([][]*x509.Certificate, []*FixError) {
@@ -52,6 +59,9 @@ func (fix *toFix) handleChain() ([][]*x509.Certificate, []*FixError) {
 	for _, c := range fix.chain.certs {
 		intermediates.AddCert(c)
 	}
+	for _, c := range fix.extra {
+		intermediates.AddCert(c)
+	}
 
 	fix.opts = &x509.VerifyOptions{
 		Intermediates:     intermediates,
@@ -191,6 +201,10 @@ func (fix *toFix) augmentIntermediates(cert *x509.Certificate, length int, seen
 	// as they are found.
 	var retferrs []*FixError
 	for _, url := range cert.IssuingCertificateURL {
+		// Stop exploring once the fix has been cancelled.
+		if fix.ctx.Err() != nil {
+			break
+		}
 		icerts, ferr := fix.getIntermediates(url)
 		if ferr != nil {
 			retferrs = append(retferrs, ferr)
@@ -219,7 +233,7 @@ func (fix *toFix) getIntermediates(url string) ([]*x509.Certificate, *FixError)
 		return r, nil
 	}
 
-	body, err := fix.cache.getURL(url)
+	body, err := fix.cache.getURL(fix.ctx, url)
 	if err != nil {
 		return nil, &FixError{
 			Type:  CannotFetchURL,
@@ -98,6 +98,12 @@ func (fl *FixAndLog) Wait() {
 	fl.logger.Wait()
 }
 
+// Stats returns the current counters of the Fixer and the Logger of fl.  It is
+// safe to call concurrently with their workers.
+func (fl *FixAndLog) Stats() (FixerStats, LoggerStats) {
+	return fl.fixer.Stats(), fl.logger.Stats()
+}
+
 // NewFixAndLog creates an object that will asynchronously fix any chains that
 // are added to its queue, and then log them to the Certificate Transparency log
 // found at the given url.  Any errors encountered along the way are pushed to
@@ -105,7 +111,7 @@ func (fl *FixAndLog) Wait() {
 func NewFixAndLog(ctx context.Context, fixerWorkerCount int, loggerWorkerCount int, errors chan<- *FixError, client *http.Client, logClient client.AddLogClient, limiter Limiter, logStats bool) *FixAndLog {
 	chains := make(chan []*x509.Certificate)
 	fl := &FixAndLog{
-		fixer:  NewFixer(fixerWorkerCount, chains, errors, client, logStats),
+		fixer:  NewFixer(ctx, fixerWorkerCount, chains, errors, client, logStats),
 		chains: chains,
 		logger: NewLogger(ctx, loggerWorkerCount, errors, logClient, limiter, logStats),
 		done:   newLockedMap(),
@@ -122,7 +128,13 @@ func NewFixAndLog(ctx context.Context, fixerWorkerCount int, loggerWorkerCount i
 	if logStats {
 		t := time.NewTicker(time.Second)
 		go func() {
-			for range t.C {
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+				}
 				log.Printf("fix-then-log: %d whole chains queued, %d whole chains already done, %d total chains queued, %d chains don't need posting (cache hits), %d chains sent to fixer", fl.queued, fl.alreadyDone, fl.chainsQueued, fl.alreadyPosted, fl.chainsSent)
 			}
 		}()
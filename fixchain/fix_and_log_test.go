@@ -222,6 +222,10 @@ func TestNewFixAndLog(t *testing.T) {
 		close(errors)
 		wg.Wait()
 
+		if fs, ls := fl.Stats(); fs != fl.fixer.Stats() || ls != fl.logger.Stats() {
+			t.Errorf("#%d: Stats()=%+v, %+v, want the Fixer's and Logger's", i, fs, ls)
+		}
+
 		// Check that no chains that were expected to be logged were not.
 		for j, val := range seen {
 			if !val {
@@ -283,7 +287,7 @@ NextToFix:
 func TestQueueAllCertsInChain(t *testing.T) {
 	ctx := context.Background()
 	for i, test := range fixAndLogQueueTests {
-		f := &Fixer{toFix: make(chan *toFix)}
+		f := &Fixer{ctx: ctx, toFix: make(chan *toFix)}
 		c := &http.Client{Transport: &testRoundTripper{}}
 		logClient, err := client.New(test.url, c, jsonclient.Options{})
 		if err != nil {
@@ -324,7 +328,7 @@ func testFixAndLogQueueChain(t *testing.T, i int, test *fixAndLogTest, fl *FixAn
 func TestFixAndLogQueueChain(t *testing.T) {
 	ctx := context.Background()
 	for i, test := range fixAndLogQueueTests {
-		f := &Fixer{toFix: make(chan *toFix)}
+		f := &Fixer{ctx: ctx, toFix: make(chan *toFix)}
 		c := &http.Client{Transport: &testRoundTripper{}}
 		logClient, err := client.New(test.url, c, jsonclient.Options{})
 		if err != nil {
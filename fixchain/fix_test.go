@@ -15,6 +15,7 @@
 package fixchain
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -160,11 +161,13 @@ var fixChainTests = []fixTest{
 
 func setUpFix(t *testing.T, i int, ft *fixTest) *toFix {
 	// Create & populate toFix to test from fixTest info
+	ctx := context.Background()
 	fix := &toFix{
+		ctx:   ctx,
 		cert:  GetTestCertificateFromPEM(t, ft.cert),
 		chain: newDedupedChain(extractTestChain(t, i, ft.chain)),
 		roots: extractTestRoots(t, i, ft.roots),
-		cache: newURLCache(&http.Client{Transport: &testRoundTripper{}}, false),
+		cache: newURLCache(ctx, &http.Client{Transport: &testRoundTripper{}}, false),
 	}
 
 	intermediates := x509.NewCertPool()
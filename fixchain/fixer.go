@@ -16,6 +16,7 @@ package fixchain
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"net/http"
 	"sort"
@@ -30,6 +31,7 @@ import (
 // properties to store information about each attempt that is made to fix a
 // certificate chain.
 type Fixer struct {
+	ctx    context.Context
 	toFix  chan *toFix
 	chains chan<- []*x509.Certificate // Chains successfully fixed by the fixer
 	errors chan<- *FixError
@@ -45,21 +47,56 @@ type Fixer struct {
 
 	wg    sync.WaitGroup
 	cache *urlCache
+	extra []*x509.Certificate
+}
+
+// FixerStats holds a snapshot of the counters of a Fixer.
+type FixerStats struct {
+	Active              uint32 // Chains currently being fixed.
+	Reconstructed       uint32 // Chains which verified as given.
+	NotReconstructed    uint32 // Chains which did not verify as given.
+	Fixed               uint32 // Chains which did not verify as given, but were fixed.
+	NotFixed            uint32 // Chains which could not be fixed.
+	ValidChainsProduced uint32 // Valid chains built, including super chains.
+	ValidChainsOut      uint32 // Valid chains sent to the chains channel.
+}
+
+// Stats returns the current counters of the Fixer.  It is safe to call
+// concurrently with the Fixer's workers.
+func (f *Fixer) Stats() FixerStats {
+	return FixerStats{
+		Active:              atomic.LoadUint32(&f.active),
+		Reconstructed:       atomic.LoadUint32(&f.reconstructed),
+		NotReconstructed:    atomic.LoadUint32(&f.notReconstructed),
+		Fixed:               atomic.LoadUint32(&f.fixed),
+		NotFixed:            atomic.LoadUint32(&f.notFixed),
+		ValidChainsProduced: atomic.LoadUint32(&f.validChainsProduced),
+		ValidChainsOut:      atomic.LoadUint32(&f.validChainsOut),
+	}
 }
 
 // QueueChain adds the given cert and chain to the queue to be fixed by the
 // fixer, with respect to the given roots.  Note: chain is expected to be in the
-// order of cert --> root.
+// order of cert --> root.  Once the fixer's context is cancelled, the chain is
+// dropped.
 func (f *Fixer) QueueChain(cert *x509.Certificate, chain []*x509.Certificate, roots *x509.CertPool) {
-	f.toFix <- &toFix{
+	fix := &toFix{
+		ctx:   f.ctx,
 		cert:  cert,
 		chain: newDedupedChain(chain),
 		roots: roots,
 		cache: f.cache,
+		extra: f.extra,
+	}
+	select {
+	case f.toFix <- fix:
+	case <-f.ctx.Done():
 	}
 }
 
-// Wait for all the fixer workers to finish.
+// Wait for all the fixer workers to finish.  If the fixer's context is
+// cancelled, the workers abandon the chains they are fixing and Wait returns
+// promptly.
 func (f *Fixer) Wait() {
 	close(f.toFix)
 	f.wg.Wait()
@@ -213,23 +250,47 @@ NextChain:
 func (f *Fixer) fixServer() {
 	defer f.wg.Done()
 
-	for fix := range f.toFix {
+	for {
+		var fix *toFix
+		select {
+		case <-f.ctx.Done():
+			return
+		case next, ok := <-f.toFix:
+			if !ok {
+				return
+			}
+			fix = next
+		}
 		atomic.AddUint32(&f.active, 1)
-		chains, ferrs := fix.handleChain()
-		f.updateCounters(chains, ferrs)
-		for _, ferr := range ferrs {
-			f.errors <- ferr
+		f.handle(fix)
+		atomic.AddUint32(&f.active, ^uint32(0))
+	}
+}
+
+// handle fixes a single chain and sends on the results, unless the fixer's
+// context is cancelled.
+func (f *Fixer) handle(fix *toFix) {
+	chains, ferrs := fix.handleChain()
+	f.updateCounters(chains, ferrs)
+	for _, ferr := range ferrs {
+		select {
+		case f.errors <- ferr:
+		case <-f.ctx.Done():
+			return
 		}
+	}
 
-		// If handleChain() outputs valid chains that are subchains of other
-		// valid chains, (where the subchains start at the leaf)
-		// e.g. A -> B -> C and A -> B -> C -> D, only forward on the shorter
-		// of the chains.
-		for _, chain := range removeSuperChains(chains) {
-			f.chains <- chain
-			atomic.AddUint32(&f.validChainsOut, 1)
+	// If handleChain() outputs valid chains that are subchains of other
+	// valid chains, (where the subchains start at the leaf)
+	// e.g. A -> B -> C and A -> B -> C -> D, only forward on the shorter
+	// of the chains.
+	for _, chain := range removeSuperChains(chains) {
+		select {
+		case f.chains <- chain:
+		case <-f.ctx.Done():
+			return
 		}
-		atomic.AddUint32(&f.active, ^uint32(0))
+		atomic.AddUint32(&f.validChainsOut, 1)
 	}
 }
 
@@ -243,12 +304,19 @@ func (f *Fixer) newFixServerPool(workerCount int) {
 func (f *Fixer) logStats() {
 	t := time.NewTicker(time.Second)
 	go func() {
-		for range t.C {
+		defer t.Stop()
+		for {
+			select {
+			case <-f.ctx.Done():
+				return
+			case <-t.C:
+			}
+			s := f.Stats()
 			log.Printf("fixers: %d active, %d reconstructed, "+
 				"%d not reconstructed, %d fixed, %d not fixed, "+
 				"%d valid chains produced, %d valid chains sent",
-				f.active, f.reconstructed, f.notReconstructed,
-				f.fixed, f.notFixed, f.validChainsProduced, f.validChainsOut)
+				s.Active, s.Reconstructed, s.NotReconstructed,
+				s.Fixed, s.NotFixed, s.ValidChainsProduced, s.ValidChainsOut)
 		}
 	}()
 }
@@ -256,13 +324,24 @@ func (f *Fixer) logStats() {
 // NewFixer creates a new asynchronous fixer and starts up a pool of
 // workerCount workers.  Errors are pushed to the errors channel, and fixed
 // chains are pushed to the chains channel.  client is used to try to get any
-// missing certificates that are needed when attempting to fix chains.
-func NewFixer(workerCount int, chains chan<- []*x509.Certificate, errors chan<- *FixError, client *http.Client, logStats bool) *Fixer {
+// missing certificates that are needed when attempting to fix chains.  The
+// workers stop, abandoning any pending fetches, when ctx is cancelled.
+func NewFixer(ctx context.Context, workerCount int, chains chan<- []*x509.Certificate, errors chan<- *FixError, client *http.Client, logStats bool) *Fixer {
+	return NewFixerWithIntermediates(ctx, workerCount, chains, errors, client, nil, logStats)
+}
+
+// NewFixerWithIntermediates is like NewFixer, but the fixer also uses the
+// given candidate intermediates when building chains.  These are tried before
+// any certificate is fetched from the network, so chains that can be fixed
+// with them alone need no network access at all.
+func NewFixerWithIntermediates(ctx context.Context, workerCount int, chains chan<- []*x509.Certificate, errors chan<- *FixError, client *http.Client, intermediates []*x509.Certificate, logStats bool) *Fixer {
 	f := &Fixer{
+		ctx:    ctx,
 		toFix:  make(chan *toFix),
 		chains: chains,
 		errors: errors,
-		cache:  newURLCache(client, logStats),
+		cache:  newURLCache(ctx, client, logStats),
+		extra:  intermediates,
 	}
 
 	f.newFixServerPool(workerCount)
@@ -15,9 +15,11 @@
 package fixchain
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 )
@@ -45,7 +47,7 @@ func TestNewFixer(t *testing.T) {
 		testErrors(t, 0, expectedErrs, errors)
 	}()
 
-	f := NewFixer(10, chains, errors, &http.Client{Transport: &testRoundTripper{}}, false)
+	f := NewFixer(context.Background(), 10, chains, errors, &http.Client{Transport: &testRoundTripper{}}, false)
 	for _, test := range handleChainTests {
 		f.QueueChain(GetTestCertificateFromPEM(t, test.cert),
 			extractTestChain(t, 0, test.chain), extractTestRoots(t, 0, test.roots))
@@ -57,10 +59,131 @@ func TestNewFixer(t *testing.T) {
 	wg.Wait()
 }
 
+// NewFixerWithIntermediates() test
+func TestNewFixerWithIntermediates(t *testing.T) {
+	tests := []struct {
+		cert           string
+		intermediates  []string
+		roots          []string
+		expectedChains [][]string
+	}{
+		{
+			cert:          googleLeaf,
+			intermediates: []string{thawteIntermediate},
+			roots:         []string{verisignRoot},
+			expectedChains: [][]string{
+				{"Google", "Thawte", "VeriSign"},
+			},
+		},
+		{
+			cert:          testLeaf,
+			intermediates: []string{testIntermediate1, megaLeaf, testIntermediate2},
+			roots:         []string{testRoot},
+			expectedChains: [][]string{
+				{"Leaf", "Intermediate2", "Intermediate1", "CA"},
+			},
+		},
+	}
+	for i, test := range tests {
+		chains := make(chan []*x509.Certificate)
+		errors := make(chan *FixError)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			testChains(t, i, test.expectedChains, chains)
+		}()
+		go func() {
+			defer wg.Done()
+			testErrors(t, i, nil, errors)
+		}()
+
+		// The chains must be fixable from the supplied intermediates alone.
+		client := &http.Client{Transport: offlineRoundTripper{t: t}}
+		f := NewFixerWithIntermediates(context.Background(), 1, chains, errors, client, extractTestChain(t, i, test.intermediates), false)
+		f.QueueChain(GetTestCertificateFromPEM(t, test.cert), nil, extractTestRoots(t, i, test.roots))
+		f.Wait()
+
+		close(chains)
+		close(errors)
+		wg.Wait()
+	}
+}
+
+// Fixer.Stats() test
+func TestFixerStats(t *testing.T) {
+	chains := make(chan []*x509.Certificate)
+	errors := make(chan *FixError)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range chains {
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range errors {
+		}
+	}()
+
+	f := NewFixer(context.Background(), 1, chains, errors, &http.Client{Transport: &testRoundTripper{}}, false)
+	roots := extractTestRoots(t, 0, []string{verisignRoot})
+	// Verifies as given.
+	f.QueueChain(GetTestCertificateFromPEM(t, googleLeaf), extractTestChain(t, 0, []string{thawteIntermediate, verisignRoot}), roots)
+	// Fixed by fetching the intermediate.
+	f.QueueChain(GetTestCertificateFromPEM(t, googleLeaf), nil, roots)
+	// Can't be fixed, the intermediate and root are wrong.
+	f.QueueChain(GetTestCertificateFromPEM(t, megaLeaf), extractTestChain(t, 0, []string{thawteIntermediate, verisignRoot}), roots)
+	f.Wait()
+
+	close(chains)
+	close(errors)
+	wg.Wait()
+
+	want := FixerStats{
+		Reconstructed:       1,
+		NotReconstructed:    2,
+		Fixed:               1,
+		NotFixed:            1,
+		ValidChainsProduced: 2,
+		ValidChainsOut:      2,
+	}
+	if got := f.Stats(); got != want {
+		t.Errorf("Stats()=%+v, want %+v", got, want)
+	}
+}
+
+// Fixer.Wait() test
+func TestFixerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chains := make(chan []*x509.Certificate)
+	errors := make(chan *FixError)
+
+	f := NewFixer(ctx, 2, chains, errors, &http.Client{Transport: &slowRoundTripper{}}, false)
+	// The intermediate has to be fetched, which never completes.
+	f.QueueChain(GetTestCertificateFromPEM(t, googleLeaf), nil, extractTestRoots(t, 0, []string{verisignRoot}))
+
+	done := make(chan struct{})
+	go func() {
+		f.Wait()
+		close(done)
+	}()
+	time.AfterFunc(100*time.Millisecond, cancel)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Wait() did not return after the context was cancelled")
+	}
+}
+
 // Fixer.fixServer() test
 func TestFixServer(t *testing.T) {
 	cache := &urlCache{cache: newLockedCache(), client: &http.Client{Transport: &testRoundTripper{}}}
-	f := &Fixer{cache: cache}
+	f := &Fixer{ctx: context.Background(), cache: cache}
 
 	var wg sync.WaitGroup
 	fixServerTests := handleChainTests
@@ -299,7 +422,7 @@ func testFixerQueueChain(t *testing.T, i int, qt *fixerQueueTest, f *Fixer) {
 func TestFixerQueueChain(t *testing.T) {
 	ch := make(chan *toFix)
 	defer close(ch)
-	f := &Fixer{toFix: ch}
+	f := &Fixer{ctx: context.Background(), toFix: ch}
 
 	for i, qt := range fixerQueueTests {
 		f.wg.Add(1)
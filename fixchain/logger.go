@@ -58,6 +58,54 @@ type Logger struct {
 
 	postCertCache  *lockedMap
 	postChainCache *lockedMap
+	// bagHashChains makes postChainCache keyed by hashBag instead of
+	// hashChain, see SetBagHashChains.
+	bagHashChains bool
+}
+
+// SetBagHashChains sets whether the Logger considers chains holding the same
+// certificates in a different order to be the same chain, and so posts only
+// the first of them. By default only chains with the same certificates in the
+// same order are deduplicated.
+//
+// Enabling this saves posting chains which, for a log, are logically identical
+// but differ in how they were ordered by their source. The tradeoff is that a
+// log may reject one ordering of a chain and accept another, e.g. because only
+// one of them is cert -> root, in which case the accepted ordering will never
+// be tried. The cache of already posted leaf certificates is unaffected.
+//
+// It must be called before any chain is queued.
+func (l *Logger) SetBagHashChains(enabled bool) {
+	l.bagHashChains = enabled
+}
+
+// chainCacheKey returns the key of the given chain in postChainCache.
+func (l *Logger) chainCacheKey(chain []*x509.Certificate) [hashSize]byte {
+	if l.bagHashChains {
+		return hashBag(chain)
+	}
+	return hashChain(chain)
+}
+
+// LoggerStats holds a snapshot of the counters of a Logger.
+type LoggerStats struct {
+	Active        uint32 // Chains currently being posted.
+	Queued        uint32 // Chains queued to be posted.
+	Posted        uint32 // Chains posted to the log.
+	Reposted      uint32 // Chains queued for a cert that had already been posted.
+	ChainReposted uint32 // Chains queued again.
+}
+
+// Stats returns the current counters of the Logger.  It is safe to call
+// concurrently with the Logger's workers.
+func (l *Logger) Stats() LoggerStats {
+	return LoggerStats{
+		Active:        atomic.LoadUint32(&l.active),
+		Queued:        atomic.LoadUint32(&l.queued),
+		Posted:        atomic.LoadUint32(&l.posted),
+		Reposted:      atomic.LoadUint32(&l.reposted),
+		ChainReposted: atomic.LoadUint32(&l.chainReposted),
+	}
 }
 
 // IsPosted tells the caller whether a chain for the given certificate has
@@ -86,7 +134,7 @@ func (l *Logger) QueueChain(chain []*x509.Certificate) {
 	// and accept another, so try each unique chain.
 
 	// Has this Logger already tried to post this chain?
-	h = hashChain(chain)
+	h = l.chainCacheKey(chain)
 	if l.postChainCache.get(h) {
 		atomic.AddUint32(&l.chainReposted, 1)
 		return
@@ -148,7 +196,12 @@ type toPost struct {
 // resulting in excessive memory usage.
 func (l *Logger) postToLog(p *toPost) {
 	l.wg.Add(1) // Add to the wg as we are adding a new active request to the logger queue.
-	l.toPost <- p
+	select {
+	case l.toPost <- p:
+	case <-l.ctx.Done():
+		// The post servers have stopped, so drop the chain.
+		l.wg.Done()
+	}
 }
 
 func (l *Logger) postChain(p *toPost) {
@@ -165,14 +218,20 @@ func (l *Logger) postChain(p *toPost) {
 
 	if err := l.limiter.Wait(l.ctx); err != nil {
 		log.Println(err)
+		if l.ctx.Err() != nil {
+			return
+		}
 	}
 	atomic.AddUint32(&l.posted, 1)
 	_, err := l.client.AddChain(l.ctx, derChain)
 	if err != nil {
-		l.errors <- &FixError{
+		select {
+		case l.errors <- &FixError{
 			Type:  LogPostFailed,
 			Chain: p.chain,
 			Error: fmt.Errorf("add-chain failed: %s", err),
+		}:
+		case <-l.ctx.Done():
 		}
 		return
 	}
@@ -183,7 +242,12 @@ func (l *Logger) postChain(p *toPost) {
 
 func (l *Logger) postServer() {
 	for {
-		c := <-l.toPost
+		var c *toPost
+		select {
+		case <-l.ctx.Done():
+			return
+		case c = <-l.toPost:
+		}
 		atomic.AddUint32(&l.active, 1)
 		l.postChain(c)
 		atomic.AddUint32(&l.active, ^uint32(0))
@@ -194,9 +258,16 @@ func (l *Logger) postServer() {
 func (l *Logger) logStats() {
 	t := time.NewTicker(time.Second)
 	go func() {
-		for range t.C {
+		defer t.Stop()
+		for {
+			select {
+			case <-l.ctx.Done():
+				return
+			case <-t.C:
+			}
+			s := l.Stats()
 			log.Printf("posters: %d active, %d posted, %d queued, %d certs requeued, %d chains requeued",
-				l.active, l.posted, l.queued, l.reposted, l.chainReposted)
+				s.Active, s.Posted, s.Queued, s.Reposted, s.ChainReposted)
 		}
 	}()
 }
@@ -204,7 +275,8 @@ func (l *Logger) logStats() {
 // NewLogger creates a new asynchronous logger to log chains to the
 // Certificate Transparency log at the given url.  It starts up a pool of
 // workerCount workers.  Errors are pushed to the errors channel.  client is
-// used to post the chains to the log.
+// used to post the chains to the log.  The workers stop, abandoning any
+// pending posts, when ctx is cancelled.
 func NewLogger(ctx context.Context, workerCount int, errors chan<- *FixError, client client.AddLogClient, limiter Limiter, logStats bool) *Logger {
 	l := &Logger{
 		ctx:            ctx,
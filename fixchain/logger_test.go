@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/OlegBabkin/certificate-transparency-go/client"
 	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
@@ -117,6 +118,120 @@ func TestNewLoggerCaching(t *testing.T) {
 	}
 }
 
+// Logger.SetBagHashChains() test
+func TestLoggerBagHashChains(t *testing.T) {
+	ctx := context.Background()
+	chains := [][]string{
+		{googleLeaf, thawteIntermediate, verisignRoot},
+		{googleLeaf, verisignRoot, thawteIntermediate},
+	}
+	tests := []struct {
+		name         string
+		bagHash      bool
+		wantPosted   uint32
+		expectedErrs []errorType
+	}{
+		{name: "chain hash", bagHash: false, wantPosted: 2, expectedErrs: []errorType{LogPostFailed, LogPostFailed}},
+		{name: "bag hash", bagHash: true, wantPosted: 1, expectedErrs: []errorType{LogPostFailed}},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errors := make(chan *FixError)
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				testErrors(t, i, test.expectedErrs, errors)
+			}()
+
+			// Posts fail, so the leaf is never cached as posted and only the
+			// chain cache can suppress the second chain.
+			pt := &postTest{ferr: &FixError{Type: LogPostFailed}}
+			c := &http.Client{Transport: &postTestRoundTripper{t: t, test: pt, testIndex: i}}
+			logClient, err := client.New("https://ct.googleapis.com/pilot", c, jsonclient.Options{})
+			if err != nil {
+				t.Fatalf("failed to create LogClient: %v", err)
+			}
+			l := NewLogger(ctx, 1, errors, logClient, newNilLimiter(), false)
+			l.SetBagHashChains(test.bagHash)
+
+			for _, chain := range chains {
+				l.QueueChain(extractTestChain(t, i, chain))
+			}
+			l.Wait()
+			close(l.errors)
+			wg.Wait()
+
+			if got := l.Stats().Posted; got != test.wantPosted {
+				t.Errorf("posted %d chains, want %d", got, test.wantPosted)
+			}
+		})
+	}
+}
+
+// Logger.Stats() test
+func TestLoggerStats(t *testing.T) {
+	ctx := context.Background()
+	errors := make(chan *FixError)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		testErrors(t, 0, nil, errors)
+	}()
+
+	c := &http.Client{Transport: &newLoggerTestRoundTripper{}}
+	logClient, err := client.New("https://ct.googleapis.com/pilot", c, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("failed to create LogClient: %v", err)
+	}
+	l := NewLogger(ctx, 1, errors, logClient, newNilLimiter(), false)
+
+	l.QueueChain(extractTestChain(t, 0, []string{googleLeaf, thawteIntermediate, verisignRoot}))
+	l.QueueChain(extractTestChain(t, 0, []string{testLeaf, testIntermediate2, testIntermediate1, testRoot}))
+	l.Wait()
+	// The leaf has been posted by now, so this is not posted again.
+	l.QueueChain(extractTestChain(t, 0, []string{googleLeaf, thawteIntermediate}))
+	l.Wait()
+	close(l.errors)
+	wg.Wait()
+
+	want := LoggerStats{Queued: 3, Posted: 2, Reposted: 1}
+	if got := l.Stats(); got != want {
+		t.Errorf("Stats()=%+v, want %+v", got, want)
+	}
+}
+
+// Logger.Wait() test
+func TestLoggerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errors := make(chan *FixError)
+	c := &http.Client{Transport: &slowRoundTripper{}}
+	logClient, err := client.New("https://ct.googleapis.com/pilot", c, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("failed to create LogClient: %v", err)
+	}
+	l := NewLogger(ctx, 1, errors, logClient, newNilLimiter(), false)
+	l.QueueChain(extractTestChain(t, 0, []string{googleLeaf, thawteIntermediate, verisignRoot}))
+
+	done := make(chan struct{})
+	go func() {
+		l.Wait()
+		close(done)
+	}()
+	time.AfterFunc(100*time.Millisecond, cancel)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Wait() did not return after the context was cancelled")
+	}
+
+	// Chains queued after cancellation are dropped without blocking.
+	l.QueueChain(extractTestChain(t, 0, []string{testLeaf, testIntermediate2, testIntermediate1, testRoot}))
+	l.Wait()
+}
+
 // Logger.postServer() test
 func TestPostServer(t *testing.T) {
 	ctx := context.Background()
@@ -217,7 +332,7 @@ func testLoggerQueueChain(t *testing.T, i int, qt *loggerQueueTest, l *Logger) {
 func TestLoggerQueueChain(t *testing.T) {
 	ch := make(chan *toPost)
 	defer close(ch)
-	l := &Logger{toPost: ch, postCertCache: newLockedMap(), postChainCache: newLockedMap()}
+	l := &Logger{ctx: context.Background(), toPost: ch, postCertCache: newLockedMap(), postChainCache: newLockedMap()}
 
 	for i, qt := range loggerQueueTests {
 		l.wg.Add(1)
@@ -318,3 +318,35 @@ func (rt rootCertsTestRoundTripper) RoundTrip(request *http.Request) (*http.Resp
 	}
 	return nil, errors.New("")
 }
+
+// offlineRoundTripper fails every request, as if there was no network.
+type offlineRoundTripper struct {
+	t *testing.T
+}
+
+func (rt offlineRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	rt.t.Errorf("unexpected request to %s", request.URL)
+	return nil, fmt.Errorf("network disabled, can't reach url %s", request.URL)
+}
+
+// slowRoundTripper serves get-roots, but blocks every other request until it
+// is cancelled.
+type slowRoundTripper struct{}
+
+func (rt slowRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if strings.Contains(request.URL.Path, "/ct/v1/get-roots") {
+		b := stringRootsToJSON([]string{verisignRoot, testRoot})
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    200,
+			Proto:         request.Proto,
+			ProtoMajor:    request.ProtoMajor,
+			ProtoMinor:    request.ProtoMinor,
+			Body:          &bytesReadCloser{bytes.NewReader(b)},
+			ContentLength: int64(len(b)),
+			Request:       request,
+		}, nil
+	}
+	<-request.Context().Done()
+	return nil, request.Context().Err()
+}
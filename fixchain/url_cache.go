@@ -15,6 +15,7 @@
 package fixchain
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -58,13 +59,18 @@ type urlCache struct {
 	readFail  uint32
 }
 
-func (u *urlCache) getURL(url string) ([]byte, error) {
+func (u *urlCache) getURL(ctx context.Context, url string) ([]byte, error) {
 	r, ok := u.cache.get(url)
 	if ok {
 		atomic.AddUint32(&u.hit, 1)
 		return r, nil
 	}
-	c, err := u.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		atomic.AddUint32(&u.errors, 1)
+		return nil, err
+	}
+	c, err := u.client.Do(req)
 	if err != nil {
 		atomic.AddUint32(&u.errors, 1)
 		return nil, err
@@ -89,13 +95,19 @@ func (u *urlCache) getURL(url string) ([]byte, error) {
 	return r, nil
 }
 
-func newURLCache(c *http.Client, logStats bool) *urlCache {
+func newURLCache(ctx context.Context, c *http.Client, logStats bool) *urlCache {
 	u := &urlCache{cache: newLockedCache(), client: c}
 
 	if logStats {
 		t := time.NewTicker(time.Second)
 		go func() {
-			for range t.C {
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+				}
 				klog.Infof("url cache: %d hits, %d misses, %d errors, "+
 					"%d bad status, %d read fail, %d cached", u.hit,
 					u.miss, u.errors, u.badStatus, u.readFail,
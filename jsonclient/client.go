@@ -18,6 +18,7 @@ package jsonclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto"
 	"encoding/json"
@@ -61,6 +62,8 @@ type JSONClient struct {
 	backoff       backoffer             // object used to store and calculate backoff information
 	userAgent     string                // If set, this is sent as the UserAgent header.
 	authorization string                // If set, this is sent as the Authorization header.
+	retryPolicy   *RetryPolicy          // If set, GetAndParse retries requests rejected as overloaded.
+	gzip          bool                  // If set, gzip-encoded responses are requested and decoded.
 }
 
 // Logger is a simple logging interface used to log internal errors and warnings
@@ -82,6 +85,73 @@ type Options struct {
 	UserAgent string
 	// If set, this is sent as the Authorization header with each request.
 	Authorization string
+	// RetryPolicy, if set, makes GetAndParse retry requests that the server
+	// rejects as overloaded.
+	RetryPolicy *RetryPolicy
+	// DisableCompression, if set, stops the client from requesting
+	// gzip-encoded responses and decoding them.
+	DisableCompression bool
+}
+
+// RetryPolicy describes how GetAndParse retries requests which fail with HTTP
+// status 429 (Too Many Requests) or 503 (Service Unavailable).
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry, which is doubled for each
+	// subsequent retry up to MaxBackoff. The server's Retry-After header, if
+	// present, takes precedence.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Jitter is the maximum random duration added to each wait.
+	Jitter time.Duration
+}
+
+// wait returns how long to wait before retrying after the given attempt,
+// which got the given response, and whether to retry at all.
+func (p *RetryPolicy) wait(attempt int, httpRsp *http.Response) (time.Duration, bool) {
+	if p == nil || attempt >= p.MaxAttempts || httpRsp == nil {
+		return 0, false
+	}
+	if httpRsp.StatusCode != http.StatusTooManyRequests && httpRsp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	var wait time.Duration
+	if retryAfter := parseRetryAfter(httpRsp); retryAfter != nil {
+		wait = *retryAfter
+	} else {
+		wait = p.MinBackoff
+		for i := 1; i < attempt && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+			wait *= 2
+		}
+		if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+			wait = p.MaxBackoff
+		}
+	}
+	if p.Jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(p.Jitter)))
+	}
+	return wait, true
+}
+
+// parseRetryAfter returns the duration given by the Retry-After header of the
+// response, or nil if there is none.
+func parseRetryAfter(httpRsp *http.Response) *time.Duration {
+	// Retry-After may be either a number of seconds as a int or a RFC 1123
+	// date string (RFC 7231 Section 7.1.3)
+	retryAfter := httpRsp.Header.Get("Retry-After")
+	if retryAfter == "" {
+		return nil
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		b := time.Duration(seconds) * time.Second
+		return &b
+	} else if date, err := time.Parse(time.RFC1123, retryAfter); err == nil {
+		b := time.Until(date)
+		return &b
+	}
+	return nil
 }
 
 // ParsePublicKey parses and returns the public key contained in opts.
@@ -158,6 +228,8 @@ func New(uri string, hc *http.Client, opts Options) (*JSONClient, error) {
 		backoff:       &backoff{},
 		userAgent:     opts.UserAgent,
 		authorization: opts.Authorization,
+		retryPolicy:   opts.RetryPolicy,
+		gzip:          !opts.DisableCompression,
 	}, nil
 }
 
@@ -170,11 +242,43 @@ func (c *JSONClient) BaseURI() string {
 // the response as a JSON representation of the rsp structure.  Returns the
 // http.Response, the body of the response, and an error (which may be of
 // type RspError if the HTTP response was available). It returns an error
-// if the response status code is not 200 OK.
+// if the response status code is not 200 OK, after retrying as specified by
+// the client's RetryPolicy, if any.
 func (c *JSONClient) GetAndParse(ctx context.Context, path string, params map[string]string, rsp interface{}) (*http.Response, []byte, error) {
+	return c.GetAndParseWithHeader(ctx, path, params, nil, rsp)
+}
+
+// GetAndParseWithHeader is like GetAndParse, but also sets the given headers
+// on the request, e.g. to make a conditional request. A response status other
+// than 200 OK, including 304 Not Modified, gives an RspError.
+func (c *JSONClient) GetAndParseWithHeader(ctx context.Context, path string, params map[string]string, hdr http.Header, rsp interface{}) (*http.Response, []byte, error) {
 	if ctx == nil {
 		return nil, nil, errors.New("context.Context required")
 	}
+	for attempt := 1; ; attempt++ {
+		httpRsp, body, err := c.getAndParse(ctx, path, params, hdr, rsp)
+		if err == nil {
+			return httpRsp, body, nil
+		}
+		wait, retry := c.retryPolicy.wait(attempt, httpRsp)
+		if !retry {
+			return nil, nil, err
+		}
+		c.logger.Printf("Request to %s failed, retrying after %s: %s", c.uri, wait, err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// getAndParse makes a single attempt at GetAndParse. On a non-OK status it
+// also returns the http.Response, so that the caller can decide whether to
+// retry.
+func (c *JSONClient) getAndParse(ctx context.Context, path string, params map[string]string, hdr http.Header, rsp interface{}) (*http.Response, []byte, error) {
 	// Build a GET request with URL-encoded parameters.
 	vals := url.Values{}
 	for k, v := range params {
@@ -185,18 +289,24 @@ func (c *JSONClient) GetAndParse(ctx context.Context, path string, params map[st
 	if err != nil {
 		return nil, nil, err
 	}
+	for k, v := range hdr {
+		httpReq.Header[k] = v
+	}
 	if len(c.userAgent) != 0 {
 		httpReq.Header.Set("User-Agent", c.userAgent)
 	}
 	if len(c.authorization) != 0 {
 		httpReq.Header.Add("Authorization", c.authorization)
 	}
+	if c.gzip {
+		httpReq.Header.Set("Accept-Encoding", "gzip")
+	}
 
 	httpRsp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, nil, err
 	}
-	body, err := io.ReadAll(httpRsp.Body)
+	body, err := readBody(httpRsp)
 	if err != nil {
 		return nil, nil, RspError{Err: fmt.Errorf("failed to read response body: %w", err), StatusCode: httpRsp.StatusCode, Body: body}
 	}
@@ -204,7 +314,7 @@ func (c *JSONClient) GetAndParse(ctx context.Context, path string, params map[st
 		return nil, nil, RspError{Err: fmt.Errorf("failed to close response body: %w", err), StatusCode: httpRsp.StatusCode, Body: body}
 	}
 	if httpRsp.StatusCode != http.StatusOK {
-		return nil, nil, RspError{Err: fmt.Errorf("got HTTP Status %q", httpRsp.Status), StatusCode: httpRsp.StatusCode, Body: body}
+		return httpRsp, nil, RspError{Err: fmt.Errorf("got HTTP Status %q", httpRsp.Status), StatusCode: httpRsp.StatusCode, Body: body}
 	}
 
 	if err := json.NewDecoder(bytes.NewReader(body)).Decode(rsp); err != nil {
@@ -239,13 +349,16 @@ func (c *JSONClient) PostAndParse(ctx context.Context, path string, req, rsp int
 	if len(c.authorization) != 0 {
 		httpReq.Header.Add("Authorization", c.authorization)
 	}
+	if c.gzip {
+		httpReq.Header.Set("Accept-Encoding", "gzip")
+	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	httpRsp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, nil, err
 	}
-	body, err := io.ReadAll(httpRsp.Body)
+	body, err := readBody(httpRsp)
 	if err != nil {
 		_ = httpRsp.Body.Close()
 		return nil, nil, err
@@ -266,6 +379,28 @@ func (c *JSONClient) PostAndParse(ctx context.Context, path string, req, rsp int
 	return httpRsp, body, nil
 }
 
+// readBody reads the body of the response, decoding it if it is gzip-encoded.
+// As for the transparent decoding done by http.Transport, the response is
+// then updated to describe the decoded body.
+func readBody(httpRsp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(httpRsp.Header.Get("Content-Encoding"), "gzip") {
+		return io.ReadAll(httpRsp.Body)
+	}
+	zr, err := gzip.NewReader(httpRsp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gzip response: %w", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return body, fmt.Errorf("failed to decode gzip response: %w", err)
+	}
+	httpRsp.Header.Del("Content-Encoding")
+	httpRsp.Header.Del("Content-Length")
+	httpRsp.ContentLength = -1
+	httpRsp.Uncompressed = true
+	return body, nil
+}
+
 // waitForBackoff blocks until the defined backoff interval or context has expired, if the returned
 // not before time is in the past it returns immediately.
 func (c *JSONClient) waitForBackoff(ctx context.Context) error {
@@ -308,19 +443,7 @@ func (c *JSONClient) PostAndParseWithRetry(ctx context.Context, path string, req
 			case http.StatusServiceUnavailable:
 				fallthrough
 			case http.StatusTooManyRequests:
-				var backoff *time.Duration
-				// Retry-After may be either a number of seconds as a int or a RFC 1123
-				// date string (RFC 7231 Section 7.1.3)
-				if retryAfter := httpRsp.Header.Get("Retry-After"); retryAfter != "" {
-					if seconds, err := strconv.Atoi(retryAfter); err == nil {
-						b := time.Duration(seconds) * time.Second
-						backoff = &b
-					} else if date, err := time.Parse(time.RFC1123, retryAfter); err == nil {
-						b := time.Until(date)
-						backoff = &b
-					}
-				}
-				wait := c.backoff.set(backoff)
+				wait := c.backoff.set(parseRetryAfter(httpRsp))
 				c.logger.Printf("Request to %s failed, backing-off for %s: got HTTP status %s", c.uri, wait, httpRsp.Status)
 			default:
 				return nil, nil, RspError{
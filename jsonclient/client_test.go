@@ -15,6 +15,7 @@
 package jsonclient
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"encoding/pem"
@@ -197,6 +198,18 @@ func MockServer(t *testing.T, failCount int, retryAfter int) *httptest.Server {
 					t.Errorf("Failed to fmt.Fprintf: %v", err)
 				}
 			}
+		case "/retry-429":
+			if failCount > 0 {
+				failCount--
+				if retryAfter > 0 {
+					w.Header().Add("Retry-After", strconv.Itoa(retryAfter))
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			} else {
+				if _, err := fmt.Fprintf(w, `{"tree_size": 11, "timestamp": 99}`); err != nil {
+					t.Errorf("Failed to fmt.Fprintf: %v", err)
+				}
+			}
 		case "/retry-rfc1123":
 			if failCount > 0 {
 				failCount--
@@ -481,6 +494,132 @@ func TestPostAndParseWithRetry(t *testing.T) {
 	}
 }
 
+func TestGetAndParseWithRetryPolicy(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, Jitter: time.Millisecond}
+	tests := []struct {
+		desc       string
+		uri        string
+		policy     *RetryPolicy
+		retryAfter int // -1 indicates no Retry-After header
+		failCount  int
+		deadline   time.Duration
+		wantErr    string
+		minElapsed time.Duration
+	}{
+		{desc: "no-policy", uri: "/retry-429", retryAfter: -1, failCount: 1, wantErr: "429"},
+		{desc: "backoff", uri: "/retry-429", policy: policy, retryAfter: -1, failCount: 2},
+		{desc: "retry-after", uri: "/retry-429", policy: policy, retryAfter: 1, failCount: 1, minElapsed: time.Second},
+		{desc: "503", uri: "/retry", policy: policy, retryAfter: -1, failCount: 1},
+		{desc: "attempts-exhausted", uri: "/retry-429", policy: policy, retryAfter: -1, failCount: 3, wantErr: "429"},
+		{desc: "not-retryable", uri: "/error?rc=500", policy: policy, wantErr: "500"},
+		{desc: "deadline", uri: "/retry-429", policy: policy, retryAfter: 60, failCount: 1, deadline: 100 * time.Millisecond, wantErr: "deadline exceeded"},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ts := MockServer(t, test.failCount, test.retryAfter)
+			defer ts.Close()
+
+			logClient, err := New(ts.URL, &http.Client{}, Options{RetryPolicy: test.policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if test.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.deadline)
+				defer cancel()
+			}
+
+			path, params := test.uri, map[string]string(nil)
+			if p, q, ok := strings.Cut(test.uri, "?rc="); ok {
+				path, params = p, map[string]string{"rc": q}
+			}
+			start := time.Now()
+			var got TestStruct
+			httpRsp, _, err := logClient.GetAndParse(ctx, path, params, &got)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("GetAndParse()=_,_,%v; want error containing %q", err, test.wantErr)
+				}
+				if httpRsp != nil {
+					t.Errorf("GetAndParse()=%v,_,_; want nil response on error", httpRsp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAndParse()=_,_,%v; want nil", err)
+			}
+			if want := (TestStruct{11, 99, ""}); got != want {
+				t.Errorf("GetAndParse()=%+v; want %+v", got, want)
+			}
+			if elapsed := time.Since(start); elapsed < test.minElapsed {
+				t.Errorf("GetAndParse() took %v; want at least %v for Retry-After", elapsed, test.minElapsed)
+			}
+		})
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("Accept-Encoding=%q; want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		zw := gzip.NewWriter(w)
+		if _, err := fmt.Fprintf(zw, `{"tree_size": 11, "timestamp": 99, "data": "%s"}`, r.URL.Path); err != nil {
+			t.Errorf("Failed to fmt.Fprintf: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Errorf("Failed to close gzip writer: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	logClient, err := New(ts.URL, &http.Client{}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var got TestStruct
+	httpRsp, body, err := logClient.GetAndParse(ctx, "/get", nil, &got)
+	if err != nil {
+		t.Fatalf("GetAndParse()=_,_,%v; want nil", err)
+	}
+	if want := (TestStruct{11, 99, "/get"}); got != want {
+		t.Errorf("GetAndParse()=%+v; want %+v", got, want)
+	}
+	if !json.Valid(body) {
+		t.Errorf("GetAndParse() body=%q; want decoded JSON", body)
+	}
+	if enc := httpRsp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("GetAndParse() response Content-Encoding=%q; want none after decoding", enc)
+	}
+	if httpRsp.ContentLength != -1 {
+		t.Errorf("GetAndParse() response ContentLength=%d; want -1 after decoding", httpRsp.ContentLength)
+	}
+
+	got = TestStruct{}
+	if _, _, err := logClient.PostAndParse(ctx, "/post", nil, &got); err != nil {
+		t.Fatalf("PostAndParse()=_,_,%v; want nil", err)
+	}
+	if want := (TestStruct{11, 99, "/post"}); got != want {
+		t.Errorf("PostAndParse()=%+v; want %+v", got, want)
+	}
+
+	_, _, err = logClient.GetAndParse(ctx, "/error", nil, &got)
+	var rspErr RspError
+	if !errors.As(err, &rspErr) {
+		t.Fatalf("GetAndParse()=_,_,%v; want RspError", err)
+	}
+	if want := `"data": "/error"`; !strings.Contains(string(rspErr.Body), want) {
+		t.Errorf("RspError.Body=%q; want decoded body containing %q", rspErr.Body, want)
+	}
+}
+
 // nolint:staticcheck
 func TestContextRequired(t *testing.T) {
 	ts := MockServer(t, -1, 0)
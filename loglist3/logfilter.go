@@ -15,6 +15,8 @@
 package loglist3
 
 import (
+	"time"
+
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 	"github.com/OlegBabkin/certificate-transparency-go/x509util"
 )
@@ -117,7 +119,7 @@ func (ll *LogList) TemporallyCompatible(cert *x509.Certificate) LogList {
 				compatibleOp.Logs = append(compatibleOp.Logs, l)
 				continue
 			}
-			if cert.NotAfter.Before(l.TemporalInterval.EndExclusive) && (cert.NotAfter.After(l.TemporalInterval.StartInclusive) || cert.NotAfter.Equal(l.TemporalInterval.StartInclusive)) {
+			if l.TemporalInterval.Contains(cert.NotAfter) {
 				compatibleOp.Logs = append(compatibleOp.Logs, l)
 			}
 		}
@@ -127,3 +129,19 @@ func (ll *LogList) TemporallyCompatible(cert *x509.Certificate) LogList {
 	}
 	return compatible
 }
+
+// TemporalShards returns the temporally sharded logs whose TemporalInterval
+// covers the given NotAfter time, i.e. the shards that a certificate with that
+// NotAfter should be submitted to. Logs without a TemporalInterval are not
+// included; use TemporallyCompatible to select those too.
+func (ll *LogList) TemporalShards(notAfter time.Time) []*Log {
+	var shards []*Log
+	for _, op := range ll.Operators {
+		for _, l := range op.Logs {
+			if l.TemporalInterval != nil && l.TemporalInterval.Contains(notAfter) {
+				shards = append(shards, l)
+			}
+		}
+	}
+	return shards
+}
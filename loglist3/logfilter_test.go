@@ -292,3 +292,71 @@ func TestCompatible(t *testing.T) {
 		})
 	}
 }
+
+func TestTemporalShards(t *testing.T) {
+	date := func(year int, month time.Month) time.Time {
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	shard := func(url string, start, end time.Time) *Log {
+		return &Log{URL: url, TemporalInterval: &TemporalInterval{StartInclusive: start, EndExclusive: end}}
+	}
+	ll := LogList{
+		Operators: []*Operator{
+			{
+				Name: "Google",
+				Logs: []*Log{
+					shard("https://ct.googleapis.com/logs/us1/argon2024/", date(2024, time.January), date(2025, time.January)),
+					shard("https://ct.googleapis.com/logs/us1/argon2025h1/", date(2025, time.January), date(2025, time.July)),
+					shard("https://ct.googleapis.com/logs/us1/argon2025h2/", date(2025, time.July), date(2026, time.January)),
+					{URL: "https://ct.googleapis.com/icarus/"},
+				},
+			},
+			{
+				Name: "Cloudflare",
+				Logs: []*Log{
+					shard("https://ct.cloudflare.com/logs/nimbus2025/", date(2025, time.January), date(2026, time.January)),
+				},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		name     string
+		notAfter time.Time
+		want     []string
+	}{
+		{
+			name:     "FirstHalf",
+			notAfter: date(2025, time.March),
+			want:     []string{"https://ct.googleapis.com/logs/us1/argon2025h1/", "https://ct.cloudflare.com/logs/nimbus2025/"},
+		},
+		{
+			name:     "StartInclusive",
+			notAfter: date(2025, time.July),
+			want:     []string{"https://ct.googleapis.com/logs/us1/argon2025h2/", "https://ct.cloudflare.com/logs/nimbus2025/"},
+		},
+		{
+			name:     "EndExclusive",
+			notAfter: date(2025, time.January).Add(-time.Nanosecond),
+			want:     []string{"https://ct.googleapis.com/logs/us1/argon2024/"},
+		},
+		{
+			name:     "TooEarly",
+			notAfter: date(2023, time.June),
+		},
+		{
+			name:     "TooLate",
+			notAfter: date(2026, time.January),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, l := range ll.TemporalShards(test.notAfter) {
+				got = append(got, l.URL)
+			}
+			if diff := pretty.Compare(test.want, got); diff != "" {
+				t.Errorf("TemporalShards(%v) diff: (-want +got)\n%s", test.notAfter, diff)
+			}
+		})
+	}
+}
@@ -151,6 +151,11 @@ type TemporalInterval struct {
 	EndExclusive time.Time `json:"end_exclusive"`
 }
 
+// Contains reports whether t is within the time range.
+func (ti *TemporalInterval) Contains(t time.Time) bool {
+	return !t.Before(ti.StartInclusive) && t.Before(ti.EndExclusive)
+}
+
 // LogStatus indicates Log status.
 type LogStatus int
 
@@ -366,6 +371,40 @@ func (ll *LogList) FindLogByKey(key []byte) *Log {
 	return nil
 }
 
+// OperatorLogs returns the logs run by the operator with the given name,
+// compared case-insensitively.
+func (ll *LogList) OperatorLogs(name string) []*Log {
+	var results []*Log
+	for _, op := range ll.Operators {
+		if strings.EqualFold(op.Name, name) {
+			results = append(results, op.Logs...)
+		}
+	}
+	return results
+}
+
+// LogsByState returns all logs whose current state is one of those given.
+func (ll *LogList) LogsByState(states ...LogStatus) []*Log {
+	var results []*Log
+	for _, op := range ll.Operators {
+		for _, log := range op.Logs {
+			status := log.State.LogStatus()
+			for _, state := range states {
+				if status == state {
+					results = append(results, log)
+					break
+				}
+			}
+		}
+	}
+	return results
+}
+
+// Usable returns all logs which are currently usable or qualified.
+func (ll *LogList) Usable() []*Log {
+	return ll.LogsByState(UsableLogStatus, QualifiedLogStatus)
+}
+
 var hexDigits = regexp.MustCompile("^[0-9a-fA-F]+$")
 
 // FuzzyFindLog tries to find logs that match the given unspecified input,
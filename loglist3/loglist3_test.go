@@ -426,6 +426,58 @@ func TestFuzzyFindLog(t *testing.T) {
 	}
 }
 
+func TestOperatorLogs(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want []string
+	}{
+		{name: "Google", want: []string{"Google 'Aviator' log", "Google 'Icarus' log", "Google 'Racketeer' log", "Google 'Rocketeer' log", "Google 'Argon2020' log"}},
+		{name: "bob's ct log shop", want: []string{"Bob's Dubious Log"}},
+		{name: "Bob", want: nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := logDescriptions(sampleLogList.OperatorLogs(test.name)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("OperatorLogs(%q)=%q; want %q", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLogsByState(t *testing.T) {
+	for _, test := range []struct {
+		desc   string
+		states []LogStatus
+		want   []string
+	}{
+		{desc: "none", want: nil},
+		{desc: "usable", states: []LogStatus{UsableLogStatus}, want: []string{"Google 'Icarus' log"}},
+		{desc: "readonly-retired", states: []LogStatus{ReadOnlyLogStatus, RetiredLogStatus}, want: []string{"Google 'Aviator' log", "Bob's Dubious Log"}},
+		{desc: "undefined", states: []LogStatus{UndefinedLogStatus}, want: []string{"Google 'Racketeer' log", "Google 'Rocketeer' log"}},
+		{desc: "rejected", states: []LogStatus{RejectedLogStatus}, want: nil},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := logDescriptions(sampleLogList.LogsByState(test.states...)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("LogsByState(%v)=%q; want %q", test.states, got, test.want)
+			}
+		})
+	}
+}
+
+func TestUsable(t *testing.T) {
+	want := []string{"Google 'Icarus' log", "Google 'Argon2020' log"}
+	if got := logDescriptions(sampleLogList.Usable()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Usable()=%q; want %q", got, want)
+	}
+}
+
+func logDescriptions(logs []*Log) []string {
+	var descs []string
+	for _, l := range logs {
+		descs = append(descs, l.Description)
+	}
+	return descs
+}
+
 func TestStripInternalSpace(t *testing.T) {
 	var tests = []struct {
 		in   string
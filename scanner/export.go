@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"k8s.io/klog/v2"
+)
+
+// EntryRecord is a summary of a matched log entry, as written by
+// NewNDJSONWriter.
+type EntryRecord struct {
+	Index    int64    `json:"index"`
+	LeafType string   `json:"leaf_type"` // "x509_entry" or "precert_entry".
+	Subject  string   `json:"subject,omitempty"`
+	SANs     []string `json:"sans,omitempty"`
+	Serial   string   `json:"serial,omitempty"` // Hex-encoded.
+	// Error describes why the [pre-]certificate could not be parsed, in which
+	// case only Index and LeafType are set.
+	Error string `json:"error,omitempty"`
+}
+
+// NewEntryRecord summarizes the given log entry.
+func NewEntryRecord(rawEntry *ct.RawLogEntry) EntryRecord {
+	rec := EntryRecord{Index: rawEntry.Index}
+	var cert *x509.Certificate
+	entry, err := rawEntry.ToLogEntry()
+	switch rawEntry.Leaf.TimestampedEntry.EntryType {
+	case ct.X509LogEntryType:
+		rec.LeafType = "x509_entry"
+		if entry != nil {
+			cert = entry.X509Cert
+		}
+	case ct.PrecertLogEntryType:
+		rec.LeafType = "precert_entry"
+		if entry != nil && entry.Precert != nil {
+			cert = entry.Precert.TBSCertificate
+		}
+	default:
+		rec.LeafType = rawEntry.Leaf.TimestampedEntry.EntryType.String()
+	}
+	if x509.IsFatal(err) || cert == nil {
+		rec.Error = fmt.Sprintf("%v", err)
+		return rec
+	}
+	rec.Subject = cert.Subject.String()
+	rec.SANs = append(rec.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		rec.SANs = append(rec.SANs, ip.String())
+	}
+	if cert.SerialNumber != nil {
+		rec.Serial = cert.SerialNumber.Text(16)
+	}
+	return rec
+}
+
+// NewNDJSONWriter returns a callback for Scanner.Scan which writes an
+// EntryRecord for each matched entry to w as newline-delimited JSON. The
+// callback may be used for both certificates and precertificates, and is safe
+// to invoke from concurrent scanner workers. Write failures are logged.
+func NewNDJSONWriter(w io.Writer) func(*ct.RawLogEntry) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(rawEntry *ct.RawLogEntry) {
+		rec := NewEntryRecord(rawEntry)
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(rec); err != nil {
+			klog.Errorf("Failed to write entry %d: %v", rawEntry.Index, err)
+		}
+	}
+}
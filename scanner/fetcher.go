@@ -16,13 +16,18 @@ package scanner
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
 	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
 	"github.com/google/trillian/client/backoff"
+	"github.com/google/trillian/monitoring"
 	"k8s.io/klog/v2"
 )
 
@@ -33,6 +38,25 @@ type LogClient interface {
 	GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error)
 }
 
+// sizedLogClient is an optional LogClient extension which reports the size of
+// get-entries responses, as implemented by client.LogClient.
+type sizedLogClient interface {
+	GetRawEntriesWithSize(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, int, error)
+}
+
+// Limiter is an interface to allow different rate limiters to be used with the
+// Fetcher.
+type Limiter interface {
+	Wait(context.Context) error
+}
+
+// STHSource provides the STHs that the Fetcher uses to determine the size of
+// the Log, e.g. from a mirror or a recorded dataset, instead of requesting
+// them from the Log itself.
+type STHSource interface {
+	CurrentSTH(ctx context.Context) (*ct.SignedTreeHead, error)
+}
+
 // FetcherOptions holds configuration options for the Fetcher.
 type FetcherOptions struct {
 	// Number of entries to request in one batch from the Log.
@@ -49,6 +73,60 @@ type FetcherOptions struct {
 	// Continuous determines whether Fetcher should run indefinitely after
 	// reaching EndIndex.
 	Continuous bool
+
+	// MaxLag, if positive, bounds how far behind the Log a Continuous Fetcher
+	// may fall. Every time the Fetcher catches up with the last known STH and
+	// discovers a bigger one, it checks how many entries it is behind, and if
+	// that exceeds MaxLag then it stops generating new work and Run returns an
+	// error, as the Fetcher can't keep up with the Log. Zero means unbounded.
+	MaxLag int64
+
+	// AdaptiveBatchSize makes the Fetcher learn the effective maximum number
+	// of entries the Log returns in one response, and stop requesting more
+	// than that. The learned value starts from BatchSize, goes down to the
+	// size of a truncated response, and grows back up to the biggest response
+	// seen so far (but not above BatchSize) once the Log serves a request in
+	// full again.
+	AdaptiveBatchSize bool
+
+	// Limiter, if not nil, is shared by all fetcher workers, which wait on it
+	// before each GetRawEntries request, including retries. This allows
+	// controlling the overall request rate regardless of ParallelFetch. Note
+	// that a worker retries a failed request without a backoff pause, so the
+	// Limiter is the only thing that paces retries.
+	Limiter Limiter
+
+	// MaxBytesPerSecond, if positive, caps the overall rate of get-entries
+	// response bytes consumed by all fetcher workers. Since the size of a
+	// response is not known in advance, the cap is enforced on average: a
+	// worker waits before each request until the bytes of the previous
+	// responses are paid off. The size of responses is taken from the client
+	// if it supports GetRawEntriesWithSize, or estimated from the size of the
+	// entries otherwise.
+	MaxBytesPerSecond int64
+
+	// OnProgress, if not nil, is periodically invoked by Run with the number
+	// of entries fetched so far, the total number of entries to fetch, i.e.
+	// EndIndex-StartIndex, and the moving average fetch rate in entries per
+	// second. The calls are made sequentially from a single goroutine, and the
+	// last call is made once fetching is finished.
+	OnProgress func(done, total int64, rate float64)
+
+	// ProgressInterval is the period of OnProgress invocations. If zero, it
+	// defaults to one second.
+	ProgressInterval time.Duration
+
+	// STHSource, if not nil, is used for obtaining STHs instead of the
+	// GetSTH method of the LogClient. The client is then only used for
+	// fetching entries.
+	STHSource STHSource
+
+	// MetricFactory, if not nil, is used for creating the metrics exported by
+	// the Fetcher: the number of fetched entries, get-entries requests and
+	// errors, the current fetch position, and the current STH tree size. The
+	// metrics are created once per MetricFactory, and shared by all Fetchers
+	// that use it.
+	MetricFactory monitoring.MetricFactory
 }
 
 // DefaultFetcherOptions returns new FetcherOptions with sensible defaults.
@@ -64,6 +142,12 @@ func DefaultFetcherOptions() *FetcherOptions {
 
 // Fetcher is a tool that fetches entries from a CT Log.
 type Fetcher struct {
+	// N.B. 64-bit fields must be first due to
+	// https://golang.org/pkg/sync/atomic/#pkg-note-BUG
+
+	// The number of entries fetched by the current Run.
+	fetched int64
+
 	// Base URI of the CT log, for diagnostics.
 	uri string
 	// Client used to talk to the CT log instance.
@@ -77,8 +161,21 @@ type Fetcher struct {
 	sthBackoff *backoff.Backoff
 
 	// Stops range generator, which causes the Fetcher to terminate gracefully.
+	// Also guards opts.EndIndex, which changes as the Log grows.
 	mu     sync.Mutex
 	cancel context.CancelFunc
+	// The error that made the range generator stop early, if any.
+	genErr error
+
+	// The adaptive batch size state. Used only if AdaptiveBatchSize is set.
+	batchMu   sync.Mutex
+	batchSize int64 // The number of entries to request in one go.
+	maxSeen   int64 // The biggest number of entries seen in one response.
+
+	// The response bytes limiter. Used only if MaxBytesPerSecond is set.
+	bytes *byteLimiter
+
+	metrics *fetcherMetrics
 }
 
 // EntryBatch represents a contiguous range of entries of the Log.
@@ -97,14 +194,65 @@ type fetchRange struct {
 // taking configuration options from opts.
 func NewFetcher(client LogClient, opts *FetcherOptions) *Fetcher {
 	cancel := func() {} // Protect against calling Stop before Run.
-	return &Fetcher{
-		uri:    client.BaseURI(),
-		client: client,
-		opts:   opts,
-		cancel: cancel,
+	f := &Fetcher{
+		uri:       client.BaseURI(),
+		client:    client,
+		opts:      opts,
+		cancel:    cancel,
+		batchSize: int64(opts.BatchSize),
+		metrics:   newFetcherMetrics(opts.MetricFactory),
+	}
+	if opts.MaxBytesPerSecond > 0 {
+		f.bytes = newByteLimiter(opts.MaxBytesPerSecond)
+	}
+	return f
+}
+
+// EffectiveBatchSize returns the number of entries that the Fetcher requests
+// in one go. Unless AdaptiveBatchSize option is set, this is always equal to
+// BatchSize. Otherwise, this is the biggest number of entries that the Log has
+// returned in one response, if the Log has been seen truncating responses.
+func (f *Fetcher) EffectiveBatchSize() int64 {
+	f.batchMu.Lock()
+	defer f.batchMu.Unlock()
+	return f.batchSize
+}
+
+// observeResponse updates the adaptive batch size given that the Log returned
+// got entries in response to a request for want entries.
+func (f *Fetcher) observeResponse(want, got int64) {
+	if !f.opts.AdaptiveBatchSize || got <= 0 {
+		return
+	}
+	f.batchMu.Lock()
+	defer f.batchMu.Unlock()
+	if got > f.maxSeen {
+		f.maxSeen = got
+	}
+	// Only a truncated response signals that the Log has a limit, which may be
+	// temporary. Once the Log serves a request in full, go back to the biggest
+	// number of entries it has returned before, since it is likely to do so
+	// again.
+	if got < want {
+		if got < f.batchSize {
+			klog.V(1).Infof("%s: Reduce batch size from %d to %d", f.uri, f.batchSize, got)
+			f.batchSize = got
+		}
+	} else if size := min(f.maxSeen, int64(f.opts.BatchSize)); size > f.batchSize {
+		klog.V(1).Infof("%s: Increase batch size from %d to %d", f.uri, f.batchSize, size)
+		f.batchSize = size
 	}
 }
 
+// getSTH returns the latest STH of the Log, from the STHSource if there is
+// one, or from the Log itself otherwise.
+func (f *Fetcher) getSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
+	if src := f.opts.STHSource; src != nil {
+		return src.CurrentSTH(ctx)
+	}
+	return f.client.GetSTH(ctx)
+}
+
 // Prepare caches the latest Log's STH if not present and returns it. It also
 // adjusts the entry range to fit the size of the tree.
 func (f *Fetcher) Prepare(ctx context.Context) (*ct.SignedTreeHead, error) {
@@ -112,24 +260,28 @@ func (f *Fetcher) Prepare(ctx context.Context) (*ct.SignedTreeHead, error) {
 		return f.sth, nil
 	}
 
-	sth, err := f.client.GetSTH(ctx)
+	sth, err := f.getSTH(ctx)
 	if err != nil {
 		klog.Errorf("%s: GetSTH() failed: %v", f.uri, err)
 		return nil, err
 	}
 	klog.V(1).Infof("%s: Got STH with %d certs", f.uri, sth.TreeSize)
 
+	f.mu.Lock()
 	if size := int64(sth.TreeSize); f.opts.EndIndex == 0 || f.opts.EndIndex > size {
 		klog.V(1).Infof("%s: Reset EndIndex from %d to %d", f.uri, f.opts.EndIndex, size)
 		f.opts.EndIndex = size
 	}
+	f.mu.Unlock()
 	f.sth = sth
+	f.metrics.treeSize.Set(float64(sth.TreeSize))
 	return sth, nil
 }
 
 // Run performs fetching of the Log. Blocks until scanning is complete, the
 // passed in context is canceled, or Stop is called (and pending work is
 // finished). For each successfully fetched batch, runs the fn callback.
+// Returns an error if the Fetcher falls behind the Log by more than MaxLag.
 func (f *Fetcher) Run(ctx context.Context, fn func(EntryBatch)) error {
 	klog.V(1).Infof("%s: Starting up Fetcher...", f.uri)
 	if _, err := f.Prepare(ctx); err != nil {
@@ -141,6 +293,7 @@ func (f *Fetcher) Run(ctx context.Context, fn func(EntryBatch)) error {
 
 	f.mu.Lock()
 	f.cancel = cancel
+	f.genErr = nil
 	f.mu.Unlock()
 
 	// Use a separately-cancelable context for the range generator, so we can
@@ -148,6 +301,19 @@ func (f *Fetcher) Run(ctx context.Context, fn func(EntryBatch)) error {
 	// completion.
 	ranges := f.genRanges(cctx)
 
+	atomic.StoreInt64(&f.fetched, 0)
+	if f.opts.OnProgress != nil {
+		stop, done := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(done)
+			f.reportProgress(stop)
+		}()
+		defer func() {
+			close(stop)
+			<-done
+		}()
+	}
+
 	// Run fetcher workers.
 	var wg sync.WaitGroup
 	for w, cnt := 0, f.opts.ParallelFetch; w < cnt; w++ {
@@ -162,7 +328,188 @@ func (f *Fetcher) Run(ctx context.Context, fn func(EntryBatch)) error {
 	wg.Wait()
 
 	klog.V(1).Infof("%s: Fetcher terminated", f.uri)
-	return nil
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.genErr
+}
+
+// FetchIndices fetches the entries with the given leaf indices, which may be
+// unsorted and contain duplicates. Adjacent indices are merged into contiguous
+// get-entries requests of at most BatchSize entries, which are performed by
+// ParallelFetch workers. For each fetched batch, runs the fn callback. Each
+// requested entry is delivered exactly once, and only requested entries are.
+//
+// Returns an error if any of the indices is outside of the tree, or if the
+// passed in context is canceled before all entries are fetched.
+func (f *Fetcher) FetchIndices(ctx context.Context, indices []int64, fn func(EntryBatch)) error {
+	sth, err := f.Prepare(ctx)
+	if err != nil {
+		return err
+	}
+	sorted := make([]int64, len(indices))
+	copy(sorted, indices)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if len(sorted) > 0 {
+		if first, last := sorted[0], sorted[len(sorted)-1]; first < 0 || last >= int64(sth.TreeSize) {
+			return fmt.Errorf("indices [%d, %d] out of range for tree of size %d", first, last, sth.TreeSize)
+		}
+	}
+
+	var rs []fetchRange
+	batch := f.EffectiveBatchSize()
+	for i, idx := range sorted {
+		if i > 0 && idx == sorted[i-1] {
+			continue // Skip duplicates.
+		}
+		if n := len(rs); n > 0 && rs[n-1].end+1 == idx && idx-rs[n-1].start < batch {
+			rs[n-1].end = idx
+			continue
+		}
+		rs = append(rs, fetchRange{start: idx, end: idx})
+	}
+
+	ranges := make(chan fetchRange, len(rs))
+	for _, r := range rs {
+		ranges <- r
+	}
+	close(ranges)
+
+	var wg sync.WaitGroup
+	for w, cnt := 0, f.opts.ParallelFetch; w < cnt; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.runWorker(ctx, ranges, fn)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// maxEmptyResponses is the number of consecutive get-entries responses with
+// no entries after which GetAllRawEntries gives up.
+const maxEmptyResponses = 3
+
+// maxTransientErrors is the number of consecutive transient get-entries
+// errors after which GetAllRawEntries gives up.
+const maxTransientErrors = 3
+
+// maxPreallocEntries caps the number of entries GetAllRawEntries allocates
+// space for up front, so that a huge range doesn't allocate memory for entries
+// that the Log may fail to return.
+const maxPreallocEntries = 1024
+
+// isTransientError returns whether a failed get-entries request is worth
+// retrying, i.e. the Log was overloaded or temporarily unavailable.
+func isTransientError(err error) bool {
+	var rspErr jsonclient.RspError
+	if !errors.As(err, &rspErr) {
+		return false
+	}
+	return rspErr.StatusCode == http.StatusTooManyRequests || rspErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// GetAllRawEntries fetches the entries in the range [start, end] of the log,
+// and returns them in order. Truncated get-entries responses are followed up
+// with requests for the remainder of the range, and responses with no entries
+// are retried a few times before giving up, as are requests that fail with
+// 429 Too Many Requests or 503 Service Unavailable. This is intended for small
+// ranges for which setting up a Fetcher is not worth it; it issues requests
+// sequentially, and does not retry other failed requests.
+func GetAllRawEntries(ctx context.Context, client LogClient, start, end int64) ([]ct.LeafEntry, error) {
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid range [%d, %d]", start, end)
+	}
+	bo := backoff.Backoff{
+		Min:    100 * time.Millisecond,
+		Max:    time.Second,
+		Factor: 2,
+	}
+	wait := func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(bo.Duration()):
+			return nil
+		}
+	}
+	entries := make([]ct.LeafEntry, 0, min(end-start+1, maxPreallocEntries))
+	for empty, failed := 0, 0; start <= end; {
+		resp, err := client.GetRawEntries(ctx, start, end)
+		if err != nil {
+			if failed++; !isTransientError(err) || failed >= maxTransientErrors {
+				return nil, fmt.Errorf("GetRawEntries(%d, %d): %w", start, end, err)
+			}
+			if err := wait(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		failed = 0
+		got := resp.Entries
+		if len(got) == 0 {
+			if empty++; empty >= maxEmptyResponses {
+				return nil, fmt.Errorf("no entries returned for range [%d, %d] after %d attempts", start, end, empty)
+			}
+			if err := wait(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		empty = 0
+		bo.Reset()
+		if maxCount := end - start + 1; int64(len(got)) > maxCount {
+			got = got[:maxCount]
+		}
+		entries = append(entries, got...)
+		start += int64(len(got))
+	}
+	return entries, nil
+}
+
+// reportProgress periodically invokes the OnProgress callback until the stop
+// channel is closed, and then makes the final invocation.
+func (f *Fetcher) reportProgress(stop <-chan struct{}) {
+	interval := f.opts.ProgressInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// A window of recent samples, used for computing the moving average rate.
+	type sample struct {
+		when time.Time
+		done int64
+	}
+	const wndSize = 15
+	wnd := []sample{{when: time.Now()}}
+
+	report := func() {
+		now, done := time.Now(), atomic.LoadInt64(&f.fetched)
+		if len(wnd) == wndSize {
+			wnd = wnd[1:]
+		}
+		wnd = append(wnd, sample{when: now, done: done})
+		var rate float64
+		if first := wnd[0]; now.After(first.when) {
+			rate = float64(done-first.done) / now.Sub(first.when).Seconds()
+		}
+		f.mu.Lock()
+		total := f.opts.EndIndex - f.opts.StartIndex
+		f.mu.Unlock()
+		f.opts.OnProgress(done, total, rate)
+	}
+
+	for {
+		select {
+		case <-stop:
+			report()
+			return
+		case <-ticker.C:
+			report()
+		}
+	}
 }
 
 // Stop causes the Fetcher to terminate gracefully. After this call Run will
@@ -196,6 +543,12 @@ func (f *Fetcher) genRanges(ctx context.Context) <-chan fetchRange {
 					return
 				}
 				end = f.opts.EndIndex
+				if lag := end - start; f.opts.MaxLag > 0 && lag > f.opts.MaxLag {
+					f.mu.Lock()
+					f.genErr = fmt.Errorf("fetcher is %d entries behind the log at %d, more than MaxLag=%d", lag, start, f.opts.MaxLag)
+					f.mu.Unlock()
+					return
+				}
 			}
 
 			batchEnd := start + min(end-start, batch)
@@ -236,7 +589,7 @@ func (f *Fetcher) updateSTH(ctx context.Context) error {
 	quickDeadline := time.Now().Add(quickDur)
 
 	return f.sthBackoff.Retry(ctx, func() error {
-		sth, err := f.client.GetSTH(ctx)
+		sth, err := f.getSTH(ctx)
 		if err != nil {
 			return backoff.RetriableErrorf("GetSTH: %v", err)
 		}
@@ -251,7 +604,10 @@ func (f *Fetcher) updateSTH(ctx context.Context) error {
 			f.sthBackoff.Reset() // Growth is presumably fast, set next pause to Min.
 		}
 		f.sth = sth
+		f.metrics.treeSize.Set(float64(sth.TreeSize))
+		f.mu.Lock()
 		f.opts.EndIndex = int64(sth.TreeSize)
+		f.mu.Unlock()
 		return nil
 	})
 }
@@ -279,14 +635,25 @@ func (f *Fetcher) runWorker(ctx context.Context, ranges <-chan fetchRange, fn fu
 				Jitter: true,
 			}
 
+			end := r.end
+			if f.opts.AdaptiveBatchSize {
+				end = min(end, r.start+f.EffectiveBatchSize()-1)
+			}
+
 			var resp *ct.GetEntriesResponse
 			// TODO(pavelkalinnikov): Report errors in a LogClient decorator on failure.
 			if err := bo.Retry(ctx, func() error {
+				if l := f.opts.Limiter; l != nil {
+					if err := l.Wait(ctx); err != nil {
+						return fmt.Errorf("Limiter.Wait(): %w", err)
+					}
+				}
 				var err error
-				resp, err = f.client.GetRawEntries(ctx, r.start, r.end)
+				resp, err = f.getRawEntries(ctx, r.start, end)
+				f.metrics.observeRequest(err)
 				return err
 			}); err != nil {
-				if rspErr, isRspErr := err.(jsonclient.RspError); isRspErr && rspErr.StatusCode == http.StatusTooManyRequests {
+				if rspErr := (jsonclient.RspError{}); errors.As(err, &rspErr) && rspErr.StatusCode == http.StatusTooManyRequests {
 					klog.V(2).Infof("%s: GetRawEntries() failed: %v", f.uri, err)
 				} else {
 					klog.Errorf("%s: GetRawEntries() failed: %v", f.uri, err)
@@ -294,10 +661,92 @@ func (f *Fetcher) runWorker(ctx context.Context, ranges <-chan fetchRange, fn fu
 				// There is no error reporting yet for this worker, so just retry again.
 				continue
 			}
+			f.observeResponse(end-r.start+1, int64(len(resp.Entries)))
 			fn(EntryBatch{Start: r.start, Entries: resp.Entries})
+			atomic.AddInt64(&f.fetched, int64(len(resp.Entries)))
 			r.start += int64(len(resp.Entries))
+			f.metrics.entries.Add(float64(len(resp.Entries)))
+			f.metrics.leafIndex.Set(float64(r.start))
+		}
+	}
+}
+
+// getRawEntries requests the [start, end] entries range from the Log, subject
+// to the response bytes limiter if there is one.
+func (f *Fetcher) getRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	if f.bytes == nil {
+		return f.client.GetRawEntries(ctx, start, end)
+	}
+	if err := f.bytes.wait(ctx); err != nil {
+		return nil, fmt.Errorf("byteLimiter.wait(): %v", err)
+	}
+
+	var resp *ct.GetEntriesResponse
+	var size int
+	var err error
+	if sc, ok := f.client.(sizedLogClient); ok {
+		resp, size, err = sc.GetRawEntriesWithSize(ctx, start, end)
+	} else if resp, err = f.client.GetRawEntries(ctx, start, end); resp != nil {
+		for _, e := range resp.Entries {
+			size += len(e.LeafInput) + len(e.ExtraData)
 		}
 	}
+	f.bytes.consume(size)
+	return resp, err
+}
+
+// byteLimiter is a token bucket over bytes, which holds at most one second
+// worth of tokens. Since the number of bytes a request consumes is known only
+// after it is done, the bucket is allowed to go into debt, and wait blocks
+// until the debt is paid off.
+type byteLimiter struct {
+	rate float64 // Tokens (bytes) added per second.
+
+	mu     sync.Mutex
+	tokens float64   // The available tokens, negative if in debt.
+	last   time.Time // The last time tokens were updated.
+}
+
+func newByteLimiter(bytesPerSecond int64) *byteLimiter {
+	rate := float64(bytesPerSecond)
+	return &byteLimiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// refill adds the tokens accumulated since the last update, and returns the
+// resulting debt, if any. Must be called with l.mu locked.
+func (l *byteLimiter) refill() float64 {
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	return -l.tokens
+}
+
+// wait blocks until the bucket is not in debt, or the context is done.
+func (l *byteLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		debt := l.refill()
+		l.mu.Unlock()
+		if debt <= 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(debt / l.rate * float64(time.Second))):
+		}
+	}
+}
+
+// consume takes the given number of bytes from the bucket.
+func (l *byteLimiter) consume(bytes int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens -= float64(bytes)
 }
 
 func min(a, b int64) int64 {
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
+	"github.com/google/trillian/monitoring"
+)
+
+// fetcherMetrics holds the metrics exported by a Fetcher.
+type fetcherMetrics struct {
+	entries   monitoring.Counter // => value
+	reqs      monitoring.Counter // => value
+	errs      monitoring.Counter // status => value
+	leafIndex monitoring.Gauge   // => value
+	treeSize  monitoring.Gauge   // => value
+}
+
+// Metrics created by each MetricFactory, which must only be created once as
+// some factories register them globally.
+var (
+	metricsMu        sync.Mutex
+	metricsByFactory = make(map[monitoring.MetricFactory]*fetcherMetrics)
+)
+
+// newFetcherMetrics returns the metrics created by mf, creating them if it is
+// the first time mf is used. Fetchers using the same MetricFactory share the
+// metrics. If mf is nil, the returned metrics are not exported.
+func newFetcherMetrics(mf monitoring.MetricFactory) *fetcherMetrics {
+	if mf == nil {
+		return createFetcherMetrics(monitoring.InertMetricFactory{})
+	}
+	if !reflect.TypeOf(mf).Comparable() {
+		// Can't tell whether mf was used before.
+		return createFetcherMetrics(mf)
+	}
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	m, ok := metricsByFactory[mf]
+	if !ok {
+		m = createFetcherMetrics(mf)
+		metricsByFactory[mf] = m
+	}
+	return m
+}
+
+func createFetcherMetrics(mf monitoring.MetricFactory) *fetcherMetrics {
+	return &fetcherMetrics{
+		entries:   mf.NewCounter("fetcher_entries_fetched", "Number of log entries fetched"),
+		reqs:      mf.NewCounter("fetcher_get_entries_requests", "Number of get-entries requests sent to the log"),
+		errs:      mf.NewCounter("fetcher_get_entries_errors", "Number of failed get-entries requests", "status"),
+		leafIndex: mf.NewGauge("fetcher_leaf_index", "Index following the most recently fetched entry"),
+		treeSize:  mf.NewGauge("fetcher_sth_tree_size", "Tree size of the latest STH used by the fetcher"),
+	}
+}
+
+// observeRequest records the outcome of a get-entries request.
+func (m *fetcherMetrics) observeRequest(err error) {
+	m.reqs.Inc()
+	if err == nil {
+		return
+	}
+	status := "unknown"
+	var rspErr jsonclient.RspError
+	if errors.As(err, &rspErr) {
+		status = strconv.Itoa(rspErr.StatusCode)
+	}
+	m.errs.Inc(status)
+}
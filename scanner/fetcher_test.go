@@ -0,0 +1,646 @@
+// Copyright 2018 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/time/rate"
+)
+
+// fakeLogClient is a LogClient which serves a log of treeSize empty entries,
+// and returns at most maxEntries of them in one response. If set, limits
+// overrides maxEntries for the first len(limits) responses, and errs gives
+// the errors to fail the first len(errs) requests with.
+type fakeLogClient struct {
+	treeSize   uint64
+	maxEntries int64
+	limits     []int64
+	errs       []error
+
+	mu       sync.Mutex
+	requests []int64 // The number of entries requested by each call.
+}
+
+func (c *fakeLogClient) BaseURI() string {
+	return "fake"
+}
+
+func (c *fakeLogClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	return &ct.SignedTreeHead{TreeSize: c.treeSize}, nil
+}
+
+func (c *fakeLogClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	if start < 0 || start > end || end >= int64(c.treeSize) {
+		return nil, fmt.Errorf("bad range [%d, %d]", start, end)
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, end-start+1)
+	limit := c.maxEntries
+	if n := len(c.requests); n <= len(c.limits) {
+		limit = c.limits[n-1]
+	}
+	var err error
+	if n := len(c.requests); n <= len(c.errs) {
+		err = c.errs[n-1]
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	count := min(end-start+1, limit)
+	return &ct.GetEntriesResponse{Entries: make([]ct.LeafEntry, count)}, nil
+}
+
+func TestFetcherAdaptiveBatchSize(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		adaptive   bool
+		batchSize  int
+		maxEntries int64
+		wantBatch  int64
+	}{
+		{desc: "disabled", adaptive: false, batchSize: 100, maxEntries: 16, wantBatch: 100},
+		{desc: "no-truncation", adaptive: true, batchSize: 100, maxEntries: 1000, wantBatch: 100},
+		{desc: "truncation", adaptive: true, batchSize: 100, maxEntries: 16, wantBatch: 16},
+		{desc: "truncation-parallel", adaptive: true, batchSize: 100, maxEntries: 7, wantBatch: 7},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			const treeSize = 1000
+			client := &fakeLogClient{treeSize: treeSize, maxEntries: tc.maxEntries}
+			opts := &FetcherOptions{
+				BatchSize:         tc.batchSize,
+				ParallelFetch:     2,
+				AdaptiveBatchSize: tc.adaptive,
+			}
+			f := NewFetcher(client, opts)
+
+			var mu sync.Mutex
+			var fetched int64
+			if err := f.Run(context.Background(), func(b EntryBatch) {
+				mu.Lock()
+				defer mu.Unlock()
+				fetched += int64(len(b.Entries))
+			}); err != nil {
+				t.Fatalf("Run(): %v", err)
+			}
+
+			if fetched != treeSize {
+				t.Errorf("Fetched %d entries, want %d", fetched, treeSize)
+			}
+			if got, want := f.EffectiveBatchSize(), tc.wantBatch; got != want {
+				t.Errorf("EffectiveBatchSize()=%d, want %d", got, want)
+			}
+			if !tc.adaptive {
+				return
+			}
+			// Each worker can over-request at most once before learning the limit.
+			overRequests := 0
+			for _, n := range client.requests {
+				if n > tc.maxEntries {
+					overRequests++
+				}
+			}
+			if limit := opts.ParallelFetch; overRequests > limit {
+				t.Errorf("Made %d over-sized requests, want at most %d", overRequests, limit)
+			}
+		})
+	}
+}
+
+func TestFetcherAdaptiveBatchSizeRecovers(t *testing.T) {
+	// The Log serves 50 entries, then temporarily only 10, then 50 again.
+	client := &fakeLogClient{treeSize: 1000, maxEntries: 50, limits: []int64{50, 10, 10, 10}}
+	opts := &FetcherOptions{BatchSize: 100, ParallelFetch: 1, AdaptiveBatchSize: true}
+	f := NewFetcher(client, opts)
+	if err := f.Run(context.Background(), func(EntryBatch) {}); err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+	if got, want := f.EffectiveBatchSize(), int64(50); got != want {
+		t.Errorf("EffectiveBatchSize()=%d, want %d", got, want)
+	}
+	// The batch size goes down to 10 on each truncated response, and back up
+	// to 50 on each response served in full. Requests are also capped by the
+	// end of the [0, 99] and [100, 199] ranges.
+	want := []int64{100, 50, 10, 30, 10, 10, 50}
+	if got := client.requests[:len(want)]; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Requested batches %v, want %v", got, want)
+	}
+}
+
+// countingLimiter is a Limiter which counts the Wait calls.
+type countingLimiter struct {
+	mu    sync.Mutex
+	waits int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waits++
+	return ctx.Err()
+}
+
+func TestFetcherSharedLimiter(t *testing.T) {
+	client := &fakeLogClient{treeSize: 1000, maxEntries: 30}
+	limiter := &countingLimiter{}
+	opts := &FetcherOptions{BatchSize: 100, ParallelFetch: 4, Limiter: limiter}
+	f := NewFetcher(client, opts)
+	if err := f.Run(context.Background(), func(EntryBatch) {}); err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+	if got, want := limiter.waits, len(client.requests); got != want {
+		t.Errorf("Limiter.Wait() called %d times, want %d", got, want)
+	}
+}
+
+func TestFetcherRateLimiter(t *testing.T) {
+	const qps = 50
+	client := &fakeLogClient{treeSize: 1000, maxEntries: 100}
+	opts := &FetcherOptions{
+		BatchSize:     100,
+		ParallelFetch: 4,
+		Limiter:       rate.NewLimiter(qps, 1),
+	}
+	f := NewFetcher(client, opts)
+	start := time.Now()
+	if err := f.Run(context.Background(), func(EntryBatch) {}); err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+	elapsed := time.Since(start)
+
+	// The first request uses up the burst, each of the others waits 1/qps.
+	requests := len(client.requests)
+	if want := time.Duration(requests-1) * time.Second / qps; elapsed < want {
+		t.Errorf("Made %d requests in %v, want at least %v at %d qps", requests, elapsed, want, qps)
+	}
+}
+
+// blockingLimiter is a Limiter which never allows a request.
+type blockingLimiter struct{}
+
+func (blockingLimiter) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestFetcherLimiterCancel(t *testing.T) {
+	client := &fakeLogClient{treeSize: 1000, maxEntries: 100}
+	opts := &FetcherOptions{BatchSize: 100, ParallelFetch: 4, Limiter: blockingLimiter{}}
+	f := NewFetcher(client, opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := f.FetchIndices(ctx, []int64{1, 200, 500}, func(EntryBatch) {
+		t.Error("Unexpected batch fetched")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FetchIndices()=%v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := len(client.requests); got != 0 {
+		t.Errorf("Made %d requests, want none", got)
+	}
+}
+
+// Transient errors returned by a Log.
+var (
+	unavailable = jsonclient.RspError{Err: errors.New("unavailable"), StatusCode: http.StatusServiceUnavailable}
+	tooMany     = jsonclient.RspError{Err: errors.New("too many requests"), StatusCode: http.StatusTooManyRequests}
+)
+
+func TestGetAllRawEntries(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		start, end int64
+		maxEntries int64
+		errs       []error
+		wantReqs   int
+	}{
+		{desc: "single", start: 10, end: 10, maxEntries: 5, wantReqs: 1},
+		{desc: "no-truncation", start: 0, end: 99, maxEntries: 1000, wantReqs: 1},
+		{desc: "truncation", start: 0, end: 99, maxEntries: 16, wantReqs: 7},
+		{desc: "truncation-offset", start: 500, end: 549, maxEntries: 10, wantReqs: 5},
+		{desc: "transient-errors", start: 0, end: 9, maxEntries: 10, errs: []error{unavailable, tooMany}, wantReqs: 3},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			client := &fakeLogClient{treeSize: 1000, maxEntries: tc.maxEntries, errs: tc.errs}
+			entries, err := GetAllRawEntries(context.Background(), client, tc.start, tc.end)
+			if err != nil {
+				t.Fatalf("GetAllRawEntries(): %v", err)
+			}
+			if got, want := int64(len(entries)), tc.end-tc.start+1; got != want {
+				t.Errorf("GetAllRawEntries() returned %d entries, want %d", got, want)
+			}
+			if got, want := len(client.requests), tc.wantReqs; got != want {
+				t.Errorf("Made %d requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestGetAllRawEntriesErrors(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		start, end int64
+		maxEntries int64
+		errs       []error
+		wantReqs   int
+	}{
+		{desc: "bad-range", start: 5, end: 4, maxEntries: 10},
+		{desc: "negative-start", start: -1, end: 4, maxEntries: 10},
+		{desc: "beyond-tree", start: 990, end: 1010, maxEntries: 10, wantReqs: 0},
+		{desc: "no-entries", start: 0, end: 9, maxEntries: 0, wantReqs: maxEmptyResponses},
+		{desc: "permanent-error", start: 0, end: 9, maxEntries: 10, errs: []error{errors.New("bad")}, wantReqs: 1},
+		{desc: "bad-request", start: 0, end: 9, maxEntries: 10, errs: []error{jsonclient.RspError{Err: errors.New("bad"), StatusCode: http.StatusBadRequest}}, wantReqs: 1},
+		{desc: "transient-errors", start: 0, end: 9, maxEntries: 10, errs: []error{unavailable, tooMany, unavailable}, wantReqs: maxTransientErrors},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			client := &fakeLogClient{treeSize: 1000, maxEntries: tc.maxEntries, errs: tc.errs}
+			if _, err := GetAllRawEntries(context.Background(), client, tc.start, tc.end); err == nil {
+				t.Error("GetAllRawEntries()=_,nil; want error")
+			}
+			if got, want := len(client.requests), tc.wantReqs; got != want {
+				t.Errorf("Made %d requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+// growingLogClient is a LogClient whose tree grows by the given number of
+// entries on every GetSTH call.
+type growingLogClient struct {
+	growth uint64
+
+	mu       sync.Mutex
+	treeSize uint64
+}
+
+func (c *growingLogClient) BaseURI() string {
+	return "fake"
+}
+
+func (c *growingLogClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.treeSize += c.growth
+	return &ct.SignedTreeHead{TreeSize: c.treeSize}, nil
+}
+
+func (c *growingLogClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if start < 0 || start > end || end >= int64(c.treeSize) {
+		return nil, fmt.Errorf("bad range [%d, %d]", start, end)
+	}
+	return &ct.GetEntriesResponse{Entries: make([]ct.LeafEntry, end-start+1)}, nil
+}
+
+func TestFetcherMaxLag(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		growth  uint64
+		maxLag  int64
+		wantErr bool
+	}{
+		{desc: "unbounded", growth: 1000, maxLag: 0},
+		{desc: "within-bound", growth: 100, maxLag: 500},
+		{desc: "exceeded", growth: 1000, maxLag: 500, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			client := &growingLogClient{growth: tc.growth}
+			opts := &FetcherOptions{
+				BatchSize:     100,
+				ParallelFetch: 2,
+				Continuous:    true,
+				MaxLag:        tc.maxLag,
+			}
+			f := NewFetcher(client, opts)
+
+			// Without the lag bound, Run only returns once the context expires.
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := f.Run(ctx, func(EntryBatch) {})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("Run()=%v, want error: %v", err, tc.wantErr)
+			}
+			if tc.wantErr && ctx.Err() != nil {
+				t.Errorf("Run() returned after context expiry, want early return")
+			}
+		})
+	}
+}
+
+// staticSTHSource is an STHSource which always returns the same STH.
+type staticSTHSource struct {
+	sth *ct.SignedTreeHead
+}
+
+func (s staticSTHSource) CurrentSTH(context.Context) (*ct.SignedTreeHead, error) {
+	return s.sth, nil
+}
+
+// noSTHLogClient is a LogClient which fails the test if GetSTH is called.
+type noSTHLogClient struct {
+	*fakeLogClient
+	t *testing.T
+}
+
+func (c noSTHLogClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	c.t.Error("Unexpected GetSTH call")
+	return nil, errors.New("unexpected GetSTH call")
+}
+
+func TestFetcherSTHSource(t *testing.T) {
+	const sourceSize = 300
+	client := noSTHLogClient{fakeLogClient: &fakeLogClient{treeSize: 1000, maxEntries: 100}, t: t}
+	opts := &FetcherOptions{
+		BatchSize:     100,
+		ParallelFetch: 2,
+		STHSource:     staticSTHSource{sth: &ct.SignedTreeHead{TreeSize: sourceSize}},
+	}
+	f := NewFetcher(client, opts)
+
+	sth, err := f.Prepare(context.Background())
+	if err != nil {
+		t.Fatalf("Prepare(): %v", err)
+	}
+	if got, want := sth.TreeSize, uint64(sourceSize); got != want {
+		t.Errorf("Prepare() returned STH of size %d, want %d", got, want)
+	}
+	if got, want := opts.EndIndex, int64(sourceSize); got != want {
+		t.Errorf("EndIndex=%d, want %d", got, want)
+	}
+
+	var mu sync.Mutex
+	var fetched int64
+	if err := f.Run(context.Background(), func(b EntryBatch) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched += int64(len(b.Entries))
+	}); err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+	if fetched != sourceSize {
+		t.Errorf("Fetched %d entries, want %d", fetched, sourceSize)
+	}
+}
+
+// countingMetricFactory is an inert MetricFactory which counts the metrics it
+// creates.
+type countingMetricFactory struct {
+	monitoring.InertMetricFactory
+	created int
+}
+
+func (mf *countingMetricFactory) NewCounter(name, help string, labelNames ...string) monitoring.Counter {
+	mf.created++
+	return mf.InertMetricFactory.NewCounter(name, help, labelNames...)
+}
+
+func (mf *countingMetricFactory) NewGauge(name, help string, labelNames ...string) monitoring.Gauge {
+	mf.created++
+	return mf.InertMetricFactory.NewGauge(name, help, labelNames...)
+}
+
+func TestFetcherMetrics(t *testing.T) {
+	const treeSize = 1000
+	client := &fakeLogClient{treeSize: treeSize, maxEntries: 100}
+	mf := &countingMetricFactory{}
+	opts := &FetcherOptions{
+		BatchSize:     100,
+		ParallelFetch: 2,
+		MetricFactory: mf,
+	}
+	f := NewFetcher(client, opts)
+	// Another Fetcher with the same MetricFactory doesn't create the metrics
+	// again, which would fail with factories that register them globally.
+	if f2 := NewFetcher(client, opts); f2.metrics != f.metrics {
+		t.Error("Fetchers with the same MetricFactory don't share metrics")
+	}
+	if got, want := mf.created, 5; got != want {
+		t.Errorf("Created %d metrics, want %d", got, want)
+	}
+	if err := f.Run(context.Background(), func(EntryBatch) {}); err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+
+	m := f.metrics
+	if got, want := m.entries.Value(), float64(treeSize); got != want {
+		t.Errorf("entries fetched=%v, want %v", got, want)
+	}
+	if got, want := m.reqs.Value(), float64(len(client.requests)); got != want {
+		t.Errorf("get-entries requests=%v, want %v", got, want)
+	}
+	if got, want := m.treeSize.Value(), float64(treeSize); got != want {
+		t.Errorf("STH tree size=%v, want %v", got, want)
+	}
+	if got := m.leafIndex.Value(); got <= 0 || got > treeSize {
+		t.Errorf("leaf index=%v, want in (0, %d]", got, treeSize)
+	}
+
+	m.observeRequest(jsonclient.RspError{StatusCode: http.StatusTooManyRequests})
+	m.observeRequest(errors.New("connection reset"))
+	m.observeRequest(fmt.Errorf("wrapped: %w", jsonclient.RspError{StatusCode: http.StatusTooManyRequests}))
+	if got := m.errs.Value("429"); got != 2 {
+		t.Errorf("get-entries errors[429]=%v, want 2", got)
+	}
+	if got := m.errs.Value("unknown"); got != 1 {
+		t.Errorf("get-entries errors[unknown]=%v, want 1", got)
+	}
+}
+
+func TestFetcherProgress(t *testing.T) {
+	const treeSize = 1000
+	client := &fakeLogClient{treeSize: treeSize, maxEntries: 10}
+	var reports []int64 // Accessed from a single goroutine, by design.
+	opts := &FetcherOptions{
+		BatchSize:     50,
+		ParallelFetch: 4,
+		StartIndex:    100,
+		OnProgress: func(done, total int64, rate float64) {
+			if want := int64(treeSize - 100); total != want {
+				t.Errorf("OnProgress: total=%d, want %d", total, want)
+			}
+			if rate < 0 {
+				t.Errorf("OnProgress: rate=%f, want non-negative", rate)
+			}
+			reports = append(reports, done)
+		},
+		ProgressInterval: time.Millisecond,
+	}
+	f := NewFetcher(client, opts)
+	if err := f.Run(context.Background(), func(EntryBatch) {
+		time.Sleep(time.Millisecond) // Give the reporter some chance to run.
+	}); err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+
+	if len(reports) == 0 {
+		t.Fatal("OnProgress was not invoked")
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i] < reports[i-1] {
+			t.Errorf("OnProgress: done=%d after %d, want monotonic", reports[i], reports[i-1])
+		}
+	}
+	if got, want := reports[len(reports)-1], int64(treeSize-100); got != want {
+		t.Errorf("OnProgress: final done=%d, want %d", got, want)
+	}
+}
+
+func TestFetcherFetchIndices(t *testing.T) {
+	for _, tc := range []struct {
+		desc         string
+		indices      []int64
+		maxEntries   int64
+		want         []int64
+		wantRequests int
+		wantErr      bool
+	}{
+		{desc: "empty", want: []int64{}},
+		{desc: "single", indices: []int64{5}, maxEntries: 100, want: []int64{5}, wantRequests: 1},
+		{
+			desc:         "gaps",
+			indices:      []int64{1, 2, 3, 10, 11, 50},
+			maxEntries:   100,
+			want:         []int64{1, 2, 3, 10, 11, 50},
+			wantRequests: 3,
+		},
+		{
+			desc:         "duplicates-unsorted",
+			indices:      []int64{11, 3, 10, 2, 3, 1, 11, 11},
+			maxEntries:   100,
+			want:         []int64{1, 2, 3, 10, 11},
+			wantRequests: 2,
+		},
+		{
+			desc:         "capped-by-batch-size",
+			indices:      []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			maxEntries:   100,
+			want:         []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			wantRequests: 3,
+		},
+		{
+			desc:         "partial-responses",
+			indices:      []int64{20, 21, 22, 23},
+			maxEntries:   1,
+			want:         []int64{20, 21, 22, 23},
+			wantRequests: 4,
+		},
+		{desc: "beyond-tree-size", indices: []int64{1, 2, 100}, wantErr: true},
+		{desc: "negative", indices: []int64{-1, 2}, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			client := &fakeLogClient{treeSize: 100, maxEntries: tc.maxEntries}
+			f := NewFetcher(client, &FetcherOptions{BatchSize: 4, ParallelFetch: 2})
+
+			var mu sync.Mutex
+			got := make(map[int64]int)
+			err := f.FetchIndices(context.Background(), tc.indices, func(b EntryBatch) {
+				mu.Lock()
+				defer mu.Unlock()
+				for i := range b.Entries {
+					got[b.Start+int64(i)]++
+				}
+			})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("FetchIndices()=%v, want error: %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if len(got) != len(tc.want) {
+				t.Errorf("Fetched %d distinct entries, want %d", len(got), len(tc.want))
+			}
+			for _, idx := range tc.want {
+				if cnt := got[idx]; cnt != 1 {
+					t.Errorf("Entry %d fetched %d times, want 1", idx, cnt)
+				}
+			}
+			if got, want := len(client.requests), tc.wantRequests; got != want {
+				t.Errorf("Made %d requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+// sizedFakeLogClient is a fakeLogClient which reports a fixed size of each
+// entry in get-entries responses.
+type sizedFakeLogClient struct {
+	*fakeLogClient
+	entrySize int
+}
+
+func (c *sizedFakeLogClient) GetRawEntriesWithSize(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, int, error) {
+	resp, err := c.GetRawEntries(ctx, start, end)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, len(resp.Entries) * c.entrySize, nil
+}
+
+func TestFetcherMaxBytesPerSecond(t *testing.T) {
+	for _, tc := range []struct {
+		desc        string
+		maxBytes    int64
+		wantMinTime time.Duration
+	}{
+		{desc: "unlimited"},
+		// The 10 requests consume 100 bytes each. The last one can start only
+		// after the 900 bytes of the previous ones are paid off, which takes
+		// (900-400)/400 seconds given the initial 400 tokens.
+		{desc: "limited", maxBytes: 400, wantMinTime: time.Second},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			const treeSize = 100
+			client := &sizedFakeLogClient{
+				fakeLogClient: &fakeLogClient{treeSize: treeSize, maxEntries: 10},
+				entrySize:     10,
+			}
+			opts := &FetcherOptions{BatchSize: 10, ParallelFetch: 1, MaxBytesPerSecond: tc.maxBytes}
+			f := NewFetcher(client, opts)
+
+			var mu sync.Mutex
+			var fetched int64
+			start := time.Now()
+			if err := f.Run(context.Background(), func(b EntryBatch) {
+				mu.Lock()
+				defer mu.Unlock()
+				fetched += int64(len(b.Entries))
+			}); err != nil {
+				t.Fatalf("Run(): %v", err)
+			}
+			elapsed := time.Since(start)
+
+			if fetched != treeSize {
+				t.Errorf("Fetched %d entries, want %d", fetched, treeSize)
+			}
+			if elapsed < tc.wantMinTime {
+				t.Errorf("Run() took %v, want at least %v", elapsed, tc.wantMinTime)
+			}
+		})
+	}
+}
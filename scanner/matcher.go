@@ -16,15 +16,23 @@ package scanner
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"log"
 	"math/big"
+	"net"
+	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
 	"github.com/OlegBabkin/certificate-transparency-go/asn1"
 	"github.com/OlegBabkin/certificate-transparency-go/client"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
 )
 
 // Matcher describes how to match certificates and precertificates, based solely on the parsed [pre-]certificate;
@@ -85,33 +93,59 @@ func (m MatchSerialNumber) PrecertificateMatches(p *ct.Precertificate) bool {
 // MatchSubjectRegex is a Matcher which will use CertificateSubjectRegex and PrecertificateSubjectRegex
 // to determine whether Certificates and Precertificates are interesting.
 // The two regexes are tested against Subject CN (Common Name) as well as all
-// Subject Alternative Names
+// DNS Subject Alternative Names. Other SAN types are tested only if enabled by
+// the corresponding Match* field.
 type MatchSubjectRegex struct {
 	CertificateSubjectRegex    *regexp.Regexp
 	PrecertificateSubjectRegex *regexp.Regexp
+
+	// MatchEmailAddresses enables matching against email address SANs.
+	MatchEmailAddresses bool
+	// MatchIPAddresses enables matching against the string form of IP
+	// address SANs, e.g. "192.0.2.1" or "2001:db8::1".
+	MatchIPAddresses bool
+	// MatchURIs enables matching against URI SANs.
+	MatchURIs bool
 }
 
-// CertificateMatches returns true if either CN or any SAN of c matches m.CertificateSubjectRegex.
+// CertificateMatches returns true if either CN or any enabled SAN of c matches m.CertificateSubjectRegex.
 func (m MatchSubjectRegex) CertificateMatches(c *x509.Certificate) bool {
-	if m.CertificateSubjectRegex.FindStringIndex(c.Subject.CommonName) != nil {
+	return m.matches(m.CertificateSubjectRegex, c)
+}
+
+// PrecertificateMatches returns true if either CN or any enabled SAN of p matches m.PrecertificateSubjectRegex.
+func (m MatchSubjectRegex) PrecertificateMatches(p *ct.Precertificate) bool {
+	return m.matches(m.PrecertificateSubjectRegex, p.TBSCertificate)
+}
+
+func (m MatchSubjectRegex) matches(re *regexp.Regexp, c *x509.Certificate) bool {
+	if re.FindStringIndex(c.Subject.CommonName) != nil {
 		return true
 	}
 	for _, alt := range c.DNSNames {
-		if m.CertificateSubjectRegex.FindStringIndex(alt) != nil {
+		if re.FindStringIndex(alt) != nil {
 			return true
 		}
 	}
-	return false
-}
-
-// PrecertificateMatches returns true if either CN or any SAN of p matches m.PrecertificateSubjectRegex.
-func (m MatchSubjectRegex) PrecertificateMatches(p *ct.Precertificate) bool {
-	if m.PrecertificateSubjectRegex.FindStringIndex(p.TBSCertificate.Subject.CommonName) != nil {
-		return true
+	if m.MatchEmailAddresses {
+		for _, email := range c.EmailAddresses {
+			if re.FindStringIndex(email) != nil {
+				return true
+			}
+		}
 	}
-	for _, alt := range p.TBSCertificate.DNSNames {
-		if m.PrecertificateSubjectRegex.FindStringIndex(alt) != nil {
-			return true
+	if m.MatchIPAddresses {
+		for _, ip := range c.IPAddresses {
+			if re.FindStringIndex(ip.String()) != nil {
+				return true
+			}
+		}
+	}
+	if m.MatchURIs {
+		for _, uri := range c.URIs {
+			if re.FindStringIndex(uri.String()) != nil {
+				return true
+			}
 		}
 	}
 	return false
@@ -133,6 +167,188 @@ func (m MatchIssuerRegex) PrecertificateMatches(p *ct.Precertificate) bool {
 	return m.PrecertificateIssuerRegex.FindStringIndex(p.TBSCertificate.Issuer.CommonName) != nil
 }
 
+// MatchSPKIHash is a Matcher which matches [pre-]certificates by the SHA-256
+// hash of their DER-encoded SubjectPublicKeyInfo. This allows finding all the
+// certificates for a given (e.g. compromised) public key.
+type MatchSPKIHash struct {
+	Hash [sha256.Size]byte
+}
+
+// SPKIHash returns the SHA-256 hash of the SubjectPublicKeyInfo of c.
+func SPKIHash(c *x509.Certificate) [sha256.Size]byte {
+	return sha256.Sum256(c.RawSubjectPublicKeyInfo)
+}
+
+// CertificateMatches returns true if the SPKI hash of c matches.
+func (m MatchSPKIHash) CertificateMatches(c *x509.Certificate) bool {
+	return SPKIHash(c) == m.Hash
+}
+
+// PrecertificateMatches returns true if the SPKI hash of the TBSCertificate of
+// p matches.
+func (m MatchSPKIHash) PrecertificateMatches(p *ct.Precertificate) bool {
+	return SPKIHash(p.TBSCertificate) == m.Hash
+}
+
+// MatchExtension is a Matcher which matches [pre-]certificates that have an
+// extension with the given OID. If ValueRegex is set, the extension's DER
+// value must also match it, either as a hex string or as a raw string.
+type MatchExtension struct {
+	OID        asn1.ObjectIdentifier
+	ValueRegex *regexp.Regexp
+}
+
+// CertificateMatches returns true if c has a matching extension.
+func (m MatchExtension) CertificateMatches(c *x509.Certificate) bool {
+	return m.matches(c)
+}
+
+// PrecertificateMatches returns true if the TBSCertificate of p has a matching
+// extension.
+func (m MatchExtension) PrecertificateMatches(p *ct.Precertificate) bool {
+	return m.matches(p.TBSCertificate)
+}
+
+func (m MatchExtension) matches(c *x509.Certificate) bool {
+	for _, ext := range c.Extensions {
+		if !ext.Id.Equal(m.OID) {
+			continue
+		}
+		if m.ValueRegex == nil ||
+			m.ValueRegex.MatchString(hex.EncodeToString(ext.Value)) ||
+			m.ValueRegex.Match(ext.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchNotAfterRange is a Matcher which matches [pre-]certificates whose
+// NotAfter falls in the range [Start, Limit). A zero Start or Limit means the
+// range is unbounded on the corresponding side.
+type MatchNotAfterRange struct {
+	Start time.Time
+	Limit time.Time
+}
+
+// CertificateMatches returns true if the NotAfter of c is in range.
+func (m MatchNotAfterRange) CertificateMatches(c *x509.Certificate) bool {
+	return m.inRange(c.NotAfter)
+}
+
+// PrecertificateMatches returns true if the NotAfter of p is in range.
+func (m MatchNotAfterRange) PrecertificateMatches(p *ct.Precertificate) bool {
+	return m.inRange(p.TBSCertificate.NotAfter)
+}
+
+func (m MatchNotAfterRange) inRange(notAfter time.Time) bool {
+	if !m.Start.IsZero() && notAfter.Before(m.Start) {
+		return false
+	}
+	if !m.Limit.IsZero() && !notAfter.Before(m.Limit) {
+		return false
+	}
+	return true
+}
+
+// MatchIPSAN is a Matcher which matches [pre-]certificates that have an IP
+// address SAN within Network, e.g. to find all the certificates issued to
+// addresses in a CIDR block.
+type MatchIPSAN struct {
+	Network *net.IPNet
+}
+
+// CertificateMatches returns true if any IP address SAN of c is in Network.
+func (m MatchIPSAN) CertificateMatches(c *x509.Certificate) bool {
+	return m.matches(c)
+}
+
+// PrecertificateMatches returns true if any IP address SAN of the
+// TBSCertificate of p is in Network.
+func (m MatchIPSAN) PrecertificateMatches(p *ct.Precertificate) bool {
+	return m.matches(p.TBSCertificate)
+}
+
+func (m MatchIPSAN) matches(c *x509.Certificate) bool {
+	for _, ip := range c.IPAddresses {
+		if m.Network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAnd is a Matcher which matches [pre-]certificates that are matched by
+// all of its Matchers. An empty MatchAnd matches everything.
+type MatchAnd struct {
+	Matchers []Matcher
+}
+
+// CertificateMatches returns true if every Matcher matches c, stopping at the
+// first one that doesn't.
+func (m MatchAnd) CertificateMatches(c *x509.Certificate) bool {
+	for _, sub := range m.Matchers {
+		if !sub.CertificateMatches(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// PrecertificateMatches returns true if every Matcher matches p, stopping at
+// the first one that doesn't.
+func (m MatchAnd) PrecertificateMatches(p *ct.Precertificate) bool {
+	for _, sub := range m.Matchers {
+		if !sub.PrecertificateMatches(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchOr is a Matcher which matches [pre-]certificates that are matched by
+// any of its Matchers. An empty MatchOr matches nothing.
+type MatchOr struct {
+	Matchers []Matcher
+}
+
+// CertificateMatches returns true if any Matcher matches c, stopping at the
+// first one that does.
+func (m MatchOr) CertificateMatches(c *x509.Certificate) bool {
+	for _, sub := range m.Matchers {
+		if sub.CertificateMatches(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrecertificateMatches returns true if any Matcher matches p, stopping at the
+// first one that does.
+func (m MatchOr) PrecertificateMatches(p *ct.Precertificate) bool {
+	for _, sub := range m.Matchers {
+		if sub.PrecertificateMatches(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchNot is a Matcher which inverts the result of another Matcher.
+type MatchNot struct {
+	Matcher Matcher
+}
+
+// CertificateMatches returns true if the wrapped Matcher doesn't match c.
+func (m MatchNot) CertificateMatches(c *x509.Certificate) bool {
+	return !m.Matcher.CertificateMatches(c)
+}
+
+// PrecertificateMatches returns true if the wrapped Matcher doesn't match p.
+func (m MatchNot) PrecertificateMatches(p *ct.Precertificate) bool {
+	return !m.Matcher.PrecertificateMatches(p)
+}
+
 // MatchSCTTimestamp is a matcher which matches leaf entries with the specified Timestamp.
 type MatchSCTTimestamp struct {
 	Timestamp uint64
@@ -149,6 +365,33 @@ func (m MatchSCTTimestamp) Matches(leaf *ct.LeafEntry) bool {
 	return entry.Leaf.TimestampedEntry.Timestamp == m.Timestamp
 }
 
+// MatchEmbeddedSCTLogID is a LeafMatcher which matches certificates carrying
+// an embedded SCT from the log with the given ID, i.e. the SHA-256 hash of the
+// log's public key. Precertificates never match, as they have no embedded SCTs.
+type MatchEmbeddedSCTLogID struct {
+	LogID [sha256.Size]byte
+}
+
+// Matches returns true if any of the SCTs embedded in the leaf's certificate
+// was issued by the log specified by this matcher.
+func (m MatchEmbeddedSCTLogID) Matches(leaf *ct.LeafEntry) bool {
+	entry, _ := ct.LogEntryFromLeaf(1, leaf)
+	if entry == nil || entry.X509Cert == nil {
+		return false
+	}
+	for i, sctData := range entry.X509Cert.SCTList.SCTList {
+		sct, err := x509util.ExtractSCT(&sctData)
+		if err != nil {
+			log.Printf("Failed to deserialize SCT[%d] data: %v", i, err)
+			continue
+		}
+		if sct.LogID.KeyID == m.LogID {
+			return true
+		}
+	}
+	return false
+}
+
 // LeafMatcher describes how to match log entries, based on the Log LeafEntry
 // (which includes the unparsed [pre-]certificate; clients should implement this
 // interface to perform their own match criteria.
@@ -280,3 +523,113 @@ func dropUnhandledExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) {
 		}
 	}
 }
+
+// MatchRevoked is a LeafMatcher which matches [pre-]certificates that are
+// listed as revoked on any of the CRLs at their CRL distribution points.
+//
+// Each CRL is fetched once, on first use, and cached by URL for the lifetime of
+// the matcher. A CRL which can't be fetched or parsed is logged and treated as
+// empty. CRL signatures are not checked. A MatchRevoked must not be copied
+// after first use.
+type MatchRevoked struct {
+	// Client is used to fetch CRLs. If nil, a client with a timeout of
+	// defaultCRLFetchTimeout is used.
+	Client *http.Client
+
+	mu sync.Mutex
+	// crls maps from CRL URL to its (possibly in-flight) fetch.
+	crls map[string]*crlFetch
+}
+
+// defaultCRLFetchTimeout bounds CRL fetches by a MatchRevoked with no Client,
+// so that a hanging CRL server can't stall scanning indefinitely.
+const defaultCRLFetchTimeout = 30 * time.Second
+
+var defaultCRLClient = &http.Client{Timeout: defaultCRLFetchTimeout}
+
+// crlFetch holds the result of fetching one CRL. The serials field is only
+// valid once done has been closed.
+type crlFetch struct {
+	done chan struct{}
+	// serials is the set of revoked serial numbers, as hex.
+	serials map[string]bool
+}
+
+// Matches returns true if the [pre-]certificate in the leaf has been revoked.
+func (m *MatchRevoked) Matches(leaf *ct.LeafEntry) bool {
+	entry, _ := ct.LogEntryFromLeaf(1, leaf)
+	if entry == nil {
+		// Can't check revocation if we can't parse
+		return false
+	}
+	var cert *x509.Certificate
+	if entry.X509Cert != nil {
+		cert = entry.X509Cert
+	} else {
+		cert = entry.Precert.TBSCertificate
+	}
+	serial := cert.SerialNumber.Text(16)
+	for _, u := range cert.CRLDistributionPoints {
+		if m.revokedSerials(u)[serial] {
+			return true
+		}
+	}
+	return false
+}
+
+// revokedSerials returns the set of serial numbers revoked by the CRL at the
+// given URL, fetching it if it isn't cached. Only the first caller for a URL
+// fetches it, without holding the lock; concurrent callers for the same URL
+// wait for that fetch, while callers for other URLs are not blocked.
+func (m *MatchRevoked) revokedSerials(u string) map[string]bool {
+	m.mu.Lock()
+	f, ok := m.crls[u]
+	if !ok {
+		if m.crls == nil {
+			m.crls = make(map[string]*crlFetch)
+		}
+		f = &crlFetch{done: make(chan struct{})}
+		m.crls[u] = f
+	}
+	m.mu.Unlock()
+
+	if ok {
+		<-f.done
+		return f.serials
+	}
+	serials, err := m.fetchCRL(u)
+	if err != nil {
+		log.Printf("Failed to fetch CRL from %s: %v", u, err)
+	}
+	f.serials = serials
+	close(f.done)
+	return serials
+}
+
+func (m *MatchRevoked) fetchCRL(u string) (map[string]bool, error) {
+	client := m.Client
+	if client == nil {
+		client = defaultCRLClient
+	}
+	rsp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got HTTP status %q", rsp.Status)
+	}
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := x509.ParseCertificateList(body)
+	if x509.IsFatal(err) {
+		return nil, err
+	}
+	serials := make(map[string]bool, len(crl.TBSCertList.RevokedCertificates))
+	for _, rc := range crl.TBSCertList.RevokedCertificates {
+		serials[rc.SerialNumber.Text(16)] = true
+	}
+	return serials, nil
+}
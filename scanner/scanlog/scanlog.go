@@ -59,6 +59,8 @@ var (
 	startIndex    = flag.Int64("start_index", 0, "Log index to start scanning at")
 	endIndex      = flag.Int64("end_index", 0, "Log index to end scanning at (non-inclusive, 0 = end of log)")
 
+	maxBytesPerSec = flag.Int64("max_bytes_per_sec", 0, "Max average rate of get-entries response bytes to consume (0 = unlimited)")
+
 	printChains = flag.Bool("print_chains", false, "If true prints the whole chain rather than a summary")
 	dumpDir     = flag.String("dump_dir", "", "Directory to store matched certificates in")
 )
@@ -212,6 +214,8 @@ func main() {
 			ParallelFetch: *parallelFetch,
 			StartIndex:    *startIndex,
 			EndIndex:      *endIndex,
+
+			MaxBytesPerSecond: *maxBytesPerSec,
 		},
 		Matcher:    matcher,
 		NumWorkers: *numWorkers,
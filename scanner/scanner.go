@@ -17,6 +17,8 @@ package scanner
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -36,14 +38,25 @@ type ScannerOptions struct { // nolint:revive
 	// implementation.
 	Matcher interface{}
 
-	// Match precerts only (Matcher still applies to precerts).
+	// Match precerts only (Matcher still applies to precerts). Cannot be
+	// combined with CertOnly.
 	PrecertOnly bool
 
-	// Number of concurrent matchers to run.
+	// Match certificates only (Matcher still applies to certificates). Cannot
+	// be combined with PrecertOnly.
+	CertOnly bool
+
+	// Number of concurrent matchers to run. Parsing and matching entries is
+	// done by these workers, independently of the ParallelFetch fetchers.
 	NumWorkers int
 
-	// Number of fetched entries to buffer on their way to the callbacks.
+	// Number of fetched entries to buffer on their way to the callbacks. Once
+	// the buffer is full, fetchers wait for the matchers to catch up.
 	BufferSize int
+
+	// If positive, the scan stops once this many matches have been delivered
+	// to the callbacks, across all workers. Zero means no limit.
+	MaxMatches int
 }
 
 // DefaultScannerOptions returns a new ScannerOptions with sensible defaults.
@@ -64,8 +77,12 @@ type Scanner struct {
 	// Counters of the number of certificates scanned and matched.
 	certsProcessed int64
 	certsMatched   int64
+	// Counter of the matches delivered to the callbacks, for MaxMatches.
+	matchesDelivered int64
 
-	// Counter of the number of precertificates encountered during the scan.
+	// Counters of the number of certificates and precertificates encountered
+	// during the scan. Certificates are counted only by Census.
+	certsSeen    int64
 	precertsSeen int64
 
 	unparsableEntries         int64
@@ -85,6 +102,15 @@ type entryInfo struct {
 	entry ct.LeafEntry
 }
 
+// EntryCensus holds the tallies of the log entries seen by Census.
+type EntryCensus struct {
+	Entries        int64 // The number of entries scanned.
+	Certs          int64 // The number of X.509 certificate entries.
+	Precerts       int64 // The number of precertificate entries.
+	Unparsable     int64 // The number of entries that failed to parse.
+	NonFatalErrors int64 // The number of entries with non-fatal parse errors.
+}
+
 // Takes the error returned by either x509.ParseCertificate() or
 // x509.ParseTBSCertificate() and determines if it's non-fatal or otherwise.
 // In the case of non-fatal errors, the error will be logged,
@@ -105,9 +131,45 @@ func (s *Scanner) isCertErrorFatal(err error, logEntry *ct.LogEntry, index int64
 	return true
 }
 
+// leafEntryType peeks at the entry type of a serialized MerkleTreeLeaf without
+// parsing it. Returns false if the leaf is too short or not a timestamped
+// entry of a supported version.
+func leafEntryType(leafInput []byte) (ct.LogEntryType, bool) {
+	// version(1) + leaf_type(1) + timestamp(8) + entry_type(2).
+	if len(leafInput) < 12 || ct.Version(leafInput[0]) != ct.V1 || ct.MerkleLeafType(leafInput[1]) != ct.TimestampedEntryLeafType {
+		return 0, false
+	}
+	return ct.LogEntryType(binary.BigEndian.Uint16(leafInput[10:12])), true
+}
+
+// skipEntry reports whether the given entry can be skipped without parsing
+// because its type is excluded by PrecertOnly or CertOnly.
+func (s *Scanner) skipEntry(info entryInfo) bool {
+	if !s.opts.PrecertOnly && !s.opts.CertOnly {
+		return false
+	}
+	eType, ok := leafEntryType(info.entry.LeafInput)
+	if !ok {
+		return false // Let the full parse report the problem.
+	}
+	switch eType {
+	case ct.X509LogEntryType:
+		return s.opts.PrecertOnly
+	case ct.PrecertLogEntryType:
+		if s.opts.CertOnly {
+			atomic.AddInt64(&s.precertsSeen, 1)
+			return true
+		}
+	}
+	return false
+}
+
 // Processes the given entry in the specified log.
 func (s *Scanner) processEntry(info entryInfo, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) error {
 	atomic.AddInt64(&s.certsProcessed, 1)
+	if s.skipEntry(info) {
+		return nil
+	}
 
 	switch matcher := s.opts.Matcher.(type) {
 	case Matcher:
@@ -141,6 +203,10 @@ func (s *Scanner) processMatcherEntry(matcher Matcher, info entryInfo, foundCert
 			foundCert(rawLogEntry)
 		}
 	case logEntry.Precert != nil:
+		if s.opts.CertOnly {
+			atomic.AddInt64(&s.precertsSeen, 1)
+			return nil
+		}
 		if matcher.PrecertificateMatches(logEntry.Precert) {
 			atomic.AddInt64(&s.certsMatched, 1)
 			foundPrecert(rawLogEntry)
@@ -169,20 +235,45 @@ func (s *Scanner) processMatcherLeafEntry(matcher LeafMatcher, info entryInfo, f
 		}
 		foundCert(rawLogEntry)
 	case ct.PrecertLogEntryType:
-		foundPrecert(rawLogEntry)
+		if !s.opts.CertOnly {
+			foundPrecert(rawLogEntry)
+		}
+		atomic.AddInt64(&s.precertsSeen, 1)
+	default:
+		return fmt.Errorf("saw unknown entry type: %v", eType)
+	}
+	return nil
+}
+
+// Tallies the given entry by its type, and checks that it parses.
+func (s *Scanner) tallyEntry(info entryInfo) error {
+	atomic.AddInt64(&s.certsProcessed, 1)
+
+	rawLogEntry, err := ct.RawLogEntryFromLeaf(info.index, &info.entry)
+	if err != nil {
+		return fmt.Errorf("failed to build raw log entry %d: %v", info.index, err)
+	}
+	switch eType := rawLogEntry.Leaf.TimestampedEntry.EntryType; eType {
+	case ct.X509LogEntryType:
+		atomic.AddInt64(&s.certsSeen, 1)
+	case ct.PrecertLogEntryType:
 		atomic.AddInt64(&s.precertsSeen, 1)
 	default:
 		return fmt.Errorf("saw unknown entry type: %v", eType)
 	}
+	logEntry, err := rawLogEntry.ToLogEntry()
+	if s.isCertErrorFatal(err, logEntry, info.index) {
+		return fmt.Errorf("failed to parse [pre-]certificate in MerkleTreeLeaf[%d]: %v", info.index, err)
+	}
 	return nil
 }
 
 // Worker function to match certs.
-// Accepts MatcherJobs over the entries channel, and processes them.
-// Returns true over the done channel when the entries channel is closed.
-func (s *Scanner) matcherJob(entries <-chan entryInfo, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) {
+// Accepts MatcherJobs over the entries channel, and processes them with the
+// process function.
+func (s *Scanner) matcherJob(entries <-chan entryInfo, process func(entryInfo) error) {
 	for e := range entries {
-		if err := s.processEntry(e, foundCert, foundPrecert); err != nil {
+		if err := process(e); err != nil {
 			atomic.AddInt64(&s.unparsableEntries, 1)
 			klog.Errorf("Failed to parse entry at index %d: %s", e.index, err.Error())
 		}
@@ -257,10 +348,77 @@ func (s *Scanner) Scan(ctx context.Context, foundCert func(*ct.RawLogEntry), fou
 }
 
 // ScanLog performs a scan against the Log, returning the count of scanned entries.
+//
+// If MaxMatches is set, the scan stops without error once that many matches
+// have been delivered, after the callbacks in flight have returned. In this
+// case the returned count is the number of entries processed so far.
 func (s *Scanner) ScanLog(ctx context.Context, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) (int64, error) {
+	if s.opts.PrecertOnly && s.opts.CertOnly {
+		return 0, errors.New("PrecertOnly and CertOnly are mutually exclusive")
+	}
+	atomic.StoreInt64(&s.matchesDelivered, 0)
+	limit := int64(s.opts.MaxMatches)
+	if limit <= 0 {
+		return s.scan(ctx, func(e entryInfo) error {
+			return s.processEntry(e, foundCert, foundPrecert)
+		})
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// limited wraps a callback so that no more than limit matches are
+	// delivered in total, and the scan is canceled when the limit is reached.
+	limited := func(found func(*ct.RawLogEntry)) func(*ct.RawLogEntry) {
+		return func(e *ct.RawLogEntry) {
+			n := atomic.AddInt64(&s.matchesDelivered, 1)
+			if n > limit {
+				return
+			}
+			found(e)
+			if n == limit {
+				cancel()
+			}
+		}
+	}
+	foundCert, foundPrecert = limited(foundCert), limited(foundPrecert)
+	count, err := s.scan(cctx, func(e entryInfo) error {
+		if cctx.Err() != nil {
+			return nil // Drain the remaining entries.
+		}
+		return s.processEntry(e, foundCert, foundPrecert)
+	})
+	if atomic.LoadInt64(&s.matchesDelivered) >= limit && ctx.Err() == nil {
+		klog.V(1).Infof("Stopped after %d matches", limit)
+		return atomic.LoadInt64(&s.certsProcessed), nil
+	}
+	return count, err
+}
+
+// Census performs a dry-run scan against the Log, which only tallies the
+// entries by type and parse failures, without running the Matcher or any
+// callbacks. This is a cheap way to get statistics of a log segment.
+func (s *Scanner) Census(ctx context.Context) (EntryCensus, error) {
+	if _, err := s.scan(ctx, s.tallyEntry); err != nil {
+		return EntryCensus{}, err
+	}
+	return EntryCensus{
+		Entries:        atomic.LoadInt64(&s.certsProcessed),
+		Certs:          atomic.LoadInt64(&s.certsSeen),
+		Precerts:       atomic.LoadInt64(&s.precertsSeen),
+		Unparsable:     atomic.LoadInt64(&s.unparsableEntries),
+		NonFatalErrors: atomic.LoadInt64(&s.entriesWithNonFatalErrors),
+	}, nil
+}
+
+// scan runs the fetcher against the Log, and passes all the fetched entries to
+// the process function, which is run by NumWorkers concurrent workers. The
+// workers consume a channel of BufferSize entries which the fetchers block on,
+// so a slow process function throttles fetching instead of piling up entries.
+func (s *Scanner) scan(ctx context.Context, process func(entryInfo) error) (int64, error) {
 	klog.V(1).Infof("Starting up Scanner...")
 	s.certsProcessed = 0
 	s.certsMatched = 0
+	s.certsSeen = 0
 	s.precertsSeen = 0
 	s.unparsableEntries = 0
 	s.entriesWithNonFatalErrors = 0
@@ -281,19 +439,23 @@ func (s *Scanner) ScanLog(ctx context.Context, foundCert func(*ct.RawLogEntry),
 	// Start matcher workers.
 	var wg sync.WaitGroup
 	entries := make(chan entryInfo, s.opts.BufferSize)
-	for w, cnt := 0, s.opts.NumWorkers; w < cnt; w++ {
+	for w, cnt := 0, max(s.opts.NumWorkers, 1); w < cnt; w++ {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
 			klog.V(1).Infof("Matcher %d starting", idx)
-			s.matcherJob(entries, foundCert, foundPrecert)
+			s.matcherJob(entries, process)
 			klog.V(1).Infof("Matcher %d finished", idx)
 		}(w)
 	}
 
 	flatten := func(b EntryBatch) {
 		for i, e := range b.Entries {
-			entries <- entryInfo{index: b.Start + int64(i), entry: e}
+			select {
+			case <-ctx.Done():
+				return
+			case entries <- entryInfo{index: b.Start + int64(i), entry: e}:
+			}
 		}
 	}
 	err = s.fetcher.Run(ctx, flatten)
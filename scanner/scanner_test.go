@@ -15,18 +15,37 @@
 package scanner
 
 import (
+	"bytes"
 	"container/list"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
 	"github.com/OlegBabkin/certificate-transparency-go/client"
 	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
+	"github.com/OlegBabkin/certificate-transparency-go/testdata"
+	"github.com/OlegBabkin/certificate-transparency-go/tls"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509/pkix"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
 )
 
 func TestScannerMatchAll(t *testing.T) {
@@ -50,7 +69,7 @@ func TestScannerMatchSubjectRegexMatchesCertificateCommonName(t *testing.T) {
 	var cert x509.Certificate
 	cert.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{regexp.MustCompile(SubjectRegEx), nil}
+	m := MatchSubjectRegex{CertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	if !m.CertificateMatches(&cert) {
 		t.Fatal("MatchSubjectRegex failed to match on Cert Subject CommonName")
 	}
@@ -62,7 +81,7 @@ func TestScannerMatchSubjectRegexIgnoresDifferentCertificateCommonName(t *testin
 	var cert x509.Certificate
 	cert.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{regexp.MustCompile(SubjectRegEx), nil}
+	m := MatchSubjectRegex{CertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	if m.CertificateMatches(&cert) {
 		t.Fatal("MatchSubjectRegex incorrectly matched on Cert Subject CommonName")
 	}
@@ -74,7 +93,7 @@ func TestScannerMatchSubjectRegexIgnoresDifferentCertificateSAN(t *testing.T) {
 	var cert x509.Certificate
 	cert.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{regexp.MustCompile(SubjectRegEx), nil}
+	m := MatchSubjectRegex{CertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	cert.Subject.CommonName = "Wibble"              // Doesn't match
 	cert.DNSNames = append(cert.DNSNames, "Wibble") // Nor this
 	cert.DNSNames = append(cert.DNSNames, SubjectName)
@@ -90,7 +109,7 @@ func TestScannerMatchSubjectRegexMatchesCertificateSAN(t *testing.T) {
 	var cert x509.Certificate
 	cert.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{regexp.MustCompile(SubjectRegEx), nil}
+	m := MatchSubjectRegex{CertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	cert.Subject.CommonName = "Wibble"              // Doesn't match
 	cert.DNSNames = append(cert.DNSNames, "Wibble") // Nor this
 	cert.DNSNames = append(cert.DNSNames, SubjectName)
@@ -107,7 +126,7 @@ func TestScannerMatchSubjectRegexMatchesPrecertificateCommonName(t *testing.T) {
 	precert.TBSCertificate = &x509.Certificate{}
 	precert.TBSCertificate.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{nil, regexp.MustCompile(SubjectRegEx)}
+	m := MatchSubjectRegex{PrecertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	if !m.PrecertificateMatches(&precert) {
 		t.Fatal("MatchSubjectRegex failed to match on Precert Subject CommonName")
 	}
@@ -120,7 +139,7 @@ func TestScannerMatchSubjectRegexIgnoresDifferentPrecertificateCommonName(t *tes
 	precert.TBSCertificate = &x509.Certificate{}
 	precert.TBSCertificate.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{nil, regexp.MustCompile(SubjectRegEx)}
+	m := MatchSubjectRegex{PrecertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	if m.PrecertificateMatches(&precert) {
 		t.Fatal("MatchSubjectRegex incorrectly matched on Precert Subject CommonName")
 	}
@@ -133,7 +152,7 @@ func TestScannerMatchSubjectRegexIgnoresDifferentPrecertificateSAN(t *testing.T)
 	precert.TBSCertificate = &x509.Certificate{}
 	precert.TBSCertificate.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{nil, regexp.MustCompile(SubjectRegEx)}
+	m := MatchSubjectRegex{PrecertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	precert.TBSCertificate.Subject.CommonName = "Wibble"                                // Doesn't match
 	precert.TBSCertificate.DNSNames = append(precert.TBSCertificate.DNSNames, "Wibble") // Nor this
 	precert.TBSCertificate.DNSNames = append(precert.TBSCertificate.DNSNames, SubjectName)
@@ -150,7 +169,7 @@ func TestScannerMatchSubjectRegexMatchesPrecertificateSAN(t *testing.T) {
 	precert.TBSCertificate = &x509.Certificate{}
 	precert.TBSCertificate.Subject.CommonName = SubjectName
 
-	m := MatchSubjectRegex{nil, regexp.MustCompile(SubjectRegEx)}
+	m := MatchSubjectRegex{PrecertificateSubjectRegex: regexp.MustCompile(SubjectRegEx)}
 	precert.TBSCertificate.Subject.CommonName = "Wibble"                                // Doesn't match
 	precert.TBSCertificate.DNSNames = append(precert.TBSCertificate.DNSNames, "Wibble") // Nor this
 	precert.TBSCertificate.DNSNames = append(precert.TBSCertificate.DNSNames, SubjectName)
@@ -160,6 +179,520 @@ func TestScannerMatchSubjectRegexMatchesPrecertificateSAN(t *testing.T) {
 	}
 }
 
+func TestScannerMatchSubjectRegexSANTypes(t *testing.T) {
+	re := regexp.MustCompile(`example\.com|^192\.0\.2\.|^2001:db8::`)
+	uri, err := url.Parse("https://www.example.com/path")
+	if err != nil {
+		t.Fatalf("url.Parse(): %v", err)
+	}
+
+	for _, test := range []struct {
+		desc string
+		m    MatchSubjectRegex
+		cert x509.Certificate
+		want bool
+	}{
+		{desc: "email-disabled", cert: x509.Certificate{EmailAddresses: []string{"admin@example.com"}}, want: false},
+		{desc: "email", m: MatchSubjectRegex{MatchEmailAddresses: true}, cert: x509.Certificate{EmailAddresses: []string{"a@b.org", "admin@example.com"}}, want: true},
+		{desc: "email-mismatch", m: MatchSubjectRegex{MatchEmailAddresses: true}, cert: x509.Certificate{EmailAddresses: []string{"a@b.org"}}, want: false},
+		{desc: "ipv4-disabled", cert: x509.Certificate{IPAddresses: []net.IP{net.ParseIP("192.0.2.1")}}, want: false},
+		{desc: "ipv4", m: MatchSubjectRegex{MatchIPAddresses: true}, cert: x509.Certificate{IPAddresses: []net.IP{net.ParseIP("192.0.2.1")}}, want: true},
+		{desc: "ipv6", m: MatchSubjectRegex{MatchIPAddresses: true}, cert: x509.Certificate{IPAddresses: []net.IP{net.ParseIP("2001:db8::1")}}, want: true},
+		{desc: "ip-mismatch", m: MatchSubjectRegex{MatchIPAddresses: true}, cert: x509.Certificate{IPAddresses: []net.IP{net.ParseIP("198.51.100.1")}}, want: false},
+		{desc: "uri-disabled", cert: x509.Certificate{URIs: []*url.URL{uri}}, want: false},
+		{desc: "uri", m: MatchSubjectRegex{MatchURIs: true}, cert: x509.Certificate{URIs: []*url.URL{uri}}, want: true},
+		{desc: "dns-still-matched", m: MatchSubjectRegex{MatchURIs: true}, cert: x509.Certificate{DNSNames: []string{"www.example.com"}}, want: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			test.m.CertificateSubjectRegex = re
+			test.m.PrecertificateSubjectRegex = re
+			if got := test.m.CertificateMatches(&test.cert); got != test.want {
+				t.Errorf("CertificateMatches()=%v, want %v", got, test.want)
+			}
+			precert := &ct.Precertificate{TBSCertificate: &test.cert}
+			if got := test.m.PrecertificateMatches(precert); got != test.want {
+				t.Errorf("PrecertificateMatches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScannerMatchSPKIHash(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		t.Helper()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey(): %v", err)
+		}
+		return key
+	}
+	newCert := func(serial int64, cn string, key *ecdsa.PrivateKey) *x509.Certificate {
+		t.Helper()
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		if err != nil {
+			t.Fatalf("CreateCertificate(): %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("ParseCertificate(): %v", err)
+		}
+		return cert
+	}
+
+	compromised, other := newKey(), newKey()
+	cert1 := newCert(1, "www.example.com", compromised)
+	cert2 := newCert(2, "mail.example.org", compromised)
+	cert3 := newCert(3, "www.example.com", other)
+
+	m := MatchSPKIHash{Hash: SPKIHash(cert1)}
+	for _, test := range []struct {
+		desc string
+		cert *x509.Certificate
+		want bool
+	}{
+		{desc: "same-cert", cert: cert1, want: true},
+		{desc: "same-key", cert: cert2, want: true},
+		{desc: "other-key", cert: cert3, want: false},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := m.CertificateMatches(test.cert); got != test.want {
+				t.Errorf("CertificateMatches()=%v, want %v", got, test.want)
+			}
+			precert := &ct.Precertificate{TBSCertificate: test.cert}
+			if got := m.PrecertificateMatches(precert); got != test.want {
+				t.Errorf("PrecertificateMatches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScannerMatchExtension(t *testing.T) {
+	oid := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 99}
+	otherOID := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 98}
+	withExt := &x509.Certificate{Extensions: []pkix.Extension{
+		{Id: otherOID, Value: []byte{0x05, 0x00}},
+		{Id: oid, Value: []byte("\x0c\x05hello")},
+	}}
+	withoutExt := &x509.Certificate{Extensions: []pkix.Extension{
+		{Id: otherOID, Value: []byte("\x0c\x05hello")},
+	}}
+
+	for _, test := range []struct {
+		desc string
+		m    MatchExtension
+		cert *x509.Certificate
+		want bool
+	}{
+		{desc: "oid-present", m: MatchExtension{OID: oid}, cert: withExt, want: true},
+		{desc: "oid-absent", m: MatchExtension{OID: oid}, cert: withoutExt, want: false},
+		{desc: "no-extensions", m: MatchExtension{OID: oid}, cert: &x509.Certificate{}, want: false},
+		{desc: "string-value-match", m: MatchExtension{OID: oid, ValueRegex: regexp.MustCompile("hel+o")}, cert: withExt, want: true},
+		{desc: "hex-value-match", m: MatchExtension{OID: oid, ValueRegex: regexp.MustCompile("^0c0568656c6c6f$")}, cert: withExt, want: true},
+		{desc: "value-mismatch", m: MatchExtension{OID: oid, ValueRegex: regexp.MustCompile("goodbye")}, cert: withExt, want: false},
+		{desc: "value-match-wrong-oid", m: MatchExtension{OID: oid, ValueRegex: regexp.MustCompile("hello")}, cert: withoutExt, want: false},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := test.m.CertificateMatches(test.cert); got != test.want {
+				t.Errorf("CertificateMatches()=%v, want %v", got, test.want)
+			}
+			precert := &ct.Precertificate{TBSCertificate: test.cert}
+			if got := test.m.PrecertificateMatches(precert); got != test.want {
+				t.Errorf("PrecertificateMatches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScannerMatchNotAfterRange(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		desc     string
+		m        MatchNotAfterRange
+		notAfter time.Time
+		want     bool
+	}{
+		{desc: "inside", m: MatchNotAfterRange{Start: start, Limit: limit}, notAfter: start.Add(time.Hour), want: true},
+		{desc: "at-start", m: MatchNotAfterRange{Start: start, Limit: limit}, notAfter: start, want: true},
+		{desc: "before-start", m: MatchNotAfterRange{Start: start, Limit: limit}, notAfter: start.Add(-time.Nanosecond), want: false},
+		{desc: "at-limit", m: MatchNotAfterRange{Start: start, Limit: limit}, notAfter: limit, want: false},
+		{desc: "before-limit", m: MatchNotAfterRange{Start: start, Limit: limit}, notAfter: limit.Add(-time.Nanosecond), want: true},
+		{desc: "after-limit", m: MatchNotAfterRange{Start: start, Limit: limit}, notAfter: limit.Add(time.Hour), want: false},
+		{desc: "no-start", m: MatchNotAfterRange{Limit: limit}, notAfter: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), want: true},
+		{desc: "no-start-at-limit", m: MatchNotAfterRange{Limit: limit}, notAfter: limit, want: false},
+		{desc: "no-limit", m: MatchNotAfterRange{Start: start}, notAfter: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), want: true},
+		{desc: "no-limit-before-start", m: MatchNotAfterRange{Start: start}, notAfter: start.Add(-time.Hour), want: false},
+		{desc: "unbounded", m: MatchNotAfterRange{}, notAfter: start, want: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			cert := &x509.Certificate{NotAfter: test.notAfter}
+			if got := test.m.CertificateMatches(cert); got != test.want {
+				t.Errorf("CertificateMatches()=%v, want %v", got, test.want)
+			}
+			precert := &ct.Precertificate{TBSCertificate: cert}
+			if got := test.m.PrecertificateMatches(precert); got != test.want {
+				t.Errorf("PrecertificateMatches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScannerMatchIPSAN(t *testing.T) {
+	network := func(cidr string) *net.IPNet {
+		t.Helper()
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("ParseCIDR(%q): %v", cidr, err)
+		}
+		return n
+	}
+	ips := func(addrs ...string) []net.IP {
+		var out []net.IP
+		for _, a := range addrs {
+			out = append(out, net.ParseIP(a))
+		}
+		return out
+	}
+
+	for _, test := range []struct {
+		desc string
+		m    MatchIPSAN
+		ips  []net.IP
+		want bool
+	}{
+		{desc: "v4-in-range", m: MatchIPSAN{Network: network("192.0.2.0/24")}, ips: ips("192.0.2.17"), want: true},
+		{desc: "v4-out-of-range", m: MatchIPSAN{Network: network("192.0.2.0/24")}, ips: ips("192.0.3.17"), want: false},
+		{desc: "v4-one-of-several", m: MatchIPSAN{Network: network("192.0.2.0/24")}, ips: ips("10.0.0.1", "192.0.2.255"), want: true},
+		{desc: "v4-4-byte-form", m: MatchIPSAN{Network: network("192.0.2.0/24")}, ips: []net.IP{net.IPv4(192, 0, 2, 1).To4()}, want: true},
+		{desc: "v6-in-range", m: MatchIPSAN{Network: network("2001:db8::/32")}, ips: ips("2001:db8:1::1"), want: true},
+		{desc: "v6-out-of-range", m: MatchIPSAN{Network: network("2001:db8::/32")}, ips: ips("2001:db9::1"), want: false},
+		{desc: "v4-network-v6-ip", m: MatchIPSAN{Network: network("192.0.2.0/24")}, ips: ips("2001:db8::1"), want: false},
+		{desc: "no-ip-sans", m: MatchIPSAN{Network: network("0.0.0.0/0")}, want: false},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			cert := &x509.Certificate{DNSNames: []string{"www.example.com"}, IPAddresses: test.ips}
+			if got := test.m.CertificateMatches(cert); got != test.want {
+				t.Errorf("CertificateMatches()=%v, want %v", got, test.want)
+			}
+			precert := &ct.Precertificate{TBSCertificate: cert}
+			if got := test.m.PrecertificateMatches(precert); got != test.want {
+				t.Errorf("PrecertificateMatches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// countingMatcher wraps a Matcher and counts how often it is consulted.
+type countingMatcher struct {
+	Matcher
+	calls *int
+}
+
+func (m countingMatcher) CertificateMatches(c *x509.Certificate) bool {
+	*m.calls++
+	return m.Matcher.CertificateMatches(c)
+}
+
+func (m countingMatcher) PrecertificateMatches(p *ct.Precertificate) bool {
+	*m.calls++
+	return m.Matcher.PrecertificateMatches(p)
+}
+
+func TestScannerMatchComposite(t *testing.T) {
+	subject := regexp.MustCompile(`.*\.example\.com$`)
+	issuer := regexp.MustCompile(`^Test CA$`)
+	bySubject := MatchSubjectRegex{CertificateSubjectRegex: subject, PrecertificateSubjectRegex: subject}
+	byIssuer := MatchIssuerRegex{CertificateIssuerRegex: issuer, PrecertificateIssuerRegex: issuer}
+	bySerial := MatchSerialNumber{SerialNumber: *big.NewInt(42)}
+
+	newCert := func(cn, issuerCN string, serial int64) *x509.Certificate {
+		cert := &x509.Certificate{SerialNumber: big.NewInt(serial)}
+		cert.Subject.CommonName = cn
+		cert.Issuer.CommonName = issuerCN
+		return cert
+	}
+
+	for _, test := range []struct {
+		desc string
+		m    Matcher
+		cert *x509.Certificate
+		want bool
+	}{
+		{desc: "and-all", m: MatchAnd{Matchers: []Matcher{bySubject, byIssuer, bySerial}}, cert: newCert("www.example.com", "Test CA", 42), want: true},
+		{desc: "and-one-miss", m: MatchAnd{Matchers: []Matcher{bySubject, byIssuer, bySerial}}, cert: newCert("www.example.com", "Test CA", 43), want: false},
+		{desc: "and-empty", m: MatchAnd{}, cert: newCert("www.google.com", "Other CA", 1), want: true},
+		{desc: "or-one", m: MatchOr{Matchers: []Matcher{bySubject, bySerial}}, cert: newCert("www.google.com", "Test CA", 42), want: true},
+		{desc: "or-none", m: MatchOr{Matchers: []Matcher{bySubject, bySerial}}, cert: newCert("www.google.com", "Test CA", 43), want: false},
+		{desc: "or-empty", m: MatchOr{}, cert: newCert("www.example.com", "Test CA", 42), want: false},
+		{desc: "not", m: MatchNot{Matcher: byIssuer}, cert: newCert("www.example.com", "Other CA", 42), want: true},
+		{desc: "not-match", m: MatchNot{Matcher: byIssuer}, cert: newCert("www.example.com", "Test CA", 42), want: false},
+		{
+			desc: "subject-and-not-issuer",
+			m:    MatchAnd{Matchers: []Matcher{bySubject, MatchNot{Matcher: byIssuer}}},
+			cert: newCert("mail.example.com", "Other CA", 1),
+			want: true,
+		},
+		{
+			desc: "nested",
+			m:    MatchOr{Matchers: []Matcher{bySerial, MatchAnd{Matchers: []Matcher{bySubject, byIssuer}}}},
+			cert: newCert("mail.example.com", "Test CA", 1),
+			want: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := test.m.CertificateMatches(test.cert); got != test.want {
+				t.Errorf("CertificateMatches()=%v, want %v", got, test.want)
+			}
+			precert := &ct.Precertificate{TBSCertificate: test.cert}
+			if got := test.m.PrecertificateMatches(precert); got != test.want {
+				t.Errorf("PrecertificateMatches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScannerMatchCompositeShortCircuit(t *testing.T) {
+	cert := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	precert := &ct.Precertificate{TBSCertificate: cert}
+
+	var calls int
+	never := countingMatcher{Matcher: MatchNone{}, calls: &calls}
+	always := countingMatcher{Matcher: MatchAll{}, calls: &calls}
+
+	and := MatchAnd{Matchers: []Matcher{never, always, always}}
+	if and.CertificateMatches(cert) || and.PrecertificateMatches(precert) {
+		t.Error("MatchAnd matched, want no match")
+	}
+	if want := 2; calls != want {
+		t.Errorf("MatchAnd consulted %d matchers, want %d", calls, want)
+	}
+
+	calls = 0
+	or := MatchOr{Matchers: []Matcher{always, never, never}}
+	if !or.CertificateMatches(cert) || !or.PrecertificateMatches(precert) {
+		t.Error("MatchOr did not match, want match")
+	}
+	if want := 2; calls != want {
+		t.Errorf("MatchOr consulted %d matchers, want %d", calls, want)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestMatchRevoked(t *testing.T) {
+	const crlURL = "http://crl.example.com/ca.crl"
+	notBefore := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(CA): %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("ParseCertificate(CA): %v", err)
+	}
+	crl, err := ca.CreateCRL(rand.Reader, key, []pkix.RevokedCertificate{
+		{SerialNumber: big.NewInt(42), RevocationTime: notBefore},
+	}, notBefore, notAfter)
+	if err != nil {
+		t.Fatalf("CreateCRL(): %v", err)
+	}
+
+	newLeaf := func(serial int64, crlDPs []string) *ct.LeafEntry {
+		t.Helper()
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: "www.example.com"},
+			NotBefore:             notBefore,
+			NotAfter:              notAfter,
+			CRLDistributionPoints: crlDPs,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.Public(), key)
+		if err != nil {
+			t.Fatalf("CreateCertificate(): %v", err)
+		}
+		leaf := ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: der}, 1000)
+		leafInput, err := tls.Marshal(*leaf)
+		if err != nil {
+			t.Fatalf("tls.Marshal(): %v", err)
+		}
+		extraData, err := tls.Marshal(ct.CertificateChain{Entries: []ct.ASN1Cert{{Data: caDER}}})
+		if err != nil {
+			t.Fatalf("tls.Marshal(chain): %v", err)
+		}
+		return &ct.LeafEntry{LeafInput: leafInput, ExtraData: extraData}
+	}
+
+	var fetches int
+	m := &MatchRevoked{Client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		fetches++
+		if req.URL.String() != crlURL {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(bytes.NewReader(crl))}, nil
+	})}}
+
+	for _, test := range []struct {
+		desc string
+		leaf *ct.LeafEntry
+		want bool
+	}{
+		{desc: "revoked", leaf: newLeaf(42, []string{crlURL}), want: true},
+		{desc: "not-revoked", leaf: newLeaf(43, []string{crlURL}), want: false},
+		{desc: "revoked-second-dp", leaf: newLeaf(42, []string{"http://crl.example.com/missing.crl", crlURL}), want: true},
+		{desc: "no-crl-dp", leaf: newLeaf(42, nil), want: false},
+		{desc: "unparseable", leaf: &ct.LeafEntry{LeafInput: []byte{0x01}}, want: false},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := m.Matches(test.leaf); got != test.want {
+				t.Errorf("Matches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+	// Each CRL should only have been fetched once.
+	if want := 2; fetches != want {
+		t.Errorf("fetched CRLs %d times, want %d", fetches, want)
+	}
+}
+
+func TestMatchRevokedFetchDoesNotBlockOtherURLs(t *testing.T) {
+	const slowURL, fastURL = "http://slow.example.com/ca.crl", "http://fast.example.com/ca.crl"
+	release := make(chan struct{})
+	m := &MatchRevoked{Client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() == slowURL {
+			<-release
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	})}}
+
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		m.revokedSerials(slowURL)
+	}()
+	// Wait for the slow fetch to be registered as in flight.
+	for {
+		m.mu.Lock()
+		_, ok := m.crls[slowURL]
+		m.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	fastDone := make(chan struct{})
+	go func() {
+		defer close(fastDone)
+		m.revokedSerials(fastURL)
+	}()
+	select {
+	case <-fastDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetch of one CRL blocked on an unrelated in-flight fetch")
+	}
+	close(release)
+	<-slowDone
+}
+
+func TestMatchEmbeddedSCTLogID(t *testing.T) {
+	logA := sha256.Sum256([]byte("log A"))
+	logB := sha256.Sum256([]byte("log B"))
+	logC := sha256.Sum256([]byte("log C"))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	newLeaf := func(logIDs ...[sha256.Size]byte) *ct.LeafEntry {
+		t.Helper()
+		var scts []*ct.SignedCertificateTimestamp
+		for _, id := range logIDs {
+			scts = append(scts, &ct.SignedCertificateTimestamp{
+				SCTVersion: ct.V1,
+				LogID:      ct.LogID{KeyID: id},
+				Timestamp:  1000,
+				Signature: ct.DigitallySigned{
+					Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA},
+					Signature: []byte{0x01},
+				},
+			})
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "www.example.com"},
+			NotBefore:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		if len(scts) > 0 {
+			sctList, err := x509util.MarshalSCTsIntoSCTList(scts)
+			if err != nil {
+				t.Fatalf("MarshalSCTsIntoSCTList(): %v", err)
+			}
+			tmpl.SCTList = *sctList
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		if err != nil {
+			t.Fatalf("CreateCertificate(): %v", err)
+		}
+		leafInput, err := tls.Marshal(*ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: der}, 1000))
+		if err != nil {
+			t.Fatalf("tls.Marshal(): %v", err)
+		}
+		// The certificate is self-signed, so its chain is empty.
+		extraData, err := tls.Marshal(ct.CertificateChain{})
+		if err != nil {
+			t.Fatalf("tls.Marshal(chain): %v", err)
+		}
+		return &ct.LeafEntry{LeafInput: leafInput, ExtraData: extraData}
+	}
+
+	crossLogged := newLeaf(logA, logB)
+	for _, test := range []struct {
+		desc string
+		m    MatchEmbeddedSCTLogID
+		leaf *ct.LeafEntry
+		want bool
+	}{
+		{desc: "first-sct", m: MatchEmbeddedSCTLogID{LogID: logA}, leaf: crossLogged, want: true},
+		{desc: "second-sct", m: MatchEmbeddedSCTLogID{LogID: logB}, leaf: crossLogged, want: true},
+		{desc: "other-log", m: MatchEmbeddedSCTLogID{LogID: logC}, leaf: crossLogged, want: false},
+		{desc: "no-scts", m: MatchEmbeddedSCTLogID{LogID: logA}, leaf: newLeaf(), want: false},
+		{desc: "unparseable", m: MatchEmbeddedSCTLogID{LogID: logA}, leaf: &ct.LeafEntry{LeafInput: []byte{0x01}}, want: false},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := test.m.Matches(test.leaf); got != test.want {
+				t.Errorf("Matches()=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
 func TestScannerEndToEnd(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -189,7 +722,7 @@ func TestScannerEndToEnd(t *testing.T) {
 			ParallelFetch: 1,
 			StartIndex:    0,
 		},
-		Matcher:    &MatchSubjectRegex{regexp.MustCompile(`.*\.google\.com`), nil},
+		Matcher:    &MatchSubjectRegex{CertificateSubjectRegex: regexp.MustCompile(`.*\.google\.com`)},
 		NumWorkers: 1,
 	}
 	scanner := NewScanner(logClient, opts)
@@ -234,6 +767,185 @@ func TestScannerEndToEnd(t *testing.T) {
 	}
 }
 
+func TestScannerCensus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ct/v1/get-sth":
+			if _, err := w.Write([]byte(FourEntrySTH)); err != nil {
+				t.Error("Failed to write get-sth response")
+			}
+		case "/ct/v1/get-entries":
+			if _, err := w.Write([]byte(FourEntries)); err != nil {
+				t.Error("Failed to write get-entries response")
+			}
+		default:
+			t.Error("Unexpected request")
+		}
+	}))
+	defer ts.Close()
+
+	logClient, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	opts := ScannerOptions{
+		FetcherOptions: FetcherOptions{BatchSize: 10, ParallelFetch: 1},
+		Matcher:        countingMatcher{Matcher: MatchAll{}, calls: &calls},
+		NumWorkers:     1,
+	}
+	got, err := NewScanner(logClient, opts).Census(context.Background())
+	if err != nil {
+		t.Fatalf("Census(): %v", err)
+	}
+	if want := (EntryCensus{Entries: 4, Certs: 4}); got != want {
+		t.Errorf("Census()=%+v, want %+v", got, want)
+	}
+	if calls != 0 {
+		t.Errorf("Census() invoked the matcher %d times, want 0", calls)
+	}
+}
+
+func TestScannerCensusUnparsable(t *testing.T) {
+	client := &fakeLogClient{treeSize: 5, maxEntries: 5}
+	opts := ScannerOptions{
+		FetcherOptions: FetcherOptions{BatchSize: 10, ParallelFetch: 1},
+		NumWorkers:     2,
+	}
+	got, err := NewScanner(client, opts).Census(context.Background())
+	if err != nil {
+		t.Fatalf("Census(): %v", err)
+	}
+	if want := (EntryCensus{Entries: 5, Unparsable: 5}); got != want {
+		t.Errorf("Census()=%+v, want %+v", got, want)
+	}
+}
+
+func TestScannerMaxMatches(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ct/v1/get-sth":
+			if _, err := w.Write([]byte(FourEntrySTH)); err != nil {
+				t.Error("Failed to write get-sth response")
+			}
+		case "/ct/v1/get-entries":
+			if _, err := w.Write([]byte(FourEntries)); err != nil {
+				t.Error("Failed to write get-entries response")
+			}
+		default:
+			t.Error("Unexpected request")
+		}
+	}))
+	defer ts.Close()
+
+	logClient, err := client.New(ts.URL, &http.Client{}, jsonclient.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, maxMatches := range []int{0, 1, 2, 4, 10} {
+		t.Run(fmt.Sprintf("max-%d", maxMatches), func(t *testing.T) {
+			opts := ScannerOptions{
+				FetcherOptions: FetcherOptions{BatchSize: 10, ParallelFetch: 1},
+				Matcher:        MatchAll{},
+				NumWorkers:     2,
+				MaxMatches:     maxMatches,
+			}
+			var mu sync.Mutex
+			var matched []int64
+			found := func(re *ct.RawLogEntry) {
+				mu.Lock()
+				defer mu.Unlock()
+				matched = append(matched, re.Index)
+			}
+			if err := NewScanner(logClient, opts).Scan(context.Background(), found, found); err != nil {
+				t.Fatalf("Scan(): %v", err)
+			}
+			want := 4
+			if maxMatches > 0 && maxMatches < want {
+				want = maxMatches
+			}
+			if got := len(matched); got != want {
+				t.Errorf("Scan() delivered %d matches %v, want %d", got, matched, want)
+			}
+		})
+	}
+}
+
+// precertLeafEntry builds a log entry for the test precertificate.
+func precertLeafEntry(t *testing.T) ct.LeafEntry {
+	t.Helper()
+	precert, err := x509util.CertificateFromPEM([]byte(testdata.TestPreCertPEM))
+	if x509.IsFatal(err) {
+		t.Fatalf("Failed to parse precert: %v", err)
+	}
+	issuer, err := x509util.CertificateFromPEM([]byte(testdata.CACertPEM))
+	if x509.IsFatal(err) {
+		t.Fatalf("Failed to parse issuer: %v", err)
+	}
+	leaf, err := ct.MerkleTreeLeafFromChain([]*x509.Certificate{precert, issuer}, ct.PrecertLogEntryType, 12345)
+	if err != nil {
+		t.Fatalf("MerkleTreeLeafFromChain(): %v", err)
+	}
+	leafInput, err := tls.Marshal(*leaf)
+	if err != nil {
+		t.Fatalf("Failed to marshal leaf: %v", err)
+	}
+	extraData, err := tls.Marshal(ct.PrecertChainEntry{
+		PreCertificate:   ct.ASN1Cert{Data: precert.Raw},
+		CertificateChain: []ct.ASN1Cert{{Data: issuer.Raw}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal extra data: %v", err)
+	}
+	return ct.LeafEntry{LeafInput: leafInput, ExtraData: extraData}
+}
+
+func TestScannerEntryTypeFilter(t *testing.T) {
+	var resp ct.GetEntriesResponse
+	if err := json.Unmarshal([]byte(FourEntries), &resp); err != nil {
+		t.Fatalf("Failed to parse entries: %v", err)
+	}
+	logClient := &repeatingLogClient{treeSize: 2, entries: []ct.LeafEntry{resp.Entries[0], precertLeafEntry(t)}}
+
+	for _, tc := range []struct {
+		desc         string
+		precertOnly  bool
+		certOnly     bool
+		wantCalls    int
+		wantCerts    int
+		wantPrecerts int
+		wantErr      bool
+	}{
+		{desc: "all", wantCalls: 2, wantCerts: 1, wantPrecerts: 1},
+		{desc: "precert-only", precertOnly: true, wantCalls: 1, wantPrecerts: 1},
+		{desc: "cert-only", certOnly: true, wantCalls: 1, wantCerts: 1},
+		{desc: "both", precertOnly: true, certOnly: true, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var calls, certs, precerts int
+			opts := ScannerOptions{
+				FetcherOptions: FetcherOptions{BatchSize: 10, ParallelFetch: 1},
+				Matcher:        countingMatcher{Matcher: MatchAll{}, calls: &calls},
+				PrecertOnly:    tc.precertOnly,
+				CertOnly:       tc.certOnly,
+				NumWorkers:     1,
+			}
+			err := NewScanner(logClient, opts).Scan(context.Background(),
+				func(*ct.RawLogEntry) { certs++ },
+				func(*ct.RawLogEntry) { precerts++ })
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Scan()=%v, want error: %v", err, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("Scan() invoked the matcher %d times, want %d", calls, tc.wantCalls)
+			}
+			if certs != tc.wantCerts || precerts != tc.wantPrecerts {
+				t.Errorf("Scan() matched %d certs and %d precerts, want %d and %d", certs, precerts, tc.wantCerts, tc.wantPrecerts)
+			}
+		})
+	}
+}
+
 func TestDefaultScannerOptions(t *testing.T) {
 	opts := DefaultScannerOptions()
 	switch opts.Matcher.(type) {
@@ -258,3 +970,120 @@ func TestDefaultScannerOptions(t *testing.T) {
 		t.Fatalf("Expected StartIndex to be 0, but was %d", opts.StartIndex)
 	}
 }
+
+func TestNewNDJSONWriter(t *testing.T) {
+	certs := make([]*x509.Certificate, 0, 3)
+	for _, pemData := range []string{testdata.TestCertPEM, testdata.TestPreCertPEM, testdata.CACertPEM} {
+		cert, err := x509util.CertificateFromPEM([]byte(pemData))
+		if x509.IsFatal(err) {
+			t.Fatalf("CertificateFromPEM(): %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	cert, precert, issuer := certs[0], certs[1], certs[2]
+
+	precertLeaf, err := ct.MerkleTreeLeafFromChain([]*x509.Certificate{precert, issuer}, ct.PrecertLogEntryType, 1000)
+	if err != nil {
+		t.Fatalf("MerkleTreeLeafFromChain(): %v", err)
+	}
+	entries := []*ct.RawLogEntry{
+		{Index: 3, Leaf: *ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: cert.Raw}, 1000), Cert: ct.ASN1Cert{Data: cert.Raw}},
+		{Index: 7, Leaf: *precertLeaf, Cert: ct.ASN1Cert{Data: precert.Raw}},
+		{Index: 9, Leaf: *ct.CreateX509MerkleTreeLeaf(ct.ASN1Cert{Data: []byte("garbage")}, 1000)},
+	}
+	wants := []EntryRecord{
+		{Index: 3, LeafType: "x509_entry", Subject: cert.Subject.String(), SANs: cert.DNSNames, Serial: cert.SerialNumber.Text(16)},
+		{Index: 7, LeafType: "precert_entry", Subject: precert.Subject.String(), SANs: precert.DNSNames, Serial: precert.SerialNumber.Text(16)},
+		{Index: 9, LeafType: "x509_entry"},
+	}
+
+	var buf bytes.Buffer
+	write := NewNDJSONWriter(&buf)
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		go func(entry *ct.RawLogEntry) {
+			defer wg.Done()
+			write(entry)
+		}(entry)
+	}
+	wg.Wait()
+
+	got := make(map[int64]EntryRecord)
+	dec := json.NewDecoder(&buf)
+	for {
+		var rec EntryRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Decode(): %v", err)
+		}
+		got[rec.Index] = rec
+	}
+	if len(got) != len(wants) {
+		t.Fatalf("got %d records, want %d", len(got), len(wants))
+	}
+	for _, want := range wants {
+		rec := got[want.Index]
+		if want.Index == 9 {
+			if rec.LeafType != want.LeafType || rec.Error == "" || rec.Subject != "" {
+				t.Errorf("record %d = %+v, want leaf type %q and a parse error only", want.Index, rec, want.LeafType)
+			}
+			continue
+		}
+		if rec.LeafType != want.LeafType || rec.Subject != want.Subject || rec.Serial != want.Serial || rec.Error != "" ||
+			strings.Join(rec.SANs, ",") != strings.Join(want.SANs, ",") {
+			t.Errorf("record %d = %+v, want %+v", want.Index, rec, want)
+		}
+	}
+}
+
+// repeatingLogClient is a LogClient whose entries cycle through a fixed set.
+type repeatingLogClient struct {
+	treeSize uint64
+	entries  []ct.LeafEntry
+}
+
+func (c *repeatingLogClient) BaseURI() string {
+	return "repeating"
+}
+
+func (c *repeatingLogClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	return &ct.SignedTreeHead{TreeSize: c.treeSize}, nil
+}
+
+func (c *repeatingLogClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	if start < 0 || start > end || end >= int64(c.treeSize) {
+		return nil, fmt.Errorf("bad range [%d, %d]", start, end)
+	}
+	var resp ct.GetEntriesResponse
+	for i := start; i <= end; i++ {
+		resp.Entries = append(resp.Entries, c.entries[i%int64(len(c.entries))])
+	}
+	return &resp, nil
+}
+
+func BenchmarkScannerNumWorkers(b *testing.B) {
+	var resp ct.GetEntriesResponse
+	if err := json.Unmarshal([]byte(FourEntries), &resp); err != nil {
+		b.Fatalf("Failed to parse entries: %v", err)
+	}
+	logClient := &repeatingLogClient{treeSize: 4096, entries: resp.Entries}
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			opts := ScannerOptions{
+				FetcherOptions: FetcherOptions{BatchSize: 256, ParallelFetch: 2},
+				Matcher:        MatchAll{},
+				NumWorkers:     workers,
+				BufferSize:     256,
+			}
+			found := func(*ct.RawLogEntry) {}
+			for i := 0; i < b.N; i++ {
+				if err := NewScanner(logClient, opts).Scan(context.Background(), found, found); err != nil {
+					b.Fatalf("Scan(): %v", err)
+				}
+			}
+			b.ReportMetric(float64(b.N)*float64(logClient.treeSize)/b.Elapsed().Seconds(), "entries/s")
+		})
+	}
+}
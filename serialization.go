@@ -15,11 +15,15 @@
 package ct
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"iter"
 	"time"
 
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 )
@@ -53,6 +57,26 @@ func SerializeSCTSignatureInput(sct SignedCertificateTimestamp, entry LogEntry)
 	}
 }
 
+// SerializePrecertSCTSignatureInput serializes the passed in sct into the
+// correct format for signing, given the SHA-256 hash of the issuer's public
+// key and the DER-encoded TBSCertificate of the precertificate, with the
+// poison extension removed. This is the same as SerializeSCTSignatureInput for
+// a precert LogEntry, without the need to construct one.
+func SerializePrecertSCTSignatureInput(sct SignedCertificateTimestamp, issuerKeyHash [sha256.Size]byte, tbs []byte) ([]byte, error) {
+	entry := LogEntry{
+		Leaf: MerkleTreeLeaf{
+			TimestampedEntry: &TimestampedEntry{
+				EntryType: PrecertLogEntryType,
+				PrecertEntry: &PreCert{
+					IssuerKeyHash:  issuerKeyHash,
+					TBSCertificate: tbs,
+				},
+			},
+		},
+	}
+	return SerializeSCTSignatureInput(sct, entry)
+}
+
 // SerializeSTHSignatureInput serializes the passed in STH into the correct
 // format for signing.
 func SerializeSTHSignatureInput(sth SignedTreeHead) ([]byte, error) {
@@ -181,6 +205,17 @@ func MerkleTreeLeafForEmbeddedSCT(chain []*x509.Certificate, timestamp uint64) (
 		return nil, fmt.Errorf("failed to remove SCT List extension: %v", err)
 	}
 
+	return MerkleTreeLeafFromPrecertTBS(tbs, sha256.Sum256(issuer.RawSubjectPublicKeyInfo), timestamp, nil)
+}
+
+// MerkleTreeLeafFromPrecertTBS generates a precert MerkleTreeLeaf directly
+// from the DER-encoded TBSCertificate, with the poison extension already
+// removed, and the hash of the final issuer's SubjectPublicKeyInfo, along with
+// the timestamp and extensions of the SCT.
+func MerkleTreeLeafFromPrecertTBS(tbs []byte, issuerKeyHash [sha256.Size]byte, timestamp uint64, exts CTExtensions) (*MerkleTreeLeaf, error) {
+	if len(tbs) == 0 {
+		return nil, fmt.Errorf("empty TBSCertificate for precert leaf building")
+	}
 	return &MerkleTreeLeaf{
 		Version:  V1,
 		LeafType: TimestampedEntryLeafType,
@@ -188,9 +223,10 @@ func MerkleTreeLeafForEmbeddedSCT(chain []*x509.Certificate, timestamp uint64) (
 			EntryType: PrecertLogEntryType,
 			Timestamp: timestamp,
 			PrecertEntry: &PreCert{
-				IssuerKeyHash:  sha256.Sum256(issuer.RawSubjectPublicKeyInfo),
+				IssuerKeyHash:  issuerKeyHash,
 				TBSCertificate: tbs,
 			},
+			Extensions: exts,
 		},
 	}, nil
 }
@@ -201,10 +237,44 @@ func LeafHashForLeaf(leaf *MerkleTreeLeaf) ([sha256.Size]byte, error) {
 	if err != nil {
 		return [sha256.Size]byte{}, fmt.Errorf("failed to tls-encode MerkleTreeLeaf: %s", err)
 	}
+	return LeafHashForLeafData(leafData), nil
+}
 
+// LeafHashForLeafData returns the leaf hash for an already TLS-encoded Merkle
+// tree leaf, such as the leaf_input of a get-entries response.
+func LeafHashForLeafData(leafData []byte) [sha256.Size]byte {
 	data := append([]byte{TreeLeafPrefix}, leafData...)
-	leafHash := sha256.Sum256(data)
-	return leafHash, nil
+	return sha256.Sum256(data)
+}
+
+// LeafHashForEntry returns the leaf hash for the Merkle tree leaf of a log
+// entry.
+func LeafHashForEntry(entry *LogEntry) ([sha256.Size]byte, error) {
+	return LeafHashForLeaf(&entry.Leaf)
+}
+
+// SerializedLeaf returns the TLS encoding of the entry's MerkleTreeLeaf,
+// which is the leaf_input of the entry in a get-entries response. The leaf
+// hash of the entry is the SHA-256 hash of these bytes preceded by
+// TreeLeafPrefix (see LeafHashForLeafData).
+//
+// These are not the bytes covered by an SCT signature for the entry; see
+// SignedEntry for those.
+func (e *LogEntry) SerializedLeaf() ([]byte, error) {
+	leafData, err := tls.Marshal(e.Leaf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to tls-encode MerkleTreeLeaf: %s", err)
+	}
+	return leafData, nil
+}
+
+// SignedEntry returns the bytes covered by the signature of sct, if sct was
+// issued for the entry. For a v1 SCT this is the TLS encoding of the RFC 6962
+// digitally-signed struct (s3.2), made up of the SCT's version, timestamp and
+// extensions and the entry's type and certificate or precertificate entry. It
+// is the same as SerializeSCTSignatureInput(sct, *e).
+func (e *LogEntry) SignedEntry(sct SignedCertificateTimestamp) ([]byte, error) {
+	return SerializeSCTSignatureInput(sct, *e)
 }
 
 // IsPreIssuer indicates whether a certificate is a pre-cert issuer with the specific
@@ -218,6 +288,29 @@ func IsPreIssuer(issuer *x509.Certificate) bool {
 	return false
 }
 
+// ValidatePrecertPoison checks that the given submitted precertificate parses,
+// and carries the CT poison extension, which must be critical and hold an
+// ASN.1 NULL (RFC 6962 s3.1).
+func ValidatePrecertPoison(submitted ASN1Cert) error {
+	cert, err := x509.ParseCertificate(submitted.Data)
+	if x509.IsFatal(err) {
+		return fmt.Errorf("failed to parse precertificate: %v", err)
+	}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(x509.OIDExtensionCTPoison) {
+			continue
+		}
+		if !ext.Critical {
+			return errors.New("CT poison extension is not critical")
+		}
+		if !bytes.Equal(ext.Value, asn1.NullBytes) {
+			return fmt.Errorf("CT poison extension has value %x, want ASN.1 NULL", ext.Value)
+		}
+		return nil
+	}
+	return errors.New("precertificate has no CT poison extension")
+}
+
 // RawLogEntryFromLeaf converts a LeafEntry object (which has the raw leaf data
 // after JSON parsing) into a RawLogEntry object (i.e. a TLS-parsed structure).
 func RawLogEntryFromLeaf(index int64, entry *LeafEntry) (*RawLogEntry, error) {
@@ -308,6 +401,62 @@ func LogEntryFromLeaf(index int64, leaf *LeafEntry) (*LogEntry, error) {
 	return rle.ToLogEntry()
 }
 
+// LogEntryIterator parses a batch of log entries into LogEntry objects,
+// skipping the entries that cannot be parsed and collecting their errors.
+type LogEntryIterator struct {
+	raw    []RawLogEntry
+	start  int64
+	leaves []LeafEntry
+	errs   map[int64]error
+}
+
+// NewLogEntryIterator returns an iterator over the given raw log entries.
+func NewLogEntryIterator(raw []RawLogEntry) *LogEntryIterator {
+	return &LogEntryIterator{raw: raw, errs: make(map[int64]error)}
+}
+
+// NewLeafEntryIterator returns an iterator over the given leaf entries, such
+// as those in a get-entries response, which start at index start in the log.
+func NewLeafEntryIterator(start int64, leaves []LeafEntry) *LogEntryIterator {
+	return &LogEntryIterator{start: start, leaves: leaves, errs: make(map[int64]error)}
+}
+
+// All returns an iterator over the index and parsed LogEntry of each entry in
+// the batch. Entries that cannot be parsed are skipped, and their errors are
+// available from Errors afterwards. An entry with only non-fatal parsing
+// errors is still yielded, and its errors are recorded too.
+func (it *LogEntryIterator) All() iter.Seq2[int64, *LogEntry] {
+	return func(yield func(int64, *LogEntry) bool) {
+		for i := 0; i < len(it.raw)+len(it.leaves); i++ {
+			index, entry, err := it.parse(i)
+			if err != nil {
+				it.errs[index] = err
+			}
+			if entry == nil {
+				continue
+			}
+			if !yield(index, entry) {
+				return
+			}
+		}
+	}
+}
+
+// Errors returns the parsing errors met so far by All, keyed by entry index.
+func (it *LogEntryIterator) Errors() map[int64]error {
+	return it.errs
+}
+
+func (it *LogEntryIterator) parse(i int) (int64, *LogEntry, error) {
+	if it.leaves == nil {
+		entry, err := it.raw[i].ToLogEntry()
+		return it.raw[i].Index, entry, err
+	}
+	index := it.start + int64(i)
+	entry, err := LogEntryFromLeaf(index, &it.leaves[i])
+	return index, entry, err
+}
+
 // TimestampToTime converts a timestamp in the style of RFC 6962 (milliseconds
 // since UNIX epoch) to a Go Time.
 func TimestampToTime(ts uint64) time.Time {
@@ -315,3 +464,114 @@ func TimestampToTime(ts uint64) time.Time {
 	msecs := int64(ts % 1000)
 	return time.Unix(secs, msecs*1000000)
 }
+
+// MarshalSCT serializes the given SCT into its binary form, as held in a
+// SignedCertificateTimestampList (RFC 6962 s3.3).
+func MarshalSCT(sct SignedCertificateTimestamp) ([]byte, error) {
+	return tls.Marshal(sct)
+}
+
+// UnmarshalSCT parses an SCT from its binary form, as held in a
+// SignedCertificateTimestampList (RFC 6962 s3.3). Trailing data is rejected.
+func UnmarshalSCT(data []byte) (*SignedCertificateTimestamp, error) {
+	var sct SignedCertificateTimestamp
+	if rest, err := tls.Unmarshal(data, &sct); err != nil {
+		return nil, fmt.Errorf("error parsing SCT: %s", err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("extra data (%d bytes) after serialized SCT", len(rest))
+	}
+	return &sct, nil
+}
+
+// BuildTLSExtensionSCTList serializes the given SCTs into a
+// SignedCertificateTimestampList (RFC 6962 s3.3), as carried directly in the
+// extension_data of a TLS signed_certificate_timestamp extension.
+func BuildTLSExtensionSCTList(scts []SignedCertificateTimestamp) ([]byte, error) {
+	if len(scts) == 0 {
+		return nil, errors.New("no SCTs to serialize")
+	}
+	var sctList x509.SignedCertificateTimestampList
+	for i, sct := range scts {
+		encd, err := tls.Marshal(sct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize SCT number %d: %v", i, err)
+		}
+		sctList.SCTList = append(sctList.SCTList, x509.SerializedSCT{Val: encd})
+	}
+	return tls.Marshal(sctList)
+}
+
+// ParseTLSExtensionSCTList parses the SCTs from a SignedCertificateTimestampList
+// (RFC 6962 s3.3), as carried directly in the extension_data of a TLS
+// signed_certificate_timestamp extension.
+func ParseTLSExtensionSCTList(data []byte) ([]SignedCertificateTimestamp, error) {
+	var sctList x509.SignedCertificateTimestampList
+	if rest, err := tls.Unmarshal(data, &sctList); err != nil {
+		return nil, fmt.Errorf("failed to parse SCT list: %v", err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("extra data (%d bytes) after SCT list", len(rest))
+	}
+	scts := make([]SignedCertificateTimestamp, 0, len(sctList.SCTList))
+	for i, serialized := range sctList.SCTList {
+		var sct SignedCertificateTimestamp
+		if rest, err := tls.Unmarshal(serialized.Val, &sct); err != nil {
+			return nil, fmt.Errorf("failed to parse SCT number %d: %v", i, err)
+		} else if len(rest) > 0 {
+			return nil, fmt.Errorf("extra data (%d bytes) after SCT number %d", len(rest), i)
+		}
+		scts = append(scts, sct)
+	}
+	return scts, nil
+}
+
+// ParseCTExtensions decodes the raw bytes of an SCT's extensions into the
+// structured format of RFC 6962-bis section 4.5. Empty extensions give no
+// entries. Callers that don't need the structure can keep treating
+// CTExtensions as opaque bytes.
+func ParseCTExtensions(exts CTExtensions) ([]CTExtension, error) {
+	var ret []CTExtension
+	rest := []byte(exts)
+	for len(rest) > 0 {
+		var ext CTExtension
+		var err error
+		if rest, err = tls.Unmarshal(rest, &ext); err != nil {
+			return nil, fmt.Errorf("failed to parse extension number %d: %v", len(ret), err)
+		}
+		ret = append(ret, ext)
+	}
+	return ret, nil
+}
+
+// BuildCTExtensions serializes the given extensions into the raw bytes of an
+// SCT's extensions, in the order given.
+func BuildCTExtensions(exts []CTExtension) (CTExtensions, error) {
+	var ret CTExtensions
+	for i, ext := range exts {
+		data, err := tls.Marshal(ext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize extension number %d: %v", i, err)
+		}
+		ret = append(ret, data...)
+	}
+	if len(ret) > 65535 {
+		return nil, fmt.Errorf("serialized extensions too long (%d bytes)", len(ret))
+	}
+	return ret, nil
+}
+
+// ValidateCTExtensions checks that the raw bytes of an SCT's extensions are
+// well-formed per RFC 6962-bis section 4.5: that they parse, and that the
+// extensions are ordered by strictly increasing ExtensionType, which also
+// rules out duplicates.
+func ValidateCTExtensions(exts CTExtensions) error {
+	parsed, err := ParseCTExtensions(exts)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(parsed); i++ {
+		if prev, cur := parsed[i-1].ExtensionType, parsed[i].ExtensionType; cur <= prev {
+			return fmt.Errorf("extension number %d has type %d, not greater than previous type %d", i, cur, prev)
+		}
+	}
+	return nil
+}
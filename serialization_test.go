@@ -16,14 +16,23 @@ package ct
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/pem"
+	"math/big"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
+	"github.com/OlegBabkin/certificate-transparency-go/testdata"
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509/pkix"
 )
 
 func dh(h string) []byte {
@@ -238,6 +247,22 @@ func TestSerializeV1SCTSignatureInputForPrecertKAT(t *testing.T) {
 	}
 }
 
+func TestSerializePrecertSCTSignatureInputKAT(t *testing.T) {
+	serialized, err := SerializePrecertSCTSignatureInput(defaultSCT(), defaultPrecertIssuerHash(), defaultPrecertTBS())
+	if err != nil {
+		t.Fatalf("Failed to serialize SCT for signing: %v", err)
+	}
+	if !bytes.Equal(serialized, defaultPrecertSCTSignatureInput(t)) {
+		t.Fatalf("Serialized precertificate signature input doesn't match expected answer:\n%v\n%v", serialized, defaultPrecertSCTSignatureInput(t))
+	}
+
+	sct := defaultSCT()
+	sct.SCTVersion = 42
+	if _, err := SerializePrecertSCTSignatureInput(sct, defaultPrecertIssuerHash(), defaultPrecertTBS()); err == nil {
+		t.Error("SerializePrecertSCTSignatureInput() succeeded for unknown SCT version, want error")
+	}
+}
+
 func TestSerializeV1STHSignatureKAT(t *testing.T) {
 	b, err := SerializeSTHSignatureInput(defaultSTH())
 	if err != nil {
@@ -321,6 +346,125 @@ func TestUnmarshalSCT(t *testing.T) {
 	}
 }
 
+func TestMarshalUnmarshalSCTHelpers(t *testing.T) {
+	sctIn := defaultSCT()
+	b, err := MarshalSCT(sctIn)
+	if err != nil {
+		t.Fatalf("MarshalSCT()=nil,%v; want no error", err)
+	}
+	if want := dh(defaultSCTHexString); !bytes.Equal(b, want) {
+		t.Errorf("MarshalSCT()=%x; want %x", b, want)
+	}
+	sctOut, err := UnmarshalSCT(b)
+	if err != nil {
+		t.Fatalf("UnmarshalSCT(%x)=nil,%v; want no error", b, err)
+	}
+	if !reflect.DeepEqual(*sctOut, sctIn) {
+		t.Errorf("UnmarshalSCT(%x)=%+v; want %+v", b, *sctOut, sctIn)
+	}
+}
+
+func TestUnmarshalSCTErrors(t *testing.T) {
+	data := dh(defaultSCTHexString)
+	for _, test := range []struct {
+		desc string
+		data []byte
+	}{
+		{desc: "empty", data: []byte{}},
+		{desc: "truncated", data: data[:len(data)-1]},
+		{desc: "trailing-data", data: append(append([]byte{}, data...), 0x00)},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got, err := UnmarshalSCT(test.data); err == nil {
+				t.Errorf("UnmarshalSCT(%x)=%+v,nil; want error", test.data, got)
+			}
+		})
+	}
+}
+
+func TestTLSExtensionSCTListRoundTrip(t *testing.T) {
+	sct1 := defaultSCT()
+	sct2 := defaultSCT()
+	sct2.Timestamp++
+	sct2.Extensions = []byte{0x01, 0x02}
+
+	for _, test := range []struct {
+		desc string
+		scts []SignedCertificateTimestamp
+	}{
+		{desc: "single", scts: []SignedCertificateTimestamp{sct1}},
+		{desc: "multiple", scts: []SignedCertificateTimestamp{sct1, sct2}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			data, err := BuildTLSExtensionSCTList(test.scts)
+			if err != nil {
+				t.Fatalf("BuildTLSExtensionSCTList()=nil,%v; want no error", err)
+			}
+
+			// The list is framed by a 2-byte length, as is each SCT in it.
+			wantData := []byte{0, 0}
+			for _, sct := range test.scts {
+				b, err := tls.Marshal(sct)
+				if err != nil {
+					t.Fatalf("tls.Marshal(SCT)=nil,%v; want no error", err)
+				}
+				wantData = append(wantData, byte(len(b)>>8), byte(len(b)))
+				wantData = append(wantData, b...)
+			}
+			wantData[0], wantData[1] = byte((len(wantData)-2)>>8), byte(len(wantData)-2)
+			if !bytes.Equal(data, wantData) {
+				t.Errorf("BuildTLSExtensionSCTList()=%x; want %x", data, wantData)
+			}
+
+			got, err := ParseTLSExtensionSCTList(data)
+			if err != nil {
+				t.Fatalf("ParseTLSExtensionSCTList(%x)=nil,%v; want no error", data, err)
+			}
+			if !reflect.DeepEqual(got, test.scts) {
+				t.Errorf("ParseTLSExtensionSCTList(%x)=%+v; want %+v", data, got, test.scts)
+			}
+		})
+	}
+}
+
+func TestBuildTLSExtensionSCTListEmpty(t *testing.T) {
+	if data, err := BuildTLSExtensionSCTList(nil); err == nil {
+		t.Errorf("BuildTLSExtensionSCTList(nil)=%x,nil; want error", data)
+	}
+}
+
+func TestParseTLSExtensionSCTListErrors(t *testing.T) {
+	data, err := BuildTLSExtensionSCTList([]SignedCertificateTimestamp{defaultSCT()})
+	if err != nil {
+		t.Fatalf("BuildTLSExtensionSCTList()=nil,%v; want no error", err)
+	}
+	sctData := dh(defaultSCTHexString)
+	// A list whose only entry holds an SCT followed by an extra byte.
+	extraInSCT := append([]byte{0, 0, 0, 0}, sctData...)
+	extraInSCT = append(extraInSCT, 0xff)
+	n := len(sctData) + 1
+	extraInSCT[0], extraInSCT[1] = byte((n+2)>>8), byte(n+2)
+	extraInSCT[2], extraInSCT[3] = byte(n>>8), byte(n)
+
+	for _, test := range []struct {
+		desc string
+		data []byte
+	}{
+		{desc: "empty", data: []byte{}},
+		{desc: "empty-list", data: []byte{0, 0}},
+		{desc: "truncated", data: data[:len(data)-1]},
+		{desc: "trailing-data", data: append(append([]byte{}, data...), 0)},
+		{desc: "bad-sct", data: []byte{0, 3, 0, 1, 0xff}},
+		{desc: "trailing-sct-data", data: extraInSCT},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got, err := ParseTLSExtensionSCTList(test.data); err == nil {
+				t.Errorf("ParseTLSExtensionSCTList(%x)=%+v,nil; want error", test.data, got)
+			}
+		})
+	}
+}
+
 func TestX509MerkleTreeLeafHash(t *testing.T) {
 	certFile := "./testdata/test-cert.pem"
 	sctFile := "./testdata/test-cert.proof"
@@ -352,6 +496,84 @@ func TestX509MerkleTreeLeafHash(t *testing.T) {
 
 }
 
+func TestMerkleTreeLeafFromPrecertTBS(t *testing.T) {
+	tbs := dh("3003020101")
+	issuerKeyHash := sha256.Sum256([]byte("issuer SPKI"))
+	exts := CTExtensions{0x01, 0x02}
+	const timestamp = 1469185273000
+
+	got, err := MerkleTreeLeafFromPrecertTBS(tbs, issuerKeyHash, timestamp, exts)
+	if err != nil {
+		t.Fatalf("MerkleTreeLeafFromPrecertTBS()=nil,%v; want _,nil", err)
+	}
+	// The leaf as built inline by the integration hammer.
+	want := MerkleTreeLeaf{
+		Version:  V1,
+		LeafType: TimestampedEntryLeafType,
+		TimestampedEntry: &TimestampedEntry{
+			Timestamp: timestamp,
+			EntryType: PrecertLogEntryType,
+			PrecertEntry: &PreCert{
+				IssuerKeyHash:  issuerKeyHash,
+				TBSCertificate: tbs,
+			},
+			Extensions: exts,
+		},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("MerkleTreeLeafFromPrecertTBS()=%+v; want %+v", *got, want)
+	}
+	gotData, err := tls.Marshal(*got)
+	if err != nil {
+		t.Fatalf("tls.Marshal(got)=nil,%v", err)
+	}
+	wantData, err := tls.Marshal(want)
+	if err != nil {
+		t.Fatalf("tls.Marshal(want)=nil,%v", err)
+	}
+	if !bytes.Equal(gotData, wantData) {
+		t.Errorf("tls.Marshal(MerkleTreeLeafFromPrecertTBS())=%x; want %x", gotData, wantData)
+	}
+
+	if _, err := MerkleTreeLeafFromPrecertTBS(nil, issuerKeyHash, timestamp, exts); err == nil {
+		t.Error("MerkleTreeLeafFromPrecertTBS(nil tbs)=_,nil; want error")
+	}
+}
+
+func TestLeafHashForEntry(t *testing.T) {
+	certLeaf := CreateX509MerkleTreeLeaf(ASN1Cert{Data: dh("3003020101")}, 1469185273000)
+	precertLeaf, err := MerkleTreeLeafFromPrecertTBS(dh("3003020102"), sha256.Sum256([]byte("issuer SPKI")), 1469185273000, CTExtensions{0x01})
+	if err != nil {
+		t.Fatalf("MerkleTreeLeafFromPrecertTBS()=nil,%v", err)
+	}
+	for _, leaf := range []*MerkleTreeLeaf{certLeaf, precertLeaf} {
+		// The leaf hash as computed inline by the integration hammer.
+		leafData, err := tls.Marshal(*leaf)
+		if err != nil {
+			t.Fatalf("tls.Marshal(leaf)=nil,%v", err)
+		}
+		want := sha256.Sum256(append([]byte{TreeLeafPrefix}, leafData...))
+
+		if got := LeafHashForLeafData(leafData); got != want {
+			t.Errorf("LeafHashForLeafData(%v)=%x; want %x", leaf.TimestampedEntry.EntryType, got, want)
+		}
+		got, err := LeafHashForLeaf(leaf)
+		if err != nil {
+			t.Fatalf("LeafHashForLeaf()=nil,%v", err)
+		}
+		if got != want {
+			t.Errorf("LeafHashForLeaf(%v)=%x; want %x", leaf.TimestampedEntry.EntryType, got, want)
+		}
+		got, err = LeafHashForEntry(&LogEntry{Leaf: *leaf})
+		if err != nil {
+			t.Fatalf("LeafHashForEntry()=nil,%v", err)
+		}
+		if got != want {
+			t.Errorf("LeafHashForEntry(%v)=%x; want %x", leaf.TimestampedEntry.EntryType, got, want)
+		}
+	}
+}
+
 func TestLogEntryFromLeaf(t *testing.T) {
 	const (
 		// Cert example taken from entry #1 in argon2018 log
@@ -480,3 +702,230 @@ func TestLogEntryFromLeaf(t *testing.T) {
 		}
 	}
 }
+
+func TestLogEntryIterator(t *testing.T) {
+	certB, err := os.ReadFile("./testdata/test-cert.pem")
+	if err != nil {
+		t.Fatalf("Failed to read test cert: %v", err)
+	}
+	certDER, _ := pem.Decode(certB)
+	leafFor := func(der []byte) LeafEntry {
+		t.Helper()
+		leafInput, err := tls.Marshal(MerkleTreeLeaf{
+			Version:  V1,
+			LeafType: TimestampedEntryLeafType,
+			TimestampedEntry: &TimestampedEntry{
+				EntryType: X509LogEntryType,
+				X509Entry: &ASN1Cert{Data: der},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal leaf: %v", err)
+		}
+		extraData, err := tls.Marshal(CertificateChain{})
+		if err != nil {
+			t.Fatalf("Failed to marshal chain: %v", err)
+		}
+		return LeafEntry{LeafInput: leafInput, ExtraData: extraData}
+	}
+	leaves := []LeafEntry{
+		leafFor(certDER.Bytes),
+		{LeafInput: []byte("garbage")},
+		leafFor([]byte("not a certificate")),
+		leafFor(certDER.Bytes),
+	}
+
+	t.Run("leaves", func(t *testing.T) {
+		it := NewLeafEntryIterator(10, leaves)
+		var got []int64
+		for index, entry := range it.All() {
+			if entry.Index != index || entry.X509Cert == nil {
+				t.Errorf("All() yielded %d, %+v; want parsed entry with matching index", index, entry)
+			}
+			got = append(got, index)
+		}
+		if want := []int64{10, 13}; !reflect.DeepEqual(got, want) {
+			t.Errorf("All() yielded indices %v, want %v", got, want)
+		}
+		errs := it.Errors()
+		if len(errs) != 2 {
+			t.Errorf("Errors()=%v, want errors for 11 and 12", errs)
+		}
+		if err := errs[11]; err == nil || !strings.Contains(err.Error(), "failed to unmarshal MerkleTreeLeaf") {
+			t.Errorf("Errors()[11]=%v, want unmarshal error", err)
+		}
+		if err := errs[12]; err == nil || !strings.Contains(err.Error(), "failed to parse certificate") {
+			t.Errorf("Errors()[12]=%v, want parse error", err)
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		var raw []RawLogEntry
+		for i, leaf := range []LeafEntry{leaves[0], leaves[2], leaves[3]} {
+			rle, err := RawLogEntryFromLeaf(int64(20+i), &leaf)
+			if err != nil {
+				t.Fatalf("RawLogEntryFromLeaf(%d)=%v", i, err)
+			}
+			raw = append(raw, *rle)
+		}
+		it := NewLogEntryIterator(raw)
+		var got []int64
+		for index := range it.All() {
+			got = append(got, index)
+		}
+		if want := []int64{20, 22}; !reflect.DeepEqual(got, want) {
+			t.Errorf("All() yielded indices %v, want %v", got, want)
+		}
+		if errs := it.Errors(); len(errs) != 1 || errs[21] == nil {
+			t.Errorf("Errors()=%v, want an error for 21 only", errs)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		it := NewLeafEntryIterator(0, leaves)
+		for range it.All() {
+			break
+		}
+		if errs := it.Errors(); len(errs) != 0 {
+			t.Errorf("Errors()=%v after stopping at first entry, want none", errs)
+		}
+	})
+}
+
+func TestParseCTExtensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		exts    CTExtensions
+		want    []CTExtension
+		wantErr string
+	}{
+		{name: "empty"},
+		{
+			name: "single",
+			exts: CTExtensions(dh("0000000500000000ff")),
+			want: []CTExtension{{ExtensionType: 0, ExtensionData: dh("00000000ff")}},
+		},
+		{
+			name: "multiple",
+			exts: CTExtensions(dh("00010000" + "000200020102")),
+			want: []CTExtension{
+				{ExtensionType: 1, ExtensionData: []byte{}},
+				{ExtensionType: 2, ExtensionData: dh("0102")},
+			},
+		},
+		{
+			name:    "truncated data",
+			exts:    CTExtensions(dh("0000000500")),
+			wantErr: "extension number 0",
+		},
+		{
+			name:    "truncated length",
+			exts:    CTExtensions(dh("000000")),
+			wantErr: "extension number 0",
+		},
+		{
+			name:    "trailing garbage",
+			exts:    CTExtensions(dh("00010000" + "ff")),
+			wantErr: "extension number 1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseCTExtensions(test.exts)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("ParseCTExtensions()=_,%v; want error containing %q", err, test.wantErr)
+				}
+				if err := ValidateCTExtensions(test.exts); err == nil {
+					t.Error("ValidateCTExtensions()=nil; want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCTExtensions()=nil,%v; want _,nil", err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("ParseCTExtensions()=%+v; want %+v", got, test.want)
+			}
+			for i := range got {
+				if got[i].ExtensionType != test.want[i].ExtensionType || !bytes.Equal(got[i].ExtensionData, test.want[i].ExtensionData) {
+					t.Errorf("ParseCTExtensions()[%d]=%+v; want %+v", i, got[i], test.want[i])
+				}
+			}
+			if err := ValidateCTExtensions(test.exts); err != nil {
+				t.Errorf("ValidateCTExtensions()=%v; want nil", err)
+			}
+
+			built, err := BuildCTExtensions(got)
+			if err != nil {
+				t.Fatalf("BuildCTExtensions()=nil,%v; want _,nil", err)
+			}
+			if !bytes.Equal(built, test.exts) {
+				t.Errorf("BuildCTExtensions()=%x; want %x", built, test.exts)
+			}
+		})
+	}
+}
+
+func TestValidateCTExtensionsOrder(t *testing.T) {
+	for _, exts := range []CTExtensions{
+		CTExtensions(dh("00020000" + "00010000")),
+		CTExtensions(dh("00010000" + "00010000")),
+	} {
+		if err := ValidateCTExtensions(exts); err == nil {
+			t.Errorf("ValidateCTExtensions(%x)=nil; want error", exts)
+		}
+	}
+}
+
+func TestValidatePrecertPoison(t *testing.T) {
+	pemDER := func(data string) []byte {
+		block, _ := pem.Decode([]byte(data))
+		if block == nil {
+			t.Fatal("Failed to decode PEM")
+		}
+		return block.Bytes
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	withPoison := func(critical bool, value []byte) []byte {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "precert"},
+			ExtraExtensions: []pkix.Extension{
+				{Id: x509.OIDExtensionCTPoison, Critical: critical, Value: value},
+			},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		if err != nil {
+			t.Fatalf("Failed to create certificate: %v", err)
+		}
+		return der
+	}
+
+	for _, test := range []struct {
+		name    string
+		der     []byte
+		wantErr string
+	}{
+		{name: "precert", der: pemDER(testdata.TestPreCertPEM)},
+		{name: "generated-precert", der: withPoison(true, asn1.NullBytes)},
+		{name: "cert", der: pemDER(testdata.TestCertPEM), wantErr: "no CT poison extension"},
+		{name: "non-critical", der: withPoison(false, asn1.NullBytes), wantErr: "not critical"},
+		{name: "non-null", der: withPoison(true, []byte{0x04, 0x00}), wantErr: "want ASN.1 NULL"},
+		{name: "unparsable", der: []byte("not a certificate"), wantErr: "failed to parse"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidatePrecertPoison(ASN1Cert{Data: test.der})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidatePrecertPoison()=%v, want nil", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ValidatePrecertPoison()=%v, want error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -17,10 +17,12 @@ package ct
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/pem"
 	"fmt"
 	"log"
@@ -79,6 +81,10 @@ func NewSignatureVerifier(pk crypto.PublicKey) (*SignatureVerifier, error) {
 			log.Printf("WARNING: %v", e)
 
 		}
+	case ed25519.PublicKey:
+		if len(pkType) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("public key is Ed25519 with %d bytes, want %d", len(pkType), ed25519.PublicKeySize)
+		}
 	default:
 		return nil, fmt.Errorf("unsupported public key type %v", pkType)
 	}
@@ -100,6 +106,44 @@ func (s SignatureVerifier) VerifySCTSignature(sct SignedCertificateTimestamp, en
 	return s.VerifySignature(sctData, tls.DigitallySigned(sct.Signature))
 }
 
+// VerifySCTSignatures verifies the signatures of the given SCTs over the same
+// LogEntry, e.g. the SCTs embedded in a certificate. The signed portion of the
+// entry is serialized only once. The returned slice holds the verification
+// error for each of the SCTs, in order, with nil for valid signatures.
+func (s SignatureVerifier) VerifySCTSignatures(scts []SignedCertificateTimestamp, entry LogEntry) []error {
+	errs := make([]error, len(scts))
+	// Serialize the signature input without the per-SCT fields, and split it
+	// around the (fixed size) fields which precede and follow the entry.
+	template, err := SerializeSCTSignatureInput(SignedCertificateTimestamp{SCTVersion: V1}, entry)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	const headerLen, extLenLen = 1 + 1 + 8, 2
+	signedEntry := template[headerLen : len(template)-extLenLen]
+
+	for i, sct := range scts {
+		if sct.SCTVersion != V1 {
+			errs[i] = fmt.Errorf("unknown SCT version %d", sct.SCTVersion)
+			continue
+		}
+		if len(sct.Extensions) > 0xffff {
+			errs[i] = fmt.Errorf("SCT extensions too long (%d bytes)", len(sct.Extensions))
+			continue
+		}
+		data := make([]byte, 0, len(template)+len(sct.Extensions))
+		data = append(data, template[:2]...) // Version and signature type.
+		data = binary.BigEndian.AppendUint64(data, sct.Timestamp)
+		data = append(data, signedEntry...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(sct.Extensions)))
+		data = append(data, sct.Extensions...)
+		errs[i] = s.VerifySignature(data, tls.DigitallySigned(sct.Signature))
+	}
+	return errs
+}
+
 // VerifySTHSignature verifies that the STH's signature is valid.
 func (s SignatureVerifier) VerifySTHSignature(sth SignedTreeHead) error {
 	sthData, err := SerializeSTHSignatureInput(sth)
@@ -108,3 +152,17 @@ func (s SignatureVerifier) VerifySTHSignature(sth SignedTreeHead) error {
 	}
 	return s.VerifySignature(sthData, tls.DigitallySigned(sth.TreeHeadSignature))
 }
+
+// VerifySTHSignatureWithKey verifies that the STH's signature is valid for the
+// log's public key given in DER-encoded PKIX form, as it appears in log lists.
+func VerifySTHSignatureWithKey(sth SignedTreeHead, pubKeyDER []byte) error {
+	pk, err := x509.ParsePKIXPublicKey(pubKeyDER)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %v", err)
+	}
+	v, err := NewSignatureVerifier(pk)
+	if err != nil {
+		return err
+	}
+	return v.VerifySTHSignature(sth)
+}
@@ -22,6 +22,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/hex"
+	"encoding/pem"
 	mrand "math/rand"
 	"testing"
 
@@ -87,6 +88,20 @@ const (
 		"2d3c916eb77f167323500d1b53dc4253321a106e441af343cf2f68630873" +
 		"abd43ca52629c586107eb7eb85f2c3ee"
 
+	// sigTestEd25519PublicKeyPEM is the Ed25519 key derived from the seed
+	// 000102...1f.
+	sigTestEd25519PublicKeyPEM = "-----BEGIN PUBLIC KEY-----\n" +
+		"MCowBQYDK2VwAyEAA6EHv/POEL4dcN0Y50vAmWfk1jCbpQ1fHdyGZBJVMbg=\n" +
+		"-----END PUBLIC KEY-----\n"
+
+	sigTestCertSCTSignatureEd25519 = "0807" + "0040" +
+		"f6f766f7196d750d0b9d73b97760ccac8dd8d37230b579d849fd6b041c918722" +
+		"cca7d0e4d929062d1d92528be61f5c511773a2a8e0d2358b18b05f3df38ff305"
+
+	sigTestSTHSignatureEd25519 = "0807" + "0040" +
+		"c9418345173f0ac913d67bb11eb225d6e1e1c7b3a79c7ed6c46b47242665695e" +
+		"fec85287a478bf02e035bc28b3dd9ac45e4bf60ec2c4b176e8e6a22707f7aa0d"
+
 	sigTestCertSCTSignatureUnsupportedSignatureAlgorithm = "0402" + "0000"
 
 	sigTestCertSCTSignatureUnsupportedHashAlgorithm = "0303" + "0000"
@@ -141,6 +156,11 @@ func sigTestSCTRSA(t *testing.T) SignedCertificateTimestamp {
 	return sigTestSCTWithSignature(t, sigTestCertSCTSignatureRSA, sigTestKeyIDEC)
 }
 
+func sigTestSCTEd25519(t *testing.T) SignedCertificateTimestamp {
+	t.Helper()
+	return sigTestSCTWithSignature(t, sigTestCertSCTSignatureEd25519, sigTestKeyIDEC)
+}
+
 func sigTestECPublicKey(t *testing.T) crypto.PublicKey {
 	t.Helper()
 	pk, _, _, err := PublicKeyFromPEM([]byte(sigTestEC256PublicKeyPEM))
@@ -168,6 +188,15 @@ func sigTestRSAPublicKey(t *testing.T) crypto.PublicKey {
 	return pk
 }
 
+func sigTestEd25519PublicKey(t *testing.T) crypto.PublicKey {
+	t.Helper()
+	pk, _, _, err := PublicKeyFromPEM([]byte(sigTestEd25519PublicKeyPEM))
+	if err != nil {
+		t.Fatalf("Failed to parse sigTestEd25519PublicKey: %v", err)
+	}
+	return pk
+}
+
 func sigTestCertLogEntry(t *testing.T) LogEntry {
 	t.Helper()
 	return LogEntry{
@@ -225,6 +254,52 @@ func expectVerifySCTToFail(t *testing.T, sv SignatureVerifier, sct SignedCertifi
 	}
 }
 
+func TestVerifySCTSignatures(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestECPublicKey(t))
+	wrongTimestamp := sigTestSCTEC(t)
+	wrongTimestamp.Timestamp++
+	withExtensions := sigTestSCTEC(t)
+	withExtensions.Extensions = CTExtensions{0x01, 0x02}
+	wrongVersion := sigTestSCTEC(t)
+	wrongVersion.SCTVersion = 1
+	scts := []SignedCertificateTimestamp{
+		sigTestSCTEC(t),
+		sigTestSCTRSA(t),
+		wrongTimestamp,
+		withExtensions,
+		wrongVersion,
+		sigTestSCTEC(t),
+	}
+	wantValid := []bool{true, false, false, false, false, true}
+
+	entry := sigTestCertLogEntry(t)
+	errs := v.VerifySCTSignatures(scts, entry)
+	if got, want := len(errs), len(scts); got != want {
+		t.Fatalf("VerifySCTSignatures() returned %d errors, want %d", got, want)
+	}
+	for i, sct := range scts {
+		if got, want := errs[i] == nil, wantValid[i]; got != want {
+			t.Errorf("VerifySCTSignatures()[%d]=%v, want valid: %v", i, errs[i], want)
+		}
+		single := v.VerifySCTSignature(sct, entry)
+		if got, want := errs[i] == nil, single == nil; got != want {
+			t.Errorf("VerifySCTSignatures()[%d]=%v, but VerifySCTSignature()=%v", i, errs[i], single)
+		}
+	}
+}
+
+func TestVerifySCTSignaturesBadEntry(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestECPublicKey(t))
+	entry := sigTestCertLogEntry(t)
+	entry.Leaf.TimestampedEntry.EntryType = 99
+	scts := []SignedCertificateTimestamp{sigTestSCTEC(t), sigTestSCTEC(t)}
+	for i, err := range v.VerifySCTSignatures(scts, entry) {
+		if err == nil {
+			t.Errorf("VerifySCTSignatures()[%d]=nil, want error", i)
+		}
+	}
+}
+
 func TestVerifySCTSignatureEC(t *testing.T) {
 	v := mustCreateSignatureVerifier(t, sigTestECPublicKey(t))
 	if err := v.VerifySCTSignature(sigTestSCTEC(t), sigTestCertLogEntry(t)); err != nil {
@@ -398,6 +473,97 @@ func TestVerifySTHFailsToVerifyForDifferentKey(t *testing.T) {
 	expectVerifySTHToFail(t, v, sth)
 }
 
+func sigTestEd25519STH(t *testing.T) SignedTreeHead {
+	t.Helper()
+	sth := sigTestDefaultSTH(t)
+	if _, err := tls.Unmarshal(mustDehex(t, sigTestSTHSignatureEd25519), &sth.TreeHeadSignature); err != nil {
+		t.Fatalf("Failed to unmarshal sigTestSTHSignatureEd25519: %v", err)
+	}
+	return sth
+}
+
+func TestVerifySCTSignatureEd25519(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestEd25519PublicKey(t))
+	if err := v.VerifySCTSignature(sigTestSCTEd25519(t), sigTestCertLogEntry(t)); err != nil {
+		t.Fatalf("Failed to verify Ed25519 SCT signature: %v", err)
+	}
+}
+
+func TestVerifySCTSignatureEd25519FailsForIncorrectSignature(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestEd25519PublicKey(t))
+	testVerifySCTSignatureFailsForIncorrectSignature(t, sigTestSCTEd25519(t), v)
+}
+
+func TestVerifySCTSignatureEd25519FailsForIncorrectTimestamp(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestEd25519PublicKey(t))
+	sct := sigTestSCTEd25519(t)
+	sct.Timestamp++
+	expectVerifySCTToFail(t, v, sct, "Incorrectly verified Ed25519 SCT with incorrect timestamp")
+}
+
+func TestVerifySCTSignatureEd25519FailsForDifferentKeyType(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestECPublicKey(t))
+	expectVerifySCTToFail(t, v, sigTestSCTEd25519(t), "Incorrectly verified Ed25519 SCT with EC key")
+}
+
+func TestVerifyValidSTHEd25519(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestEd25519PublicKey(t))
+	expectVerifySTHToPass(t, v, sigTestEd25519STH(t))
+}
+
+func TestVerifySTHEd25519CatchesCorruptSignature(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestEd25519PublicKey(t))
+	sth := sigTestEd25519STH(t)
+	corruptBytes(sth.TreeHeadSignature.Signature)
+	expectVerifySTHToFail(t, v, sth)
+}
+
+func TestVerifySTHEd25519CatchesCorruptTreeSize(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestEd25519PublicKey(t))
+	sth := sigTestEd25519STH(t)
+	sth.TreeSize++
+	expectVerifySTHToFail(t, v, sth)
+}
+
+func TestVerifySTHEd25519FailsToVerifyForKeyWithDifferentAlgorithm(t *testing.T) {
+	v := mustCreateSignatureVerifier(t, sigTestECPublicKey(t))
+	expectVerifySTHToFail(t, v, sigTestEd25519STH(t))
+}
+
+func TestVerifySTHSignatureWithKey(t *testing.T) {
+	der := func(keyPEM string) []byte {
+		t.Helper()
+		p, _ := pem.Decode([]byte(keyPEM))
+		if p == nil {
+			t.Fatal("no PEM block found")
+		}
+		return p.Bytes
+	}
+	corrupt := sigTestDefaultSTH(t)
+	corrupt.TreeSize++
+
+	for _, test := range []struct {
+		desc    string
+		sth     SignedTreeHead
+		key     []byte
+		wantErr bool
+	}{
+		{desc: "valid", sth: sigTestDefaultSTH(t), key: der(sigTestEC256PublicKeyPEM)},
+		{desc: "valid-ed25519", sth: sigTestEd25519STH(t), key: der(sigTestEd25519PublicKeyPEM)},
+		{desc: "corrupt-sth", sth: corrupt, key: der(sigTestEC256PublicKeyPEM), wantErr: true},
+		{desc: "different-key", sth: sigTestDefaultSTH(t), key: der(sigTestRSAPublicKeyPEM), wantErr: true},
+		{desc: "bad-key", sth: sigTestDefaultSTH(t), key: []byte{0x30, 0x00}, wantErr: true},
+		{desc: "no-key", sth: sigTestDefaultSTH(t), wantErr: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			err := VerifySTHSignatureWithKey(test.sth, test.key)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("VerifySTHSignatureWithKey()=%v, want error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
 func TestNewSignatureVerifierFailsWithUnsupportedKeyType(t *testing.T) {
 	var k dsa.PrivateKey
 	if err := dsa.GenerateParameters(&k.Parameters, rand.Reader, dsa.L1024N160); err != nil {
@@ -452,3 +618,40 @@ func TestWillAllowNonCompliantRSAKeyWithOverride(t *testing.T) {
 		t.Fatalf("Incorrectly disallowed 1024 bit RSA key with override set: %v", err)
 	}
 }
+
+func TestLogEntrySerializedLeafAndSignedEntry(t *testing.T) {
+	entry := sigTestCertLogEntry(t)
+	sct := sigTestSCTEC(t)
+	// The cert is 718 bytes long.
+	certBytes := "0002ce" + sigTestDERCertString
+
+	leaf, err := entry.SerializedLeaf()
+	if err != nil {
+		t.Fatalf("SerializedLeaf()=nil,%v; want no error", err)
+	}
+	// Version, leaf type, timestamp, entry type, cert, extensions.
+	wantLeaf := "00" + "00" + "00000139fe353cf5" + "0000" + certBytes + "0000"
+	if got := hex.EncodeToString(leaf); got != wantLeaf {
+		t.Errorf("SerializedLeaf()=%s; want %s", got, wantLeaf)
+	}
+	hash, err := LeafHashForEntry(&entry)
+	if err != nil {
+		t.Fatalf("LeafHashForEntry()=nil,%v; want no error", err)
+	}
+	if got, want := LeafHashForLeafData(leaf), hash; got != want {
+		t.Errorf("LeafHashForLeafData(SerializedLeaf())=%x; want %x", got, want)
+	}
+
+	signed, err := entry.SignedEntry(sct)
+	if err != nil {
+		t.Fatalf("SignedEntry()=nil,%v; want no error", err)
+	}
+	// SCT version, signature type, timestamp, entry type, cert, extensions.
+	wantSigned := "00" + "00" + "00000139fe353cf5" + "0000" + certBytes + "0000"
+	if got := hex.EncodeToString(signed); got != wantSigned {
+		t.Errorf("SignedEntry()=%s; want %s", got, wantSigned)
+	}
+	if err := tls.VerifySignature(sigTestECPublicKey(t), signed, tls.DigitallySigned(sct.Signature)); err != nil {
+		t.Errorf("SCT signature does not verify over SignedEntry(): %v", err)
+	}
+}
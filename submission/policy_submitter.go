@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package submission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/client"
+	"github.com/OlegBabkin/certificate-transparency-go/ctpolicy"
+	"github.com/OlegBabkin/certificate-transparency-go/loglist3"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+)
+
+// clientSubmitter is a Submitter which posts to Logs using the given
+// clients, keyed by Log URL.
+type clientSubmitter map[string]client.AddLogClient
+
+// SubmitToLog implements Submitter interface.
+func (cs clientSubmitter) SubmitToLog(ctx context.Context, logURL string, chain []ct.ASN1Cert, asPreChain bool) (*ct.SignedCertificateTimestamp, error) {
+	lc, ok := cs[logURL]
+	if !ok {
+		return nil, fmt.Errorf("no client registered for Log with URL %q", logURL)
+	}
+	if asPreChain {
+		return lc.AddPreChain(ctx, chain)
+	}
+	return lc.AddChain(ctx, chain)
+}
+
+// PolicySubmission holds the outcome of submitting a chain to enough Logs to
+// satisfy a CT policy.
+type PolicySubmission struct {
+	// SCTs holds all the SCTs collected, including those from groups which
+	// were not satisfied.
+	SCTs []*AssignedSCT
+	// GroupsSatisfied reports, for each Log-group of the policy, whether it
+	// received the minimum number of SCTs it requires.
+	GroupsSatisfied map[string]bool
+}
+
+// SubmitChainForPolicy submits the chain, which must start with the leaf, to
+// the Logs of the approved list that policy requires, concurrently, using the
+// clients keyed by Log URL. Failures of single Logs are tolerated as long as
+// other Logs in the same group can make up for them.
+//
+// The returned PolicySubmission is non-nil whenever submission was attempted,
+// and holds all SCTs collected even if an error is returned because some
+// groups did not receive enough SCTs.
+func SubmitChainForPolicy(ctx context.Context, chain []*x509.Certificate, asPreChain bool, policy ctpolicy.CTPolicy, approved *loglist3.LogList, clients map[string]client.AddLogClient) (*PolicySubmission, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("empty chain")
+	}
+	groups, err := policy.LogsByGroup(chain[0], approved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select Logs with %s policy: %v", policy.Name(), err)
+	}
+	derChain := make([]ct.ASN1Cert, 0, len(chain))
+	for _, cert := range chain {
+		derChain = append(derChain, ct.ASN1Cert{Data: cert.Raw})
+	}
+	scts, groupComplete := getSCTs(ctx, clientSubmitter(clients), derChain, asPreChain, groups)
+	return &PolicySubmission{SCTs: scts, GroupsSatisfied: groupComplete}, completenessError(groupComplete)
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package submission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OlegBabkin/certificate-transparency-go/client"
+	"github.com/OlegBabkin/certificate-transparency-go/ctpolicy"
+	"github.com/OlegBabkin/certificate-transparency-go/testdata"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+)
+
+func TestSubmitChainForPolicy(t *testing.T) {
+	leaf, err := x509util.CertificateFromPEM([]byte(testdata.TestCertPEM))
+	if x509.IsFatal(err) {
+		t.Fatalf("failed to parse test cert: %v", err)
+	}
+	ll := sampleValidLogList()
+	// Only the Logs listed in RootsCerts return SCTs, the others fail.
+	clients := make(map[string]client.AddLogClient)
+	for _, op := range ll.Operators {
+		for _, log := range op.Logs {
+			lc, err := newLocalStubLogClient(log)
+			if err != nil {
+				t.Fatalf("newLocalStubLogClient(%q): %v", log.URL, err)
+			}
+			clients[log.URL] = lc
+		}
+	}
+
+	tests := []struct {
+		name          string
+		baseNum       int
+		wantErr       bool
+		wantSatisfied bool
+		wantMinSCTs   int
+		wantMaxSCTs   int
+	}{
+		{name: "satisfied", baseNum: 2, wantSatisfied: true, wantMinSCTs: 2, wantMaxSCTs: 3},
+		{name: "partial", baseNum: 4, wantErr: true, wantMinSCTs: 3, wantMaxSCTs: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			got, err := SubmitChainForPolicy(ctx, []*x509.Certificate{leaf}, false, buildStubCTPolicy(test.baseNum), ll, clients)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("SubmitChainForPolicy()=_, %v; want err=%t", err, test.wantErr)
+			}
+			if got == nil {
+				t.Fatal("SubmitChainForPolicy()=nil; want submission result")
+			}
+			if n := len(got.SCTs); n < test.wantMinSCTs || n > test.wantMaxSCTs {
+				t.Errorf("SubmitChainForPolicy() got %d SCTs; want %d..%d", n, test.wantMinSCTs, test.wantMaxSCTs)
+			}
+			for _, sct := range got.SCTs {
+				if _, ok := RootsCerts[sct.LogURL]; !ok {
+					t.Errorf("SubmitChainForPolicy() got SCT from failing Log %q", sct.LogURL)
+				}
+			}
+			if satisfied := got.GroupsSatisfied[ctpolicy.BaseName]; satisfied != test.wantSatisfied {
+				t.Errorf("SubmitChainForPolicy() GroupsSatisfied[%q]=%t; want %t", ctpolicy.BaseName, satisfied, test.wantSatisfied)
+			}
+		})
+	}
+
+	t.Run("unsatisfiable policy", func(t *testing.T) {
+		got, err := SubmitChainForPolicy(context.Background(), []*x509.Certificate{leaf}, false, buildStubCTPolicy(10), ll, clients)
+		if err == nil {
+			t.Fatalf("SubmitChainForPolicy()=%v, nil; want error", got)
+		}
+		if got != nil {
+			t.Errorf("SubmitChainForPolicy()=%v, _; want nil", got)
+		}
+	})
+}
@@ -285,6 +285,13 @@ func completenessError(groupComplete map[string]bool) error {
 // collects SCTs from them.
 // Emits all collected SCTs even when any error produced.
 func GetSCTs(ctx context.Context, submitter Submitter, chain []ct.ASN1Cert, asPreChain bool, groups ctpolicy.LogPolicyData) ([]*AssignedSCT, error) {
+	scts, groupComplete := getSCTs(ctx, submitter, chain, asPreChain, groups)
+	return scts, completenessError(groupComplete)
+}
+
+// getSCTs runs the submission races for all groups and returns the collected
+// SCTs along with whether each group received enough of them.
+func getSCTs(ctx context.Context, submitter Submitter, chain []ct.ASN1Cert, asPreChain bool, groups ctpolicy.LogPolicyData) ([]*AssignedSCT, map[string]bool) {
 	groupComplete := make(map[string]bool)
 	for _, g := range groups {
 		groupComplete[g.Name] = false
@@ -305,10 +312,10 @@ func GetSCTs(ctx context.Context, submitter Submitter, chain []ct.ASN1Cert, asPr
 	for i := 0; i < len(groups); i++ {
 		select {
 		case <-ctx.Done():
-			return submissions.collectSCTs(), completenessError(groupComplete)
+			return submissions.collectSCTs(), groupComplete
 		case g := <-groupEvents:
 			groupComplete[g.Name] = g.Success
 		}
 	}
-	return submissions.collectSCTs(), completenessError(groupComplete)
+	return submissions.collectSCTs(), groupComplete
 }
@@ -18,6 +18,7 @@ import (
 	"crypto"
 	"crypto/dsa" //nolint:staticcheck
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	_ "crypto/md5" // For registration side-effect
 	"crypto/rand"
 	"crypto/rsa"
@@ -64,6 +65,21 @@ func generateHash(algo HashAlgorithm, data []byte) ([]byte, crypto.Hash, error)
 
 // VerifySignature verifies that the passed in signature over data was created by the given PublicKey.
 func VerifySignature(pubKey crypto.PublicKey, data []byte, sig DigitallySigned) error {
+	if sig.Algorithm.Signature == Ed25519 {
+		// Ed25519 signs the data itself, rather than a hash of it.
+		if sig.Algorithm.Hash != Intrinsic {
+			return fmt.Errorf("unsupported Algorithm.Hash for Ed25519 signature: %v", sig.Algorithm.Hash)
+		}
+		edKey, ok := pubKey.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("cannot verify Ed25519 signature with %T key", pubKey)
+		}
+		if !ed25519.Verify(edKey, data, sig.Signature) {
+			return errors.New("failed to verify Ed25519 signature")
+		}
+		return nil
+	}
+
 	hash, hashType, err := generateHash(sig.Algorithm.Hash, data)
 	if err != nil {
 		return err
@@ -126,6 +142,14 @@ func VerifySignature(pubKey crypto.PublicKey, data []byte, sig DigitallySigned)
 // CreateSignature builds a signature over the given data using the specified hash algorithm and private key.
 func CreateSignature(privKey crypto.PrivateKey, hashAlgo HashAlgorithm, data []byte) (DigitallySigned, error) {
 	var sig DigitallySigned
+	if edKey, ok := privKey.(ed25519.PrivateKey); ok {
+		if hashAlgo != Intrinsic {
+			return sig, fmt.Errorf("unsupported hash algorithm %v for Ed25519 key", hashAlgo)
+		}
+		sig.Algorithm = SignatureAndHashAlgorithm{Hash: Intrinsic, Signature: Ed25519}
+		sig.Signature = ed25519.Sign(edKey, data)
+		return sig, nil
+	}
 	sig.Algorithm.Hash = hashAlgo
 	hash, hashType, err := generateHash(sig.Algorithm.Hash, data)
 	if err != nil {
@@ -16,6 +16,7 @@ package tls_test
 
 import (
 	"crypto"
+	"crypto/ed25519"
 	"encoding/pem"
 	mathrand "math/rand"
 	"reflect"
@@ -28,6 +29,12 @@ import (
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 )
 
+// ed25519TestKey is an Ed25519 key derived from an all-zero seed.
+var ed25519TestKey = ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+// ed25519SignedAbcdHex is the signature of "abcd" by ed25519TestKey.
+const ed25519SignedAbcdHex = "0b66f97c399279b480dea958f26d5bd4cf7c9f43dc7e3f461c903f8b2420a44f8f5dab773fe22e47e5fa2ece8dd7bc2d405be9c6035e7c6164197481df755d09"
+
 func TestVerifySignature(t *testing.T) {
 	var tests = []struct {
 		pubKey   crypto.PublicKey
@@ -56,10 +63,18 @@ func TestVerifySignature(t *testing.T) {
 		{PEM2PK(testdata.EcdsaPublicKeyPEM), "61626364", tls.SHA256, tls.ECDSA, "failed to unmarshal ECDSA signature", "1234"},
 		{PEM2PK(testdata.EcdsaPublicKeyPEM), "61626364", tls.SHA256, tls.ECDSA, "failed to verify ECDSA signature", "3006020101020101eeff"},
 		{PEM2PK(testdata.EcdsaPublicKeyPEM), "61626364", tls.SHA256, tls.ECDSA, "zero or negative values", "3006020100020181"},
+		{PEM2PK(testdata.EcdsaPublicKeyPEM), "61626364", tls.Intrinsic, tls.ECDSA, "unsupported Algorithm.Hash", "1234"},
+
+		{ed25519TestKey.Public(), "61626364", tls.SHA256, tls.Ed25519, "unsupported Algorithm.Hash for Ed25519", ed25519SignedAbcdHex},
+		{ed25519TestKey.Public(), "61626364", tls.Intrinsic, tls.ECDSA, "unsupported Algorithm.Hash", "1234"},
+		{ed25519TestKey.Public(), "61626364", tls.Intrinsic, tls.Ed25519, "failed to verify Ed25519 signature", "1234"},
+		{ed25519TestKey.Public(), "61626360", tls.Intrinsic, tls.Ed25519, "failed to verify Ed25519 signature", ed25519SignedAbcdHex},
+		{PEM2PK(testdata.EcdsaPublicKeyPEM), "61626364", tls.Intrinsic, tls.Ed25519, "cannot verify Ed25519", ed25519SignedAbcdHex},
 
 		{PEM2PK(testdata.RsaPublicKeyPEM), "61626364", tls.SHA256, tls.RSA, "", testdata.RsaSignedAbcdHex},
 		{PEM2PK(testdata.DsaPublicKeyPEM), "61626364", tls.SHA1, tls.DSA, "", testdata.DsaSignedAbcdHex},
 		{PEM2PK(testdata.EcdsaPublicKeyPEM), "61626364", tls.SHA256, tls.ECDSA, "", testdata.EcdsaSignedAbcdHex},
+		{ed25519TestKey.Public(), "61626364", tls.Intrinsic, tls.Ed25519, "", ed25519SignedAbcdHex},
 	}
 	for _, test := range tests {
 		algo := tls.SignatureAndHashAlgorithm{Hash: test.hashAlgo, Signature: test.sigAlgo}
@@ -88,6 +103,7 @@ func TestCreateSignatureVerifySignatureRoundTrip(t *testing.T) {
 	}{
 		{PEM2PrivKey(testdata.RsaPrivateKeyPEM), PEM2PK(testdata.RsaPublicKeyPEM), tls.SHA256},
 		{PEM2PrivKey(testdata.EcdsaPrivateKeyPKCS8PEM), PEM2PK(testdata.EcdsaPublicKeyPEM), tls.SHA256},
+		{ed25519TestKey, ed25519TestKey.Public(), tls.Intrinsic},
 	}
 	seed := time.Now().UnixNano()
 	r := mathrand.New(mathrand.NewSource(seed))
@@ -118,6 +134,7 @@ func TestCreateSignatureFailures(t *testing.T) {
 	}{
 		{PEM2PrivKey(testdata.EcdsaPrivateKeyPKCS8PEM), 99, "abcd", "unsupported Algorithm.Hash"},
 		{nil, tls.SHA256, "abcd", "unsupported private key type"},
+		{ed25519TestKey, tls.SHA256, "abcd", "unsupported hash algorithm"},
 	}
 	for _, test := range tests {
 		if sig, err := tls.CreateSignature(test.privKey, test.hashAlgo, testdata.FromHex(test.in)); err == nil {
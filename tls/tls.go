@@ -93,6 +93,7 @@ var (
 //	Type<N,M>	[]Type		minlen:N,maxlen:M
 //	opaque[N]	[N]byte / [N]uint8
 //	uint8[N]	[N]byte / [N]uint8
+//	opaque[N]	[]byte		fixed:N
 //	struct { }	struct { }
 //	select(T) {
 //	 case e1: Type	*T		selector:Field,val:e1
@@ -124,6 +125,9 @@ var (
 //	 }
 //
 // TLS fixed-length vectors of types other than opaque or uint8 are not supported.
+// A fixed:N tag marks a byte slice or array as a fixed-length vector of N bytes,
+// encoded without a length prefix; for arrays it checks that N matches the
+// array length.
 //
 // For TLS variable-length vectors that are themselves used in other vectors,
 // create a single-field structure to represent the inner type. For example, for:
@@ -194,6 +198,7 @@ type fieldInfo struct {
 	maxlen   uint64 // Only relevant for slices
 	selector string // Only relevant for select sub-values
 	val      uint64 // Only relevant for select sub-values
+	fixed    uint64 // Only relevant for fixed-length byte slices and arrays
 	name     string // Used for better error messages
 }
 
@@ -207,16 +212,20 @@ func (i *fieldInfo) fieldName() string {
 // Given a tag string, return a fieldInfo describing the field.
 func fieldTagToFieldInfo(str string, name string) (*fieldInfo, error) {
 	var info *fieldInfo
+	var fixed uint64
+	var lengthSet bool // Whether any clause other than fixed gives a length.
 	// Iterate over clauses in the tag, ignoring any that don't parse properly.
 	for _, part := range strings.Split(str, ",") {
 		switch {
 		case strings.HasPrefix(part, "maxval:"):
 			if v, err := strconv.ParseUint(part[7:], 10, 64); err == nil {
 				info = &fieldInfo{count: byteCount(v), countSet: true}
+				lengthSet = true
 			}
 		case strings.HasPrefix(part, "size:"):
 			if sz, err := strconv.ParseUint(part[5:], 10, 32); err == nil {
 				info = &fieldInfo{count: uint(sz), countSet: true}
+				lengthSet = true
 			}
 		case strings.HasPrefix(part, "maxlen:"):
 			v, err := strconv.ParseUint(part[7:], 10, 64)
@@ -229,6 +238,7 @@ func fieldTagToFieldInfo(str string, name string) (*fieldInfo, error) {
 			info.count = byteCount(v)
 			info.countSet = true
 			info.maxlen = v
+			lengthSet = true
 		case strings.HasPrefix(part, "minlen:"):
 			v, err := strconv.ParseUint(part[7:], 10, 64)
 			if err != nil {
@@ -238,6 +248,11 @@ func fieldTagToFieldInfo(str string, name string) (*fieldInfo, error) {
 				info = &fieldInfo{}
 			}
 			info.minlen = v
+			lengthSet = true
+		case strings.HasPrefix(part, "fixed:"):
+			if v, err := strconv.ParseUint(part[6:], 10, 64); err == nil {
+				fixed = v
+			}
 		case strings.HasPrefix(part, "selector:"):
 			if info == nil {
 				info = &fieldInfo{}
@@ -254,9 +269,23 @@ func fieldTagToFieldInfo(str string, name string) (*fieldInfo, error) {
 			info.val = v
 		}
 	}
+	if fixed > 0 {
+		// Applied after the loop, as maxval and size clauses replace info.
+		if lengthSet {
+			return nil, structuralError{name, "fixed length with length prefix in " + str}
+		}
+		if info == nil {
+			info = &fieldInfo{}
+		}
+		info.fixed = fixed
+	}
 	if info != nil {
 		info.name = name
-		if info.selector == "" {
+		if info.selector == "" && info.fixed > 0 {
+			if info.val > 0 {
+				return nil, structuralError{name, "specified selector value but not field in " + str}
+			}
+		} else if info.selector == "" {
 			if info.count < 1 {
 				return nil, structuralError{name, "field of unknown size in " + str}
 			} else if info.count > 8 {
@@ -275,9 +304,8 @@ func fieldTagToFieldInfo(str string, name string) (*fieldInfo, error) {
 
 // Check that a value fits into a field described by a fieldInfo structure.
 func (i fieldInfo) check(val uint64, fldName string) error {
-	if val >= (1 << (8 * i.count)) {
-		return structuralError{fldName, fmt.Sprintf("value %d too large for size", val)}
-	}
+	// Check the declared range first, as it gives the more useful error for
+	// a vector that has grown too large.
 	if i.maxlen != 0 {
 		if val < i.minlen {
 			return structuralError{fldName, fmt.Sprintf("value %d too small for minimum %d", val, i.minlen)}
@@ -286,6 +314,10 @@ func (i fieldInfo) check(val uint64, fldName string) error {
 			return structuralError{fldName, fmt.Sprintf("value %d too large for maximum %d", val, i.maxlen)}
 		}
 	}
+	// A count of 8 bytes can hold any uint64 (and 1<<64 would wrap to zero).
+	if i.count < 8 && val >= (1<<(8*i.count)) {
+		return structuralError{fldName, fmt.Sprintf("value %d too large for size", val)}
+	}
 	return nil
 }
 
@@ -336,7 +368,7 @@ func parseField(v reflect.Value, data []byte, initOffset int, info *fieldInfo) (
 		if len(rest) < 3 {
 			return offset, syntaxError{info.fieldName(), "truncated uint24"}
 		}
-		v.SetUint(uint64(data[0])<<16 | uint64(data[1])<<8 | uint64(data[2]))
+		v.SetUint(uint64(rest[0])<<16 | uint64(rest[1])<<8 | uint64(rest[2]))
 		offset += 3
 		return offset, nil
 	case uint32Type:
@@ -447,6 +479,9 @@ func parseField(v reflect.Value, data []byte, initOffset int, info *fieldInfo) (
 		return offset, nil
 	case reflect.Array:
 		datalen := v.Len()
+		if info != nil && info.fixed > 0 && info.fixed != uint64(datalen) {
+			return offset, structuralError{info.fieldName(), fmt.Sprintf("array length %d does not match fixed length %d", datalen, info.fixed)}
+		}
 
 		if datalen > len(rest) {
 			return offset, syntaxError{info.fieldName(), "truncated array"}
@@ -462,19 +497,34 @@ func parseField(v reflect.Value, data []byte, initOffset int, info *fieldInfo) (
 
 	case reflect.Slice:
 		sliceType := fieldType
+		if info != nil && info.fixed > 0 {
+			// A fixed-length vector, with no length prefix.
+			if fieldType.Elem().Kind() != reflect.Uint8 {
+				return offset, structuralError{info.fieldName(), "fixed length for non-byte slice: " + v.Type().String()}
+			}
+			if info.fixed > uint64(len(rest)) {
+				return offset, syntaxError{info.fieldName(), "truncated fixed-length vector"}
+			}
+			datalen := int(info.fixed)
+			v.Set(reflect.MakeSlice(sliceType, datalen, datalen))
+			reflect.Copy(v, reflect.ValueOf(rest[:datalen]))
+			offset += datalen
+			return offset, nil
+		}
 		// Slices represent variable-length vectors, which are prefixed by a length field.
 		// The fieldInfo indicates the size of that length field.
 		varlen, err := readVarUint(rest, info)
 		if err != nil {
 			return offset, err
 		}
-		datalen := int(varlen)
 		offset += int(info.count)
 		rest = rest[info.count:]
 
-		if datalen > len(rest) {
+		// Compare before converting to int, so that a huge length cannot wrap.
+		if varlen > uint64(len(rest)) {
 			return offset, syntaxError{info.fieldName(), "truncated slice"}
 		}
+		datalen := int(varlen)
 		inner := rest[:datalen]
 		offset += datalen
 		if fieldType.Elem().Kind() == reflect.Uint8 {
@@ -653,6 +703,9 @@ func marshalField(out *bytes.Buffer, v reflect.Value, info *fieldInfo) error {
 			// Only byte/uint8 arrays are supported
 			return structuralError{info.fieldName(), "unsupported array type"}
 		}
+		if info != nil && info.fixed > 0 && info.fixed != uint64(datalen) {
+			return structuralError{info.fieldName(), fmt.Sprintf("array length %d does not match fixed length %d", datalen, info.fixed)}
+		}
 		bytes := make([]byte, datalen)
 		for i := 0; i < datalen; i++ {
 			bytes[i] = uint8(v.Index(i).Uint())
@@ -666,16 +719,27 @@ func marshalField(out *bytes.Buffer, v reflect.Value, info *fieldInfo) error {
 		}
 
 		sliceType := fieldType
+		if info.fixed > 0 {
+			// A fixed-length vector, with no length prefix.
+			if sliceType.Elem().Kind() != reflect.Uint8 {
+				return structuralError{info.fieldName(), "fixed length for non-byte slice: " + sliceType.String()}
+			}
+			if datalen := v.Len(); info.fixed != uint64(datalen) {
+				return structuralError{info.fieldName(), fmt.Sprintf("slice length %d does not match fixed length %d", datalen, info.fixed)}
+			}
+			_, err := out.Write(v.Bytes())
+			return err
+		}
 		if sliceType.Elem().Kind() == reflect.Uint8 {
-			// Fast version for []byte: first write the length as info.count bytes.
+			// Fast version for []byte: first check and write the length as
+			// info.count bytes.
 			datalen := v.Len()
-			scratch := make([]byte, 8)
-			binary.BigEndian.PutUint64(scratch, uint64(datalen))
-			out.Write(scratch[(8 - info.count):])
-
 			if err := info.check(uint64(datalen), prefix); err != nil {
 				return err
 			}
+			scratch := make([]byte, 8)
+			binary.BigEndian.PutUint64(scratch, uint64(datalen))
+			out.Write(scratch[(8 - info.count):])
 			// Then just write the data.
 			bytes := make([]byte, datalen)
 			for i := 0; i < datalen; i++ {
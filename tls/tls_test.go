@@ -68,6 +68,41 @@ type testSliceOfSlices struct {
 	Inners []testInnerType `tls:"minlen:0,maxlen:65535"`
 }
 
+type testFixed struct {
+	Hash  [4]byte `tls:"fixed:4"`
+	ID    []byte  `tls:"fixed:2"`
+	After uint8
+}
+
+type testFixedMismatch struct {
+	Hash [4]byte `tls:"fixed:3"`
+}
+
+type testFixedNonByte struct {
+	Vals []uint16 `tls:"fixed:2"`
+}
+
+type testUint24AfterByte struct {
+	First  uint8
+	Second Uint24
+}
+
+// maxUint24 is the largest length that fits a 24-bit length prefix, as used
+// for e.g. ASN.1Cert certificate_chain<0..2^24-1>.
+const maxUint24 = 1<<24 - 1
+
+type testLargeVector struct {
+	Data []byte `tls:"minlen:0,maxlen:16777215"`
+}
+
+type testLargeInner struct {
+	Data []byte `tls:"minlen:1,maxlen:16777215"`
+}
+
+type testLargeChain struct {
+	Entries []testLargeInner `tls:"minlen:0,maxlen:16777215"`
+}
+
 func TestMarshalUnmarshalRoundTrip(t *testing.T) {
 	thing := testStruct{Data: []byte{0x01, 0x02, 0x03}, IntVal: 42, Other: [4]byte{1, 2, 3, 4}, Enum: 17}
 	data, err := Marshal(thing)
@@ -137,6 +172,14 @@ func TestFieldTagToFieldInfo(t *testing.T) {
 		{"selector:Bob,val:x9", &fieldInfo{selector: "Bob"}, ""},
 		{"selector:Fred,val:1", &fieldInfo{selector: "Fred", val: 1}, ""},
 		{"val:9,selector:Fred,val:1", &fieldInfo{selector: "Fred", val: 1}, ""},
+		{"fixed:32", &fieldInfo{fixed: 32}, ""},
+		{"fixed:x", nil, ""},
+		{"fixed:32,maxlen:32", nil, "fixed length with length prefix"},
+		{"minlen:1,fixed:32", nil, "fixed length with length prefix"},
+		{"minlen:0,fixed:32", nil, "fixed length with length prefix"},
+		{"fixed:32,maxval:255", nil, "fixed length with length prefix"},
+		{"size:2,fixed:32", nil, "fixed length with length prefix"},
+		{"fixed:32,val:1", nil, "selector value"},
 	}
 	for _, test := range tests {
 		got, err := fieldTagToFieldInfo(test.tag, "")
@@ -177,12 +220,17 @@ func TestUnmarshalMarshalWithParamsRoundTrip(t *testing.T) {
 		{"0101", "", newUint16(0x0101)},
 		{"010203", "", newUint24(0x010203)},
 		{"000000", "", newUint24(0x00)},
+		{"01020304", "", &testUint24AfterByte{First: 1, Second: 0x020304}},
 		{"00000009", "", newUint32(0x09)},
 		{"0000000901020304", "", newUint64(0x0901020304)},
 		{"030405", "", &[3]byte{3, 4, 5}},
 		{"03", "", &[1]byte{3}},
+		{"030405", "fixed:3", &[3]byte{3, 4, 5}},
+		{"030405", "fixed:3", &[]byte{3, 4, 5}},
+		{"01020304050607", "", &testFixed{Hash: [4]byte{1, 2, 3, 4}, ID: []byte{5, 6}, After: 7}},
 		{"0001", "size:2", newEnum(1)},
 		{"0100000001", "size:5", newEnum(0x100000001)},
+		{"ffffffffffffffff", "size:8", newEnum(0xffffffffffffffff)},
 		{"12", "maxval:18", newEnum(18)},
 		// Note that maxval is just used to give enum size; it's not policed
 		{"20", "maxval:18", newEnum(32)},
@@ -287,6 +335,8 @@ func TestUnmarshalWithParamsFailures(t *testing.T) {
 		{"0001", "minlen:0,maxlen:256", &[]byte{0x0a, 0x0b}, "truncated"},
 		{"020a", "minlen:0", &[]byte{0x0a, 0x0b}, "unknown size"},
 		{"020a", "", &[]byte{0x0a, 0x0b}, "no field size information"},
+		{"ffffffffffffffff0a", "minlen:0,maxlen:18446744073709551615", &[]byte{0x0a}, "truncated"},
+		{"ffffff0a0b", "minlen:0,maxlen:16777215", &[]byte{0x0a, 0x0b}, "truncated"},
 		{"020a0b", "", &testInvalidFieldTag{}, "range inverted"},
 		{"020a0b01010102030400", "",
 			&testStruct{Data: []byte{0xa, 0xb}, IntVal: 0x101, Other: [4]byte{1, 2, 3, 4}, Enum: 17}, "truncated"},
@@ -297,6 +347,9 @@ func TestUnmarshalWithParamsFailures(t *testing.T) {
 		{"000007", "", &testChoiceNotPointer{Which: 0, Val: 7}, "choice field not a pointer type"},
 		{"05010102020303", "", &testNonByteSlice{Vals: []uint16{0x101, 0x202, 0x303}}, "truncated"},
 		{"0101", "size:2", newNonEnumAlias(0x0102), "unsupported type"},
+		{"0304", "fixed:3", &[]byte{3, 4, 5}, "truncated fixed-length vector"},
+		{"010203", "", &testFixedMismatch{}, "does not match fixed length"},
+		{"00010002", "", &testFixedNonByte{}, "fixed length for non-byte slice"},
 		{"0403010203", "",
 			&DigitallySigned{
 				Algorithm: SignatureAndHashAlgorithm{Hash: SHA256, Signature: ECDSA},
@@ -344,6 +397,10 @@ func TestMarshalWithParamsFailures(t *testing.T) {
 		{testNonByteSlice{Vals: []uint16{1, 2, 3, 4}}, "", "too large"},
 		{testSliceOfStructs{[]testVariant{{Which: 3}}}, "", "unhandled value for selector"},
 		{nonEnumAlias(0x0102), "", "unsupported type"},
+		{[]byte{1, 2}, "fixed:3", "slice length 2 does not match fixed length 3"},
+		{[2]byte{1, 2}, "fixed:3", "array length 2 does not match fixed length 3"},
+		{testFixed{Hash: [4]byte{1, 2, 3, 4}, ID: []byte{5}}, "", "slice length 1 does not match"},
+		{testFixedNonByte{Vals: []uint16{1, 2}}, "", "fixed length for non-byte slice"},
 	}
 	for _, test := range tests {
 		if data, err := MarshalWithParams(test.item, test.params); err == nil {
@@ -353,3 +410,68 @@ func TestMarshalWithParamsFailures(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalUnmarshal24BitBoundary(t *testing.T) {
+	// Each chain entry carries its own 3-byte length prefix, so split
+	// maxUint24 bytes of chain between two entries.
+	firstLen := 1 << 23
+	for _, test := range []struct {
+		name    string
+		item    interface{}
+		wantLen int // Length of the marshalled vector, excluding its prefix.
+		errstr  string
+	}{
+		{name: "bytes-at-limit", item: &testLargeVector{Data: make([]byte, maxUint24)}, wantLen: maxUint24},
+		{name: "bytes-over-limit", item: &testLargeVector{Data: make([]byte, maxUint24+1)}, errstr: "too large for maximum 16777215"},
+		{
+			name: "chain-at-limit",
+			item: &testLargeChain{Entries: []testLargeInner{
+				{Data: make([]byte, firstLen)},
+				{Data: make([]byte, maxUint24-firstLen-6)},
+			}},
+			wantLen: maxUint24,
+		},
+		{
+			name: "chain-over-limit",
+			item: &testLargeChain{Entries: []testLargeInner{
+				{Data: make([]byte, firstLen)},
+				{Data: make([]byte, maxUint24-firstLen-5)},
+			}},
+			errstr: "too large for maximum 16777215",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := Marshal(reflect.ValueOf(test.item).Elem().Interface())
+			if test.errstr != "" {
+				if err == nil {
+					t.Fatalf("Marshal()=%d bytes,nil; want error %q", len(data), test.errstr)
+				}
+				if !strings.Contains(err.Error(), test.errstr) {
+					t.Fatalf("Marshal()=nil,%q; want error %q", err.Error(), test.errstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Marshal()=nil,%q; want success", err.Error())
+			}
+			if got, want := len(data), 3+test.wantLen; got != want {
+				t.Fatalf("Marshal() gave %d bytes; want %d", got, want)
+			}
+			if got, want := hex.EncodeToString(data[:3]), "ffffff"; got != want {
+				t.Errorf("Marshal() length prefix=%s; want %s", got, want)
+			}
+
+			got := reflect.New(reflect.TypeOf(test.item).Elem()).Interface()
+			rest, err := Unmarshal(data, got)
+			if err != nil {
+				t.Fatalf("Unmarshal()=nil,%q; want success", err.Error())
+			}
+			if len(rest) > 0 {
+				t.Errorf("Unmarshal() left %d bytes", len(rest))
+			}
+			if !reflect.DeepEqual(got, test.item) {
+				t.Error("Unmarshal(Marshal(item)) differs from item")
+			}
+		})
+	}
+}
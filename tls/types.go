@@ -18,6 +18,7 @@ import (
 	"crypto"
 	"crypto/dsa" //nolint:staticcheck
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"fmt"
 )
@@ -52,6 +53,9 @@ const (
 	SHA256 HashAlgorithm = 4
 	SHA384 HashAlgorithm = 5
 	SHA512 HashAlgorithm = 6
+	// Intrinsic is used for signature algorithms which do their own hashing
+	// of the signed data, such as Ed25519 (RFC 8422 s5.1.3).
+	Intrinsic HashAlgorithm = 8
 )
 
 func (h HashAlgorithm) String() string {
@@ -70,6 +74,8 @@ func (h HashAlgorithm) String() string {
 		return "SHA384"
 	case SHA512:
 		return "SHA512"
+	case Intrinsic:
+		return "Intrinsic"
 	default:
 		return fmt.Sprintf("UNKNOWN(%d)", h)
 	}
@@ -84,6 +90,9 @@ const (
 	RSA       SignatureAlgorithm = 1
 	DSA       SignatureAlgorithm = 2
 	ECDSA     SignatureAlgorithm = 3
+	// Ed25519 is from RFC 8422 s5.1.3, and is always used with the Intrinsic
+	// hash algorithm.
+	Ed25519 SignatureAlgorithm = 7
 )
 
 func (s SignatureAlgorithm) String() string {
@@ -96,13 +105,16 @@ func (s SignatureAlgorithm) String() string {
 		return "DSA"
 	case ECDSA:
 		return "ECDSA"
+	case Ed25519:
+		return "Ed25519"
 	default:
 		return fmt.Sprintf("UNKNOWN(%d)", s)
 	}
 }
 
 // SignatureAlgorithmFromPubKey returns the algorithm used for this public key.
-// ECDSA, RSA, and DSA keys are supported. Other key types will return Anonymous.
+// ECDSA, RSA, DSA and Ed25519 keys are supported. Other key types will return
+// Anonymous.
 func SignatureAlgorithmFromPubKey(k crypto.PublicKey) SignatureAlgorithm {
 	switch k.(type) {
 	case *ecdsa.PublicKey:
@@ -111,6 +123,8 @@ func SignatureAlgorithmFromPubKey(k crypto.PublicKey) SignatureAlgorithm {
 		return RSA
 	case *dsa.PublicKey:
 		return DSA
+	case ed25519.PublicKey:
+		return Ed25519
 	default:
 		return Anonymous
 	}
@@ -18,6 +18,7 @@ import (
 	"crypto"
 	"crypto/dsa" //nolint:staticcheck
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"testing"
 )
@@ -34,6 +35,7 @@ func TestHashAlgorithmString(t *testing.T) {
 		{SHA256, "SHA256"},
 		{SHA384, "SHA384"},
 		{SHA512, "SHA512"},
+		{Intrinsic, "Intrinsic"},
 		{99, "UNKNOWN(99)"},
 	}
 	for _, test := range tests {
@@ -52,6 +54,7 @@ func TestSignatureAlgorithmString(t *testing.T) {
 		{RSA, "RSA"},
 		{DSA, "DSA"},
 		{ECDSA, "ECDSA"},
+		{Ed25519, "Ed25519"},
 		{99, "UNKNOWN(99)"},
 	}
 	for _, test := range tests {
@@ -91,6 +94,7 @@ func TestSignatureAlgorithm(t *testing.T) {
 		{name: "ECDSA", key: new(ecdsa.PublicKey), want: ECDSA},
 		{name: "RSA", key: new(rsa.PublicKey), want: RSA},
 		{name: "DSA", key: new(dsa.PublicKey), want: DSA},
+		{name: "Ed25519", key: make(ed25519.PublicKey, ed25519.PublicKeySize), want: Ed25519},
 		{name: "Other", key: "foo", want: Anonymous},
 	} {
 		if got := SignatureAlgorithmFromPubKey(test.key); got != test.want {
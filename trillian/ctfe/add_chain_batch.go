@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"k8s.io/klog/v2"
+)
+
+// AddChainBatchPath is the path of the non-standard add-chain-batch entrypoint,
+// which is not part of RFC 6962, and so is kept out of the /ct/v1/ namespace.
+// It is only served if enabled by the MaxAddChainBatchSize instance option. It
+// only accepts chains of final certificates; there is no batch counterpart of
+// add-pre-chain.
+const AddChainBatchPath = "/ct/internal/add-chain-batch"
+
+// AddChainBatchName identifies the add-chain-batch entrypoint. It is not listed
+// in Entrypoints because it is non-standard and optional.
+const AddChainBatchName = EntrypointName("AddChainBatch")
+
+// defaultAddChainBatchConcurrency is the number of concurrent Trillian
+// submissions per add-chain-batch request, unless configured otherwise.
+const defaultAddChainBatchConcurrency = 8
+
+// maxAddChainBatchBytesPerChain bounds the size of an add-chain-batch request
+// body, which may be at most this many bytes per chain allowed in a batch.
+const maxAddChainBatchBytesPerChain = 128 << 10
+
+// AddChainBatchRequest is the JSON request body of the add-chain-batch
+// entrypoint. Each of the chains is processed as if it was sent to add-chain.
+type AddChainBatchRequest struct {
+	Chains []ct.AddChainRequest `json:"chains"`
+}
+
+// AddChainBatchResult is the outcome of submitting one of the chains of an
+// add-chain-batch request.
+type AddChainBatchResult struct {
+	// Status is the HTTP status code that add-chain would have returned.
+	Status int `json:"status"`
+	// SCT is set iff Status is 200 OK.
+	SCT *ct.AddChainResponse `json:"sct,omitempty"`
+	// Error describes the reason of the failure if Status is not 200 OK.
+	Error string `json:"error,omitempty"`
+}
+
+// AddChainBatchResponse is the JSON response body of the add-chain-batch
+// entrypoint. Results are in the same order as the chains in the request.
+type AddChainBatchResponse struct {
+	Results []AddChainBatchResult `json:"results"`
+}
+
+// addChainBatch handles the add-chain-batch entrypoint. The request fails as a
+// whole only if it is malformed, otherwise the outcome of each submission is
+// reported in the corresponding AddChainBatchResult. Each submission is also
+// reported to the RequestLog as a request of its own, with its own status.
+func addChainBatch(ctx context.Context, li *logInfo, w http.ResponseWriter, r *http.Request) (int, error) {
+	limit := int64(li.instanceOpts.MaxAddChainBatchSize) * maxAddChainBatchBytesPerChain
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to read add-chain-batch body: %s", err)
+	}
+	var req AddChainBatchRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to parse add-chain-batch body: %s", err)
+	}
+	if len(req.Chains) == 0 {
+		return http.StatusBadRequest, errors.New("add-chain-batch request has no chains")
+	}
+	if got, limit := len(req.Chains), li.instanceOpts.MaxAddChainBatchSize; got > limit {
+		return http.StatusBadRequest, fmt.Errorf("add-chain-batch request has %d chains, the limit is %d", got, limit)
+	}
+
+	concurrency := li.instanceOpts.AddChainBatchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultAddChainBatchConcurrency
+	}
+	// Don't let a single batch take up all the submission slots.
+	if n := li.instanceOpts.MaxConcurrentSubmissions; n > 0 && concurrency > n {
+		concurrency = n
+	}
+	sem := make(chan struct{}, concurrency)
+	results := make([]AddChainBatchResult, len(req.Chains))
+
+	var wg sync.WaitGroup
+	for i, chainReq := range req.Chains {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chainReq ct.AddChainRequest) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = li.addChainBatchEntry(ctx, r, chainReq)
+		}(i, chainReq)
+	}
+	wg.Wait()
+
+	w.Header().Set(contentTypeHeader, contentTypeJSON)
+	jsonData, err := json.Marshal(&AddChainBatchResponse{Results: results})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to marshal add-chain-batch resp: %s", err)
+	}
+	if _, err := w.Write(jsonData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to write add-chain-batch resp: %s", err)
+	}
+	klog.V(3).Infof("%s: %s <= %d results", li.LogPrefix, AddChainBatchName, len(results))
+
+	return http.StatusOK, nil
+}
+
+// addChainBatchEntry submits a single chain of an add-chain-batch request, and
+// reports it to the RequestLog in a scope of its own.
+func (li *logInfo) addChainBatchEntry(ctx context.Context, r *http.Request, chainReq ct.AddChainRequest) AddChainBatchResult {
+	ctx = li.RequestLog.Start(ctx)
+	li.RequestLog.LogPrefix(ctx, li.LogPrefix)
+	res := li.submitChainBatchEntry(ctx, r, chainReq)
+	li.RequestLog.Status(ctx, res.Status)
+	return res
+}
+
+// submitChainBatchEntry does the work of addChainBatchEntry.
+func (li *logInfo) submitChainBatchEntry(ctx context.Context, r *http.Request, chainReq ct.AddChainRequest) AddChainBatchResult {
+	if len(chainReq.Chain) == 0 {
+		return AddChainBatchResult{Status: http.StatusBadRequest, Error: "cert chain was empty"}
+	}
+	sct, statusCode, err := submitChain(ctx, li, r, chainReq, false)
+	if err == nil {
+		var rsp *ct.AddChainResponse
+		if rsp, err = buildAddChainResponse(sct, li.signer); err == nil {
+			return AddChainBatchResult{Status: http.StatusOK, SCT: rsp}
+		}
+		statusCode = http.StatusInternalServerError
+	}
+
+	klog.V(1).Infof("%s: %s entry failed: %v", li.LogPrefix, AddChainBatchName, err)
+	res := AddChainBatchResult{Status: statusCode, Error: http.StatusText(statusCode)}
+	if !li.instanceOpts.MaskInternalErrors || statusCode != http.StatusInternalServerError {
+		res.Error = err.Error()
+	}
+	return res
+}
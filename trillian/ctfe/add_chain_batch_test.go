@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	cttestonly "github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/testonly"
+)
+
+func TestAddChainBatchHandlers(t *testing.T) {
+	const path = "/test/ct/internal/add-chain-batch"
+	info := setupTest(t, nil, nil)
+	defer info.mockCtrl.Finish()
+
+	if h, ok := info.li.Handlers("test")[path]; ok {
+		t.Errorf("Handlers()[%q]=%+v; want none when disabled", path, h)
+	}
+	info.li.instanceOpts.MaxAddChainBatchSize = 10
+	handlers := info.li.Handlers("test")
+	if h, ok := handlers[path]; !ok {
+		t.Errorf("Handlers()[%q] missing when enabled", path)
+	} else if h.Name != AddChainBatchName {
+		t.Errorf("Handlers()[%q].Name=%q; want %q", path, h.Name, AddChainBatchName)
+	}
+	if h, ok := handlers["/test/ct/v1/add-chain-batch"]; ok {
+		t.Errorf("Handlers() serves add-chain-batch under /ct/v1/: %+v", h)
+	}
+}
+
+func TestAddChainBatch(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+	info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+	defer info.mockCtrl.Finish()
+	info.li.instanceOpts.MaxAddChainBatchSize = 3
+	info.li.instanceOpts.AddChainBatchConcurrency = 2
+
+	good := []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM, cttestonly.FakeCACertPEM}
+	pool := loadCertsIntoPoolOrDie(t, good)
+	merkleLeaf, err := ct.MerkleTreeLeafFromChain(pool.RawCertificates(), ct.X509LogEntryType, fakeTimeMillis)
+	if err != nil {
+		t.Fatalf("MerkleTreeLeafFromChain(): %v", err)
+	}
+	leaf := logLeafForCert(t, pool.RawCertificates(), merkleLeaf, false)
+	rsp := trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{Leaf: leaf, Status: status.New(codes.OK, "ok").Proto()}}
+	req := &trillian.QueueLeafRequest{LogId: 0x42, Leaf: leaf}
+	info.client.EXPECT().QueueLeaf(deadlineMatcher(), cmpMatcher{req}).Return(&rsp, nil).Times(2)
+
+	chainOf := func(pems ...string) ct.AddChainRequest {
+		var req ct.AddChainRequest
+		for _, c := range loadCertsIntoPoolOrDie(t, pems).RawCertificates() {
+			req.Chain = append(req.Chain, c.Raw)
+		}
+		return req
+	}
+
+	tooLarge := `{"chains": [], "padding": "` + strings.Repeat("a", 3*maxAddChainBatchBytesPerChain) + `"}`
+
+	for _, tc := range []struct {
+		desc       string
+		body       io.Reader
+		want       int
+		wantStatus []int
+		wantLogged []int // Statuses passed to the RequestLog, sorted.
+	}{
+		{desc: "malformed", body: strings.NewReader("{ not json"), want: http.StatusBadRequest},
+		{desc: "too-large", body: strings.NewReader(tooLarge), want: http.StatusBadRequest},
+		{desc: "no-chains", body: strings.NewReader(`{"chains": []}`), want: http.StatusBadRequest},
+		{
+			desc: "too-many-chains",
+			body: batchBody(t, chainOf(good...), chainOf(good...), chainOf(good...), chainOf(good...)),
+			want: http.StatusBadRequest,
+		},
+		{
+			desc:       "mixed",
+			body:       batchBody(t, chainOf(good...), chainOf(cttestonly.LeafSignedByFakeIntermediateCertPEM), chainOf(good...)),
+			want:       http.StatusOK,
+			wantStatus: []int{http.StatusOK, http.StatusBadRequest, http.StatusOK},
+			// One record for each chain, and one for the whole request.
+			wantLogged: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusBadRequest},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var logOut bytes.Buffer
+			info.li.RequestLog = NewJSONRequestLog(&logOut, fakeTimeSource)
+			handler := AppHandler{Info: info.li, Handler: addChainBatch, Name: AddChainBatchName, Method: http.MethodPost}
+			recorder := makeAddChainRequestInternal(t, handler, "add-chain-batch", tc.body)
+			if recorder.Code != tc.want {
+				t.Fatalf("addChainBatch()=%d (body:%v); want %d", recorder.Code, recorder.Body, tc.want)
+			}
+			if tc.want != http.StatusOK {
+				return
+			}
+			var resp AddChainBatchResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&resp); err != nil {
+				t.Fatalf("json.Decode(): %v", err)
+			}
+			if got, want := len(resp.Results), len(tc.wantStatus); got != want {
+				t.Fatalf("len(Results)=%d; want %d", got, want)
+			}
+			for i, res := range resp.Results {
+				if got, want := res.Status, tc.wantStatus[i]; got != want {
+					t.Errorf("Results[%d].Status=%d; want %d (error: %q)", i, got, want, res.Error)
+				}
+				if got, want := res.SCT != nil, res.Status == http.StatusOK; got != want {
+					t.Errorf("Results[%d] has SCT: %v; want %v", i, got, want)
+				}
+				if res.SCT != nil && !bytes.Equal(res.SCT.ID, demoLogID[:]) {
+					t.Errorf("Results[%d].SCT.ID=%x; want %x", i, res.SCT.ID, demoLogID[:])
+				}
+			}
+
+			var logged []int
+			for dec := json.NewDecoder(&logOut); dec.More(); {
+				var record JSONRequestRecord
+				if err := dec.Decode(&record); err != nil {
+					t.Fatalf("failed to decode request log: %v", err)
+				}
+				logged = append(logged, record.Status)
+			}
+			sort.Ints(logged)
+			if got, want := fmt.Sprint(logged), fmt.Sprint(tc.wantLogged); got != want {
+				t.Errorf("RequestLog statuses=%s; want %s", got, want)
+			}
+		})
+	}
+}
+
+func batchBody(t *testing.T, chains ...ct.AddChainRequest) io.Reader {
+	t.Helper()
+	data, err := json.Marshal(AddChainBatchRequest{Chains: chains})
+	if err != nil {
+		t.Fatalf("json.Marshal(): %v", err)
+	}
+	return bytes.NewReader(data)
+}
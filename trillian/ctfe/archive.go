@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"crypto/sha256"
+	"strconv"
+
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"k8s.io/klog/v2"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+)
+
+// ChainArchiver records accepted certificate chains outside of the log, e.g.
+// in object storage for retention or analytics.
+type ChainArchiver interface {
+	// Archive stores the given chain, which starts with the submitted
+	// (pre-)certificate and ends with the root it chains to. The chainHash is
+	// the SHA-256 hash of the ASN.1 encoding of the chain, and can be used as
+	// a key for deduplication.
+	Archive(ctx context.Context, chainHash []byte, chain []ct.ASN1Cert) error
+}
+
+// archiveChain passes the chain to the log's ChainArchiver, if any. The
+// archiving happens asynchronously, and its failures do not affect the
+// submission.
+func (li *logInfo) archiveChain(ctx context.Context, chain []*x509.Certificate) {
+	archiver := li.instanceOpts.ChainArchiver
+	if archiver == nil {
+		return
+	}
+	label := strconv.FormatInt(li.logID, 10)
+	raw := extractRawCerts(chain)
+	data, err := asn1.Marshal(raw)
+	if err != nil {
+		klog.Warningf("%s: failed to marshal chain for archiving: %v", li.LogPrefix, err)
+		chainArchiveFailures.Inc(label)
+		return
+	}
+	hash := sha256.Sum256(data)
+
+	// The archiving may outlive the request, so must not be cancelled with it.
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if li.instanceOpts.Deadline > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, li.instanceOpts.Deadline)
+			defer cancel()
+		}
+		if err := archiver.Archive(ctx, hash[:], raw); err != nil {
+			klog.Warningf("%s: failed to archive chain %x: %v", li.LogPrefix, hash, err)
+			chainArchiveFailures.Inc(label)
+		}
+	}()
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	cttestonly "github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/testonly"
+)
+
+// archivedChain holds the arguments of a ChainArchiver.Archive call.
+type archivedChain struct {
+	hash  []byte
+	chain []ct.ASN1Cert
+}
+
+// fakeChainArchiver is a ChainArchiver which reports the archived chains on a
+// channel, and returns a fixed error.
+type fakeChainArchiver struct {
+	archived chan archivedChain
+	err      error
+}
+
+func (a *fakeChainArchiver) Archive(ctx context.Context, chainHash []byte, chain []ct.ASN1Cert) error {
+	a.archived <- archivedChain{hash: chainHash, chain: chain}
+	return a.err
+}
+
+func TestAddChainArchiver(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		err  error
+	}{
+		{desc: "success"},
+		{desc: "failure", err: errors.New("archive unavailable")},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			signer, err := setupSigner(fakeSignature)
+			if err != nil {
+				t.Fatalf("Failed to create test signer: %v", err)
+			}
+			info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+			defer info.mockCtrl.Finish()
+			archiver := &fakeChainArchiver{archived: make(chan archivedChain, 1), err: test.err}
+			info.li.instanceOpts.ChainArchiver = archiver
+
+			pool := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+			merkleLeaf, err := ct.MerkleTreeLeafFromChain(pool.RawCertificates(), ct.X509LogEntryType, fakeTimeMillis)
+			if err != nil {
+				t.Fatalf("Unexpected error signing SCT: %v", err)
+			}
+			fullChain := append(pool.RawCertificates(), info.roots.RawCertificates()[0])
+			leaf := logLeafForCert(t, fullChain, merkleLeaf, false)
+			rsp := trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{Leaf: leaf, Status: status.New(codes.OK, "ok").Proto()}}
+			req := &trillian.QueueLeafRequest{LogId: 0x42, Leaf: leaf}
+			info.client.EXPECT().QueueLeaf(deadlineMatcher(), cmpMatcher{req}).Return(&rsp, nil)
+
+			recorder := makeAddChainRequest(t, info.li, createJSONChain(t, *pool))
+			if got, want := recorder.Code, http.StatusOK; got != want {
+				t.Fatalf("addChain()=%d (body:%v); want %d", got, recorder.Body, want)
+			}
+
+			var got archivedChain
+			select {
+			case got = <-archiver.archived:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Chain was not archived")
+			}
+			want := extractRawCerts(fullChain)
+			if len(got.chain) != len(want) {
+				t.Fatalf("Archived chain of %d certs, want %d", len(got.chain), len(want))
+			}
+			for i := range want {
+				if !bytes.Equal(got.chain[i].Data, want[i].Data) {
+					t.Errorf("Archived chain[%d] differs from submitted chain", i)
+				}
+			}
+			data, err := asn1.Marshal(want)
+			if err != nil {
+				t.Fatalf("asn1.Marshal(): %v", err)
+			}
+			if wantHash := sha256.Sum256(data); !bytes.Equal(got.hash, wantHash[:]) {
+				t.Errorf("Archived chain hash %x, want %x", got.hash, wantHash)
+			}
+		})
+	}
+}
@@ -38,6 +38,9 @@ const (
 type Option struct {
 	Size int
 	TTL  time.Duration
+	// OnEvict, if not nil, is called whenever an entry is evicted from the
+	// cache, either to make room for a new one or because it has expired.
+	OnEvict func()
 }
 
 // IssuanceChainCache is an interface which allows CTFE binaries to use different cache implementations for issuance chains.
@@ -61,7 +64,7 @@ func NewIssuanceChainCache(_ context.Context, cacheType Type, option Option) (Is
 		if option.TTL < 0*time.Second {
 			return nil, errors.New("invalid cache_ttl flag")
 		}
-		return lru.NewIssuanceChainCache(lru.CacheOption{Size: option.Size, TTL: option.TTL}), nil
+		return lru.NewIssuanceChainCache(lru.CacheOption{Size: option.Size, TTL: option.TTL, OnEvict: option.OnEvict}), nil
 	}
 
 	return nil, errors.New("invalid cache_type flag")
@@ -23,8 +23,9 @@ import (
 )
 
 type CacheOption struct {
-	Size int
-	TTL  time.Duration
+	Size    int
+	TTL     time.Duration
+	OnEvict func()
 }
 
 type IssuanceChainCache struct {
@@ -33,7 +34,11 @@ type IssuanceChainCache struct {
 }
 
 func NewIssuanceChainCache(opt CacheOption) *IssuanceChainCache {
-	cache := expirable.NewLRU[string, []byte](int(opt.Size), nil, opt.TTL)
+	var onEvict expirable.EvictCallback[string, []byte]
+	if opt.OnEvict != nil {
+		onEvict = func(string, []byte) { opt.OnEvict() }
+	}
+	cache := expirable.NewLRU[string, []byte](int(opt.Size), onEvict, opt.TTL)
 	return &IssuanceChainCache{
 		opt:   opt,
 		cache: cache,
@@ -50,6 +50,20 @@ func TestLRUIssuanceChainCache(t *testing.T) {
 	}
 }
 
+func TestLRUIssuanceChainCacheOnEvict(t *testing.T) {
+	evictions := 0
+	cache := NewIssuanceChainCache(CacheOption{Size: 1, OnEvict: func() { evictions++ }})
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := cache.Set(context.Background(), []byte(key), []byte(key)); err != nil {
+			t.Errorf("cache.Set: %v", err)
+		}
+	}
+	if got, want := evictions, 2; got != want {
+		t.Errorf("evictions=%d, want %d", got, want)
+	}
+}
+
 func setupTestData(t *testing.T, filenames ...string) map[string][]byte {
 	t.Helper()
 
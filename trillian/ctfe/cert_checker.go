@@ -29,6 +29,48 @@ var (
 	ErrNoRFCCompliantPathFound = errors.New("no RFC compliant path to root found when trying to validate chain")
 )
 
+// ValidationFailureReason identifies the check that a submitted chain failed.
+type ValidationFailureReason string
+
+// Reasons for a chain to fail validation. There is no reason for a
+// certificate which is not yet valid: chains are verified with time checks
+// disabled, so the CTFE never rejects such certificates. With
+// reject_unexpired, a certificate which is not yet valid is unexpired too.
+const (
+	ReasonUnparseable           = ValidationFailureReason("unparseable_certificate")
+	ReasonNotAfterOutOfRange    = ValidationFailureReason("not_after_out_of_range")
+	ReasonNotCA                 = ValidationFailureReason("not_ca")
+	ReasonExpired               = ValidationFailureReason("expired")
+	ReasonUnexpired             = ValidationFailureReason("unexpired")
+	ReasonRejectedExtension     = ValidationFailureReason("rejected_extension")
+	ReasonEKUMismatch           = ValidationFailureReason("eku_mismatch")
+	ReasonKeyPolicy             = ValidationFailureReason("key_policy")
+	ReasonUnknownRoot           = ValidationFailureReason("unknown_root")
+	ReasonInvalidChain          = ValidationFailureReason("invalid_chain")
+	ReasonNoCompliantPath       = ValidationFailureReason("no_compliant_path")
+	ReasonPrecertMismatch       = ValidationFailureReason("precert_mismatch")
+	ReasonInvalidPrecertificate = ValidationFailureReason("invalid_precertificate")
+)
+
+// ValidationError is returned when a submitted chain fails validation. It
+// records which check failed, along with the underlying error.
+type ValidationError struct {
+	Reason ValidationFailureReason
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func validationError(reason ValidationFailureReason, err error) error {
+	return &ValidationError{Reason: reason, Err: err}
+}
+
 // IsPrecertificate tests if a certificate is a pre-certificate as defined in CT.
 // An error is returned if the CT extension is present but is not ASN.1 NULL as defined
 // by the spec.
@@ -59,7 +101,7 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 	for i, certBytes := range rawChain {
 		cert, err := x509.ParseCertificate(certBytes)
 		if x509.IsFatal(err) {
-			return nil, err
+			return nil, validationError(ReasonUnparseable, err)
 		}
 
 		chain = append(chain, cert)
@@ -76,14 +118,14 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 
 	// Check whether the expiry date of the cert is within the acceptable range.
 	if naStart != nil && cert.NotAfter.Before(*naStart) {
-		return nil, fmt.Errorf("certificate NotAfter (%v) < %v", cert.NotAfter, *naStart)
+		return nil, validationError(ReasonNotAfterOutOfRange, fmt.Errorf("certificate NotAfter (%v) < %v", cert.NotAfter, *naStart))
 	}
 	if naLimit != nil && !cert.NotAfter.Before(*naLimit) {
-		return nil, fmt.Errorf("certificate NotAfter (%v) >= %v", cert.NotAfter, *naLimit)
+		return nil, validationError(ReasonNotAfterOutOfRange, fmt.Errorf("certificate NotAfter (%v) >= %v", cert.NotAfter, *naLimit))
 	}
 
 	if validationOpts.acceptOnlyCA && !cert.IsCA {
-		return nil, errors.New("only certificates with CA bit set are accepted")
+		return nil, validationError(ReasonNotCA, errors.New("only certificates with CA bit set are accepted"))
 	}
 
 	now := validationOpts.currentTime
@@ -92,10 +134,10 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 	}
 	expired := now.After(cert.NotAfter)
 	if validationOpts.rejectExpired && expired {
-		return nil, errors.New("rejecting expired certificate")
+		return nil, validationError(ReasonExpired, errors.New("rejecting expired certificate"))
 	}
 	if validationOpts.rejectUnexpired && !expired {
-		return nil, errors.New("rejecting unexpired certificate")
+		return nil, validationError(ReasonUnexpired, errors.New("rejecting unexpired certificate"))
 	}
 
 	// Check for unwanted extension types, if required.
@@ -109,7 +151,7 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 		for idx, ext := range cert.Extensions {
 			extOid := ext.Id.String()
 			if _, ok := badIDs[extOid]; ok {
-				return nil, fmt.Errorf("rejecting certificate containing extension %v at index %d", extOid, idx)
+				return nil, validationError(ReasonRejectedExtension, fmt.Errorf("rejecting certificate containing extension %v at index %d", extOid, idx))
 			}
 		}
 	}
@@ -129,10 +171,14 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 			}
 		}
 		if !good {
-			return nil, fmt.Errorf("rejecting certificate without EKU in %v", validationOpts.extKeyUsages)
+			return nil, validationError(ReasonEKUMismatch, fmt.Errorf("rejecting certificate without EKU in %v", validationOpts.extKeyUsages))
 		}
 	}
 
+	if err := validationOpts.keyPolicy.Check(cert.PublicKey); err != nil {
+		return nil, validationError(ReasonKeyPolicy, fmt.Errorf("rejecting certificate with disallowed public key: %v", err))
+	}
+
 	// We can now do the verification.  Use fairly lax options for verification, as
 	// CT is intended to observe certificates rather than police them.
 	verifyOpts := x509.VerifyOptions{
@@ -160,11 +206,15 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 
 	verifiedChains, err := cert.Verify(verifyOpts)
 	if err != nil {
-		return nil, err
+		var unknownAuthority x509.UnknownAuthorityError
+		if errors.As(err, &unknownAuthority) {
+			return nil, validationError(ReasonUnknownRoot, err)
+		}
+		return nil, validationError(ReasonInvalidChain, err)
 	}
 
 	if len(verifiedChains) == 0 {
-		return nil, errors.New("no path to root found when trying to validate chains")
+		return nil, validationError(ReasonUnknownRoot, errors.New("no path to root found when trying to validate chains"))
 	}
 
 	// Verify might have found multiple paths to roots. Now we check that we have a path that
@@ -176,7 +226,7 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 		}
 	}
 
-	return nil, ErrNoRFCCompliantPathFound
+	return nil, validationError(ReasonNoCompliantPath, ErrNoRFCCompliantPathFound)
 }
 
 func chainsEquivalent(inChain []*x509.Certificate, verifiedChain []*x509.Certificate) bool {
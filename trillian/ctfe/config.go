@@ -42,6 +42,7 @@ type ValidatedLogConfig struct {
 	NotAfterStart                        *time.Time
 	NotAfterLimit                        *time.Time
 	FrozenSTH                            *ct.SignedTreeHead
+	KeyPolicy                            *KeyPolicy
 	CTFEStorageConnectionString          string
 	ExtraDataIssuanceChainStorageBackend configpb.LogConfig_IssuanceChainStorageBackend
 }
@@ -168,6 +169,11 @@ func ValidateLogConfig(cfg *configpb.LogConfig) (*ValidatedLogConfig, error) {
 		}
 	}
 
+	var err error
+	if vCfg.KeyPolicy, err = keyPolicyFromConfig(cfg); err != nil {
+		return nil, fmt.Errorf("invalid key policy: %v", err)
+	}
+
 	// Validate the time interval.
 	start, limit := cfg.NotAfterStart, cfg.NotAfterLimit
 	if start != nil {
@@ -153,6 +153,24 @@ func TestValidateLogConfig(t *testing.T) {
 				PrivateKey:      privKey,
 			},
 		},
+		{
+			desc:    "unknown-ecdsa-curve",
+			wantErr: "unknown ECDSA curve",
+			cfg: &configpb.LogConfig{
+				LogId:              123,
+				PrivateKey:         privKey,
+				AllowedEcdsaCurves: []string{"P-256", "P-257"},
+			},
+		},
+		{
+			desc:    "negative-min-rsa-key-bits",
+			wantErr: "negative min_rsa_key_bits",
+			cfg: &configpb.LogConfig{
+				LogId:         123,
+				PrivateKey:    privKey,
+				MinRsaKeyBits: -1,
+			},
+		},
 		{
 			desc:    "unknown-ext-key-usage-1",
 			wantErr: "unknown extended key usage",
@@ -36,6 +36,7 @@ import (
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe"
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/cache"
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/configpb"
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/util"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/keys/der"
@@ -81,12 +82,22 @@ var (
 	quotaIntermediate       = flag.Bool("quota_intermediate", true, "Enable requesting of quota for intermediate certificates in submitted chains")
 	nonFreshSubmissionAge   = flag.Duration("non_fresh_submission_age", time.Hour*24, "Maximum age of a fresh submission")
 	nonFreshSubmissionBurst = flag.Int("non_fresh_submission_burst", 1, "Maximum burst size when rate-limiting non-fresh submissions")
+	issuerRateLimit         = flag.Float64("issuer_rate_limit", 0, "Max rate in submissions/sec accepted from any one issuing intermediate (0 to disable)")
+	issuerRateBurst         = flag.Int("issuer_rate_burst", 1, "Maximum burst size when rate-limiting submissions per issuer")
 	nonFreshSubmissionLimit = flag.String("non_fresh_submission_limit", "", "Maximum rate at which non-fresh submissions will be accepted (e.g., \"30/1s\"; or \"\" to disable)")
 	handlerPrefix           = flag.String("handler_prefix", "", "If set e.g. to '/logs' will prefix all handlers that don't define a custom prefix")
 	pkcs11ModulePath        = flag.String("pkcs11_module_path", "", "Path to the PKCS#11 module to use for keys that use the PKCS#11 interface")
 	cacheType               = flag.String("cache_type", "noop", "Supported cache type: noop, lru (Default: noop)")
 	cacheSize               = flag.Int("cache_size", -1, "Size parameter set to 0 makes cache of unlimited size")
 	cacheTTL                = flag.Duration("cache_ttl", -1*time.Second, "Providing 0 TTL turns expiring off")
+	redactRequestLogCerts   = flag.Bool("request_log_redact_certs", false, "Log only fingerprints of submitted certificates in the request log, rather than full DER")
+	requestLogJSON          = flag.Bool("request_log_json", false, "Write one JSON object per request to stderr as the request log, instead of logging request details via klog")
+	requestLogChainsOnError = flag.Bool("request_log_chains_on_error", false, "Pass submitted chains to the request log only for requests that fail")
+	addChainBatchSize       = flag.Int("add_chain_batch_size", 0, "Max number of chains in a request to the non-standard add-chain-batch endpoint (0 to disable the endpoint)")
+	addChainBatchParallel   = flag.Int("add_chain_batch_parallel", 8, "Max number of concurrent backend submissions per add-chain-batch request")
+	getEntriesTypeFilter    = flag.Bool("get_entries_type_filter", false, "Allow the non-standard entry_type parameter of get-entries, which filters the returned entries by type")
+	healthzMaxSTHAge        = flag.Duration("healthz_max_sth_age", 10*time.Minute, "Max time since the last successful get-sth before a log's healthz endpoint reports it unhealthy")
+	maxConcurrentSubmits    = flag.Int("max_concurrent_submissions", 0, "Max number of add-chain and add-pre-chain submissions in flight to the backend, beyond which 503 is returned (0 for no limit)")
 	trillianTLSCACertFile   = flag.String("trillian_tls_ca_cert_file", "", "CA certificate file to use for secure connections with Trillian server")
 )
 
@@ -387,21 +398,43 @@ func awaitSignal(doneFn func()) {
 	doneFn()
 }
 
+// jsonRequestLog is shared by all log instances, so that records written by
+// different instances are not interleaved.
+var jsonRequestLog = sync.OnceValue(func() *ctfe.JSONRequestLog {
+	return ctfe.NewJSONRequestLog(os.Stderr, util.SystemTimeSource{})
+})
+
 func setupAndRegister(ctx context.Context, client trillian.TrillianLogClient, deadline time.Duration, cfg *configpb.LogConfig, mux *http.ServeMux, globalHandlerPrefix string, maskInternalErrors bool, cacheType cache.Type, cacheOption cache.Option) (*ctfe.Instance, error) {
 	vCfg, err := ctfe.ValidateLogConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	var requestLog ctfe.RequestLog = new(ctfe.DefaultRequestLog)
+	if *requestLogJSON {
+		requestLog = jsonRequestLog()
+	}
+
 	opts := ctfe.InstanceOptions{
-		Validated:          vCfg,
-		Client:             client,
-		Deadline:           deadline,
-		MetricFactory:      prometheus.MetricFactory{},
-		RequestLog:         new(ctfe.DefaultRequestLog),
-		MaskInternalErrors: maskInternalErrors,
-		CacheType:          cacheType,
-		CacheOption:        cacheOption,
+		Validated:             vCfg,
+		Client:                client,
+		Deadline:              deadline,
+		MetricFactory:         prometheus.MetricFactory{},
+		RequestLog:            requestLog,
+		RedactRequestLogCerts: *redactRequestLogCerts,
+		MaskInternalErrors:    maskInternalErrors,
+		CacheType:             cacheType,
+		CacheOption:           cacheOption,
+
+		AllowGetEntriesTypeFilter: *getEntriesTypeFilter,
+		RequestLogChainsOnError:   *requestLogChainsOnError,
+		MaxConcurrentSubmissions:  *maxConcurrentSubmits,
+		HealthzMaxSTHAge:          *healthzMaxSTHAge,
+	}
+	if *addChainBatchSize > 0 {
+		klog.Infof("Enabling add-chain-batch endpoint for up to %d chains", *addChainBatchSize)
+		opts.MaxAddChainBatchSize = *addChainBatchSize
+		opts.AddChainBatchConcurrency = *addChainBatchParallel
 	}
 	if *quotaRemote {
 		klog.Info("Enabling quota for requesting IP")
@@ -430,6 +463,11 @@ func setupAndRegister(ctx context.Context, client trillian.TrillianLogClient, de
 			klog.Infof("Enabling rate limiting at %f req/sec for non-fresh submissions", opts.NonFreshSubmissionLimiter.Limit())
 		}
 	}
+	if *issuerRateLimit > 0 {
+		opts.IssuerRateLimit = rate.Limit(*issuerRateLimit)
+		opts.IssuerRateBurst = *issuerRateBurst
+		klog.Infof("Enabling rate limiting at %f req/sec per issuer", *issuerRateLimit)
+	}
 
 	// Full handler pattern will be of the form "/logs/yyz/ct/v1/add-chain", where "/logs" is the
 	// HandlerPrefix and "yyz" is the c.Prefix for this particular log. Use the default
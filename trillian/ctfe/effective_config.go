@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ConfigPath is the path of the per-log configuration introspection
+// entrypoint, relative to the log prefix.
+const ConfigPath = "/config"
+
+// ConfigName identifies the configuration introspection entrypoint. It is not
+// listed in Entrypoints because it is not part of RFC 6962.
+const ConfigName = EntrypointName("Config")
+
+// EffectiveConfig is a summary of the configuration that a log instance is
+// running with. It deliberately omits secrets, such as the private key and the
+// CTFE storage connection string.
+type EffectiveConfig struct {
+	LogID      int64  `json:"log_id"`
+	Prefix     string `json:"prefix"`
+	IsMirror   bool   `json:"is_mirror"`
+	IsReadonly bool   `json:"is_readonly"`
+	// RootsCount is the number of trusted roots currently loaded.
+	RootsCount         int        `json:"roots_count"`
+	RejectExpired      bool       `json:"reject_expired"`
+	RejectUnexpired    bool       `json:"reject_unexpired"`
+	NotAfterStart      *time.Time `json:"not_after_start,omitempty"`
+	NotAfterLimit      *time.Time `json:"not_after_limit,omitempty"`
+	AcceptOnlyCA       bool       `json:"accept_only_ca"`
+	ExtKeyUsages       []string   `json:"ext_key_usages,omitempty"`
+	RejectExtensions   []string   `json:"reject_extensions,omitempty"`
+	MinRSAKeyBits      int        `json:"min_rsa_key_bits,omitempty"`
+	AllowedECDSACurves []string   `json:"allowed_ecdsa_curves,omitempty"`
+	RejectEd25519      bool       `json:"reject_ed25519"`
+	MaxMergeDelaySec   int32      `json:"max_merge_delay_sec"`
+	// FrozenTreeSize is the tree size of the frozen STH, if the log is frozen.
+	FrozenTreeSize              *uint64 `json:"frozen_tree_size,omitempty"`
+	IssuanceChainStorageBackend string  `json:"issuance_chain_storage_backend"`
+	CacheType                   string  `json:"cache_type"`
+}
+
+// effectiveConfig builds the EffectiveConfig for the log.
+func (li *logInfo) effectiveConfig() EffectiveConfig {
+	vCfg := li.instanceOpts.Validated
+	cfg := vCfg.Config
+	ec := EffectiveConfig{
+		LogID:                       cfg.LogId,
+		Prefix:                      cfg.Prefix,
+		IsMirror:                    cfg.IsMirror,
+		IsReadonly:                  cfg.IsReadonly,
+		RejectExpired:               cfg.RejectExpired,
+		RejectUnexpired:             cfg.RejectUnexpired,
+		NotAfterStart:               vCfg.NotAfterStart,
+		NotAfterLimit:               vCfg.NotAfterLimit,
+		AcceptOnlyCA:                cfg.AcceptOnlyCa,
+		ExtKeyUsages:                cfg.ExtKeyUsages,
+		RejectExtensions:            cfg.RejectExtensions,
+		MaxMergeDelaySec:            cfg.MaxMergeDelaySec,
+		IssuanceChainStorageBackend: cfg.ExtraDataIssuanceChainStorageBackend.String(),
+		CacheType:                   string(li.instanceOpts.CacheType),
+	}
+	if roots := li.getValidationOpts().trustedRoots; roots != nil {
+		ec.RootsCount = len(roots.RawCertificates())
+	}
+	if kp := vCfg.KeyPolicy; kp != nil {
+		ec.MinRSAKeyBits = kp.MinRSABits
+		ec.AllowedECDSACurves = kp.AllowedECDSACurves
+		ec.RejectEd25519 = kp.RejectEd25519
+	}
+	if sth := vCfg.FrozenSTH; sth != nil {
+		ec.FrozenTreeSize = &sth.TreeSize
+	}
+	return ec
+}
+
+// getConfig writes a JSON summary of the log's effective configuration.
+func getConfig(_ context.Context, li *logInfo, w http.ResponseWriter, _ *http.Request) (int, error) {
+	w.Header().Set(contentTypeHeader, contentTypeJSON)
+	if err := json.NewEncoder(w).Encode(li.effectiveConfig()); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to write config: %s", err)
+	}
+	return http.StatusOK, nil
+}
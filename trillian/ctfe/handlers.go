@@ -68,6 +68,8 @@ const (
 	getEntriesParamStart = "start"
 	// The name of the get-entries end parameter
 	getEntriesParamEnd = "end"
+	// The name of the non-standard get-entries entry type filter parameter
+	getEntriesParamEntryType = "entry_type"
 	// The name of the get-proof-by-hash parameter
 	getProofParamHash = "hash"
 	// The name of the get-proof-by-hash tree size parameter
@@ -123,6 +125,11 @@ var (
 	rspLatency                 monitoring.Histogram // logid, ep, rc => value
 	alignedGetEntries          monitoring.Counter   // logid, aligned => count
 	getEntriesStartPercentiles monitoring.Histogram // logid => percentile
+	rejectedSubmissions        monitoring.Counter   // logid => value
+	chainArchiveFailures       monitoring.Counter   // logid => value
+	issuanceChainCacheHits     monitoring.Counter   // logid => value
+	issuanceChainCacheMisses   monitoring.Counter   // logid => value
+	issuanceChainCacheEvicts   monitoring.Counter   // logid => value
 )
 
 // setupMetrics initializes all the exported metrics.
@@ -145,6 +152,11 @@ func setupMetrics(mf monitoring.MetricFactory) {
 		monitoring.PercentileBuckets(5),
 		"logid",
 	)
+	rejectedSubmissions = mf.NewCounter("rejected_submissions", "Number of submissions rejected because too many were in flight to the backend", "logid")
+	chainArchiveFailures = mf.NewCounter("chain_archive_failures", "Number of accepted chains which failed to be archived", "logid")
+	issuanceChainCacheHits = mf.NewCounter("issuance_chain_cache_hits", "Number of issuance chain lookups served from the cache", "logid")
+	issuanceChainCacheMisses = mf.NewCounter("issuance_chain_cache_misses", "Number of issuance chain lookups not found in the cache", "logid")
+	issuanceChainCacheEvicts = mf.NewCounter("issuance_chain_cache_evictions", "Number of issuance chains evicted from the cache", "logid")
 }
 
 // Entrypoints is a list of entrypoint names as exposed in statistics/logging.
@@ -170,13 +182,15 @@ func (a AppHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	label1 := string(a.Name)
 	reqsCounter.Inc(label0, label1)
 	startTime := a.Info.TimeSource.Now()
-	logCtx := a.Info.RequestLog.Start(r.Context())
+	reqID := requestID(r)
+	w.Header().Set(RequestIDHeader, reqID)
+	logCtx := a.Info.RequestLog.Start(context.WithValue(r.Context(), requestIDKey{}, reqID))
 	a.Info.RequestLog.LogPrefix(logCtx, a.Info.LogPrefix)
 	defer func() {
 		latency := a.Info.TimeSource.Now().Sub(startTime).Seconds()
 		rspLatency.Observe(latency, label0, label1, strconv.Itoa(statusCode))
 	}()
-	klog.V(2).Infof("%s: request %v %q => %s", a.Info.LogPrefix, r.Method, r.URL, a.Name)
+	klog.V(2).Infof("%s: request %v %q => %s (id %s)", a.Info.LogPrefix, r.Method, r.URL, a.Name, reqID)
 	if r.Method != a.Method {
 		klog.Warningf("%s: %s wrong HTTP method: %v", a.Info.LogPrefix, a.Name, r.Method)
 		a.Info.SendHTTPError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
@@ -202,7 +216,7 @@ func (a AppHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var err error
 	statusCode, err = a.Handler(ctx, a.Info, w, r)
 	a.Info.RequestLog.Status(ctx, statusCode)
-	klog.V(2).Infof("%s: %s <= st=%d", a.Info.LogPrefix, a.Name, statusCode)
+	klog.V(2).Infof("%s: %s <= st=%d (id %s)", a.Info.LogPrefix, a.Name, statusCode, reqID)
 	rspsCounter.Inc(label0, label1, strconv.Itoa(statusCode))
 	if err != nil {
 		klog.Warningf("%s: %s handler error: %v", a.Info.LogPrefix, a.Name, err)
@@ -242,6 +256,8 @@ type CertValidationOpts struct {
 	extKeyUsages []x509.ExtKeyUsage
 	// rejectExtIds contains a list of X.509 extension IDs to reject during chain verification.
 	rejectExtIds []asn1.ObjectIdentifier
+	// keyPolicy restricts the public keys accepted in leaf certificates, if set.
+	keyPolicy *KeyPolicy
 }
 
 // NewCertValidationOpts builds validation options based on parameters.
@@ -277,8 +293,11 @@ type logInfo struct {
 	instanceOpts InstanceOptions
 	// logID is the tree ID that identifies this log in node storage
 	logID int64
-	// validationOpts contains the certificate chain validation parameters
+	// validationOpts contains the certificate chain validation parameters.
+	// Its trustedRoots field can be replaced at runtime, so must only be
+	// accessed with rootsMu held.
 	validationOpts CertValidationOpts
+	rootsMu        sync.RWMutex
 	// rpcClient is the client used to communicate with the Trillian backend
 	rpcClient trillian.TrillianLogClient
 	// signer signs objects (e.g. STHs, SCTs) for regular logs
@@ -287,6 +306,18 @@ type logInfo struct {
 	sthGetter STHGetter
 	// issuanceChainService provides the issuance chain add and get operations
 	issuanceChainService leafChainBuilder
+	// submissions is a semaphore bounding the number of in-flight backend
+	// submissions, or nil if they are unbounded.
+	submissions chan struct{}
+	// issuerLimiter limits the rate of submissions per issuer, or is nil if
+	// they are unlimited.
+	issuerLimiter *issuerRateLimiter
+
+	// healthMu guards the details of the last successfully retrieved STH,
+	// which are reported by the health check.
+	healthMu             sync.RWMutex
+	lastGoodSTHTimestamp uint64
+	lastGoodSTHFetched   time.Time
 }
 
 // newLogInfo creates a new instance of logInfo.
@@ -311,6 +342,12 @@ func newLogInfo(
 		validationOpts: validationOpts,
 		RequestLog:     instanceOpts.RequestLog,
 	}
+	if _, ok := li.RequestLog.(*DefaultRequestLog); ok && instanceOpts.RedactRequestLogCerts {
+		li.RequestLog = &DefaultRequestLog{RedactCerts: true}
+	}
+	if instanceOpts.RequestLogChainsOnError {
+		li.RequestLog = NewErrorChainRequestLog(li.RequestLog)
+	}
 
 	once.Do(func() { setupMetrics(instanceOpts.MetricFactory) })
 	label := strconv.FormatInt(logID, 10)
@@ -341,6 +378,16 @@ func newLogInfo(
 	expMergeDelay.Set(float64(cfg.ExpectedMergeDelaySec), label)
 
 	li.issuanceChainService = issuanceChainService
+	if n := instanceOpts.MaxConcurrentSubmissions; n > 0 {
+		li.submissions = make(chan struct{}, n)
+	}
+	if limit := instanceOpts.IssuerRateLimit; limit > 0 {
+		burst := instanceOpts.IssuerRateBurst
+		if burst <= 0 {
+			burst = 1
+		}
+		li.issuerLimiter = newIssuerRateLimiter(limit, burst)
+	}
 
 	return li
 }
@@ -348,10 +395,7 @@ func newLogInfo(
 // Handlers returns a map from URL paths (with the given prefix) and AppHandler instances
 // to handle those entrypoints.
 func (li *logInfo) Handlers(prefix string) PathHandlers {
-	if !strings.HasPrefix(prefix, "/") {
-		prefix = "/" + prefix
-	}
-	prefix = strings.TrimRight(prefix, "/")
+	prefix = normalizePrefix(prefix)
 
 	// Bind the logInfo instance to give an AppHandler instance for each endpoint.
 	ph := PathHandlers{
@@ -368,11 +412,22 @@ func (li *logInfo) Handlers(prefix string) PathHandlers {
 	if li.instanceOpts.Validated.Config.IsReadonly || li.instanceOpts.Validated.Config.IsMirror {
 		delete(ph, prefix+ct.AddChainPath)
 		delete(ph, prefix+ct.AddPreChainPath)
+	} else if li.instanceOpts.MaxAddChainBatchSize > 0 {
+		ph[prefix+AddChainBatchPath] = AppHandler{Info: li, Handler: addChainBatch, Name: AddChainBatchName, Method: http.MethodPost}
 	}
 
 	return ph
 }
 
+// normalizePrefix returns the given handler prefix with a leading slash and
+// no trailing slash.
+func normalizePrefix(prefix string) string {
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return strings.TrimRight(prefix, "/")
+}
+
 // SendHTTPError generates a custom error page to give more information on why something didn't work
 func (li *logInfo) SendHTTPError(w http.ResponseWriter, statusCode int, err error) {
 	errorBody := http.StatusText(statusCode)
@@ -392,6 +447,7 @@ func (li *logInfo) getSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
 	logID := strconv.FormatInt(li.logID, 10)
 	lastSTHTimestamp.Set(float64(sth.Timestamp), logID)
 	lastSTHTreeSize.Set(float64(sth.TreeSize), logID)
+	li.recordSTH(sth.Timestamp, li.TimeSource.Now())
 	return sth, nil
 }
 
@@ -446,6 +502,34 @@ func (li *logInfo) chargeUser(r *http.Request) *trillian.ChargeTo {
 // addChainInternal is called by add-chain and add-pre-chain as the logic involved in
 // processing these requests is almost identical
 func addChainInternal(ctx context.Context, li *logInfo, w http.ResponseWriter, r *http.Request, isPrecert bool) (int, error) {
+	method := AddChainName
+	if isPrecert {
+		method = AddPreChainName
+	}
+
+	// Check the contents of the request and convert to slice of certificates.
+	addChainReq, err := ParseBodyAsJSONChain(r)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("%s: failed to parse add-chain body: %s", li.LogPrefix, err)
+	}
+	sct, statusCode, err := submitChain(ctx, li, r, addChainReq, isPrecert)
+	if err != nil {
+		return statusCode, err
+	}
+	err = marshalAndWriteAddChainResponse(sct, li.signer, w)
+	if err != nil {
+		// reason is logged and http status is already set
+		return http.StatusInternalServerError, fmt.Errorf("failed to write response: %s", err)
+	}
+	klog.V(3).Infof("%s: %s <= SCT", li.LogPrefix, method)
+
+	return http.StatusOK, nil
+}
+
+// submitChain verifies the submitted [pre-]certificate chain, sends it on to
+// the Log server, and returns an SCT for it. On failure, returns the HTTP
+// status code to respond with.
+func submitChain(ctx context.Context, li *logInfo, r *http.Request, addChainReq ct.AddChainRequest, isPrecert bool) (*ct.SignedCertificateTimestamp, int, error) {
 	var method EntrypointName
 	var etype ct.LogEntryType
 	if isPrecert {
@@ -456,25 +540,23 @@ func addChainInternal(ctx context.Context, li *logInfo, w http.ResponseWriter, r
 		etype = ct.X509LogEntryType
 	}
 
-	// Check the contents of the request and convert to slice of certificates.
-	addChainReq, err := ParseBodyAsJSONChain(r)
-	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("%s: failed to parse add-chain body: %s", li.LogPrefix, err)
-	}
 	// Log the DERs now because they might not parse as valid X.509.
 	for _, der := range addChainReq.Chain {
 		li.RequestLog.AddDERToChain(ctx, der)
 	}
 	chain, err := verifyAddChain(li, addChainReq, isPrecert)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("failed to verify add-chain contents: %s", err)
+		return nil, http.StatusBadRequest, verifyAddChainError(li, err)
 	}
 	for _, cert := range chain {
 		li.RequestLog.AddCertToChain(ctx, cert)
 	}
 
 	if rateLimitNonFreshSubmission(li, chain[0]) {
-		return http.StatusTooManyRequests, fmt.Errorf("rate-limited submission considered to be non-fresh")
+		return nil, http.StatusTooManyRequests, fmt.Errorf("rate-limited submission considered to be non-fresh")
+	}
+	if !li.issuerLimiter.allow(chain) {
+		return nil, http.StatusTooManyRequests, errors.New("rate-limited submission from issuer")
 	}
 
 	// Get the current time in the form used throughout RFC6962, namely milliseconds since Unix
@@ -484,11 +566,11 @@ func addChainInternal(ctx context.Context, li *logInfo, w http.ResponseWriter, r
 	// Build the MerkleTreeLeaf that gets sent to the backend, and make a trillian.LogLeaf for it.
 	merkleLeaf, err := ct.MerkleTreeLeafFromChain(chain, etype, timeMillis)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("failed to build MerkleTreeLeaf: %s", err)
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to build MerkleTreeLeaf: %s", err)
 	}
 	leaf, err := li.buildLeaf(ctx, chain, merkleLeaf, isPrecert)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return nil, http.StatusInternalServerError, err
 	}
 
 	// Send the Merkle tree leaf on to the Log server.
@@ -504,50 +586,71 @@ func addChainInternal(ctx context.Context, li *logInfo, w http.ResponseWriter, r
 		}
 	}
 
+	if !li.acquireSubmission() {
+		rejectedSubmissions.Inc(strconv.FormatInt(li.logID, 10))
+		return nil, http.StatusServiceUnavailable, errors.New("too many concurrent submissions")
+	}
 	klog.V(2).Infof("%s: %s => grpc.QueueLeaves", li.LogPrefix, method)
 	rsp, err := li.rpcClient.QueueLeaf(ctx, &req)
+	li.releaseSubmission()
 	klog.V(2).Infof("%s: %s <= grpc.QueueLeaves err=%v", li.LogPrefix, method, err)
 	if err != nil {
-		return li.toHTTPStatus(err), fmt.Errorf("backend QueueLeaves request failed: %s", err)
+		return nil, li.toHTTPStatus(err), fmt.Errorf("backend QueueLeaves request failed: %s", err)
 	}
 	if rsp == nil {
-		return http.StatusInternalServerError, errors.New("missing QueueLeaves response")
+		return nil, http.StatusInternalServerError, errors.New("missing QueueLeaves response")
 	}
 	if rsp.QueuedLeaf == nil {
-		return http.StatusInternalServerError, errors.New("QueueLeaf did not return the leaf")
+		return nil, http.StatusInternalServerError, errors.New("QueueLeaf did not return the leaf")
 	}
 
 	// Always use the returned leaf as the basis for an SCT.
 	var loggedLeaf ct.MerkleTreeLeaf
 	if rest, err := tls.Unmarshal(rsp.QueuedLeaf.Leaf.LeafValue, &loggedLeaf); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to reconstruct MerkleTreeLeaf: %s", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to reconstruct MerkleTreeLeaf: %s", err)
 	} else if len(rest) > 0 {
-		return http.StatusInternalServerError, fmt.Errorf("extra data (%d bytes) on reconstructing MerkleTreeLeaf", len(rest))
+		return nil, http.StatusInternalServerError, fmt.Errorf("extra data (%d bytes) on reconstructing MerkleTreeLeaf", len(rest))
 	}
 
 	// As the Log server has definitely got the Merkle tree leaf, we can
 	// generate an SCT and respond with it.
 	sct, err := buildV1SCT(li.signer, &loggedLeaf)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to generate SCT: %s", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to generate SCT: %s", err)
 	}
 	sctBytes, err := tls.Marshal(*sct)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to marshall SCT: %s", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to marshall SCT: %s", err)
 	}
 	// We could possibly fail to issue the SCT after this but it's v. unlikely.
 	li.RequestLog.IssueSCT(ctx, sctBytes)
-	err = marshalAndWriteAddChainResponse(sct, li.signer, w)
-	if err != nil {
-		// reason is logged and http status is already set
-		return http.StatusInternalServerError, fmt.Errorf("failed to write response: %s", err)
-	}
-	klog.V(3).Infof("%s: %s <= SCT", li.LogPrefix, method)
+	li.archiveChain(ctx, chain)
 	if sct.Timestamp == timeMillis {
 		lastSCTTimestamp.Set(float64(sct.Timestamp), strconv.FormatInt(li.logID, 10))
 	}
 
-	return http.StatusOK, nil
+	return sct, http.StatusOK, nil
+}
+
+// acquireSubmission reserves a slot for a backend submission, returning false
+// if the maximum number of concurrent submissions is already in flight.
+func (li *logInfo) acquireSubmission() bool {
+	if li.submissions == nil {
+		return true
+	}
+	select {
+	case li.submissions <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseSubmission frees a slot reserved by acquireSubmission.
+func (li *logInfo) releaseSubmission() {
+	if li.submissions != nil {
+		<-li.submissions
+	}
 }
 
 func addChain(ctx context.Context, li *logInfo, w http.ResponseWriter, r *http.Request) (int, error) {
@@ -799,9 +902,21 @@ func getEntries(ctx context.Context, li *logInfo, w http.ResponseWriter, r *http
 	// to serialize the leaves in JSON format for the HTTP response. Doing a
 	// round trip via the leaf deserializer gives us another chance to
 	// prevent bad / corrupt data from reaching the client.
-	jsonRsp, err := marshalGetEntriesResponse(li, leaves)
-	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("failed to process leaves returned from backend: %s", err)
+	var jsonRsp interface{}
+	if entryType := r.FormValue(getEntriesParamEntryType); entryType != "" && li.instanceOpts.AllowGetEntriesTypeFilter {
+		filterType, err := parseEntryTypeFilter(entryType)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		jsonRsp, err = marshalFilteredGetEntriesResponse(li, leaves, filterType)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to process leaves returned from backend: %s", err)
+		}
+	} else {
+		jsonRsp, err = marshalGetEntriesResponse(li, leaves)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to process leaves returned from backend: %s", err)
+		}
 	}
 
 	if len(rsp.Leaves) < int(count) {
@@ -810,7 +925,7 @@ func getEntries(ctx context.Context, li *logInfo, w http.ResponseWriter, r *http
 		w.Header().Set(cacheControlHeader, cacheControlImmutable)
 	}
 	w.Header().Set(contentTypeHeader, contentTypeJSON)
-	jsonData, err := json.Marshal(&jsonRsp)
+	jsonData, err := json.Marshal(jsonRsp)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("failed to marshal get-entries resp: %s", err)
 	}
@@ -841,8 +956,9 @@ func rpcGetLeavesByRange(ctx context.Context, li *logInfo, req *trillian.GetLeav
 
 func getRoots(_ context.Context, li *logInfo, w http.ResponseWriter, _ *http.Request) (int, error) {
 	// Pull out the raw certificates from the parsed versions
-	rawCerts := make([][]byte, 0, len(li.validationOpts.trustedRoots.RawCertificates()))
-	for _, cert := range li.validationOpts.trustedRoots.RawCertificates() {
+	roots := li.getValidationOpts().trustedRoots.RawCertificates()
+	rawCerts := make([][]byte, 0, len(roots))
+	for _, cert := range roots {
 		rawCerts = append(rawCerts, cert.Raw)
 	}
 
@@ -935,6 +1051,21 @@ func rpcGetEntryAndProof(ctx context.Context, li *logInfo, req *trillian.GetEntr
 	return rsp, http.StatusOK, nil
 }
 
+// getValidationOpts returns a copy of the log's current certificate chain
+// validation parameters.
+func (li *logInfo) getValidationOpts() CertValidationOpts {
+	li.rootsMu.RLock()
+	defer li.rootsMu.RUnlock()
+	return li.validationOpts
+}
+
+// setTrustedRoots replaces the set of roots that the log accepts.
+func (li *logInfo) setTrustedRoots(roots *x509util.PEMCertPool) {
+	li.rootsMu.Lock()
+	defer li.rootsMu.Unlock()
+	li.validationOpts.trustedRoots = roots
+}
+
 // getRPCDeadlineTime calculates the future time an RPC should expire based on our config
 func getRPCDeadlineTime(li *logInfo) time.Time {
 	return li.TimeSource.Now().Add(li.instanceOpts.Deadline)
@@ -944,16 +1075,16 @@ func getRPCDeadlineTime(li *logInfo) time.Time {
 // cert is of the correct type and chains to a trusted root.
 func verifyAddChain(li *logInfo, req ct.AddChainRequest, expectingPrecert bool) ([]*x509.Certificate, error) {
 	// We already checked that the chain is not empty so can move on to verification
-	validPath, err := ValidateChain(req.Chain, li.validationOpts)
+	validPath, err := ValidateChain(req.Chain, li.getValidationOpts())
 	if err != nil {
 		// We rejected it because the cert failed checks or we could not find a path to a root etc.
 		// Lots of possible causes for errors
-		return nil, fmt.Errorf("chain failed to verify: %s", err)
+		return nil, fmt.Errorf("chain failed to verify: %w", err)
 	}
 
 	isPrecert, err := IsPrecertificate(validPath[0])
 	if err != nil {
-		return nil, fmt.Errorf("precert test failed: %s", err)
+		return nil, validationError(ReasonInvalidPrecertificate, fmt.Errorf("precert test failed: %s", err))
 	}
 
 	// The type of the leaf must match the one the handler expects
@@ -963,12 +1094,26 @@ func verifyAddChain(li *logInfo, req ct.AddChainRequest, expectingPrecert bool)
 		} else {
 			klog.Warningf("%s: Precert (or cert with invalid CT ext) submitted as cert chain: %q", li.LogPrefix, req.Chain)
 		}
-		return nil, fmt.Errorf("cert / precert mismatch: %T", expectingPrecert)
+		return nil, validationError(ReasonPrecertMismatch, fmt.Errorf("cert / precert mismatch: %T", expectingPrecert))
 	}
 
 	return validPath, nil
 }
 
+// verifyAddChainError returns the error to report to the submitter of a chain
+// which failed verification. It names the check which failed, and includes the
+// details of the failure unless internal errors are masked.
+func verifyAddChainError(li *logInfo, err error) error {
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		return fmt.Errorf("failed to verify add-chain contents: %s", err)
+	}
+	if li.instanceOpts.MaskInternalErrors {
+		return fmt.Errorf("failed to verify add-chain contents: reason=%s", vErr.Reason)
+	}
+	return fmt.Errorf("failed to verify add-chain contents: reason=%s: %s", vErr.Reason, vErr.Err)
+}
+
 func rateLimitNonFreshSubmission(li *logInfo, leafCert *x509.Certificate) bool {
 	if li.instanceOpts.NonFreshSubmissionLimiter != nil {
 		if li.TimeSource.Now().Add(-li.instanceOpts.FreshSubmissionMaxAge).After(leafCert.NotBefore) {
@@ -982,25 +1127,13 @@ func rateLimitNonFreshSubmission(li *logInfo, leafCert *x509.Certificate) bool {
 // marshalAndWriteAddChainResponse is used by add-chain and add-pre-chain to create and write
 // the JSON response to the client
 func marshalAndWriteAddChainResponse(sct *ct.SignedCertificateTimestamp, signer crypto.Signer, w http.ResponseWriter) error {
-	logID, err := GetCTLogID(signer.Public())
+	rsp, err := buildAddChainResponse(sct, signer)
 	if err != nil {
-		return fmt.Errorf("failed to marshal logID: %s", err)
-	}
-	sig, err := tls.Marshal(sct.Signature)
-	if err != nil {
-		return fmt.Errorf("failed to marshal signature: %s", err)
-	}
-
-	rsp := ct.AddChainResponse{
-		SCTVersion: sct.SCTVersion,
-		Timestamp:  sct.Timestamp,
-		ID:         logID[:],
-		Extensions: base64.StdEncoding.EncodeToString(sct.Extensions),
-		Signature:  sig,
+		return err
 	}
 
 	w.Header().Set(contentTypeHeader, contentTypeJSON)
-	jsonData, err := json.Marshal(&rsp)
+	jsonData, err := json.Marshal(rsp)
 	if err != nil {
 		return fmt.Errorf("failed to marshal add-chain: %s", err)
 	}
@@ -1013,6 +1146,27 @@ func marshalAndWriteAddChainResponse(sct *ct.SignedCertificateTimestamp, signer
 	return nil
 }
 
+// buildAddChainResponse converts the SCT into the form returned to the client
+// by add-chain and add-pre-chain.
+func buildAddChainResponse(sct *ct.SignedCertificateTimestamp, signer crypto.Signer) (*ct.AddChainResponse, error) {
+	logID, err := GetCTLogID(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal logID: %s", err)
+	}
+	sig, err := tls.Marshal(sct.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signature: %s", err)
+	}
+
+	return &ct.AddChainResponse{
+		SCTVersion: sct.SCTVersion,
+		Timestamp:  sct.Timestamp,
+		ID:         logID[:],
+		Extensions: base64.StdEncoding.EncodeToString(sct.Extensions),
+		Signature:  sig,
+	}, nil
+}
+
 func parseGetEntriesRange(r *http.Request, maxRange, logID int64) (int64, int64, error) {
 	start, err := strconv.ParseInt(r.FormValue(getEntriesParamStart), 10, 64)
 	if err != nil {
@@ -1133,6 +1287,54 @@ func marshalGetEntriesResponse(li *logInfo, leaves []*trillian.LogLeaf) (ct.GetE
 	return jsonRsp, nil
 }
 
+// GetEntriesFilteredResponse is the non-standard JSON response to a get-entries
+// request with the entry_type parameter, returned by logs which allow entry
+// type filtering. Since the returned entries are not contiguous, the response
+// also holds the leaf index of each entry.
+type GetEntriesFilteredResponse struct {
+	ct.GetEntriesResponse
+	LeafIndices []int64 `json:"leaf_indices"`
+}
+
+// parseEntryTypeFilter parses the value of the get-entries entry_type
+// parameter, which uses the RFC6962 names of the LogEntryType values.
+func parseEntryTypeFilter(value string) (ct.LogEntryType, error) {
+	switch value {
+	case "x509_entry":
+		return ct.X509LogEntryType, nil
+	case "precert_entry":
+		return ct.PrecertLogEntryType, nil
+	}
+	return 0, fmt.Errorf("invalid %s parameter: %q", getEntriesParamEntryType, value)
+}
+
+// marshalFilteredGetEntriesResponse is similar to marshalGetEntriesResponse,
+// but returns only the leaves of the given entry type. Leaves which fail to
+// deserialize are skipped, since their entry type can't be determined.
+func marshalFilteredGetEntriesResponse(li *logInfo, leaves []*trillian.LogLeaf, entryType ct.LogEntryType) (GetEntriesFilteredResponse, error) {
+	jsonRsp := GetEntriesFilteredResponse{LeafIndices: []int64{}}
+	matching := make([]*trillian.LogLeaf, 0, len(leaves))
+	for _, leaf := range leaves {
+		var treeLeaf ct.MerkleTreeLeaf
+		if _, err := tls.Unmarshal(leaf.LeafValue, &treeLeaf); err != nil || treeLeaf.TimestampedEntry == nil {
+			klog.Errorf("%s: Failed to deserialize Merkle leaf from backend: %d", li.LogPrefix, leaf.LeafIndex)
+			continue
+		}
+		if treeLeaf.TimestampedEntry.EntryType != entryType {
+			continue
+		}
+		matching = append(matching, leaf)
+		jsonRsp.LeafIndices = append(jsonRsp.LeafIndices, leaf.LeafIndex)
+	}
+
+	rsp, err := marshalGetEntriesResponse(li, matching)
+	if err != nil {
+		return GetEntriesFilteredResponse{}, err
+	}
+	jsonRsp.GetEntriesResponse = rsp
+	return jsonRsp, nil
+}
+
 // checkAuditPath does a quick scan of the proof we got from the backend for consistency.
 // All the hashes should be non zero length.
 func checkAuditPath(path [][]byte) bool {
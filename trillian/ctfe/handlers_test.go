@@ -32,6 +32,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/mockclient"
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/testdata"
@@ -306,6 +307,26 @@ func TestHandlers(t *testing.T) {
 	}
 }
 
+func TestRedactRequestLogCerts(t *testing.T) {
+	for _, redact := range []bool{false, true} {
+		cfg := &configpb.LogConfig{LogId: 0x42, Prefix: "test"}
+		iOpts := InstanceOptions{
+			Validated:             &ValidatedLogConfig{Config: cfg},
+			MetricFactory:         monitoring.InertMetricFactory{},
+			RequestLog:            new(DefaultRequestLog),
+			RedactRequestLogCerts: redact,
+		}
+		li := newLogInfo(iOpts, CertValidationOpts{}, nil, fakeTimeSource, &directIssuanceChainService{})
+		rl, ok := li.RequestLog.(*DefaultRequestLog)
+		if !ok {
+			t.Fatalf("RequestLog is %T, want *DefaultRequestLog", li.RequestLog)
+		}
+		if got, want := rl.RedactCerts, redact; got != want {
+			t.Errorf("RedactRequestLogCerts=%v: RequestLog.RedactCerts=%v, want %v", redact, got, want)
+		}
+	}
+}
+
 func TestGetRoots(t *testing.T) {
 	info := setupTest(t, []string{caAndIntermediateCertsPEM}, nil)
 	defer info.mockCtrl.Finish()
@@ -577,6 +598,143 @@ func TestAddChain(t *testing.T) {
 	}
 }
 
+func TestAddChainMaxConcurrentSubmissions(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+
+	info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+	defer info.mockCtrl.Finish()
+	info.li.submissions = make(chan struct{}, 1)
+
+	pool := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+	merkleLeaf, err := ct.MerkleTreeLeafFromChain(pool.RawCertificates(), ct.X509LogEntryType, fakeTimeMillis)
+	if err != nil {
+		t.Fatalf("Unexpected error signing SCT: %v", err)
+	}
+	leafChain := append(pool.RawCertificates(), info.roots.RawCertificates()[0])
+	leaf := logLeafForCert(t, leafChain, merkleLeaf, false)
+	rsp := trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{Leaf: leaf, Status: status.New(codes.OK, "ok").Proto()}}
+	req := &trillian.QueueLeafRequest{LogId: 0x42, Leaf: leaf}
+
+	// Occupy the only slot, so the submission must be rejected without
+	// reaching the backend.
+	info.li.submissions <- struct{}{}
+	recorder := makeAddChainRequest(t, info.li, createJSONChain(t, *pool))
+	if got, want := recorder.Code, http.StatusServiceUnavailable; got != want {
+		t.Fatalf("addChain()=%d (body:%v); want %d", got, recorder.Body, want)
+	}
+
+	<-info.li.submissions
+	info.client.EXPECT().QueueLeaf(deadlineMatcher(), cmpMatcher{req}).Return(&rsp, nil)
+	recorder = makeAddChainRequest(t, info.li, createJSONChain(t, *pool))
+	if got, want := recorder.Code, http.StatusOK; got != want {
+		t.Fatalf("addChain()=%d (body:%v); want %d", got, recorder.Body, want)
+	}
+	if got := len(info.li.submissions); got != 0 {
+		t.Errorf("%d submission slots still held after addChain(), want 0", got)
+	}
+}
+
+func TestAddChainValidationFailureReasons(t *testing.T) {
+	chain := []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM}
+	// The leaf is valid from 2016-05-13 to 2019-07-12.
+	var tests = []struct {
+		descr      string
+		roots      []string
+		modifyOpts func(v *CertValidationOpts)
+		mask       bool
+		wantBody   []string
+		noBody     string
+	}{
+		{
+			descr: "expired",
+			modifyOpts: func(v *CertValidationOpts) {
+				v.rejectExpired = true
+				v.currentTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			wantBody: []string{"reason=expired", "rejecting expired certificate"},
+		},
+		{
+			// The CTFE does not reject certificates which are not yet valid, so
+			// only reject_unexpired applies to them.
+			descr: "unexpired-before-not-before",
+			modifyOpts: func(v *CertValidationOpts) {
+				v.rejectUnexpired = true
+				v.currentTime = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			wantBody: []string{"reason=unexpired"},
+		},
+		{
+			descr: "unexpired",
+			modifyOpts: func(v *CertValidationOpts) {
+				v.rejectUnexpired = true
+				v.currentTime = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			wantBody: []string{"reason=unexpired"},
+		},
+		{
+			descr:    "unknown-root",
+			roots:    []string{cttestonly.CACertPEM},
+			wantBody: []string{"reason=unknown_root"},
+		},
+		{
+			descr: "eku-mismatch",
+			modifyOpts: func(v *CertValidationOpts) {
+				v.extKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+			},
+			wantBody: []string{"reason=eku_mismatch", "rejecting certificate without EKU"},
+		},
+		{
+			descr: "rejected-extension",
+			modifyOpts: func(v *CertValidationOpts) {
+				v.rejectExtIds = []asn1.ObjectIdentifier{x509.OIDExtensionSubjectKeyId}
+			},
+			wantBody: []string{"reason=rejected_extension", "2.5.29.14"},
+		},
+		{
+			descr: "masked",
+			modifyOpts: func(v *CertValidationOpts) {
+				v.rejectExtIds = []asn1.ObjectIdentifier{x509.OIDExtensionSubjectKeyId}
+			},
+			mask:     true,
+			wantBody: []string{"reason=rejected_extension"},
+			noBody:   "2.5.29.14",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.descr, func(t *testing.T) {
+			roots := test.roots
+			if roots == nil {
+				roots = []string{cttestonly.FakeCACertPEM}
+			}
+			info := setupTest(t, roots, nil)
+			defer info.mockCtrl.Finish()
+			if test.modifyOpts != nil {
+				test.modifyOpts(&info.li.validationOpts)
+			}
+			info.li.instanceOpts.MaskInternalErrors = test.mask
+
+			pool := loadCertsIntoPoolOrDie(t, chain)
+			recorder := makeAddChainRequest(t, info.li, createJSONChain(t, *pool))
+			if got, want := recorder.Code, http.StatusBadRequest; got != want {
+				t.Fatalf("addChain()=%d (body:%v); want %d", got, recorder.Body, want)
+			}
+			body := recorder.Body.String()
+			for _, want := range test.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("addChain() body=%q; want to contain %q", body, want)
+				}
+			}
+			if test.noBody != "" && strings.Contains(body, test.noBody) {
+				t.Errorf("addChain() body=%q; want not to contain %q", body, test.noBody)
+			}
+		})
+	}
+}
+
 func TestAddPrechain(t *testing.T) {
 	var tests = []struct {
 		descr         string
@@ -1076,6 +1234,96 @@ func TestGetEntries(t *testing.T) {
 	}
 }
 
+func TestGetEntriesEntryTypeFilter(t *testing.T) {
+	newLeaf := func(idx int64, entry *ct.TimestampedEntry) *trillian.LogLeaf {
+		t.Helper()
+		data, err := tls.Marshal(ct.MerkleTreeLeaf{Version: ct.V1, LeafType: ct.TimestampedEntryLeafType, TimestampedEntry: entry})
+		if err != nil {
+			t.Fatalf("failed to tls.Marshal() test data for get-entries: %v", err)
+		}
+		return &trillian.LogLeaf{LeafIndex: idx, MerkleLeafHash: []byte("hash"), LeafValue: data, ExtraData: []byte("extra")}
+	}
+	certEntry := &ct.TimestampedEntry{
+		Timestamp: 12345,
+		EntryType: ct.X509LogEntryType,
+		X509Entry: &ct.ASN1Cert{Data: []byte("certdatacertdata")},
+	}
+	precertEntry := &ct.TimestampedEntry{
+		Timestamp:    67890,
+		EntryType:    ct.PrecertLogEntryType,
+		PrecertEntry: &ct.PreCert{TBSCertificate: []byte("tbsdatatbsdata")},
+	}
+	leaves := []*trillian.LogLeaf{
+		newLeaf(1, certEntry),
+		newLeaf(2, precertEntry),
+		newLeaf(3, certEntry),
+		{LeafIndex: 4, MerkleLeafHash: []byte("hash"), LeafValue: []byte("NOT A MERKLE TREE LEAF")},
+	}
+
+	for _, test := range []struct {
+		desc        string
+		allow       bool
+		req         string
+		want        int
+		wantIndices []int64 // nil if the response is not filtered
+	}{
+		{desc: "disabled", req: "start=1&end=4&entry_type=precert_entry", want: http.StatusOK},
+		{desc: "no-filter", allow: true, req: "start=1&end=4", want: http.StatusOK},
+		{desc: "x509", allow: true, req: "start=1&end=4&entry_type=x509_entry", want: http.StatusOK, wantIndices: []int64{1, 3}},
+		{desc: "precert", allow: true, req: "start=1&end=4&entry_type=precert_entry", want: http.StatusOK, wantIndices: []int64{2}},
+		{desc: "invalid", allow: true, req: "start=1&end=4&entry_type=wibble", want: http.StatusBadRequest},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			info := setupTest(t, nil, nil)
+			defer info.mockCtrl.Finish()
+			info.li.instanceOpts.AllowGetEntriesTypeFilter = test.allow
+			handler := AppHandler{Info: info.li, Handler: getEntries, Name: "GetEntries", Method: http.MethodGet}
+
+			glbrr := &trillian.GetLeavesByRangeRequest{LogId: 0x42, StartIndex: 1, Count: 4}
+			rsp := trillian.GetLeavesByRangeResponse{SignedLogRoot: mustMarshalRoot(t, &types.LogRootV1{TreeSize: 100}), Leaves: leaves}
+			info.client.EXPECT().GetLeavesByRange(deadlineMatcher(), cmpMatcher{glbrr}).Return(&rsp, nil)
+
+			req, err := http.NewRequest(http.MethodGet, "/ct/v1/get-entries?"+test.req, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if got := w.Code; got != test.want {
+				t.Fatalf("GetEntries(%q)=%d; want %d", test.req, got, test.want)
+			}
+			if test.want != http.StatusOK {
+				return
+			}
+
+			var got GetEntriesFilteredResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal json response %s: %v", w.Body.Bytes(), err)
+			}
+			if test.wantIndices == nil {
+				if got.LeafIndices != nil {
+					t.Errorf("GetEntries(%q) returned leaf_indices %v, want none", test.req, got.LeafIndices)
+				}
+				if got, want := len(got.Entries), len(leaves); got != want {
+					t.Errorf("GetEntries(%q) returned %d entries, want %d", test.req, got, want)
+				}
+				return
+			}
+			if diff := cmp.Diff(test.wantIndices, got.LeafIndices); diff != "" {
+				t.Errorf("GetEntries(%q) returned leaf_indices diff (-want +got):\n%s", test.req, diff)
+			}
+			if got, want := len(got.Entries), len(test.wantIndices); got != want {
+				t.Fatalf("GetEntries(%q) returned %d entries, want %d", test.req, got, want)
+			}
+			for i, idx := range test.wantIndices {
+				if got, want := got.Entries[i].LeafInput, leaves[idx-1].LeafValue; !bytes.Equal(got, want) {
+					t.Errorf("GetEntries(%q): entries[%d].LeafInput=%x, want %x", test.req, i, got, want)
+				}
+			}
+		})
+	}
+}
+
 func TestGetEntriesRanges(t *testing.T) {
 	var tests = []struct {
 		desc          string
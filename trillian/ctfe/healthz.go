@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// HealthzPath is the path of the per-log health check entrypoint, relative to
+// the log prefix.
+const HealthzPath = "/healthz"
+
+// HealthzName identifies the health check entrypoint. It is not listed in
+// Entrypoints because it is not part of RFC 6962.
+const HealthzName = EntrypointName("Healthz")
+
+// defaultHealthzMaxSTHAge is the maximum time since the last successful
+// get-sth for the log to be reported healthy, unless configured otherwise.
+const defaultHealthzMaxSTHAge = 10 * time.Minute
+
+// recordSTH notes that the given STH was successfully retrieved at the given
+// time, for use by the health check.
+func (li *logInfo) recordSTH(timestamp uint64, at time.Time) {
+	li.healthMu.Lock()
+	defer li.healthMu.Unlock()
+	li.lastGoodSTHTimestamp = timestamp
+	li.lastGoodSTHFetched = at
+}
+
+// lastSTH returns the timestamp of the last successfully retrieved STH, and
+// the time it was retrieved at. The latter is zero if there was none.
+func (li *logInfo) lastSTH() (uint64, time.Time) {
+	li.healthMu.RLock()
+	defer li.healthMu.RUnlock()
+	return li.lastGoodSTHTimestamp, li.lastGoodSTHFetched
+}
+
+// healthz reports whether the log's Trillian backend is serving STHs. It
+// returns 200 OK if the last successful get-sth, internal or external, is
+// more recent than the configured bound, and 503 Service Unavailable
+// otherwise. The instance should be running RunUpdateSTH for this to be
+// meaningful.
+func healthz(_ context.Context, li *logInfo, w http.ResponseWriter, _ *http.Request) (int, error) {
+	maxAge := li.instanceOpts.HealthzMaxSTHAge
+	if maxAge <= 0 {
+		maxAge = defaultHealthzMaxSTHAge
+	}
+	timestamp, fetched := li.lastSTH()
+	if fetched.IsZero() {
+		return http.StatusServiceUnavailable, fmt.Errorf("no STH retrieved yet")
+	}
+	if age := li.TimeSource.Now().Sub(fetched); age > maxAge {
+		return http.StatusServiceUnavailable, fmt.Errorf("last STH (timestamp %d) retrieved %v ago, more than %v", timestamp, age, maxAge)
+	}
+
+	w.Header().Set(contentTypeHeader, "text/plain")
+	if _, err := fmt.Fprintf(w, "ok\nlast STH timestamp: %d\n", timestamp); err != nil {
+		klog.Errorf("%s: failed to write healthz response: %v", li.LogPrefix, err)
+	}
+	return http.StatusOK, nil
+}
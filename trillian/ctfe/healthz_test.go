@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/util"
+)
+
+func TestHealthz(t *testing.T) {
+	const sthTimestamp = 1469185273000
+	info := setupTest(t, nil, nil)
+	defer info.mockCtrl.Finish()
+	info.li.sthGetter = &FrozenSTHGetter{sth: &ct.SignedTreeHead{TreeSize: 10, Timestamp: sthTimestamp}}
+	info.li.instanceOpts.HealthzMaxSTHAge = time.Minute
+	handler := AppHandler{Info: info.li, Handler: healthz, Name: HealthzName, Method: http.MethodGet}
+
+	serve := func() *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/test/healthz", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// No STH has been retrieved yet.
+	if got, want := serve().Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("healthz before get-sth=%d; want %d", got, want)
+	}
+
+	if _, err := info.li.getSTH(context.Background()); err != nil {
+		t.Fatalf("getSTH()=%v; want nil", err)
+	}
+	w := serve()
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Errorf("healthz after get-sth=%d; want %d", got, want)
+	}
+	if body, want := w.Body.String(), "1469185273000"; !strings.Contains(body, want) {
+		t.Errorf("healthz body=%q; want to contain %q", body, want)
+	}
+
+	// Simulate the backend going dark, so the last STH becomes stale.
+	info.li.TimeSource = util.NewFixedTimeSource(fakeTime.Add(2 * time.Minute))
+	w = serve()
+	if got, want := w.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("healthz with stale STH=%d; want %d", got, want)
+	}
+	if body, want := w.Body.String(), "1469185273000"; !strings.Contains(body, want) {
+		t.Errorf("healthz body=%q; want to contain %q", body, want)
+	}
+}
@@ -57,6 +57,13 @@ type InstanceOptions struct {
 	ErrorMapper func(error) (int, bool)
 	// RequestLog provides structured logging of CTFE requests.
 	RequestLog RequestLog
+	// RedactRequestLogCerts makes a DefaultRequestLog log only fingerprints of
+	// the submitted certificates instead of their full DER bytes. It has no
+	// effect on other RequestLog implementations.
+	RedactRequestLogCerts bool
+	// RequestLogChainsOnError makes the RequestLog receive submitted chains
+	// (via AddDERToChain) only for requests that end in an error status.
+	RequestLogChainsOnError bool
 	// RemoteUser returns a string representing the originating host for the
 	// given request. This string will be used as a User quota key.
 	// If unset, no quota will be requested for remote users.
@@ -77,6 +84,15 @@ type InstanceOptions struct {
 	// This is used to prevent the log from being flooded with requests for
 	// "old" certificates.
 	NonFreshSubmissionLimiter *rate.Limiter
+	// IssuerRateLimit, if positive, limits the rate at which this log instance
+	// accepts submissions issued by any one intermediate, identified by its
+	// public key. Submissions beyond the limit are rejected with 429 Too Many
+	// Requests. Unlike CertificateQuotaUser, it does not need an external
+	// quota manager.
+	IssuerRateLimit rate.Limit
+	// IssuerRateBurst is the token bucket size used with IssuerRateLimit. If
+	// zero, a burst of 1 is used.
+	IssuerRateBurst int
 	// STHStorage provides STHs of a source log for the mirror. Only mirror
 	// instances will use it, i.e. when IsMirror == true in the config. If it is
 	// empty then the DefaultMirrorSTHStorage will be used.
@@ -88,6 +104,32 @@ type InstanceOptions struct {
 	CacheType cache.Type
 	// CacheOption includes the cache size and time-to-live (TTL).
 	CacheOption cache.Option
+	// MaxAddChainBatchSize is the maximum number of chains accepted in one
+	// request to the non-standard add-chain-batch entrypoint. If zero, the
+	// entrypoint is not served.
+	MaxAddChainBatchSize int
+	// AddChainBatchConcurrency limits the number of concurrent Trillian
+	// submissions made for one add-chain-batch request. If zero, a default
+	// limit is used.
+	AddChainBatchConcurrency int
+	// AllowGetEntriesTypeFilter enables the non-standard entry_type parameter
+	// of get-entries, which makes the log return only the entries of the given
+	// type, along with their leaf indices. This deviates from RFC6962, so it
+	// is disabled by default, in which case the parameter is ignored.
+	AllowGetEntriesTypeFilter bool
+	// MaxConcurrentSubmissions bounds the number of add-chain and
+	// add-pre-chain submissions which can be in flight to the Trillian
+	// backend at once. Submissions beyond the limit are rejected with 503
+	// Service Unavailable. If zero, the number is unbounded.
+	MaxConcurrentSubmissions int
+	// ChainArchiver, if set, is given every chain accepted by add-chain and
+	// add-pre-chain, for archiving outside of the log. It is called
+	// asynchronously, and its failures do not fail the submission.
+	ChainArchiver ChainArchiver
+	// HealthzMaxSTHAge is the maximum time since the last successful get-sth
+	// for the log's healthz entrypoint to report it as healthy. If zero, a
+	// default of 10 minutes is used.
+	HealthzMaxSTHAge time.Duration
 }
 
 // Instance is a set up log/mirror instance. It must be created with the
@@ -119,6 +161,24 @@ func (i *Instance) GetPublicKey() crypto.PublicKey {
 	return nil
 }
 
+// ReloadRoots re-reads the trusted roots from the configured RootsPemFile
+// list, and replaces the set of roots the instance accepts. Submissions
+// validated after it returns use the new roots. On error, the previous roots
+// are kept.
+func (i *Instance) ReloadRoots(ctx context.Context) error {
+	cfg := i.li.instanceOpts.Validated.Config
+	if !cfg.IsMirror && len(cfg.RootsPemFile) == 0 {
+		return errors.New("need to specify RootsPemFile")
+	}
+	roots, err := loadRoots(cfg.RootsPemFile)
+	if err != nil {
+		return err
+	}
+	i.li.setTrustedRoots(roots)
+	klog.Infof("Reloaded %d trusted roots for %v (%d)", len(roots.RawCertificates()), cfg.Prefix, cfg.LogId)
+	return nil
+}
+
 // SetUpInstance sets up a log (or log mirror) instance using the provided
 // configuration, and returns an object containing a set of handlers for this
 // log, and an STH getter.
@@ -127,7 +187,10 @@ func SetUpInstance(ctx context.Context, opts InstanceOptions) (*Instance, error)
 	if err != nil {
 		return nil, err
 	}
-	handlers := logInfo.Handlers(opts.Validated.Config.Prefix)
+	prefix := normalizePrefix(opts.Validated.Config.Prefix)
+	handlers := logInfo.Handlers(prefix)
+	handlers[prefix+HealthzPath] = AppHandler{Info: logInfo, Handler: healthz, Name: HealthzName, Method: http.MethodGet}
+	handlers[prefix+ConfigPath] = AppHandler{Info: logInfo, Handler: getConfig, Name: ConfigName, Method: http.MethodGet}
 	return &Instance{Handlers: handlers, STHGetter: logInfo.sthGetter, li: logInfo}, nil
 }
 
@@ -140,16 +203,13 @@ func setUpLogInfo(ctx context.Context, opts InstanceOptions) (*logInfo, error) {
 		return nil, errors.New("need to specify RootsPemFile")
 	}
 	// Load the trusted roots.
-	roots := x509util.NewPEMCertPool()
-	for _, pemFile := range cfg.RootsPemFile {
-		if err := roots.AppendCertsFromPEMFile(pemFile); err != nil {
-			return nil, fmt.Errorf("failed to read trusted roots: %v", err)
-		}
+	roots, err := loadRoots(cfg.RootsPemFile)
+	if err != nil {
+		return nil, err
 	}
 
 	var signer crypto.Signer
 	if !cfg.IsMirror {
-		var err error
 		if signer, err = keys.NewSigner(ctx, vCfg.PrivKey); err != nil {
 			return nil, fmt.Errorf("failed to load private key: %v", err)
 		}
@@ -183,8 +243,8 @@ func setUpLogInfo(ctx context.Context, opts InstanceOptions) (*logInfo, error) {
 		notAfterLimit:   vCfg.NotAfterLimit,
 		acceptOnlyCA:    cfg.AcceptOnlyCa,
 		extKeyUsages:    vCfg.KeyUsages,
+		keyPolicy:       vCfg.KeyPolicy,
 	}
-	var err error
 	validationOpts.rejectExtIds, err = parseOIDs(cfg.RejectExtensions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse RejectExtensions: %v", err)
@@ -200,17 +260,38 @@ func setUpLogInfo(ctx context.Context, opts InstanceOptions) (*logInfo, error) {
 	}
 
 	// We are storing chains outside of Trillian, so set up cache and service.
-	issuanceChainCache, err := cache.NewIssuanceChainCache(ctx, opts.CacheType, opts.CacheOption)
+	label := strconv.FormatInt(cfg.LogId, 10)
+	cacheOpt := opts.CacheOption
+	if onEvict := cacheOpt.OnEvict; onEvict != nil {
+		cacheOpt.OnEvict = func() {
+			issuanceChainCacheEvicts.Inc(label)
+			onEvict()
+		}
+	} else {
+		cacheOpt.OnEvict = func() { issuanceChainCacheEvicts.Inc(label) }
+	}
+	issuanceChainCache, err := cache.NewIssuanceChainCache(ctx, opts.CacheType, cacheOpt)
 	if err != nil {
 		return nil, err
 	}
 
-	issuanceChainService := newIndirectIssuanceChainService(issuanceChainStorage, issuanceChainCache)
+	issuanceChainService := newIndirectIssuanceChainService(issuanceChainStorage, issuanceChainCache, label)
 
 	logInfo := newLogInfo(opts, validationOpts, signer, new(util.SystemTimeSource), issuanceChainService)
 	return logInfo, nil
 }
 
+// loadRoots reads the trusted roots from the given PEM files into a new pool.
+func loadRoots(pemFiles []string) (*x509util.PEMCertPool, error) {
+	roots := x509util.NewPEMCertPool()
+	for _, pemFile := range pemFiles {
+		if err := roots.AppendCertsFromPEMFile(pemFile); err != nil {
+			return nil, fmt.Errorf("failed to read trusted roots: %v", err)
+		}
+	}
+	return roots, nil
+}
+
 func parseOIDs(oids []string) ([]asn1.ObjectIdentifier, error) {
 	ret := make([]asn1.ObjectIdentifier, 0, len(oids))
 	for _, s := range oids {
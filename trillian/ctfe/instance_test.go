@@ -16,9 +16,13 @@ package ctfe
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -282,6 +286,84 @@ func TestSetUpInstanceSetsValidationOpts(t *testing.T) {
 	}
 }
 
+func TestReloadRoots(t *testing.T) {
+	ctx := context.Background()
+	rootsFile := filepath.Join(t.TempDir(), "roots.pem")
+	writeRoots := func(files ...string) {
+		t.Helper()
+		var data []byte
+		for _, f := range files {
+			pem, err := os.ReadFile(f)
+			if err != nil {
+				t.Fatalf("ReadFile(%s): %v", f, err)
+			}
+			data = append(data, pem...)
+		}
+		if err := os.WriteFile(rootsFile, data, 0o644); err != nil {
+			t.Fatalf("WriteFile(): %v", err)
+		}
+	}
+	writeRoots("../testdata/fake-ca.cert")
+
+	cfg := &configpb.LogConfig{
+		LogId:        1,
+		Prefix:       "/log",
+		RootsPemFile: []string{rootsFile},
+		PrivateKey:   mustMarshalAny(&keyspb.PEMKeyFile{Path: "../testdata/ct-http-server.privkey.pem", Password: "dirk"}),
+	}
+	vCfg, err := ValidateLogConfig(cfg)
+	if err != nil {
+		t.Fatalf("ValidateLogConfig(): %v", err)
+	}
+	opts := InstanceOptions{Validated: vCfg, Deadline: time.Second, MetricFactory: monitoring.InertMetricFactory{}, RequestLog: new(DefaultRequestLog), CacheType: cache.NOOP, CacheOption: cache.Option{}}
+	inst, err := SetUpInstance(ctx, opts)
+	if err != nil {
+		t.Fatalf("SetUpInstance() = %v, want no error", err)
+	}
+
+	countRoots := func() int {
+		t.Helper()
+		handler, ok := inst.Handlers[cfg.Prefix+ct.GetRootsPath]
+		if !ok {
+			t.Fatal("Couldn't find GetRoots handler")
+		}
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/log/ct/v1/get-roots", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("get-roots=%d; want %d", got, want)
+		}
+		var rsp map[string][]string
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Fatalf("json.Unmarshal(%q)=%v; want nil", w.Body.Bytes(), err)
+		}
+		return len(rsp[jsonMapKeyCertificates])
+	}
+
+	if got, want := countRoots(), 1; got != want {
+		t.Errorf("before reload: got %d roots, want %d", got, want)
+	}
+
+	writeRoots("../testdata/fake-ca.cert", "../testdata/fake-ca-1.cert")
+	if err := inst.ReloadRoots(ctx); err != nil {
+		t.Fatalf("ReloadRoots() = %v, want no error", err)
+	}
+	if got, want := countRoots(), 2; got != want {
+		t.Errorf("after reload: got %d roots, want %d", got, want)
+	}
+
+	// A failed reload keeps the previous roots.
+	if err := os.Remove(rootsFile); err != nil {
+		t.Fatalf("Remove(): %v", err)
+	}
+	if err := inst.ReloadRoots(ctx); err == nil {
+		t.Error("ReloadRoots() with missing file = nil, want error")
+	}
+	if got, want := countRoots(), 2; got != want {
+		t.Errorf("after failed reload: got %d roots, want %d", got, want)
+	}
+}
+
 func TestErrorMasking(t *testing.T) {
 	info := logInfo{}
 	w := httptest.NewRecorder()
@@ -299,3 +381,60 @@ func TestErrorMasking(t *testing.T) {
 	}
 
 }
+
+func TestConfigHandler(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &configpb.LogConfig{
+		LogId:         1,
+		Prefix:        "/log",
+		RootsPemFile:  []string{"../testdata/fake-ca.cert", "../testdata/fake-ca-1.cert"},
+		PrivateKey:    mustMarshalAny(&keyspb.PEMKeyFile{Path: "../testdata/ct-http-server.privkey.pem", Password: "dirk"}),
+		NotAfterStart: timestamppb.New(start),
+		NotAfterLimit: timestamppb.New(limit),
+		AcceptOnlyCa:  true,
+	}
+	vCfg, err := ValidateLogConfig(cfg)
+	if err != nil {
+		t.Fatalf("ValidateLogConfig(): %v", err)
+	}
+	opts := InstanceOptions{Validated: vCfg, Deadline: time.Second, MetricFactory: monitoring.InertMetricFactory{}, RequestLog: new(DefaultRequestLog), CacheType: cache.NOOP, CacheOption: cache.Option{}}
+	inst, err := SetUpInstance(ctx, opts)
+	if err != nil {
+		t.Fatalf("SetUpInstance() = %v, want no error", err)
+	}
+
+	handler, ok := inst.Handlers[cfg.Prefix+ConfigPath]
+	if !ok {
+		t.Fatal("Couldn't find Config handler")
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/log/config", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("config=%d; want %d", got, want)
+	}
+	if strings.Contains(w.Body.String(), "private") {
+		t.Errorf("config body=%s; want no private key", w.Body.String())
+	}
+	var got EffectiveConfig
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%q)=%v; want nil", w.Body.Bytes(), err)
+	}
+	if got.NotAfterStart == nil || !got.NotAfterStart.Equal(start) {
+		t.Errorf("NotAfterStart=%v; want %v", got.NotAfterStart, start)
+	}
+	if got.NotAfterLimit == nil || !got.NotAfterLimit.Equal(limit) {
+		t.Errorf("NotAfterLimit=%v; want %v", got.NotAfterLimit, limit)
+	}
+	if got, want := got.RootsCount, 2; got != want {
+		t.Errorf("RootsCount=%d; want %d", got, want)
+	}
+	if !got.AcceptOnlyCA {
+		t.Error("AcceptOnlyCA=false; want true")
+	}
+	if got, want := got.CacheType, string(cache.NOOP); got != want {
+		t.Errorf("CacheType=%q; want %q", got, want)
+	}
+}
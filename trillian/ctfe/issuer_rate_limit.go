@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"golang.org/x/time/rate"
+)
+
+// issuerRateLimiter limits the rate of submissions per issuer, using a token
+// bucket for each issuer keyed by the hash of its SubjectPublicKeyInfo.
+//
+// Buckets are only created for issuers of chains which have been validated,
+// so their number is bounded by the number of CAs chaining to the log's
+// trusted roots.
+type issuerRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[[sha256.Size]byte]*rate.Limiter
+}
+
+func newIssuerRateLimiter(limit rate.Limit, burst int) *issuerRateLimiter {
+	return &issuerRateLimiter{
+		limit:    limit,
+		burst:    burst,
+		limiters: make(map[[sha256.Size]byte]*rate.Limiter),
+	}
+}
+
+// allow reports whether a submission with the given validated chain may
+// proceed. It takes a token from the bucket of each intermediate in the chain,
+// and of the root only if it issued the leaf directly, so that the issuers
+// below a busy root are limited independently. Tokens are only taken if every
+// bucket has one, so a rejected submission costs none of the issuers anything.
+func (l *issuerRateLimiter) allow(chain []*x509.Certificate) bool {
+	if l == nil || len(chain) < 2 {
+		return true
+	}
+	issuers := chain[1:]
+	if len(issuers) > 1 {
+		issuers = issuers[:len(issuers)-1]
+	}
+
+	// Holding mu for the whole check makes it atomic, as the limiters are
+	// only used from here.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	lims := make([]*rate.Limiter, len(issuers))
+	for i, cert := range issuers {
+		lims[i] = l.limiterFor(cert)
+		if lims[i].TokensAt(now) < 1 {
+			return false
+		}
+	}
+	for _, lim := range lims {
+		lim.AllowN(now, 1)
+	}
+	return true
+}
+
+// limiterFor returns the bucket for the issuer cert, creating it if needed.
+// It must be called with mu held.
+func (l *issuerRateLimiter) limiterFor(cert *x509.Certificate) *rate.Limiter {
+	key := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	lim, ok := l.limiters[key]
+	if !ok {
+		lim = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[key] = lim
+	}
+	return lim
+}
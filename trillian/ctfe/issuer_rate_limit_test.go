@@ -0,0 +1,85 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cttestonly "github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/testonly"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+)
+
+func TestIssuerRateLimit(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+	info := setupTest(t, []string{cttestonly.FakeCACertPEM, cttestonly.CACertPEM}, signer)
+	defer info.mockCtrl.Finish()
+	info.li.issuerLimiter = newIssuerRateLimiter(rate.Every(time.Hour), 1)
+
+	// Echo back the queued leaf, so that an SCT can be built for it.
+	info.client.EXPECT().QueueLeaf(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *trillian.QueueLeafRequest, _ ...grpc.CallOption) (*trillian.QueueLeafResponse, error) {
+			return &trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{Leaf: req.Leaf, Status: status.New(codes.OK, "ok").Proto()}}, nil
+		}).Times(2)
+
+	// Issued by the fake intermediate.
+	chainA := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+	// Issued directly by the CA root.
+	chainB := loadCertsIntoPoolOrDie(t, []string{cttestonly.PrecertPEMValid})
+
+	if got, want := makeAddChainRequest(t, info.li, createJSONChain(t, *chainA)).Code, http.StatusOK; got != want {
+		t.Fatalf("first addChain() from issuer A=%d; want %d", got, want)
+	}
+	if got, want := makeAddChainRequest(t, info.li, createJSONChain(t, *chainA)).Code, http.StatusTooManyRequests; got != want {
+		t.Errorf("second addChain() from issuer A=%d; want %d", got, want)
+	}
+	if got, want := makeAddPrechainRequest(t, info.li, createJSONChain(t, *chainB)).Code, http.StatusOK; got != want {
+		t.Errorf("addPreChain() from issuer B=%d; want %d", got, want)
+	}
+}
+
+func TestIssuerRateLimitAllOrNothing(t *testing.T) {
+	cert := func(spki string) *x509.Certificate {
+		return &x509.Certificate{RawSubjectPublicKeyInfo: []byte(spki)}
+	}
+	leaf, inter1, inter2, root := cert("leaf"), cert("inter1"), cert("inter2"), cert("root")
+	l := newIssuerRateLimiter(rate.Every(time.Hour), 1)
+
+	if !l.allow([]*x509.Certificate{leaf, inter2, root}) {
+		t.Fatal("allow(inter2)=false, want true")
+	}
+	// inter2 is exhausted, so this is rejected without costing inter1 a token.
+	if l.allow([]*x509.Certificate{leaf, inter1, inter2, root}) {
+		t.Error("allow(inter1, inter2)=true, want false")
+	}
+	if !l.allow([]*x509.Certificate{leaf, inter1, root}) {
+		t.Error("allow(inter1)=false, want true")
+	}
+	if l.allow([]*x509.Certificate{leaf, inter1, root}) {
+		t.Error("second allow(inter1)=true, want false")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/util"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+	"k8s.io/klog/v2"
+)
+
+// jsonRecordKey is the context key for the per-request record kept by
+// JSONRequestLog.
+type jsonRecordKey struct{}
+
+// JSONRequestRecord is the structure of the JSON object that JSONRequestLog
+// writes for each request. Fields that were not set while handling the request
+// are omitted.
+type JSONRequestRecord struct {
+	RequestID      string   `json:"request_id,omitempty"`
+	Prefix         string   `json:"prefix,omitempty"`
+	ChainSubjects  []string `json:"chain_subjects,omitempty"`
+	First          *int64   `json:"first,omitempty"`
+	Second         *int64   `json:"second,omitempty"`
+	Start          *int64   `json:"start,omitempty"`
+	End            *int64   `json:"end,omitempty"`
+	LeafIndex      *int64   `json:"leaf_index,omitempty"`
+	TreeSize       *int64   `json:"tree_size,omitempty"`
+	LeafHash       string   `json:"leaf_hash,omitempty"`
+	SCT            []byte   `json:"sct,omitempty"`
+	Status         int      `json:"status"`
+	LatencySeconds float64  `json:"latency_seconds"`
+}
+
+// jsonRequestState accumulates the record for one request.
+type jsonRequestState struct {
+	started time.Time
+
+	mu     sync.Mutex
+	record JSONRequestRecord
+}
+
+// JSONRequestLog is an implementation of RequestLog that collects the details
+// of each request and writes them as a single line of JSON to an io.Writer
+// once the request status is known.
+type JSONRequestLog struct {
+	out        io.Writer
+	timeSource util.TimeSource
+
+	mu sync.Mutex // Serializes writes to out.
+}
+
+// NewJSONRequestLog returns a JSONRequestLog that writes one JSON object per
+// request to out, using timeSource to measure request latency.
+func NewJSONRequestLog(out io.Writer, timeSource util.TimeSource) *JSONRequestLog {
+	return &JSONRequestLog{out: out, timeSource: timeSource}
+}
+
+// update runs f on the record for the request that ctx belongs to, with the
+// record locked. It does nothing if the context was not set up by Start.
+func (jrl *JSONRequestLog) update(ctx context.Context, f func(r *JSONRequestRecord)) {
+	st, ok := ctx.Value(jsonRecordKey{}).(*jsonRequestState)
+	if !ok {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	f(&st.record)
+}
+
+// Start attaches a new request record, holding the request ID if there is one,
+// to the returned context.
+func (jrl *JSONRequestLog) Start(ctx context.Context) context.Context {
+	st := &jsonRequestState{started: jrl.timeSource.Now()}
+	st.record.RequestID = RequestIDFromContext(ctx)
+	return context.WithValue(ctx, jsonRecordKey{}, st)
+}
+
+// LogPrefix records the prefix of the CT log that this request is for.
+func (jrl *JSONRequestLog) LogPrefix(ctx context.Context, p string) {
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.Prefix = p })
+}
+
+// AddDERToChain does nothing; submitted chains are recorded by subject once
+// they have been parsed.
+func (jrl *JSONRequestLog) AddDERToChain(context.Context, []byte) {}
+
+// AddCertToChain records the subject of a certificate that is part of a
+// submitted chain.
+func (jrl *JSONRequestLog) AddCertToChain(ctx context.Context, cert *x509.Certificate) {
+	subject := x509util.NameToString(cert.Subject)
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.ChainSubjects = append(r.ChainSubjects, subject) })
+}
+
+// FirstAndSecond records request parameters.
+func (jrl *JSONRequestLog) FirstAndSecond(ctx context.Context, f, s int64) {
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.First, r.Second = &f, &s })
+}
+
+// StartAndEnd records request parameters.
+func (jrl *JSONRequestLog) StartAndEnd(ctx context.Context, s, e int64) {
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.Start, r.End = &s, &e })
+}
+
+// LeafIndex records request parameters.
+func (jrl *JSONRequestLog) LeafIndex(ctx context.Context, li int64) {
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.LeafIndex = &li })
+}
+
+// TreeSize records request parameters.
+func (jrl *JSONRequestLog) TreeSize(ctx context.Context, ts int64) {
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.TreeSize = &ts })
+}
+
+// LeafHash records request parameters.
+func (jrl *JSONRequestLog) LeafHash(ctx context.Context, lh []byte) {
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.LeafHash = hex.EncodeToString(lh) })
+}
+
+// IssueSCT records the TLS-serialized SCT that will be issued to a client.
+func (jrl *JSONRequestLog) IssueSCT(ctx context.Context, sct []byte) {
+	jrl.update(ctx, func(r *JSONRequestRecord) { r.SCT = sct })
+}
+
+// Status records the response HTTP status code and the request latency, and
+// writes out the request record.
+func (jrl *JSONRequestLog) Status(ctx context.Context, s int) {
+	var record JSONRequestRecord
+	if st, ok := ctx.Value(jsonRecordKey{}).(*jsonRequestState); ok {
+		st.mu.Lock()
+		record = st.record
+		st.mu.Unlock()
+		record.LatencySeconds = jrl.timeSource.Now().Sub(st.started).Seconds()
+	}
+	record.Status = s
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		klog.Warningf("JSONRequestLog: failed to marshal request record: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	jrl.mu.Lock()
+	defer jrl.mu.Unlock()
+	if _, err := jrl.out.Write(data); err != nil {
+		klog.Warningf("JSONRequestLog: failed to write request record: %v", err)
+	}
+}
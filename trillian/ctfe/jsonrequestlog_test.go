@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509/pkix"
+)
+
+// stepTimeSource returns a time one step later on each call to Now.
+type stepTimeSource struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (s *stepTimeSource) Now() time.Time {
+	now := s.now
+	s.now = s.now.Add(s.step)
+	return now
+}
+
+func TestJSONRequestLog(t *testing.T) {
+	var out bytes.Buffer
+	ts := &stepTimeSource{now: time.Unix(1500000000, 0), step: 250 * time.Millisecond}
+	rl := NewJSONRequestLog(&out, ts)
+
+	// An add-chain request.
+	ctx := rl.Start(context.Background())
+	rl.LogPrefix(ctx, "test{1}")
+	rl.AddDERToChain(ctx, []byte{0x30, 0x00})
+	rl.AddCertToChain(ctx, &x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}})
+	rl.AddCertToChain(ctx, &x509.Certificate{Subject: pkix.Name{CommonName: "root"}})
+	rl.IssueSCT(ctx, []byte{0x00, 0x01, 0x02})
+	rl.Status(ctx, http.StatusOK)
+
+	// A get-proof-by-hash request, for a tree size starting at 0.
+	ctx = rl.Start(context.Background())
+	rl.LogPrefix(ctx, "test{1}")
+	rl.LeafHash(ctx, []byte{0xab, 0xcd})
+	rl.TreeSize(ctx, 0)
+	rl.Status(ctx, http.StatusBadRequest)
+
+	// A get-entries request.
+	ctx = rl.Start(context.Background())
+	rl.StartAndEnd(ctx, 0, 9)
+	rl.Status(ctx, http.StatusOK)
+
+	// Calls for a context that was not set up by Start only produce a status.
+	rl.LeafIndex(context.Background(), 5)
+	rl.Status(context.Background(), http.StatusMethodNotAllowed)
+
+	want := []string{
+		`{"prefix":"test{1}","chain_subjects":["CN=leaf","CN=root"],"sct":"AAEC","status":200,"latency_seconds":0.25}`,
+		`{"prefix":"test{1}","tree_size":0,"leaf_hash":"abcd","status":400,"latency_seconds":0.25}`,
+		`{"start":0,"end":9,"status":200,"latency_seconds":0.25}`,
+		`{"status":405,"latency_seconds":0}`,
+	}
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("JSONRequestLog wrote %d records, want %d:\n%s", len(got), len(want), out.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d:\n got: %s\nwant: %s", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/configpb"
+)
+
+// knownECDSACurves holds the names of the curves that can be given in a
+// KeyPolicy, which are those supported by the x509 package.
+var knownECDSACurves = map[string]bool{
+	"P-224":           true,
+	"P-256":           true,
+	"P-384":           true,
+	"P-521":           true,
+	"brainpoolP256r1": true,
+	"brainpoolP384r1": true,
+	"brainpoolP512r1": true,
+}
+
+// KeyPolicy restricts the public keys that are acceptable in the leaf
+// certificates of submitted chains.
+type KeyPolicy struct {
+	// MinRSABits is the minimum size of an RSA modulus, if non-zero.
+	MinRSABits int
+	// AllowedECDSACurves holds the names of the acceptable ECDSA curves, e.g.
+	// "P-256". If empty, all curves are acceptable.
+	AllowedECDSACurves []string
+	// RejectEd25519 indicates that Ed25519 keys are not acceptable.
+	RejectEd25519 bool
+}
+
+// keyPolicyFromConfig returns the KeyPolicy specified by the given log
+// config, or nil if it does not restrict keys.
+func keyPolicyFromConfig(cfg *configpb.LogConfig) (*KeyPolicy, error) {
+	if cfg.MinRsaKeyBits < 0 {
+		return nil, errors.New("negative min_rsa_key_bits")
+	}
+	for _, name := range cfg.AllowedEcdsaCurves {
+		if !knownECDSACurves[name] {
+			return nil, fmt.Errorf("unknown ECDSA curve: %s", name)
+		}
+	}
+	if cfg.MinRsaKeyBits == 0 && len(cfg.AllowedEcdsaCurves) == 0 && !cfg.RejectEd25519 {
+		return nil, nil
+	}
+	return &KeyPolicy{
+		MinRSABits:         int(cfg.MinRsaKeyBits),
+		AllowedECDSACurves: cfg.AllowedEcdsaCurves,
+		RejectEd25519:      cfg.RejectEd25519,
+	}, nil
+}
+
+// Check returns an error describing why the given public key violates the
+// policy, or nil if it is acceptable. A nil KeyPolicy accepts all keys, as
+// does any policy for key types it does not cover.
+func (p *KeyPolicy) Check(pub interface{}) error {
+	if p == nil {
+		return nil
+	}
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if bits := key.N.BitLen(); bits < p.MinRSABits {
+			return fmt.Errorf("RSA key size %d is below the minimum of %d bits", bits, p.MinRSABits)
+		}
+	case *ecdsa.PublicKey:
+		if len(p.AllowedECDSACurves) == 0 {
+			return nil
+		}
+		name := key.Curve.Params().Name
+		for _, allowed := range p.AllowedECDSACurves {
+			if name == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("ECDSA curve %s is not one of %v", name, p.AllowedECDSACurves)
+	case ed25519.PublicKey:
+		if p.RejectEd25519 {
+			return errors.New("Ed25519 keys are not accepted")
+		}
+	}
+	return nil
+}
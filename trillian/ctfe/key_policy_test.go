@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/testonly"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+)
+
+func TestValidateChainKeyPolicy(t *testing.T) {
+	roots := x509util.NewPEMCertPool()
+	if !roots.AppendCertsFromPEM([]byte(testonly.FakeCACertPEM)) {
+		t.Fatal("failed to load fake root")
+	}
+	if !roots.AppendCertsFromPEM([]byte(testonly.CACertPEM)) {
+		t.Fatal("failed to load CA root")
+	}
+	// The precertificate has an RSA-1024 key, and the leaf signed by the fake
+	// intermediate has a P-256 key.
+	rsa1024Chain := pemsToDERChain(t, []string{testonly.PrecertPEMValid})
+	p256Chain := pemsToDERChain(t, []string{testonly.LeafSignedByFakeIntermediateCertPEM, testonly.FakeIntermediateCertPEM})
+
+	var tests = []struct {
+		desc    string
+		chain   [][]byte
+		policy  *KeyPolicy
+		wantErr string
+	}{
+		{
+			desc:  "rsa-1024-no-policy",
+			chain: rsa1024Chain,
+		},
+		{
+			desc:    "rsa-1024-rejected",
+			chain:   rsa1024Chain,
+			policy:  &KeyPolicy{MinRSABits: 2048},
+			wantErr: "RSA key size 1024 is below the minimum of 2048 bits",
+		},
+		{
+			desc:   "rsa-1024-allowed",
+			chain:  rsa1024Chain,
+			policy: &KeyPolicy{MinRSABits: 1024},
+		},
+		{
+			desc:   "p256-allowed",
+			chain:  p256Chain,
+			policy: &KeyPolicy{MinRSABits: 2048, AllowedECDSACurves: []string{"P-256", "P-384"}, RejectEd25519: true},
+		},
+		{
+			desc:    "p256-rejected",
+			chain:   p256Chain,
+			policy:  &KeyPolicy{AllowedECDSACurves: []string{"P-384"}},
+			wantErr: "ECDSA curve P-256 is not one of [P-384]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			opts := CertValidationOpts{trustedRoots: roots, keyPolicy: test.policy}
+			_, err := ValidateChain(test.chain, opts)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateChain()=_,%v; want _,nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ValidateChain()=_,%v; want error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -16,7 +16,11 @@ package ctfe
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
@@ -26,6 +30,51 @@ import (
 
 const vLevel = 9
 
+// RequestIDHeader is the HTTP header that carries a request ID for
+// correlating CTFE logs with those of upstream proxies. An incoming value is
+// reused if it is acceptable, otherwise a new ID is generated; either way the
+// ID is echoed in the response.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLen is the maximum length of an incoming request ID that will be
+// reused.
+const maxRequestIDLen = 128
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID of the request being handled,
+// or "" if there is none. The request ID is set on the context passed to
+// RequestLog.Start, so it is available to all RequestLog calls.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestID returns the ID from the request's RequestIDHeader if it is
+// non-empty, no longer than maxRequestIDLen and contains only printable ASCII
+// characters other than space. Otherwise it returns a new random ID.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); len(id) > 0 && len(id) <= maxRequestIDLen {
+		valid := true
+		for i := 0; i < len(id); i++ {
+			if id[i] <= ' ' || id[i] > '~' {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			return id
+		}
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		klog.Warningf("Failed to generate request ID: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
 // RequestLog allows implementations to do structured logging of CTFE
 // request parameters, submitted chains and other internal details that
 // are useful for log operators when debugging issues. CTFE handlers will
@@ -76,31 +125,42 @@ type RequestLog interface {
 }
 
 // DefaultRequestLog is an implementation of RequestLog that does nothing
-// except log the calls at a high level of verbosity.
+// except log the calls at a high level of verbosity. Each line is tagged with
+// the ID of the request it belongs to.
 type DefaultRequestLog struct {
+	// RedactCerts makes the log contain only SHA-256 fingerprints of the
+	// submitted certificates, rather than their full DER bytes.
+	RedactCerts bool
 }
 
 // Start logs the start of request processing.
 func (dlr *DefaultRequestLog) Start(ctx context.Context) context.Context {
-	klog.V(vLevel).Info("RL: Start")
+	klog.V(vLevel).Infof("RL[%s]: Start", RequestIDFromContext(ctx))
 	return ctx
 }
 
 // LogPrefix logs the prefix of the CT log that this request is for.
-func (dlr *DefaultRequestLog) LogPrefix(_ context.Context, p string) {
-	klog.V(vLevel).Infof("RL: LogPrefix: %s", p)
+func (dlr *DefaultRequestLog) LogPrefix(ctx context.Context, p string) {
+	klog.V(vLevel).Infof("RL[%s]: LogPrefix: %s", RequestIDFromContext(ctx), p)
 }
 
-// AddDERToChain logs the raw bytes of a submitted certificate.
-func (dlr *DefaultRequestLog) AddDERToChain(_ context.Context, d []byte) {
+// AddDERToChain logs the raw bytes of a submitted certificate, or only their
+// fingerprint if RedactCerts is set.
+func (dlr *DefaultRequestLog) AddDERToChain(ctx context.Context, d []byte) {
+	if dlr.RedactCerts {
+		fp := sha256.Sum256(d)
+		klog.V(vLevel).Infof("RL[%s]: Cert DER SHA-256: %s (%d bytes)", RequestIDFromContext(ctx), hex.EncodeToString(fp[:]), len(d))
+		return
+	}
 	// Explicit hex encoding below to satisfy CodeQL:
-	klog.V(vLevel).Infof("RL: Cert DER: %s", hex.EncodeToString(d))
+	klog.V(vLevel).Infof("RL[%s]: Cert DER: %s", RequestIDFromContext(ctx), hex.EncodeToString(d))
 }
 
 // AddCertToChain logs some issuer / subject / timing fields from a
 // certificate that is part of a submitted chain.
-func (dlr *DefaultRequestLog) AddCertToChain(_ context.Context, cert *x509.Certificate) {
-	klog.V(vLevel).Infof("RL: Cert: Sub: %s Iss: %s notBef: %s notAft: %s",
+func (dlr *DefaultRequestLog) AddCertToChain(ctx context.Context, cert *x509.Certificate) {
+	klog.V(vLevel).Infof("RL[%s]: Cert: Sub: %s Iss: %s notBef: %s notAft: %s",
+		RequestIDFromContext(ctx),
 		x509util.NameToString(cert.Subject),
 		x509util.NameToString(cert.Issuer),
 		cert.NotBefore.Format(time.RFC1123Z),
@@ -108,37 +168,102 @@ func (dlr *DefaultRequestLog) AddCertToChain(_ context.Context, cert *x509.Certi
 }
 
 // FirstAndSecond logs request parameters.
-func (dlr *DefaultRequestLog) FirstAndSecond(_ context.Context, f, s int64) {
-	klog.V(vLevel).Infof("RL: First: %d Second: %d", f, s)
+func (dlr *DefaultRequestLog) FirstAndSecond(ctx context.Context, f, s int64) {
+	klog.V(vLevel).Infof("RL[%s]: First: %d Second: %d", RequestIDFromContext(ctx), f, s)
 }
 
 // StartAndEnd logs request parameters.
-func (dlr *DefaultRequestLog) StartAndEnd(_ context.Context, s, e int64) {
-	klog.V(vLevel).Infof("RL: Start: %d End: %d", s, e)
+func (dlr *DefaultRequestLog) StartAndEnd(ctx context.Context, s, e int64) {
+	klog.V(vLevel).Infof("RL[%s]: Start: %d End: %d", RequestIDFromContext(ctx), s, e)
 }
 
 // LeafIndex logs request parameters.
-func (dlr *DefaultRequestLog) LeafIndex(_ context.Context, li int64) {
-	klog.V(vLevel).Infof("RL: LeafIndex: %d", li)
+func (dlr *DefaultRequestLog) LeafIndex(ctx context.Context, li int64) {
+	klog.V(vLevel).Infof("RL[%s]: LeafIndex: %d", RequestIDFromContext(ctx), li)
 }
 
 // TreeSize logs request parameters.
-func (dlr *DefaultRequestLog) TreeSize(_ context.Context, ts int64) {
-	klog.V(vLevel).Infof("RL: TreeSize: %d", ts)
+func (dlr *DefaultRequestLog) TreeSize(ctx context.Context, ts int64) {
+	klog.V(vLevel).Infof("RL[%s]: TreeSize: %d", RequestIDFromContext(ctx), ts)
 }
 
 // LeafHash logs request parameters.
-func (dlr *DefaultRequestLog) LeafHash(_ context.Context, lh []byte) {
+func (dlr *DefaultRequestLog) LeafHash(ctx context.Context, lh []byte) {
 	// Explicit hex encoding below to satisfy CodeQL:
-	klog.V(vLevel).Infof("RL: LeafHash: %s", hex.EncodeToString(lh))
+	klog.V(vLevel).Infof("RL[%s]: LeafHash: %s", RequestIDFromContext(ctx), hex.EncodeToString(lh))
 }
 
 // IssueSCT logs an SCT that will be issued to a client.
-func (dlr *DefaultRequestLog) IssueSCT(_ context.Context, sct []byte) {
-	klog.V(vLevel).Infof("RL: Issuing SCT: %x", sct)
+func (dlr *DefaultRequestLog) IssueSCT(ctx context.Context, sct []byte) {
+	klog.V(vLevel).Infof("RL[%s]: Issuing SCT: %x", RequestIDFromContext(ctx), sct)
 }
 
 // Status logs the response HTTP status code after processing completes.
-func (dlr *DefaultRequestLog) Status(_ context.Context, s int) {
-	klog.V(vLevel).Infof("RL: Status: %d", s)
+func (dlr *DefaultRequestLog) Status(ctx context.Context, s int) {
+	klog.V(vLevel).Infof("RL[%s]: Status: %d", RequestIDFromContext(ctx), s)
+}
+
+// chainBufferKey is the context key for the per-request chain buffer kept by
+// ErrorChainRequestLog.
+type chainBufferKey struct{}
+
+// chainBuffer holds the DER certificates of a submitted chain until the
+// outcome of the request is known.
+type chainBuffer struct {
+	mu   sync.Mutex
+	ders [][]byte
+}
+
+// ErrorChainRequestLog is a RequestLog that wraps another RequestLog and
+// holds back the submitted chain. AddDERToChain calls are buffered for the
+// duration of the request and only passed on to the wrapped RequestLog when
+// the request completes with an error status (4xx or 5xx); the buffered chain
+// is discarded for successful requests. All other calls are passed through
+// unchanged.
+type ErrorChainRequestLog struct {
+	RequestLog
+}
+
+// NewErrorChainRequestLog returns a RequestLog which only logs submitted
+// chains to rl for requests that fail.
+func NewErrorChainRequestLog(rl RequestLog) *ErrorChainRequestLog {
+	return &ErrorChainRequestLog{RequestLog: rl}
+}
+
+// Start starts the wrapped RequestLog and attaches a chain buffer to the
+// returned context.
+func (elr *ErrorChainRequestLog) Start(ctx context.Context) context.Context {
+	ctx = elr.RequestLog.Start(ctx)
+	return context.WithValue(ctx, chainBufferKey{}, &chainBuffer{})
+}
+
+// AddDERToChain buffers a submitted certificate until the request status is
+// known. If the context was not set up by Start, the certificate is passed
+// straight through.
+func (elr *ErrorChainRequestLog) AddDERToChain(ctx context.Context, d []byte) {
+	buf, ok := ctx.Value(chainBufferKey{}).(*chainBuffer)
+	if !ok {
+		elr.RequestLog.AddDERToChain(ctx, d)
+		return
+	}
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	buf.ders = append(buf.ders, d)
+}
+
+// Status passes any buffered certificates to the wrapped RequestLog if s is an
+// error status, and then records the status itself.
+func (elr *ErrorChainRequestLog) Status(ctx context.Context, s int) {
+	if buf, ok := ctx.Value(chainBufferKey{}).(*chainBuffer); ok {
+		buf.mu.Lock()
+		ders := buf.ders
+		buf.ders = nil
+		buf.mu.Unlock()
+		if s >= http.StatusBadRequest {
+			for _, d := range ders {
+				elr.RequestLog.AddDERToChain(ctx, d)
+			}
+		}
+	}
+	elr.RequestLog.Status(ctx, s)
 }
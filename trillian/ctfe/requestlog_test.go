@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingRequestLog is a RequestLog that remembers the chain and status it
+// was given.
+type recordingRequestLog struct {
+	DefaultRequestLog
+	ders   [][]byte
+	status int
+}
+
+func (rrl *recordingRequestLog) AddDERToChain(_ context.Context, d []byte) {
+	rrl.ders = append(rrl.ders, d)
+}
+
+func (rrl *recordingRequestLog) Status(_ context.Context, s int) {
+	rrl.status = s
+}
+
+func TestErrorChainRequestLog(t *testing.T) {
+	chain := [][]byte{[]byte("leaf"), []byte("intermediate"), []byte("root")}
+	for _, test := range []struct {
+		name   string
+		status int
+		want   [][]byte
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "bad-request", status: http.StatusBadRequest, want: chain},
+		{name: "internal-error", status: http.StatusInternalServerError, want: chain},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rec := &recordingRequestLog{}
+			rl := NewErrorChainRequestLog(rec)
+
+			ctx := rl.Start(context.Background())
+			rl.LogPrefix(ctx, "test")
+			for _, der := range chain {
+				rl.AddDERToChain(ctx, der)
+			}
+			if len(rec.ders) != 0 {
+				t.Fatalf("AddDERToChain() passed %d certs through before Status()", len(rec.ders))
+			}
+			rl.Status(ctx, test.status)
+
+			if diff := cmp.Diff(test.want, rec.ders); diff != "" {
+				t.Errorf("logged chain diff (-want +got):\n%s", diff)
+			}
+			if rec.status != test.status {
+				t.Errorf("logged status=%d, want %d", rec.status, test.status)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		header  string
+		wantID  string // Empty if a new ID should be generated.
+		wantLen int
+	}{
+		{name: "from-header", header: "proxy-1234:abcd", wantID: "proxy-1234:abcd"},
+		{name: "missing", wantLen: 32},
+		{name: "has-space", header: "bad id", wantLen: 32},
+		{name: "too-long", header: strings.Repeat("a", maxRequestIDLen+1), wantLen: 32},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			info := setupTest(t, nil, nil)
+			defer info.mockCtrl.Finish()
+			var out bytes.Buffer
+			info.li.RequestLog = NewJSONRequestLog(&out, fakeTimeSource)
+			handler := AppHandler{Info: info.li, Handler: getRoots, Name: GetRootsName, Method: http.MethodGet}
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/ct/v1/get-roots", nil)
+			if test.header != "" {
+				req.Header.Set(RequestIDHeader, test.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			gotID := w.Header().Get(RequestIDHeader)
+			if test.wantID != "" && gotID != test.wantID {
+				t.Errorf("response %s=%q, want %q", RequestIDHeader, gotID, test.wantID)
+			}
+			if test.wantID == "" && len(gotID) != test.wantLen {
+				t.Errorf("response %s=%q, want generated ID of length %d", RequestIDHeader, gotID, test.wantLen)
+			}
+
+			var record JSONRequestRecord
+			if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+				t.Fatalf("failed to unmarshal request log %q: %v", out.String(), err)
+			}
+			if record.RequestID != gotID {
+				t.Errorf("logged request_id=%q, want %q", record.RequestID, gotID)
+			}
+		})
+	}
+}
@@ -50,13 +50,17 @@ func (s *directIssuanceChainService) FixLogLeaf(ctx context.Context, leaf *trill
 	return nil
 }
 
-func newIndirectIssuanceChainService(s storage.IssuanceChainStorage, c cache.IssuanceChainCache) *indirectIssuanceChainService {
+// newIndirectIssuanceChainService creates an indirectIssuanceChainService,
+// which reports cache metrics for the log with the given label. The metrics
+// must have been set up before the service is used.
+func newIndirectIssuanceChainService(s storage.IssuanceChainStorage, c cache.IssuanceChainCache, label string) *indirectIssuanceChainService {
 	if s == nil || c == nil {
 		panic("storage and cache are required")
 	}
 	service := &indirectIssuanceChainService{
 		storage: s,
 		cache:   c,
+		label:   label,
 	}
 
 	return service
@@ -68,6 +72,7 @@ func newIndirectIssuanceChainService(s storage.IssuanceChainStorage, c cache.Iss
 type indirectIssuanceChainService struct {
 	storage storage.IssuanceChainStorage
 	cache   cache.IssuanceChainCache
+	label   string // The logid label for metrics.
 }
 
 // BuildLogLeaf builds the MerkleTreeLeaf that gets sent to the backend, and make a trillian.LogLeaf for it.
@@ -170,7 +175,7 @@ func (s *indirectIssuanceChainService) FixLogLeaf(ctx context.Context, leaf *tri
 // getByHash returns the issuance chain with hash as the input.
 func (s *indirectIssuanceChainService) getByHash(ctx context.Context, hash []byte) ([]byte, error) {
 	// Return if found in cache.
-	chain, err := s.cache.Get(ctx, hash)
+	chain, err := s.cacheGet(ctx, hash)
 	if chain != nil || err != nil {
 		return chain, err
 	}
@@ -198,7 +203,7 @@ func (s *indirectIssuanceChainService) add(ctx context.Context, chain []byte) ([
 	hash := issuanceChainHash(chain)
 
 	// If present in cache, then the chain is already stored.
-	if cachedChain, err := s.cache.Get(ctx, hash); err == nil && cachedChain != nil {
+	if cachedChain, err := s.cacheGet(ctx, hash); err == nil && cachedChain != nil {
 		return hash, nil
 	}
 
@@ -217,6 +222,18 @@ func (s *indirectIssuanceChainService) add(ctx context.Context, chain []byte) ([
 	return hash, nil
 }
 
+// cacheGet looks up the issuance chain with the given hash in the cache, and
+// records whether it was a hit or a miss.
+func (s *indirectIssuanceChainService) cacheGet(ctx context.Context, hash []byte) ([]byte, error) {
+	chain, err := s.cache.Get(ctx, hash)
+	if err == nil && chain != nil {
+		issuanceChainCacheHits.Inc(s.label)
+	} else {
+		issuanceChainCacheMisses.Inc(s.label)
+	}
+	return chain, err
+}
+
 // issuanceChainHash returns the SHA-256 hash of the chain.
 func issuanceChainHash(chain []byte) []byte {
 	checksum := sha256.Sum256(chain)
@@ -21,6 +21,8 @@ import (
 	"os"
 	"sync"
 	"testing"
+
+	"github.com/google/trillian/monitoring"
 )
 
 func TestIssuanceChainServiceAddAndGet(t *testing.T) {
@@ -36,7 +38,8 @@ func TestIssuanceChainServiceAddAndGet(t *testing.T) {
 	ctx := context.Background()
 	storage := &fakeIssuanceChainStorage{}
 	cache := &fakeIssuanceChainCache{}
-	issuanceChainService := newIndirectIssuanceChainService(storage, cache)
+	once.Do(func() { setupMetrics(monitoring.InertMetricFactory{}) })
+	issuanceChainService := newIndirectIssuanceChainService(storage, cache, "add-and-get")
 
 	for _, test := range tests {
 		hash, err := issuanceChainService.add(ctx, test.chain)
@@ -55,6 +58,46 @@ func TestIssuanceChainServiceAddAndGet(t *testing.T) {
 	}
 }
 
+func TestIssuanceChainServiceCacheMetrics(t *testing.T) {
+	ctx := context.Background()
+	storage := &fakeIssuanceChainStorage{}
+	cache := &fakeIssuanceChainCache{}
+	once.Do(func() { setupMetrics(monitoring.InertMetricFactory{}) })
+	const label = "cache-metrics"
+	issuanceChainService := newIndirectIssuanceChainService(storage, cache, label)
+
+	cached := readTestData(t, "leaf00.chain")
+	cachedHash := issuanceChainHash(cached)
+	if err := cache.Set(ctx, cachedHash, cached); err != nil {
+		t.Fatalf("cache.Set(): %v", err)
+	}
+	novel := readTestData(t, "leaf01.chain")
+	novelHash := issuanceChainHash(novel)
+	if err := storage.Add(ctx, novelHash, novel); err != nil {
+		t.Fatalf("storage.Add(): %v", err)
+	}
+
+	hits, misses := issuanceChainCacheHits.Value(label), issuanceChainCacheMisses.Value(label)
+	for i := 0; i < 2; i++ {
+		if _, err := issuanceChainService.getByHash(ctx, cachedHash); err != nil {
+			t.Fatalf("IssuanceChainService.GetByHash(): %v", err)
+		}
+	}
+	if got, want := issuanceChainCacheHits.Value(label)-hits, 2.0; got != want {
+		t.Errorf("cache hits after repeated lookup=%v, want %v", got, want)
+	}
+	if got, want := issuanceChainCacheMisses.Value(label)-misses, 0.0; got != want {
+		t.Errorf("cache misses after repeated lookup=%v, want %v", got, want)
+	}
+
+	if _, err := issuanceChainService.getByHash(ctx, novelHash); err != nil {
+		t.Fatalf("IssuanceChainService.GetByHash(): %v", err)
+	}
+	if got, want := issuanceChainCacheMisses.Value(label)-misses, 1.0; got != want {
+		t.Errorf("cache misses after novel lookup=%v, want %v", got, want)
+	}
+}
+
 func TestIssuanceChainHashLen(t *testing.T) {
 	want := sha256.Size
 	tests := []struct {
@@ -78,8 +78,9 @@ func (sg *LogSTHGetter) GetSTH(ctx context.Context) (*ct.SignedTreeHead, error)
 		TreeSize:  uint64(currentRoot.TreeSize),
 		Timestamp: uint64(currentRoot.TimestampNanos / 1000 / 1000),
 	}
-	// Note: The size was checked in getSignedLogRoot.
-	copy(sth.SHA256RootHash[:], currentRoot.RootHash)
+	if err := sth.SHA256RootHash.FromBytes(currentRoot.RootHash); err != nil {
+		return nil, fmt.Errorf("root hash is %v", err)
+	}
 
 	// Add the signature over the STH contents.
 	err = signV1TreeHead(sg.li.signer, sth, &sg.cache)
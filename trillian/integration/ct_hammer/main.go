@@ -26,6 +26,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +38,7 @@ import (
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/configpb"
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/integration"
 	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+	"github.com/google/trillian/client/backoff"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/monitoring/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -47,6 +49,8 @@ import (
 var (
 	banner      = flag.Bool("banner", true, "Display intro")
 	httpServers = flag.String("ct_http_servers", "localhost:8092", "Comma-separated list of (assumed interchangeable) servers, each as address:port")
+	clientPool  = flag.String("client_pool", integration.RandomPoolStrategy, "How to distribute requests across --ct_http_servers: random, weighted or least_in_flight")
+	weights     = flag.String("ct_http_server_weights", "", "Comma-separated list of positive weights, one per --ct_http_servers entry, for --client_pool=weighted")
 	bearerToken = flag.String("bearer_token", "", "The bearer token for authentication with servers. Not set if empty. For GCP this is the result of `gcloud auth print-identity-token`")
 
 	// Options for synthetic cert generation.
@@ -67,6 +71,7 @@ var (
 	logConfig           = flag.String("log_config", "", "File holding log config in JSON")
 	mmd                 = flag.Duration("mmd", 2*time.Minute, "Default MMD for logs")
 	operations          = flag.Uint64("operations", ^uint64(0), "Number of operations to perform")
+	duration            = flag.Duration("duration", 0, "How long to perform operations for, if --operations are not completed first (0 for no limit)")
 	minGetEntries       = flag.Int("min_get_entries", 1, "Minimum get-entries request size")
 	maxGetEntries       = flag.Int("max_get_entries", 500, "Maximum get-entries request size")
 	oversizedGetEntries = flag.Bool("oversized_get_entries", false, "Whether get-entries requests can go beyond log size")
@@ -75,6 +80,10 @@ var (
 	ignoreErrors        = flag.Bool("ignore_errors", false, "Whether to ignore errors and retry the operation")
 	maxRetry            = flag.Duration("max_retry", 60*time.Second, "How long to keep retrying when ignore_errors is set")
 	reqDeadline         = flag.Duration("req_deadline", 10*time.Second, "Deadline to set on individual requests")
+	addDeadline         = flag.Duration("add_deadline", 0, "Deadline to set on individual add-chain and add-pre-chain requests (0 to use --req_deadline)")
+	retryBackoffMin     = flag.Duration("retry_backoff_min", 100*time.Millisecond, "Pause before the first retry of a failed operation when ignore_errors is set")
+	retryBackoffMax     = flag.Duration("retry_backoff_max", 10*time.Second, "Maximum pause between retries of a failed operation when ignore_errors is set")
+	retryBackoffFactor  = flag.Float64("retry_backoff_factor", 2, "Factor by which the pause between retries of a failed operation grows")
 )
 var (
 	addChainBias             = flag.Int("add_chain", 20, "Bias for add-chain operations")
@@ -88,6 +97,12 @@ var (
 	invalidChance            = flag.Int("invalid_chance", 10, "Chance of generating an invalid operation, as the N in 1-in-N (0 for never)")
 	dupeChance               = flag.Int("duplicate_chance", 10, "Chance of generating a duplicate submission, as the N in 1-in-N (0 for never)")
 	strictSTHConsistencySize = flag.Bool("strict_sth_consistency_size", true, "If set to true, hammer will use only tree sizes from STHs it's seen for consistency proofs, otherwise it'll choose a random size for the smaller tree")
+	verifyGetEntriesChains   = flag.Bool("verify_get_entries_chains", false, "If set to true, hammer will check that get-entries chains parse, and are consistent with precert entries")
+	consistencyAllPairs      = flag.Bool("consistency_all_pairs", false, "If set to true, hammer will verify consistency proofs between every adjacent pair of STHs it has retained, rather than a single random pair")
+	checkSTHMonotonic        = flag.Bool("check_sth_monotonic", false, "If set to true, hammer will fail if an STH has a smaller tree size or earlier timestamp than the one fetched before it")
+	sthCacheDuration         = flag.Duration("sth_cache_duration", 0, "How long operations other than get-sth may reuse the last fetched STH (0 to always fetch a fresh one)")
+	resultsFile              = flag.String("results_file", "", "File to write a JSON record of the results for each log to at the end of the run")
+	seed                     = flag.Int64("seed", 0, "Seed for the hammer's random choices, to reproduce a run (0 for a time-based seed, which is logged)")
 )
 
 func newLimiter(limit int) integration.Limiter {
@@ -226,7 +241,7 @@ func main() {
 			ctfe.GetProofByHashName:    *invalidChance,
 			ctfe.GetEntriesName:        *invalidChance,
 			ctfe.GetRootsName:          0,
-			ctfe.GetEntryAndProofName:  0,
+			ctfe.GetEntryAndProofName:  *invalidChance,
 		},
 	}
 
@@ -267,7 +282,31 @@ func main() {
 		prefix string
 		err    error
 	}
+	var serverWeights []int
+	if *weights != "" {
+		for _, w := range strings.Split(*weights, ",") {
+			weight, err := strconv.Atoi(strings.TrimSpace(w))
+			if err != nil {
+				klog.Exitf("Failed to parse --ct_http_server_weights: %v", err)
+			}
+			serverWeights = append(serverWeights, weight)
+		}
+	}
+
 	results := make(chan result, len(cfg))
+	var resultsOut io.Writer
+	if *resultsFile != "" {
+		f, err := os.Create(*resultsFile)
+		if err != nil {
+			klog.Exitf("Failed to create results file: %v", err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				klog.Errorf("Failed to close results file: %v", err)
+			}
+		}()
+		resultsOut = &syncWriter{w: f}
+	}
 	var wg sync.WaitGroup
 	for _, c := range cfg {
 		wg.Add(1)
@@ -275,7 +314,7 @@ func main() {
 		if *bearerToken != "" {
 			auth = fmt.Sprintf("Bearer %s", *bearerToken)
 		}
-		pool, err := integration.NewRandomPool(*httpServers, c.PublicKey, c.Prefix, auth)
+		pool, err := integration.NewClientPool(*clientPool, *httpServers, serverWeights, c.PublicKey, c.Prefix, auth)
 		if err != nil {
 			klog.Exitf("Failed to create client pool: %v", err)
 		}
@@ -292,24 +331,47 @@ func main() {
 			klog.Exitf("Failed to build chain generator: %v", err)
 		}
 
+		var deadlines map[ctfe.EntrypointName]time.Duration
+		if *addDeadline > 0 {
+			deadlines = map[ctfe.EntrypointName]time.Duration{
+				ctfe.AddChainName:    *addDeadline,
+				ctfe.AddPreChainName: *addDeadline,
+			}
+		}
+
+		retryBackoff := backoff.Backoff{
+			Min:    *retryBackoffMin,
+			Max:    *retryBackoffMax,
+			Factor: *retryBackoffFactor,
+		}
+
 		cfg := integration.HammerConfig{
-			LogCfg:                   c,
-			MetricFactory:            mf,
-			MMD:                      mmd,
-			ChainGenerator:           generator,
-			ClientPool:               pool,
-			EPBias:                   bias,
-			MinGetEntries:            *minGetEntries,
-			MaxGetEntries:            *maxGetEntries,
-			OversizedGetEntries:      *oversizedGetEntries,
-			Operations:               *operations,
-			Limiter:                  newLimiter(*limit),
-			MaxParallelChains:        *maxParallelChains,
-			IgnoreErrors:             *ignoreErrors,
-			MaxRetryDuration:         *maxRetry,
-			RequestDeadline:          *reqDeadline,
-			DuplicateChance:          *dupeChance,
-			StrictSTHConsistencySize: *strictSTHConsistencySize,
+			LogCfg:                    c,
+			MetricFactory:             mf,
+			MMD:                       mmd,
+			ChainGenerator:            generator,
+			ClientPool:                pool,
+			EPBias:                    bias,
+			MinGetEntries:             *minGetEntries,
+			MaxGetEntries:             *maxGetEntries,
+			OversizedGetEntries:       *oversizedGetEntries,
+			Operations:                *operations,
+			Duration:                  *duration,
+			Limiter:                   newLimiter(*limit),
+			MaxParallelChains:         *maxParallelChains,
+			IgnoreErrors:              *ignoreErrors,
+			MaxRetryDuration:          *maxRetry,
+			RequestDeadline:           *reqDeadline,
+			RequestDeadlines:          deadlines,
+			RetryBackoff:              retryBackoff,
+			DuplicateChance:           *dupeChance,
+			StrictSTHConsistencySize:  *strictSTHConsistencySize,
+			STHCacheDuration:          *sthCacheDuration,
+			CheckSTHMonotonic:         *checkSTHMonotonic,
+			StrictConsistencyAllPairs: *consistencyAllPairs,
+			VerifyGetEntriesChains:    *verifyGetEntriesChains,
+			Seed:                      *seed,
+			Results:                   resultsOut,
 		}
 		go func(cfg integration.HammerConfig) {
 			defer wg.Done()
@@ -333,3 +395,15 @@ func main() {
 	}
 	klog.Info("  no errors; done")
 }
+
+// syncWriter serializes writes to an io.Writer shared by several hammers.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
@@ -99,6 +99,18 @@ func (p RandomPool) Next() *client.LogClient {
 
 // NewRandomPool creates a pool which returns a random client from list of servers.
 func NewRandomPool(servers string, pubKey *keyspb.PublicKey, prefix string, auth string) (ClientPool, error) {
+	clients, err := newLogClients(servers, pubKey, prefix, auth, nil)
+	if err != nil {
+		return nil, err
+	}
+	pool := RandomPool(clients)
+	return &pool, nil
+}
+
+// newLogClients creates a LogClient for the log with the given prefix on each
+// of the comma-separated servers. If wrap is not nil, it is used to wrap the
+// HTTP transport of the i-th client.
+func newLogClients(servers string, pubKey *keyspb.PublicKey, prefix string, auth string, wrap func(i int, rt http.RoundTripper) http.RoundTripper) ([]*client.LogClient, error) {
 	opts := jsonclient.Options{
 		PublicKeyDER:  pubKey.GetDer(),
 		UserAgent:     "ct-go-integrationtest/1.0",
@@ -107,18 +119,21 @@ func NewRandomPool(servers string, pubKey *keyspb.PublicKey, prefix string, auth
 
 	hc := &http.Client{Transport: DefaultTransport}
 
-	var pool RandomPool
-	for _, s := range strings.Split(servers, ",") {
+	var clients []*client.LogClient
+	for i, s := range strings.Split(servers, ",") {
 		if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
 			s = "http://" + s
 		}
+		if wrap != nil {
+			hc = &http.Client{Transport: wrap(i, DefaultTransport)}
+		}
 		c, err := client.New(fmt.Sprintf("%s/%s", s, prefix), hc, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create LogClient instance: %v", err)
 		}
-		pool = append(pool, c)
+		clients = append(clients, c)
 	}
-	return &pool, nil
+	return clients, nil
 }
 
 // testInfo holds per-test information.
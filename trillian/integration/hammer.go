@@ -15,10 +15,14 @@
 package integration
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"strconv"
@@ -31,6 +35,7 @@ import (
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe"
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/configpb"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/google/trillian/client/backoff"
 	"github.com/google/trillian/monitoring"
 	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/proof"
@@ -128,8 +133,15 @@ type HammerConfig struct {
 	// OversizedGetEntries governs whether get-entries requests that go beyond the
 	// current tree size are allowed (with a truncated response expected).
 	OversizedGetEntries bool
+	// VerifyGetEntriesChains governs whether get-entries responses are checked
+	// more thoroughly: the certificates of each entry's chain must parse, and
+	// the issuer key hash of each precert entry must match its issuer.
+	VerifyGetEntriesChains bool
 	// Number of operations to perform.
 	Operations uint64
+	// Duration, if positive, limits how long to perform operations for; the
+	// run stops at whichever of Operations or Duration is reached first.
+	Duration time.Duration
 	// Rate limiter
 	Limiter Limiter
 	// MaxParallelChains sets the upper limit for the number of parallel
@@ -141,17 +153,50 @@ type HammerConfig struct {
 	IgnoreErrors bool
 	// MaxRetryDuration governs how long to keep retrying when IgnoreErrors is true.
 	MaxRetryDuration time.Duration
+	// RetryBackoff governs the pauses between attempts when a failed
+	// operation is retried. Unset fields get default values.
+	RetryBackoff backoff.Backoff
 	// RequestDeadline indicates the deadline to set on each request to the log.
 	RequestDeadline time.Duration
+	// RequestDeadlines overrides RequestDeadline for particular entrypoints,
+	// e.g. to give add-chain requests longer than get-sth ones.
+	RequestDeadlines map[ctfe.EntrypointName]time.Duration
 	// DuplicateChance sets the probability of attempting to add a duplicate when
 	// calling add[-pre]-chain (as the N in 1-in-N). Set to 0 to disable sending
-	// duplicates.
+	// duplicates. The SCT returned for a duplicate must match the one returned
+	// when the chain was first submitted.
 	DuplicateChance int
 	// StrictSTHConsistencySize if set to true will cause Hammer to only request
 	// STH consistency proofs between tree sizes for which it's seen valid STHs.
 	// If set to false, Hammer will request a consistency proof between the
 	// current tree size, and a random smaller size greater than zero.
 	StrictSTHConsistencySize bool
+	// StrictConsistencyAllPairs if set to true will cause get-sth-consistency
+	// operations to verify consistency proofs between every adjacent pair of
+	// retained STHs, rather than between the current STH and a random one.
+	StrictConsistencyAllPairs bool
+	// CheckSTHMonotonic if set to true will cause get-sth operations to fail
+	// if the new STH has a smaller tree size or an earlier timestamp than the
+	// previously fetched one, as would happen if the log rolled back or forked.
+	// Logs whose frontends serve STHs independently may legitimately regress
+	// between requests, so this is off by default.
+	CheckSTHMonotonic bool
+	// STHCacheDuration, if positive, allows operations that need the current
+	// STH (e.g. get-sth-consistency) to reuse an STH fetched less than this
+	// long ago, rather than making an extra get-sth request each time. The
+	// get-sth operation itself always fetches a fresh STH, and refreshes the
+	// cache.
+	STHCacheDuration time.Duration
+	// Seed seeds the source of all the random choices the hammer makes, so
+	// that runs can be reproduced. If zero, a time-based seed is picked and
+	// logged. Ignored if Rand is set.
+	Seed int64
+	// Rand, if set, is used as the source of all the random choices the
+	// hammer makes. It must not be used elsewhere while the hammer runs.
+	Rand *rand.Rand
+	// Results, if set, receives a JSON-encoded HammerResults record at the
+	// end of the run.
+	Results io.Writer
 }
 
 // HammerBias indicates the bias for selecting different log operations.
@@ -164,12 +209,16 @@ type HammerBias struct {
 
 // Choose randomly picks an operation to perform according to the biases.
 func (hb HammerBias) Choose() ctfe.EntrypointName {
+	return hb.choose(rand.Intn)
+}
+
+func (hb HammerBias) choose(intn func(int) int) ctfe.EntrypointName {
 	if hb.total == 0 {
 		for _, ep := range ctfe.Entrypoints {
 			hb.total += hb.Bias[ep]
 		}
 	}
-	which := rand.Intn(hb.total)
+	which := intn(hb.total)
 	for _, ep := range ctfe.Entrypoints {
 		which -= hb.Bias[ep]
 		if which < 0 {
@@ -181,11 +230,33 @@ func (hb HammerBias) Choose() ctfe.EntrypointName {
 
 // Invalid randomly chooses whether an operation should be invalid.
 func (hb HammerBias) Invalid(ep ctfe.EntrypointName) bool {
+	return hb.invalid(ep, rand.Intn)
+}
+
+func (hb HammerBias) invalid(ep ctfe.EntrypointName, intn func(int) int) bool {
 	chance := hb.InvalidChance[ep]
 	if chance <= 0 {
 		return false
 	}
-	return rand.Intn(chance) == 0
+	return intn(chance) == 0
+}
+
+// lockedRand wraps a rand.Rand so that it can be used concurrently.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
 }
 
 type submittedCert struct {
@@ -293,11 +364,17 @@ type hammerState struct {
 	firstPreChain, lastPreChain []ct.ASN1Cert
 	firstPreChainIntegrated     time.Time
 	firstTBS, lastTBS           []byte
+	// SCTs received for the chains above, for checking duplicate submissions.
+	firstSCT, lastSCT       *ct.SignedCertificateTimestamp
+	firstPreSCT, lastPreSCT *ct.SignedCertificateTimestamp
 
 	mu sync.RWMutex
 	// STHs are arranged from later to earlier (so [0] is the most recent), and the
 	// discovery of new STHs will push older ones off the end.
 	sth [sthCount]*ct.SignedTreeHead
+	// The most recently fetched STH, and when it was fetched.
+	cachedSTH   *ct.SignedTreeHead
+	cachedSTHAt time.Time
 	// Submitted certs also run from later to earlier, but the discovery of new SCTs
 	// does not affect the existing contents of the array, so if the array is full it
 	// keeps the same elements.  Instead, the oldest entry is removed (and a space
@@ -305,6 +382,10 @@ type hammerState struct {
 	pending pendingCerts
 	// Operations that are required to fix dependencies.
 	nextOp []ctfe.EntrypointName
+	// Source of random choices, shared by concurrent operations.
+	rng *lockedRand
+	// Latencies of successful valid operations, per entrypoint.
+	latencies map[ctfe.EntrypointName]*latencyReservoir
 
 	hasher merkle.LogHasher
 }
@@ -330,6 +411,15 @@ func newHammerState(cfg *HammerConfig) (*hammerState, error) {
 	if cfg.MaxRetryDuration <= 0 {
 		cfg.MaxRetryDuration = 60 * time.Second
 	}
+	if cfg.RetryBackoff.Min <= 0 {
+		cfg.RetryBackoff.Min = 100 * time.Millisecond
+	}
+	if cfg.RetryBackoff.Max < cfg.RetryBackoff.Min {
+		cfg.RetryBackoff.Max = max(10*time.Second, cfg.RetryBackoff.Min)
+	}
+	if cfg.RetryBackoff.Factor < 1 {
+		cfg.RetryBackoff.Factor = 2
+	}
 
 	if cfg.LogCfg.IsMirror {
 		klog.Warningf("%v: disabling add-[pre-]chain for mirror log", cfg.LogCfg.Prefix)
@@ -337,14 +427,47 @@ func newHammerState(cfg *HammerConfig) (*hammerState, error) {
 		cfg.EPBias.Bias[ctfe.AddPreChainName] = 0
 	}
 
+	rng := cfg.Rand
+	if rng == nil {
+		if cfg.Seed == 0 {
+			cfg.Seed = time.Now().UnixNano()
+			klog.Infof("%v: using random seed %d", cfg.LogCfg.Prefix, cfg.Seed)
+		}
+		rng = rand.New(rand.NewSource(cfg.Seed))
+	}
+
 	state := hammerState{
 		cfg:    cfg,
 		nextOp: make([]ctfe.EntrypointName, 0),
+		rng:    &lockedRand{r: rng},
 		hasher: rfc6962.DefaultHasher,
 	}
+	if pool, ok := cfg.ClientPool.(seededPool); ok {
+		pool.setIntn(state.rng.Intn)
+	}
+	state.latencies = make(map[ctfe.EntrypointName]*latencyReservoir)
+	for _, ep := range ctfe.Entrypoints {
+		state.latencies[ep] = newLatencyReservoir(latencySamples)
+	}
 	return &state, nil
 }
 
+// intn returns a random number in [0, n) from the hammer's source.
+func (s *hammerState) intn(n int) int {
+	if s.rng == nil {
+		return rand.Intn(n)
+	}
+	return s.rng.Intn(n)
+}
+
+// int63n returns a random number in [0, n) from the hammer's source.
+func (s *hammerState) int63n(n int64) int64 {
+	if s.rng == nil {
+		return rand.Int63n(n)
+	}
+	return s.rng.Int63n(n)
+}
+
 func (s *hammerState) client() *client.LogClient {
 	return s.cfg.ClientPool.Next()
 }
@@ -366,7 +489,7 @@ func (s *hammerState) needOps(ops ...ctfe.EntrypointName) {
 // The first of any errors returned by calls to addOne will be returned by this function.
 func (s *hammerState) addMultiple(ctx context.Context, addOne func(context.Context) error) error {
 	var wg sync.WaitGroup
-	numAdds := rand.Intn(s.cfg.MaxParallelChains) + 1
+	numAdds := s.intn(s.cfg.MaxParallelChains) + 1
 	klog.V(2).Infof("%s: do %d parallel add operations...", s.cfg.LogCfg.Prefix, numAdds)
 	errs := make(chan error, numAdds)
 	for i := 0; i < numAdds; i++ {
@@ -411,6 +534,7 @@ func (s *hammerState) getChain() (Choice, []ct.ASN1Cert, error) {
 			s.firstChainIntegrated = time.Now().Add(s.cfg.MMD)
 		}
 		s.lastChain = chain
+		s.lastSCT = nil
 		return choice, chain, nil
 	case FirstCert:
 		return choice, s.firstChain, nil
@@ -434,6 +558,9 @@ func (s *hammerState) addChain(ctx context.Context) error {
 		return fmt.Errorf("failed to add-chain(%s): %v", choice, err)
 	}
 	klog.V(2).Infof("%s: Uploaded %s cert, got SCT(time=%q)", s.cfg.LogCfg.Prefix, choice, timeFromMS(sct.Timestamp))
+	if err := s.checkSCT(choice, false, chain, sct); err != nil {
+		return fmt.Errorf("add-chain(%s): %v", choice, err)
+	}
 	// Calculate leaf hash =  SHA256(0x00 | tls-encode(MerkleTreeLeaf))
 	submitted := submittedCert{precert: false, sct: sct}
 	leaf := ct.MerkleTreeLeaf{
@@ -451,7 +578,7 @@ func (s *hammerState) addChain(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to tls.Marshal leaf cert: %v", err)
 	}
-	submitted.leafHash = sha256.Sum256(append([]byte{ct.TreeLeafPrefix}, submitted.leafData...))
+	submitted.leafHash = ct.LeafHashForLeafData(submitted.leafData)
 	s.pending.tryAppendCert(time.Now(), s.cfg.MMD, &submitted)
 	klog.V(3).Infof("%s: Uploaded %s cert has leaf-hash %x", s.cfg.LogCfg.Prefix, choice, submitted.leafHash)
 	return nil
@@ -459,7 +586,7 @@ func (s *hammerState) addChain(ctx context.Context) error {
 
 func (s *hammerState) addChainInvalid(ctx context.Context) error {
 	choices := []Choice{EmptyChain, PrecertNotCert, NoChainToRoot, UnparsableCert}
-	choice := choices[rand.Intn(len(choices))]
+	choice := choices[s.intn(len(choices))]
 
 	var err error
 	var chain []ct.ASN1Cert
@@ -499,9 +626,65 @@ func (s *hammerState) addChainInvalid(ctx context.Context) error {
 	return nil
 }
 
+// checkSCT remembers the SCT received for a [pre-]chain that may later be
+// resubmitted as a duplicate, and checks the SCT received for a duplicate
+// against the one originally received for the same chain.
+func (s *hammerState) checkSCT(choice Choice, precert bool, chain []ct.ASN1Cert, sct *ct.SignedCertificateTimestamp) error {
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+
+	type knownSCT struct {
+		chain []ct.ASN1Cert
+		sct   **ct.SignedCertificateTimestamp
+	}
+	known := []knownSCT{{s.firstChain, &s.firstSCT}, {s.lastChain, &s.lastSCT}}
+	if precert {
+		known = []knownSCT{{s.firstPreChain, &s.firstPreSCT}, {s.lastPreChain, &s.lastPreSCT}}
+	}
+	for _, k := range known {
+		if len(k.chain) == 0 || !bytes.Equal(k.chain[0].Data, chain[0].Data) {
+			continue
+		}
+		if *k.sct == nil {
+			// The original submission may still be in flight, in which case
+			// the first response to arrive is the one to compare against.
+			*k.sct = sct
+			continue
+		}
+		if choice == NewCert {
+			continue
+		}
+		if err := compareSCTs(*k.sct, sct); err != nil {
+			return fmt.Errorf("duplicate submission got different SCT: %v", err)
+		}
+	}
+	return nil
+}
+
+// compareSCTs checks that an SCT received for a duplicate submission matches
+// the one received originally. The signatures themselves are not compared, as
+// signing schemes like ECDSA are randomized, so a log that re-signs the same
+// data for a duplicate can legitimately produce a different signature; only
+// the signature algorithm must match.
+func compareSCTs(orig, dup *ct.SignedCertificateTimestamp) error {
+	if orig.Timestamp != dup.Timestamp {
+		return fmt.Errorf("timestamp %d, originally %d", dup.Timestamp, orig.Timestamp)
+	}
+	if orig.LogID != dup.LogID {
+		return fmt.Errorf("log ID %x, originally %x", dup.LogID.KeyID, orig.LogID.KeyID)
+	}
+	if !bytes.Equal(orig.Extensions, dup.Extensions) {
+		return fmt.Errorf("extensions %x, originally %x", dup.Extensions, orig.Extensions)
+	}
+	if orig.Signature.Algorithm != dup.Signature.Algorithm {
+		return fmt.Errorf("signature algorithm %v, originally %v", dup.Signature.Algorithm, orig.Signature.Algorithm)
+	}
+	return nil
+}
+
 // chooseCertToAdd determines whether to add a new or pre-existing cert.
 func (s *hammerState) chooseCertToAdd() Choice {
-	if s.cfg.DuplicateChance > 0 && rand.Intn(s.cfg.DuplicateChance) == 0 {
+	if s.cfg.DuplicateChance > 0 && s.intn(s.cfg.DuplicateChance) == 0 {
 		// TODO(drysdale): restore LastCert as an option
 		return FirstCert
 	}
@@ -533,6 +716,7 @@ func (s *hammerState) getPreChain() (Choice, []ct.ASN1Cert, []byte, error) {
 		}
 		s.lastPreChain = prechain
 		s.lastTBS = tbs
+		s.lastPreSCT = nil
 		return choice, prechain, tbs, nil
 	case FirstCert:
 		return choice, s.firstPreChain, s.firstTBS, nil
@@ -560,28 +744,22 @@ func (s *hammerState) addPreChain(ctx context.Context) error {
 		return fmt.Errorf("failed to add-pre-chain: %v", err)
 	}
 	klog.V(2).Infof("%s: Uploaded %s pre-cert, got SCT(time=%q)", s.cfg.LogCfg.Prefix, choice, timeFromMS(sct.Timestamp))
+	if err := s.checkSCT(choice, true, prechain, sct); err != nil {
+		return fmt.Errorf("add-pre-chain(%s): %v", choice, err)
+	}
 
 	// Calculate leaf hash =  SHA256(0x00 | tls-encode(MerkleTreeLeaf))
 	submitted := submittedCert{precert: true, sct: sct}
-	leaf := ct.MerkleTreeLeaf{
-		Version:  ct.V1,
-		LeafType: ct.TimestampedEntryLeafType,
-		TimestampedEntry: &ct.TimestampedEntry{
-			Timestamp: sct.Timestamp,
-			EntryType: ct.PrecertLogEntryType,
-			PrecertEntry: &ct.PreCert{
-				IssuerKeyHash:  sha256.Sum256(issuer.RawSubjectPublicKeyInfo),
-				TBSCertificate: tbs,
-			},
-			Extensions: sct.Extensions,
-		},
+	leaf, err := ct.MerkleTreeLeafFromPrecertTBS(tbs, sha256.Sum256(issuer.RawSubjectPublicKeyInfo), sct.Timestamp, sct.Extensions)
+	if err != nil {
+		return fmt.Errorf("failed to build precert leaf: %v", err)
 	}
 	submitted.integrateBy = timeFromMS(sct.Timestamp).Add(s.cfg.MMD)
-	submitted.leafData, err = tls.Marshal(leaf)
+	submitted.leafData, err = tls.Marshal(*leaf)
 	if err != nil {
 		return fmt.Errorf("tls.Marshal(precertLeaf)=(nil,%v); want (_,nil)", err)
 	}
-	submitted.leafHash = sha256.Sum256(append([]byte{ct.TreeLeafPrefix}, submitted.leafData...))
+	submitted.leafHash = ct.LeafHashForLeafData(submitted.leafData)
 	s.pending.tryAppendCert(time.Now(), s.cfg.MMD, &submitted)
 	klog.V(3).Infof("%s: Uploaded %s pre-cert has leaf-hash %x", s.cfg.LogCfg.Prefix, choice, submitted.leafHash)
 	return nil
@@ -589,7 +767,7 @@ func (s *hammerState) addPreChain(ctx context.Context) error {
 
 func (s *hammerState) addPreChainInvalid(ctx context.Context) error {
 	choices := []Choice{EmptyChain, CertNotPrecert, NoChainToRoot, UnparsableCert}
-	choice := choices[rand.Intn(len(choices))]
+	choice := choices[s.intn(len(choices))]
 
 	var err error
 	var prechain []ct.ASN1Cert
@@ -630,27 +808,59 @@ func (s *hammerState) addPreChainInvalid(ctx context.Context) error {
 }
 
 func (s *hammerState) getSTH(ctx context.Context) error {
-	// Shuffle earlier STHs along.
+	sth, err := s.client().GetSTH(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get-sth: %v", err)
+	}
+	klog.V(2).Infof("%s: Got STH(time=%q, size=%d)", s.cfg.LogCfg.Prefix, sth.TimestampTime(), sth.TreeSize)
+	// Check before recording the STH, so that a regressed one doesn't become
+	// the baseline for later checks.
+	if prev := s.sth[0]; s.cfg.CheckSTHMonotonic && prev != nil {
+		if sth.TreeSize < prev.TreeSize {
+			return fmt.Errorf("get-sth: tree size went backwards: %d => %d", prev.TreeSize, sth.TreeSize)
+		}
+		if sth.Timestamp < prev.Timestamp {
+			return fmt.Errorf("get-sth: timestamp went backwards: %v => %v", prev.TimestampTime(), sth.TimestampTime())
+		}
+	}
+	s.pushSTH(sth)
+	return nil
+}
+
+// pushSTH records a newly fetched STH as the most recent one, shuffling the
+// earlier STHs along. It must be called with mu locked.
+func (s *hammerState) pushSTH(sth *ct.SignedTreeHead) {
 	for i := sthCount - 1; i > 0; i-- {
 		s.sth[i] = s.sth[i-1]
 	}
-	var err error
-	s.sth[0], err = s.client().GetSTH(ctx)
+	s.sth[0] = sth
+	s.cachedSTH, s.cachedSTHAt = sth, time.Now()
+}
+
+// currentSTH returns the current STH of the log, which may come from the cache
+// if STHCacheDuration is set. A newly fetched STH is also recorded as the most
+// recent one. It must be called with mu locked.
+func (s *hammerState) currentSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
+	if s.cachedSTH != nil && time.Since(s.cachedSTHAt) < s.cfg.STHCacheDuration {
+		return s.cachedSTH, nil
+	}
+	sth, err := s.client().GetSTH(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get-sth: %v", err)
+		return nil, err
 	}
-	klog.V(2).Infof("%s: Got STH(time=%q, size=%d)", s.cfg.LogCfg.Prefix, timeFromMS(s.sth[0].Timestamp), s.sth[0].TreeSize)
-	return nil
+	s.pushSTH(sth)
+	return sth, nil
 }
 
-// chooseSTHs gets the current STH, and also picks an earlier STH.
+// chooseSTHs gets the current STH, and also picks an earlier STH. It must be
+// called with mu locked.
 func (s *hammerState) chooseSTHs(ctx context.Context) (*ct.SignedTreeHead, *ct.SignedTreeHead, error) {
 	// Get current size, and pick an earlier size
-	sthNow, err := s.client().GetSTH(ctx)
+	sthNow, err := s.currentSTH(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get-sth for current tree: %v", err)
 	}
-	which := rand.Intn(sthCount)
+	which := s.intn(sthCount)
 	if s.sth[which] == nil {
 		klog.V(3).Infof("%s: skipping get-sth-consistency as no earlier STH", s.cfg.LogCfg.Prefix)
 		s.needOps(ctfe.GetSTHName)
@@ -670,6 +880,9 @@ func (s *hammerState) chooseSTHs(ctx context.Context) (*ct.SignedTreeHead, *ct.S
 }
 
 func (s *hammerState) getSTHConsistency(ctx context.Context) error {
+	if s.cfg.StrictConsistencyAllPairs {
+		return s.getSTHConsistencyAllPairs(ctx)
+	}
 	sthOld, sthNow, err := s.chooseSTHs(ctx)
 	if err != nil {
 		// bail on actual errors
@@ -688,27 +901,62 @@ func (s *hammerState) getSTHConsistency(ctx context.Context) error {
 			klog.V(3).Infof("%s: current STH size too small to invent a smaller STH for consistency proof (%d)", s.cfg.LogCfg.Prefix, sthNow.TreeSize)
 			return errSkip{}
 		}
-		sthOld = &ct.SignedTreeHead{TreeSize: uint64(1 + rand.Int63n(int64(sthNow.TreeSize)))}
+		sthOld = &ct.SignedTreeHead{TreeSize: uint64(1 + s.int63n(int64(sthNow.TreeSize)))}
 		klog.V(3).Infof("%s: Inventing a smaller STH size for consistency proof (%d)", s.cfg.LogCfg.Prefix, sthOld.TreeSize)
 	}
 
-	proof, err := s.client().GetSTHConsistency(ctx, sthOld.TreeSize, sthNow.TreeSize)
-	if err != nil {
-		return fmt.Errorf("failed to get-sth-consistency(%d, %d): %v", sthOld.TreeSize, sthNow.TreeSize, err)
-	}
 	if sthOld.Timestamp == 0 {
+		if _, err := s.client().GetSTHConsistency(ctx, sthOld.TreeSize, sthNow.TreeSize); err != nil {
+			return fmt.Errorf("failed to get-sth-consistency(%d, %d): %v", sthOld.TreeSize, sthNow.TreeSize, err)
+		}
 		klog.V(3).Infof("%s: Skipping consistency proof verification for invented STH", s.cfg.LogCfg.Prefix)
 		return nil
 	}
 
-	if err := s.checkCTConsistencyProof(sthOld, sthNow, proof); err != nil {
-		return fmt.Errorf("get-sth-consistency(%d, %d) proof check failed: %v", sthOld.TreeSize, sthNow.TreeSize, err)
+	proof, err := s.client().GetVerifiedConsistencyProof(ctx, sthOld.TreeSize, sthNow.TreeSize, sthOld.SHA256RootHash[:], sthNow.SHA256RootHash[:])
+	if err != nil {
+		return fmt.Errorf("get-sth-consistency(%d, %d) failed: %v", sthOld.TreeSize, sthNow.TreeSize, err)
 	}
 	klog.V(2).Infof("%s: Got STH consistency proof (size=%d => %d) len %d",
 		s.cfg.LogCfg.Prefix, sthOld.TreeSize, sthNow.TreeSize, len(proof))
 	return nil
 }
 
+// getSTHConsistencyAllPairs verifies consistency between each adjacent pair of
+// retained STHs.
+func (s *hammerState) getSTHConsistencyAllPairs(ctx context.Context) error {
+	checked := 0
+	for i := 0; i+1 < sthCount; i++ {
+		sthOld, sthNew := s.sth[i+1], s.sth[i]
+		if sthOld == nil || sthNew == nil {
+			break
+		}
+		if sthOld.TreeSize > sthNew.TreeSize {
+			sthOld, sthNew = sthNew, sthOld
+		}
+		if sthOld.TreeSize == 0 {
+			continue
+		}
+		if sthOld.TreeSize == sthNew.TreeSize {
+			if sthOld.SHA256RootHash != sthNew.SHA256RootHash {
+				return fmt.Errorf("STHs for size %d have different root hashes: %x, %x", sthOld.TreeSize, sthOld.SHA256RootHash, sthNew.SHA256RootHash)
+			}
+			continue
+		}
+		if _, err := s.client().GetVerifiedConsistencyProof(ctx, sthOld.TreeSize, sthNew.TreeSize, sthOld.SHA256RootHash[:], sthNew.SHA256RootHash[:]); err != nil {
+			return fmt.Errorf("get-sth-consistency(%d, %d) failed: %v", sthOld.TreeSize, sthNew.TreeSize, err)
+		}
+		checked++
+	}
+	if checked == 0 {
+		klog.V(3).Infof("%s: skipping get-sth-consistency as no earlier STHs of different sizes", s.cfg.LogCfg.Prefix)
+		s.needOps(ctfe.AddChainName, ctfe.GetSTHName)
+		return errSkip{}
+	}
+	klog.V(2).Infof("%s: Verified STH consistency proofs between %d pairs of STHs", s.cfg.LogCfg.Prefix, checked)
+	return nil
+}
+
 func (s *hammerState) getSTHConsistencyInvalid(ctx context.Context) error {
 	lastSize := s.lastTreeSize()
 	if lastSize == 0 {
@@ -716,7 +964,7 @@ func (s *hammerState) getSTHConsistencyInvalid(ctx context.Context) error {
 	}
 
 	choices := []Choice{ParamTooBig, ParamsInverted, ParamNegative, ParamInvalid}
-	choice := choices[rand.Intn(len(choices))]
+	choice := choices[s.intn(len(choices))]
 
 	var err error
 	var proof [][]byte
@@ -731,7 +979,9 @@ func (s *hammerState) getSTHConsistencyInvalid(ctx context.Context) error {
 		proof, err = s.client().GetSTHConsistency(ctx, first, second)
 	case ParamsInverted:
 		var sthOld, sthNow *ct.SignedTreeHead
+		s.mu.Lock()
 		sthOld, sthNow, err = s.chooseSTHs(ctx)
+		s.mu.Unlock()
 		if err != nil {
 			return err
 		}
@@ -800,7 +1050,7 @@ func (s *hammerState) getProofByHashInvalid(ctx context.Context) error {
 	submitted := s.pending.oldestIfMMDPassed(time.Now())
 
 	choices := []Choice{ParamInvalid, ParamTooBig, ParamNegative, InvalidBase64}
-	choice := choices[rand.Intn(len(choices))]
+	choice := choices[s.intn(len(choices))]
 
 	var err error
 	var rsp *ct.GetProofByHashResponse
@@ -866,16 +1116,22 @@ func (s *hammerState) getEntries(ctx context.Context) error {
 	}
 	// Entry indices are zero-based, and may or may not be allowed to extend
 	// beyond current tree size (RFC 6962 s4.6).
-	first := rand.Intn(int(lastSize))
+	first := s.intn(int(lastSize))
 	span := s.cfg.MaxGetEntries - s.cfg.MinGetEntries
-	count := s.cfg.MinGetEntries + rand.Intn(int(span))
+	count := s.cfg.MinGetEntries + s.intn(int(span))
 	last := first + count
 
 	if !s.cfg.OversizedGetEntries && last >= int(lastSize) {
 		last = int(lastSize) - 1
 	}
 
-	entries, err := s.client().GetEntries(ctx, int64(first), int64(last))
+	var entries []ct.LogEntry
+	var err error
+	if s.cfg.VerifyGetEntriesChains {
+		entries, err = s.getVerifiedEntries(ctx, int64(first), int64(last))
+	} else {
+		entries, err = s.client().GetEntries(ctx, int64(first), int64(last))
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get-entries(%d,%d): %v", first, last, err)
 	}
@@ -899,6 +1155,76 @@ func (s *hammerState) getEntries(ctx context.Context) error {
 	return nil
 }
 
+// getVerifiedEntries retrieves the [first, last] range of entries, and checks
+// that the chain of each entry parses, and is consistent with the entry.
+func (s *hammerState) getVerifiedEntries(ctx context.Context, first, last int64) ([]ct.LogEntry, error) {
+	rsp, err := s.client().GetRawEntries(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]ct.LogEntry, len(rsp.Entries))
+	for i := range rsp.Entries {
+		index := first + int64(i)
+		entry, err := ct.LogEntryFromLeaf(index, &rsp.Entries[i])
+		if x509.IsFatal(err) {
+			return nil, fmt.Errorf("leaf[%d]: failed to parse entry: %v", index, err)
+		}
+		if err := checkEntryChain(entry); err != nil {
+			return nil, fmt.Errorf("leaf[%d]: %v", index, err)
+		}
+		entries[i] = *entry
+	}
+	return entries, nil
+}
+
+// checkEntryChain checks that the [pre-]certificate and all the chain
+// certificates of the entry parse, and for a precert entry that its issuer key
+// hash matches the key of the issuer from the chain.
+func checkEntryChain(entry *ct.LogEntry) error {
+	if entry.X509Cert == nil && (entry.Precert == nil || entry.Precert.TBSCertificate == nil) {
+		return errors.New("no parsed [pre-]certificate")
+	}
+	chain := make([]*x509.Certificate, len(entry.Chain))
+	for i, c := range entry.Chain {
+		cert, err := x509.ParseCertificate(c.Data)
+		if x509.IsFatal(err) {
+			return fmt.Errorf("failed to parse chain[%d]: %v", i, err)
+		}
+		chain[i] = cert
+	}
+	if entry.Precert == nil {
+		return nil
+	}
+
+	if len(chain) == 0 {
+		return errors.New("precert entry with empty chain")
+	}
+	// If the precert is issued by a precert signing certificate, then the
+	// issuer key hash refers to the next certificate in the chain.
+	issuer := chain[0]
+	if isPrecertSigningCert(issuer) {
+		if len(chain) < 2 {
+			return errors.New("precert signing certificate without issuer in chain")
+		}
+		issuer = chain[1]
+	}
+	if got, want := entry.Precert.IssuerKeyHash, sha256.Sum256(issuer.RawSubjectPublicKeyInfo); got != want {
+		return fmt.Errorf("issuer key hash %x does not match issuer %q key hash %x", got, issuer.Subject, want)
+	}
+	return nil
+}
+
+// isPrecertSigningCert returns whether cert has the Certificate Transparency
+// extended key usage, i.e. is a precert signing certificate (RFC6962 s3.1).
+func isPrecertSigningCert(cert *x509.Certificate) bool {
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageCertificateTransparency {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *hammerState) getEntriesInvalid(ctx context.Context) error {
 	lastSize := s.lastTreeSize()
 	if lastSize == 0 {
@@ -906,7 +1232,7 @@ func (s *hammerState) getEntriesInvalid(ctx context.Context) error {
 	}
 
 	choices := []Choice{ParamTooBig, ParamNegative, ParamsInverted}
-	choice := choices[rand.Intn(len(choices))]
+	choice := choices[s.intn(len(choices))]
 
 	var first, last int64
 	switch choice {
@@ -934,6 +1260,96 @@ func (s *hammerState) getEntriesInvalid(ctx context.Context) error {
 	return nil
 }
 
+func (s *hammerState) getEntryAndProof(ctx context.Context) error {
+	sth := s.sth[0]
+	if sth == nil {
+		klog.V(3).Infof("%s: skipping get-entry-and-proof as no earlier STH", s.cfg.LogCfg.Prefix)
+		s.needOps(ctfe.GetSTHName)
+		return errSkip{}
+	}
+	if sth.TreeSize == 0 {
+		klog.V(3).Infof("%s: skipping get-entry-and-proof as tree size 0", s.cfg.LogCfg.Prefix)
+		s.needOps(ctfe.AddChainName, ctfe.GetSTHName)
+		return errSkip{}
+	}
+	// Pick a leaf which is covered by the STH, so that the inclusion proof
+	// can be checked against its root hash.
+	index := uint64(s.int63n(int64(sth.TreeSize)))
+
+	rsp, err := s.client().GetEntryAndProof(ctx, index, sth.TreeSize)
+	if err != nil {
+		return fmt.Errorf("failed to get-entry-and-proof(%d, %d): %v", index, sth.TreeSize, err)
+	}
+	var leaf ct.MerkleTreeLeaf
+	if rest, err := tls.Unmarshal(rsp.LeafInput, &leaf); err != nil {
+		return fmt.Errorf("failed to parse leaf %d: %v", index, err)
+	} else if len(rest) > 0 {
+		return fmt.Errorf("trailing data (%d bytes) after leaf %d", len(rest), index)
+	}
+	if leaf.LeafType != ct.TimestampedEntryLeafType {
+		return fmt.Errorf("leaf[%d].LeafType=%v; want TimestampedEntryLeafType", index, leaf.LeafType)
+	}
+	if et := leaf.TimestampedEntry.EntryType; et != ct.X509LogEntryType && et != ct.PrecertLogEntryType {
+		return fmt.Errorf("leaf[%d].ts.EntryType=%v; want {X509,Precert}LogEntryType", index, et)
+	}
+	leafHash := s.hasher.HashLeaf(rsp.LeafInput)
+	if err := proof.VerifyInclusion(s.hasher, index, sth.TreeSize, leafHash, rsp.AuditPath, sth.SHA256RootHash[:]); err != nil {
+		return fmt.Errorf("failed to VerifyInclusion(%d, %d)=%v", index, sth.TreeSize, err)
+	}
+	klog.V(2).Infof("%s: Got entry and proof for leaf %d (size=%d)", s.cfg.LogCfg.Prefix, index, sth.TreeSize)
+	return nil
+}
+
+func (s *hammerState) getEntryAndProofInvalid(ctx context.Context) error {
+	lastSize := s.lastTreeSize()
+	if lastSize == 0 {
+		return errSkip{}
+	}
+
+	choices := []Choice{ParamTooBig, ParamsInverted, ParamNegative, ParamInvalid}
+	choice := choices[s.intn(len(choices))]
+
+	var err error
+	var rsp *ct.GetEntryAndProofResponse
+	switch choice {
+	case ParamTooBig:
+		index := lastSize + uint64(invalidStretch)
+		rsp, err = s.client().GetEntryAndProof(ctx, index, index+1)
+	case ParamsInverted:
+		// The leaf index is beyond the tree size it is requested for.
+		rsp, err = s.client().GetEntryAndProof(ctx, lastSize, lastSize-1)
+	case ParamNegative, ParamInvalid:
+		params := make(map[string]string)
+		switch choice {
+		case ParamNegative:
+			params["leaf_index"] = "-1"
+			params["tree_size"] = strconv.FormatUint(lastSize, 10)
+		case ParamInvalid:
+			params["leaf_index"] = "foo"
+			params["tree_size"] = "bar"
+		}
+		var r ct.GetEntryAndProofResponse
+		rsp = &r
+		var httpRsp *http.Response
+		var body []byte
+		httpRsp, body, err = s.client().GetAndParse(ctx, ct.GetEntryAndProofPath, params, &r)
+		if err != nil && httpRsp != nil {
+			err = client.RspError{Err: err, StatusCode: httpRsp.StatusCode, Body: body}
+		}
+	default:
+		klog.Exitf("Unhandled choice %s", choice)
+	}
+
+	klog.V(3).Infof("invalid get-entry-and-proof(%s) => error %v", choice, err)
+	if err, ok := err.(client.RspError); ok {
+		klog.V(3).Infof("   HTTP status %d body %s", err.StatusCode, err.Body)
+	}
+	if err == nil {
+		return fmt.Errorf("unexpected success: get-entry-and-proof(%s): %+v", choice, rsp)
+	}
+	return nil
+}
+
 func (s *hammerState) getRoots(ctx context.Context) error {
 	roots, err := s.client().GetAcceptedRoots(ctx)
 	if err != nil {
@@ -954,6 +1370,68 @@ func (s *hammerState) label() string {
 	return strconv.FormatInt(s.cfg.LogCfg.LogId, 10)
 }
 
+// HammerResults summarizes the requests made by a hammer run.
+type HammerResults struct {
+	Prefix               string                       `json:"prefix"`
+	TotalRequests        int                          `json:"total_requests"`
+	TotalErrors          int                          `json:"total_errors"`
+	TotalInvalidRequests int                          `json:"total_invalid_requests"`
+	Entrypoints          map[string]EntrypointResults `json:"entrypoints"`
+}
+
+// EntrypointResults summarizes the requests made to one entrypoint by a
+// hammer run. Latencies are only set if there were valid requests, and are
+// estimated from a sample of them.
+type EntrypointResults struct {
+	Requests        int     `json:"requests"`
+	Successes       int     `json:"successes"`
+	Errors          int     `json:"errors"`
+	InvalidRequests int     `json:"invalid_requests"`
+	LatencyP50Ms    float64 `json:"latency_p50_ms,omitempty"`
+	LatencyP95Ms    float64 `json:"latency_p95_ms,omitempty"`
+	LatencyP99Ms    float64 `json:"latency_p99_ms,omitempty"`
+}
+
+// results gathers the metrics of the run so far. Entrypoints which were not
+// exercised are omitted.
+func (s *hammerState) results() HammerResults {
+	statusOK := strconv.Itoa(http.StatusOK)
+	res := HammerResults{Prefix: s.cfg.LogCfg.Prefix, Entrypoints: make(map[string]EntrypointResults)}
+	for _, ep := range ctfe.Entrypoints {
+		epRes := EntrypointResults{
+			Requests:        int(reqs.Value(s.label(), string(ep))),
+			Successes:       int(rsps.Value(s.label(), string(ep), statusOK)),
+			Errors:          int(errs.Value(s.label(), string(ep))),
+			InvalidRequests: int(invalidReqs.Value(s.label(), string(ep))),
+		}
+		res.TotalRequests += epRes.Requests
+		res.TotalErrors += epRes.Errors
+		res.TotalInvalidRequests += epRes.InvalidRequests
+		if lat := s.latencies[ep]; lat != nil {
+			if pcts := lat.percentiles(50, 95, 99); pcts != nil {
+				ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+				epRes.LatencyP50Ms, epRes.LatencyP95Ms, epRes.LatencyP99Ms = ms(pcts[0]), ms(pcts[1]), ms(pcts[2])
+			}
+		}
+		if epRes.Requests > 0 || epRes.InvalidRequests > 0 {
+			res.Entrypoints[string(ep)] = epRes
+		}
+	}
+	return res
+}
+
+// writeResults writes the results of the run so far as JSON to w.
+func (s *hammerState) writeResults(w io.Writer) error {
+	data, err := json.Marshal(s.results())
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %v", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write results: %v", err)
+	}
+	return nil
+}
+
 func (s *hammerState) String() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -968,6 +1446,11 @@ func (s *hammerState) String() string {
 		totalReqs += reqCount
 		if s.cfg.EPBias.Bias[ep] > 0 {
 			details += fmt.Sprintf(" %s=%d/%d", ep, int(rsps.Value(s.label(), string(ep), statusOK)), reqCount)
+			if lat := s.latencies[ep]; lat != nil {
+				if pcts := lat.String(); pcts != "" {
+					details += "(" + pcts + ")"
+				}
+			}
 		}
 		totalInvalidReqs += int(invalidReqs.Value(s.label(), string(ep)))
 		totalErrs += int(errs.Value(s.label(), string(ep)))
@@ -975,6 +1458,15 @@ func (s *hammerState) String() string {
 	return fmt.Sprintf("%10s: lastSTH.size=%s ops: total=%d invalid=%d errs=%v%s", s.cfg.LogCfg.Prefix, sthSize(s.sth[0]), totalReqs, totalInvalidReqs, totalErrs, details)
 }
 
+// requestDeadline returns the deadline to set on requests for the given
+// entrypoint, or zero for none.
+func (s *hammerState) requestDeadline(ep ctfe.EntrypointName) time.Duration {
+	if d, ok := s.cfg.RequestDeadlines[ep]; ok {
+		return d
+	}
+	return s.cfg.RequestDeadline
+}
+
 func (s *hammerState) performOp(ctx context.Context, ep ctfe.EntrypointName) (int, error) {
 	if err := s.cfg.Limiter.Wait(ctx); err != nil {
 		return http.StatusRequestTimeout, fmt.Errorf("Limiter.Wait(): %v", err)
@@ -983,8 +1475,8 @@ func (s *hammerState) performOp(ctx context.Context, ep ctfe.EntrypointName) (in
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.cfg.RequestDeadline > 0 {
-		cctx, cancel := context.WithTimeout(ctx, s.cfg.RequestDeadline)
+	if deadline := s.requestDeadline(ep); deadline > 0 {
+		cctx, cancel := context.WithTimeout(ctx, deadline)
 		defer cancel()
 		ctx = cctx
 	}
@@ -1007,8 +1499,7 @@ func (s *hammerState) performOp(ctx context.Context, ep ctfe.EntrypointName) (in
 	case ctfe.GetRootsName:
 		err = s.getRoots(ctx)
 	case ctfe.GetEntryAndProofName:
-		status = http.StatusNotImplemented
-		klog.V(2).Infof("%s: hammering entrypoint %s not yet implemented", s.cfg.LogCfg.Prefix, ep)
+		err = s.getEntryAndProof(ctx)
 	default:
 		err = fmt.Errorf("internal error: unknown entrypoint %s selected", ep)
 	}
@@ -1033,7 +1524,7 @@ func (s *hammerState) performInvalidOp(ctx context.Context, ep ctfe.EntrypointNa
 	case ctfe.GetSTHName, ctfe.GetRootsName:
 		return fmt.Errorf("no invalid request possible for entrypoint %s", ep)
 	case ctfe.GetEntryAndProofName:
-		return fmt.Errorf("hammering entrypoint %s not yet implemented", ep)
+		return s.getEntryAndProofInvalid(ctx)
 	}
 	return fmt.Errorf("internal error: unknown entrypoint %s", ep)
 }
@@ -1049,8 +1540,8 @@ func (s *hammerState) chooseOp() (ctfe.EntrypointName, bool) {
 			return ep, false
 		}
 	}
-	ep := s.cfg.EPBias.Choose()
-	return ep, s.cfg.EPBias.Invalid(ep)
+	ep := s.cfg.EPBias.choose(s.intn)
+	return ep, s.cfg.EPBias.invalid(ep, s.intn)
 }
 
 // Perform a random operation on the log, retrying if necessary. If non-empty, the
@@ -1070,6 +1561,7 @@ func (s *hammerState) retryOneOp(ctx context.Context) error {
 
 	klog.V(3).Infof("perform %s operation", ep)
 	deadline := time.Now().Add(s.cfg.MaxRetryDuration)
+	bo := s.cfg.RetryBackoff
 
 	for {
 		if err := ctx.Err(); err != nil {
@@ -1084,6 +1576,11 @@ func (s *hammerState) retryOneOp(ctx context.Context) error {
 		switch err.(type) {
 		case nil:
 			rsps.Inc(s.label(), string(ep), strconv.Itoa(status))
+			// Only successful operations are sampled, so that fast failures
+			// and skips do not skew the reported percentiles.
+			if lat := s.latencies[ep]; lat != nil {
+				lat.add(period)
+			}
 			return nil
 		case errSkip:
 			klog.V(2).Infof("operation %s was skipped", ep)
@@ -1096,7 +1593,13 @@ func (s *hammerState) retryOneOp(ctx context.Context) error {
 					klog.Warningf("%s: gave up retrying failed op %v after %v, returning last err: %v", s.cfg.LogCfg.Prefix, ep, s.cfg.MaxRetryDuration, err)
 					return err
 				}
-				klog.Warningf("%s: op %v failed after %v (will retry for %v more): %v", s.cfg.LogCfg.Prefix, ep, period, left, err)
+				pause := bo.Duration()
+				klog.Warningf("%s: op %v failed after %v (will retry in %v, for %v more): %v", s.cfg.LogCfg.Prefix, ep, period, pause, left, err)
+				select {
+				case <-time.After(pause):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
 			} else {
 				return err
 			}
@@ -1104,17 +1607,19 @@ func (s *hammerState) retryOneOp(ctx context.Context) error {
 	}
 }
 
-// checkCTConsistencyProof checks the given consistency proof.
-func (s *hammerState) checkCTConsistencyProof(sth1, sth2 *ct.SignedTreeHead, pf [][]byte) error {
-	return proof.VerifyConsistency(s.hasher, sth1.TreeSize, sth2.TreeSize, pf, sth1.SHA256RootHash[:], sth2.SHA256RootHash[:])
-}
-
 // HammerCTLog performs load/stress operations according to given config.
-func HammerCTLog(ctx context.Context, cfg HammerConfig) error {
+func HammerCTLog(ctx context.Context, cfg HammerConfig) (err error) {
 	s, err := newHammerState(&cfg)
 	if err != nil {
 		return err
 	}
+	if cfg.Results != nil {
+		defer func() {
+			if werr := s.writeResults(cfg.Results); werr != nil && err == nil {
+				err = werr
+			}
+		}()
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -1122,15 +1627,41 @@ func HammerCTLog(ctx context.Context, cfg HammerConfig) error {
 		klog.Info(s.String())
 	})
 
-	for count := uint64(1); count < cfg.Operations; count++ {
-		if err := s.retryOneOp(ctx); err != nil {
+	// Operations run under a separate context, so that reaching the Duration
+	// can be told apart from the caller cancelling the run.
+	opCtx := ctx
+	if cfg.Duration > 0 {
+		var opCancel context.CancelFunc
+		opCtx, opCancel = context.WithTimeout(ctx, cfg.Duration)
+		defer opCancel()
+	}
+	timedOut := func() bool {
+		return ctx.Err() == nil && opCtx.Err() != nil
+	}
+
+	count := uint64(1)
+	var completed uint64
+	for ; count < cfg.Operations; count++ {
+		if err := s.retryOneOp(opCtx); err != nil {
+			if timedOut() {
+				break
+			}
 			return err
 		}
+		completed++
 		// Terminate from the loop if the context is cancelled.
-		if err := ctx.Err(); err != nil {
+		if err := opCtx.Err(); err != nil {
+			if timedOut() {
+				break
+			}
 			return err
 		}
 	}
+	klog.Info(s.String())
+	if count < cfg.Operations {
+		klog.Infof("%s: stopped after %v, having completed %d operations on log", cfg.LogCfg.Prefix, cfg.Duration, completed)
+		return nil
+	}
 	klog.Infof("%s: completed %d operations on log", cfg.LogCfg.Prefix, cfg.Operations)
 
 	return nil
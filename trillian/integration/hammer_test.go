@@ -15,21 +15,32 @@
 package integration
 
 import (
+	"bytes"
 	"context"
 	"crypto"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/OlegBabkin/certificate-transparency-go/client"
 	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe"
 	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/configpb"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian/client/backoff"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"k8s.io/klog/v2"
 
@@ -174,8 +185,31 @@ type fakeCTServer struct {
 
 	addedCerts []*x509.Certificate
 	sthNow     ct.SignedTreeHead
+	// freshSCTs makes every add-[pre-]chain response carry a new SCT
+	// timestamp, even for a duplicate submission.
+	freshSCTs bool
 
 	getConsistencyCalled bool
+	getSTHCalls          int
+	// failSTH makes get-sth requests fail, and sthTimes records when they
+	// were made.
+	failSTH  bool
+	sthTimes []time.Time
+
+	// leaves hold the Merkle tree leaves served by get-entry-and-proof. The
+	// proofs are only valid for the tree of size 2.
+	leaves [2][]byte
+	// entries are served by get-entries, regardless of the requested range.
+	entries []ct.LeafEntry
+
+	// tree, if set, provides the proofs served by get-sth-consistency, and
+	// the proof between the sizes in badConsistency is corrupted.
+	tree           *testonly.Tree
+	badConsistency [2]uint64
+
+	// requests records the URIs of all the requests received.
+	requestsMu sync.Mutex
+	requests   []string
 }
 
 func (s *fakeCTServer) addChain(w http.ResponseWriter, req *http.Request) {
@@ -207,6 +241,9 @@ func (s *fakeCTServer) addChain(w http.ResponseWriter, req *http.Request) {
 		SCTVersion: ct.V1,
 		Signature:  dsBytes,
 	}
+	if s.freshSCTs {
+		resp.Timestamp = uint64(len(s.addedCerts))
+	}
 	respBytes, err := json.Marshal(resp)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, err)
@@ -243,6 +280,12 @@ func (s *fakeCTServer) serve() {
 }
 
 func (s *fakeCTServer) getSTH(w http.ResponseWriter, req *http.Request) {
+	s.getSTHCalls++
+	s.sthTimes = append(s.sthTimes, time.Now())
+	if s.failSTH {
+		writeErr(w, http.StatusServiceUnavailable, fmt.Errorf("get-sth unavailable"))
+		return
+	}
 	resp := &ct.GetSTHResponse{
 		TreeSize:       s.sthNow.TreeSize,
 		Timestamp:      s.sthNow.Timestamp,
@@ -271,6 +314,27 @@ func (s *fakeCTServer) getConsistency(w http.ResponseWriter, req *http.Request)
 	cp := &ct.GetSTHConsistencyResponse{
 		Consistency: [][]byte{[]byte("bogus")},
 	}
+	if s.tree != nil {
+		first, err := strconv.ParseUint(req.FormValue("first"), 10, 64)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, err)
+			return
+		}
+		second, err := strconv.ParseUint(req.FormValue("second"), 10, 64)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, err)
+			return
+		}
+		cp.Consistency, err = s.tree.ConsistencyProof(first, second)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, err)
+			return
+		}
+		if [2]uint64{first, second} == s.badConsistency && len(cp.Consistency) > 0 {
+			cp.Consistency[0] = append([]byte(nil), cp.Consistency[0]...)
+			cp.Consistency[0][0] ^= 0xff
+		}
+	}
 	respBytes, err := json.Marshal(cp)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, err)
@@ -285,6 +349,51 @@ func (s *fakeCTServer) getConsistency(w http.ResponseWriter, req *http.Request)
 	s.getConsistencyCalled = true
 }
 
+func (s *fakeCTServer) getEntryAndProof(w http.ResponseWriter, req *http.Request) {
+	index, err := strconv.ParseInt(req.FormValue("leaf_index"), 10, 64)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	treeSize, err := strconv.ParseInt(req.FormValue("tree_size"), 10, 64)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	if treeSize != int64(len(s.leaves)) || index < 0 || index >= treeSize {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("bad leaf_index=%d for tree_size=%d", index, treeSize))
+		return
+	}
+	sibling := rfc6962.DefaultHasher.HashLeaf(s.leaves[1-index])
+	resp := &ct.GetEntryAndProofResponse{
+		LeafInput: s.leaves[index],
+		AuditPath: [][]byte{sibling},
+	}
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(respBytes); err != nil {
+		klog.Errorf("Write(): %v", err)
+	}
+}
+
+func (s *fakeCTServer) getEntries(w http.ResponseWriter, req *http.Request) {
+	respBytes, err := json.Marshal(&ct.GetEntriesResponse{Entries: s.entries})
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(respBytes); err != nil {
+		klog.Errorf("Write(): %v", err)
+	}
+}
+
 func writeErr(w http.ResponseWriter, status int, err error) {
 	w.WriteHeader(status)
 	if _, err := io.WriteString(w, err.Error()); err != nil {
@@ -309,8 +418,15 @@ func newFakeCTServer(t *testing.T) (*fakeCTServer, *client.LogClient) {
 	mux.HandleFunc("/ct/v1/add-pre-chain", s.addChain)
 	mux.HandleFunc("/ct/v1/get-sth", s.getSTH)
 	mux.HandleFunc("/ct/v1/get-sth-consistency", s.getConsistency)
+	mux.HandleFunc("/ct/v1/get-entry-and-proof", s.getEntryAndProof)
+	mux.HandleFunc("/ct/v1/get-entries", s.getEntries)
 
-	s.server = &http.Server{Handler: mux}
+	s.server = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.requestsMu.Lock()
+		s.requests = append(s.requests, req.URL.RequestURI())
+		s.requestsMu.Unlock()
+		mux.ServeHTTP(w, req)
+	})}
 	go s.serve()
 
 	lc, err := client.New(fmt.Sprintf("http://%s", s.lis.Addr()), nil, jsonclient.Options{})
@@ -415,6 +531,167 @@ func TestChooseCertToAdd(t *testing.T) {
 	}
 }
 
+func TestDuplicateSCTs(t *testing.T) {
+	keys := loadTestKeys(t)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name      string
+		freshSCTs bool
+		wantErr   bool
+	}{
+		{name: "same_sct"},
+		{name: "different_sct", freshSCTs: true, wantErr: true},
+	} {
+		for _, precert := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/precert=%t", test.name, precert), func(t *testing.T) {
+				s, lc := newFakeCTServer(t)
+				defer s.close()
+				s.freshSCTs = test.freshSCTs
+
+				generator, err := NewSyntheticChainGenerator(keys.leafChain, keys.signer, time.Time{})
+				if err != nil {
+					t.Fatalf("Failed to build chain generator: %v", err)
+				}
+				hs, err := newHammerState(&HammerConfig{
+					ChainGenerator:  generator,
+					ClientPool:      RandomPool{lc},
+					LogCfg:          &configpb.LogConfig{},
+					DuplicateChance: 1,
+				})
+				if err != nil {
+					t.Fatalf("newHammerState() returned err = %v", err)
+				}
+				add := hs.addChain
+				if precert {
+					add = hs.addPreChain
+				}
+
+				// The first submission is necessarily of a new chain, and
+				// with MMD unset every later one is a duplicate of it.
+				if err := add(ctx); err != nil {
+					t.Fatalf("first add()=%v", err)
+				}
+				err = add(ctx)
+				if gotErr := err != nil; gotErr != test.wantErr {
+					t.Fatalf("duplicate add()=%v, want error %t", err, test.wantErr)
+				}
+				if got := len(s.addedCerts); got != 2 {
+					t.Fatalf("server got %d submissions, want 2", got)
+				}
+				if !bytes.Equal(s.addedCerts[0].Raw, s.addedCerts[1].Raw) {
+					t.Error("second submission was not a duplicate")
+				}
+			})
+		}
+	}
+}
+
+func TestCheckSTHMonotonic(t *testing.T) {
+	ctx := context.Background()
+	type sth struct{ size, ts uint64 }
+
+	for _, test := range []struct {
+		name     string
+		check    bool
+		sths     []sth
+		wantErrs []int // indices of the get-sths that should fail
+	}{
+		{name: "increasing", check: true, sths: []sth{{1, 100}, {2, 200}, {5, 300}}},
+		{name: "unchanged", check: true, sths: []sth{{2, 200}, {2, 200}, {2, 300}}},
+		{name: "size_regresses", check: true, sths: []sth{{1, 100}, {3, 200}, {2, 300}}, wantErrs: []int{2}},
+		{name: "time_regresses", check: true, sths: []sth{{1, 100}, {2, 200}, {3, 150}}, wantErrs: []int{2}},
+		// The regressed STH is not recorded, so the next one is also checked
+		// against {3, 200}.
+		{name: "size_regresses_twice", check: true, sths: []sth{{3, 200}, {1, 300}, {2, 400}}, wantErrs: []int{1, 2}},
+		{name: "unchecked", sths: []sth{{3, 300}, {2, 200}, {1, 100}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, lc := newFakeCTServer(t)
+			defer s.close()
+
+			hs, err := newHammerState(&HammerConfig{
+				CheckSTHMonotonic: test.check,
+				ClientPool:        RandomPool{lc},
+				LogCfg:            &configpb.LogConfig{},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create HammerState: %v", err)
+			}
+			for i, sth := range test.sths {
+				s.sthNow.TreeSize, s.sthNow.Timestamp = sth.size, sth.ts
+				err := hs.getSTH(ctx)
+				if gotErr, wantErr := err != nil, slices.Contains(test.wantErrs, i); gotErr != wantErr {
+					t.Errorf("getSTH(%d)=%v, want error %t", i, err, wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictConsistencyAllPairs(t *testing.T) {
+	ctx := context.Background()
+	tree := testonly.New(rfc6962.DefaultHasher)
+	for i := 0; i < 8; i++ {
+		tree.AppendData([]byte(fmt.Sprintf("leaf-%d", i)))
+	}
+	sthAt := func(size uint64) *ct.SignedTreeHead {
+		sth := &ct.SignedTreeHead{TreeSize: size, Timestamp: size}
+		copy(sth.SHA256RootHash[:], tree.HashAt(size))
+		return sth
+	}
+
+	for _, test := range []struct {
+		name    string
+		sizes   []uint64 // most recent first
+		bad     [2]uint64
+		wantErr bool
+	}{
+		{name: "consistent", sizes: []uint64{8, 6, 6, 3, 1}},
+		{name: "inconsistent_pair", sizes: []uint64{8, 6, 3, 1}, bad: [2]uint64{3, 6}, wantErr: true},
+		{name: "unchecked_pair", sizes: []uint64{8, 6}, bad: [2]uint64{3, 6}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, lc := newFakeCTServer(t)
+			defer s.close()
+			s.tree = tree
+			s.badConsistency = test.bad
+
+			hs, err := newHammerState(&HammerConfig{
+				StrictConsistencyAllPairs: true,
+				ClientPool:                RandomPool{lc},
+				LogCfg:                    &configpb.LogConfig{},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create HammerState: %v", err)
+			}
+			for i, size := range test.sizes {
+				hs.sth[i] = sthAt(size)
+			}
+
+			err = hs.getSTHConsistency(ctx)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("getSTHConsistency()=%v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+
+	t.Run("no_pairs", func(t *testing.T) {
+		hs, err := newHammerState(&HammerConfig{
+			StrictConsistencyAllPairs: true,
+			LogCfg:                    &configpb.LogConfig{},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create HammerState: %v", err)
+		}
+		hs.sth[0] = sthAt(8)
+		err = hs.getSTHConsistency(ctx)
+		if _, ok := err.(errSkip); !ok {
+			t.Errorf("getSTHConsistency()=%v, want errSkip", err)
+		}
+	})
+}
+
 func TestStrictSTHConsistencySize(t *testing.T) {
 	ctx := context.Background()
 
@@ -461,3 +738,467 @@ func TestStrictSTHConsistencySize(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEntryAndProof(t *testing.T) {
+	ctx := context.Background()
+
+	var leaves [2][]byte
+	for i := range leaves {
+		leaf := ct.MerkleTreeLeaf{
+			Version:  ct.V1,
+			LeafType: ct.TimestampedEntryLeafType,
+			TimestampedEntry: &ct.TimestampedEntry{
+				Timestamp: uint64(i),
+				EntryType: ct.X509LogEntryType,
+				X509Entry: &ct.ASN1Cert{Data: []byte(fmt.Sprintf("cert%d", i))},
+			},
+		}
+		var err error
+		if leaves[i], err = tls.Marshal(leaf); err != nil {
+			t.Fatalf("tls.Marshal() returned err = %v", err)
+		}
+	}
+	hasher := rfc6962.DefaultHasher
+	root := hasher.HashChildren(hasher.HashLeaf(leaves[0]), hasher.HashLeaf(leaves[1]))
+
+	for _, test := range []struct {
+		name     string
+		treeSize uint64
+		root     []byte
+		leaf     []byte // If set, overrides leaf 0 and 1.
+		wantSkip bool
+		wantErr  bool
+	}{
+		{name: "empty_tree", treeSize: 0, wantSkip: true},
+		{name: "valid", treeSize: 2, root: root},
+		{name: "bad_root", treeSize: 2, root: hasher.HashLeaf([]byte("bogus")), wantErr: true},
+		{name: "unparsable_leaf", treeSize: 2, root: root, leaf: []byte("bogus"), wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, lc := newFakeCTServer(t)
+			defer s.close()
+			s.leaves = leaves
+			if test.leaf != nil {
+				s.leaves = [2][]byte{test.leaf, test.leaf}
+			}
+			s.sthNow.TreeSize = test.treeSize
+			copy(s.sthNow.SHA256RootHash[:], test.root)
+
+			hs, err := newHammerState(&HammerConfig{
+				ClientPool: RandomPool{lc},
+				LogCfg:     &configpb.LogConfig{},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create HammerState: %v", err)
+			}
+			if err := hs.getSTH(ctx); err != nil {
+				t.Fatalf("getSTH() returned err = %v", err)
+			}
+
+			err = hs.getEntryAndProof(ctx)
+			if _, gotSkip := err.(errSkip); gotSkip != test.wantSkip {
+				t.Fatalf("getEntryAndProof() = %v, wanted Skip=%v", err, test.wantSkip)
+			}
+			if test.wantSkip {
+				return
+			}
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("getEntryAndProof() = %v, want error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetEntryAndProofInvalid(t *testing.T) {
+	ctx := context.Background()
+	s, lc := newFakeCTServer(t)
+	defer s.close()
+	s.sthNow.TreeSize = 2
+
+	hs, err := newHammerState(&HammerConfig{
+		ClientPool: RandomPool{lc},
+		LogCfg:     &configpb.LogConfig{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create HammerState: %v", err)
+	}
+	if err := hs.getSTH(ctx); err != nil {
+		t.Fatalf("getSTH() returned err = %v", err)
+	}
+	// The invalid request is chosen randomly, so try a few times.
+	for i := 0; i < 20; i++ {
+		if err := hs.getEntryAndProofInvalid(ctx); err != nil {
+			t.Errorf("getEntryAndProofInvalid() returned err = %v", err)
+		}
+	}
+}
+
+func TestSTHCacheDuration(t *testing.T) {
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name      string
+		cacheDur  time.Duration
+		wantCalls int
+	}{
+		{name: "no_cache", wantCalls: 6},
+		{name: "cache", cacheDur: time.Hour, wantCalls: 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, lc := newFakeCTServer(t)
+			defer s.close()
+			s.sthNow.TreeSize = 10
+
+			hs, err := newHammerState(&HammerConfig{
+				STHCacheDuration: test.cacheDur,
+				ClientPool:       RandomPool{lc},
+				LogCfg:           &configpb.LogConfig{},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create HammerState: %v", err)
+			}
+
+			// The get-sth operation always makes a request.
+			if err := hs.getSTH(ctx); err != nil {
+				t.Fatalf("getSTH() returned err = %v", err)
+			}
+			for i := 0; i < 4; i++ {
+				if _, _, err := hs.chooseSTHs(ctx); err != nil {
+					if _, ok := err.(errSkip); !ok {
+						t.Fatalf("chooseSTHs() returned err = %v", err)
+					}
+				}
+			}
+			if err := hs.getSTH(ctx); err != nil {
+				t.Fatalf("getSTH() returned err = %v", err)
+			}
+			// Every fetched STH is pushed into the ring.
+			ringSize := 0
+			for _, sth := range hs.sth {
+				if sth != nil {
+					ringSize++
+				}
+			}
+			if want := min(test.wantCalls, sthCount); ringSize != want {
+				t.Errorf("Got %d STHs in the ring, want %d", ringSize, want)
+			}
+
+			if got, want := s.getSTHCalls, test.wantCalls; got != want {
+				t.Errorf("Made %d get-sth requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	s, lc := newFakeCTServer(t)
+	defer s.close()
+	s.failSTH = true
+
+	bo := backoff.Backoff{Min: 20 * time.Millisecond, Max: time.Second, Factor: 2}
+	hs, err := newHammerState(&HammerConfig{
+		ClientPool:       RandomPool{lc},
+		LogCfg:           &configpb.LogConfig{},
+		EPBias:           HammerBias{Bias: map[ctfe.EntrypointName]int{ctfe.GetSTHName: 1}},
+		IgnoreErrors:     true,
+		MaxRetryDuration: 300 * time.Millisecond,
+		RetryBackoff:     bo,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create HammerState: %v", err)
+	}
+
+	if err := hs.retryOneOp(context.Background()); err == nil {
+		t.Fatal("retryOneOp()=nil, want error")
+	}
+	if got := len(s.sthTimes); got < 3 {
+		t.Fatalf("Made %d get-sth requests, want at least 3", got)
+	}
+	if got := hs.latencies[ctfe.GetSTHName].String(); got != "" {
+		t.Errorf("latencies of failed ops = %q, want none recorded", got)
+	}
+	var prev time.Duration
+	for i := 1; i < len(s.sthTimes); i++ {
+		gap := s.sthTimes[i].Sub(s.sthTimes[i-1])
+		if want := bo.Duration(); gap < want {
+			t.Errorf("Retry %d made after %v, want at least %v", i, gap, want)
+		}
+		if gap <= prev {
+			t.Errorf("Retry %d made after %v, want more than the previous %v", i, gap, prev)
+		}
+		prev = gap
+	}
+}
+
+func TestRetryBackoffCancel(t *testing.T) {
+	s, lc := newFakeCTServer(t)
+	defer s.close()
+	s.failSTH = true
+
+	hs, err := newHammerState(&HammerConfig{
+		ClientPool:       RandomPool{lc},
+		LogCfg:           &configpb.LogConfig{},
+		EPBias:           HammerBias{Bias: map[ctfe.EntrypointName]int{ctfe.GetSTHName: 1}},
+		IgnoreErrors:     true,
+		MaxRetryDuration: time.Hour,
+		RetryBackoff:     backoff.Backoff{Min: time.Hour, Max: time.Hour, Factor: 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create HammerState: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := hs.retryOneOp(ctx); err != context.DeadlineExceeded {
+		t.Errorf("retryOneOp()=%v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("retryOneOp() returned after %v, want prompt return on cancellation", elapsed)
+	}
+}
+
+func TestHammerSeed(t *testing.T) {
+	// run performs a sequence of operations with a hammer seeded with seed,
+	// and returns the requests made.
+	run := func(seed int64) []string {
+		s, lc := newFakeCTServer(t)
+		defer s.close()
+		s.sthNow.TreeSize = 1000
+
+		hs, err := newHammerState(&HammerConfig{
+			ClientPool: RandomPool{lc},
+			LogCfg:     &configpb.LogConfig{},
+			EPBias: HammerBias{
+				Bias:          map[ctfe.EntrypointName]int{ctfe.GetSTHName: 1, ctfe.GetSTHConsistencyName: 3},
+				InvalidChance: map[ctfe.EntrypointName]int{ctfe.GetSTHConsistencyName: 4},
+			},
+			Seed: seed,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create HammerState: %v", err)
+		}
+		for i := 0; i < 50; i++ {
+			// Some operations fail against the fake server, which is fine as
+			// long as they do so in the same way for the same seed.
+			if err := hs.retryOneOp(context.Background()); err != nil {
+				klog.V(2).Infof("retryOneOp(): %v", err)
+			}
+		}
+		s.requestsMu.Lock()
+		defer s.requestsMu.Unlock()
+		return s.requests
+	}
+
+	reqs1, reqs2 := run(42), run(42)
+	if len(reqs1) == 0 {
+		t.Fatal("Hammer made no requests")
+	}
+	if !slices.Equal(reqs1, reqs2) {
+		t.Errorf("Hammers with the same seed made different requests:\n%v\n%v", reqs1, reqs2)
+	}
+	if reqs3 := run(43); slices.Equal(reqs1, reqs3) {
+		t.Errorf("Hammers with different seeds made identical requests %v", reqs1)
+	}
+}
+
+func TestHammerDuration(t *testing.T) {
+	s, lc := newFakeCTServer(t)
+	defer s.close()
+	s.sthNow.TreeSize = 10
+
+	const ops = 1000000
+	cfg := HammerConfig{
+		ClientPool: RandomPool{lc},
+		LogCfg:     &configpb.LogConfig{Prefix: "duration"},
+		EPBias:     HammerBias{Bias: map[ctfe.EntrypointName]int{ctfe.GetSTHName: 1}},
+		Operations: ops,
+		Duration:   200 * time.Millisecond,
+	}
+	start := time.Now()
+	if err := HammerCTLog(context.Background(), cfg); err != nil {
+		t.Fatalf("HammerCTLog()=%v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("HammerCTLog() returned after %v, want prompt return after Duration", elapsed)
+	}
+	if s.getSTHCalls == 0 || s.getSTHCalls >= ops {
+		t.Errorf("HammerCTLog() made %d get-sth requests, want between 1 and %d", s.getSTHCalls, ops)
+	}
+
+	// Cancellation by the caller is still reported.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	cfg.Duration = time.Hour
+	if err := HammerCTLog(ctx, cfg); err == nil {
+		t.Error("HammerCTLog(cancelled)=nil, want error")
+	}
+}
+
+func TestHammerResults(t *testing.T) {
+	s, lc := newFakeCTServer(t)
+	defer s.close()
+	s.sthNow.TreeSize = 10
+
+	// Metrics are process-wide, so use a log ID of our own.
+	logCfg := &configpb.LogConfig{LogId: 826, Prefix: "results"}
+	var buf bytes.Buffer
+	cfg := HammerConfig{
+		ClientPool: RandomPool{lc},
+		LogCfg:     logCfg,
+		EPBias: HammerBias{
+			Bias:          map[ctfe.EntrypointName]int{ctfe.GetSTHName: 1, ctfe.GetSTHConsistencyName: 1},
+			InvalidChance: map[ctfe.EntrypointName]int{ctfe.GetSTHConsistencyName: 2},
+		},
+		IgnoreErrors:     true,
+		MaxRetryDuration: time.Millisecond,
+		Operations:       20,
+		Seed:             1,
+		Results:          &buf,
+	}
+	// Invalid get-sth-consistency requests unexpectedly succeed against the
+	// fake server, so the run may fail, but results are written regardless.
+	if err := HammerCTLog(context.Background(), cfg); err != nil {
+		t.Logf("HammerCTLog(): %v", err)
+	}
+
+	var got HammerResults
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse results %q: %v", buf.String(), err)
+	}
+	label := strconv.FormatInt(logCfg.LogId, 10)
+	want := HammerResults{Prefix: "results", Entrypoints: make(map[string]EntrypointResults)}
+	for _, ep := range ctfe.Entrypoints {
+		epRes := EntrypointResults{
+			Requests:        int(reqs.Value(label, string(ep))),
+			Successes:       int(rsps.Value(label, string(ep), strconv.Itoa(http.StatusOK))),
+			Errors:          int(errs.Value(label, string(ep))),
+			InvalidRequests: int(invalidReqs.Value(label, string(ep))),
+		}
+		want.TotalRequests += epRes.Requests
+		want.TotalErrors += epRes.Errors
+		want.TotalInvalidRequests += epRes.InvalidRequests
+		if epRes.Requests == 0 && epRes.InvalidRequests == 0 {
+			continue
+		}
+		// Latencies are sampled, so just check that they are present.
+		gotEP := got.Entrypoints[string(ep)]
+		if epRes.Requests > 0 && (gotEP.LatencyP50Ms <= 0 || gotEP.LatencyP99Ms < gotEP.LatencyP50Ms) {
+			t.Errorf("Results for %s have latencies p50=%vms p99=%vms, want 0 < p50 <= p99", ep, gotEP.LatencyP50Ms, gotEP.LatencyP99Ms)
+		}
+		epRes.LatencyP50Ms, epRes.LatencyP95Ms, epRes.LatencyP99Ms = gotEP.LatencyP50Ms, gotEP.LatencyP95Ms, gotEP.LatencyP99Ms
+		want.Entrypoints[string(ep)] = epRes
+	}
+	if want.TotalRequests == 0 {
+		t.Fatal("Hammer made no requests")
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Results differ from metrics (-want +got):\n%s", diff)
+	}
+}
+
+func TestRequestDeadlines(t *testing.T) {
+	hs, err := newHammerState(&HammerConfig{
+		LogCfg:           &configpb.LogConfig{},
+		RequestDeadline:  time.Second,
+		RequestDeadlines: map[ctfe.EntrypointName]time.Duration{ctfe.AddChainName: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create HammerState: %v", err)
+	}
+	for ep, want := range map[ctfe.EntrypointName]time.Duration{
+		ctfe.AddChainName:    time.Minute,
+		ctfe.AddPreChainName: time.Second,
+		ctfe.GetSTHName:      time.Second,
+	} {
+		if got := hs.requestDeadline(ep); got != want {
+			t.Errorf("requestDeadline(%s)=%v, want %v", ep, got, want)
+		}
+	}
+}
+
+func TestVerifyGetEntriesChains(t *testing.T) {
+	ctx := context.Background()
+	keys := loadTestKeys(t)
+	issuerKeyHash := sha256.Sum256(keys.caCert.RawSubjectPublicKeyInfo)
+
+	certEntry := func(chain []ct.ASN1Cert) ct.LeafEntry {
+		t.Helper()
+		return mustLeafEntry(t, &ct.TimestampedEntry{
+			EntryType: ct.X509LogEntryType,
+			X509Entry: &keys.leafChain[0],
+		}, ct.CertificateChain{Entries: chain})
+	}
+	precertEntry := func(keyHash [sha256.Size]byte, chain []ct.ASN1Cert) ct.LeafEntry {
+		t.Helper()
+		return mustLeafEntry(t, &ct.TimestampedEntry{
+			EntryType: ct.PrecertLogEntryType,
+			PrecertEntry: &ct.PreCert{
+				IssuerKeyHash:  keyHash,
+				TBSCertificate: keys.leafCert.RawTBSCertificate,
+			},
+		}, ct.PrecertChainEntry{PreCertificate: keys.leafChain[0], CertificateChain: chain})
+	}
+	bogusChain := []ct.ASN1Cert{{Data: []byte("bogus")}}
+
+	for _, test := range []struct {
+		name    string
+		entry   ct.LeafEntry
+		verify  bool
+		wantErr string
+	}{
+		{name: "cert", entry: certEntry(keys.leafChain[1:]), verify: true},
+		{name: "precert", entry: precertEntry(issuerKeyHash, keys.leafChain[1:]), verify: true},
+		{name: "cert_malformed_chain_unverified", entry: certEntry(bogusChain)},
+		{name: "cert_malformed_chain", entry: certEntry(bogusChain), verify: true, wantErr: "failed to parse chain[0]"},
+		{name: "precert_malformed_chain", entry: precertEntry(issuerKeyHash, bogusChain), verify: true, wantErr: "failed to parse chain[0]"},
+		{name: "precert_empty_chain", entry: precertEntry(issuerKeyHash, nil), verify: true, wantErr: "empty chain"},
+		{name: "precert_wrong_key_hash", entry: precertEntry([sha256.Size]byte{1}, keys.leafChain[1:]), verify: true, wantErr: "issuer key hash"},
+		{name: "garbage_extra_data", entry: ct.LeafEntry{LeafInput: certEntry(nil).LeafInput, ExtraData: []byte("bogus")}, verify: true, wantErr: "failed to parse entry"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, lc := newFakeCTServer(t)
+			defer s.close()
+			s.sthNow.TreeSize = 1
+			s.entries = []ct.LeafEntry{test.entry}
+
+			hs, err := newHammerState(&HammerConfig{
+				VerifyGetEntriesChains: test.verify,
+				ClientPool:             RandomPool{lc},
+				LogCfg:                 &configpb.LogConfig{},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create HammerState: %v", err)
+			}
+			if err := hs.getSTH(ctx); err != nil {
+				t.Fatalf("getSTH() returned err = %v", err)
+			}
+
+			err = hs.getEntries(ctx)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("getEntries() returned err = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("getEntries() returned err = %v, want error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// mustLeafEntry returns a LeafEntry for the given timestamped entry, with the
+// TLS-encoded extraData.
+func mustLeafEntry(t *testing.T, entry *ct.TimestampedEntry, extraData interface{}) ct.LeafEntry {
+	t.Helper()
+	leafInput, err := tls.Marshal(ct.MerkleTreeLeaf{Version: ct.V1, LeafType: ct.TimestampedEntryLeafType, TimestampedEntry: entry})
+	if err != nil {
+		t.Fatalf("tls.Marshal(leaf) returned err = %v", err)
+	}
+	extra, err := tls.Marshal(extraData)
+	if err != nil {
+		t.Fatalf("tls.Marshal(extraData) returned err = %v", err)
+	}
+	return ct.LeafEntry{LeafInput: leafInput, ExtraData: extra}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencySamples is the number of latencies kept per entrypoint for
+// computing percentiles.
+const latencySamples = 1024
+
+// latencyReservoir holds a uniform random sample of bounded size of all the
+// latencies added to it (reservoir sampling), so that percentiles over a whole
+// run can be estimated in constant memory and cheaply.
+type latencyReservoir struct {
+	mu      sync.Mutex
+	rng     *rand.Rand // Separate from the hammer's, to not perturb its choices.
+	seen    int64
+	samples []time.Duration
+}
+
+func newLatencyReservoir(size int) *latencyReservoir {
+	return &latencyReservoir{
+		rng:     rand.New(rand.NewSource(1)),
+		samples: make([]time.Duration, 0, size),
+	}
+}
+
+// add records a latency.
+func (r *latencyReservoir) add(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen++
+	if len(r.samples) < cap(r.samples) {
+		r.samples = append(r.samples, d)
+		return
+	}
+	if i := r.rng.Int63n(r.seen); i < int64(len(r.samples)) {
+		r.samples[i] = d
+	}
+}
+
+// percentiles returns the estimated latencies at each of the given
+// percentiles, or nil if no latencies were added.
+func (r *latencyReservoir) percentiles(ps ...float64) []time.Duration {
+	r.mu.Lock()
+	sorted := slices.Clone(r.samples)
+	r.mu.Unlock()
+	if len(sorted) == 0 {
+		return nil
+	}
+	slices.Sort(sorted)
+	result := make([]time.Duration, len(ps))
+	for i, p := range ps {
+		result[i] = percentile(sorted, p)
+	}
+	return result
+}
+
+// String returns the p50, p95 and p99 latencies, or the empty string if no
+// latencies were added.
+func (r *latencyReservoir) String() string {
+	pcts := r.percentiles(50, 95, 99)
+	if pcts == nil {
+		return ""
+	}
+	return fmt.Sprintf("p50=%v,p95=%v,p99=%v", pcts[0].Round(time.Microsecond), pcts[1].Round(time.Microsecond), pcts[2].Round(time.Microsecond))
+}
+
+// percentile returns the value at percentile p of the sorted (non-empty)
+// values, using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
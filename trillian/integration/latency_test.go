@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	for _, test := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: time.Millisecond},
+		{p: 1, want: time.Millisecond},
+		{p: 50, want: 50 * time.Millisecond},
+		{p: 95, want: 95 * time.Millisecond},
+		{p: 99, want: 99 * time.Millisecond},
+		{p: 99.5, want: 100 * time.Millisecond},
+		{p: 100, want: 100 * time.Millisecond},
+	} {
+		if got := percentile(sorted, test.p); got != test.want {
+			t.Errorf("percentile(1..100ms, %v)=%v, want %v", test.p, got, test.want)
+		}
+	}
+	if got, want := percentile([]time.Duration{time.Second}, 99), time.Second; got != want {
+		t.Errorf("percentile(single, 99)=%v, want %v", got, want)
+	}
+}
+
+func TestLatencyReservoir(t *testing.T) {
+	r := newLatencyReservoir(10)
+	if got := r.percentiles(50); got != nil {
+		t.Errorf("percentiles() of empty reservoir = %v, want nil", got)
+	}
+	if got := r.String(); got != "" {
+		t.Errorf("String() of empty reservoir = %q, want empty", got)
+	}
+
+	// While not full, all samples are kept, so the percentiles are exact.
+	for i := 10; i >= 1; i-- {
+		r.add(time.Duration(i) * time.Millisecond)
+	}
+	got := r.percentiles(50, 90, 100)
+	want := []time.Duration{5 * time.Millisecond, 9 * time.Millisecond, 10 * time.Millisecond}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("percentiles()[%d]=%v, want %v", i, got[i], want[i])
+		}
+	}
+	if got, want := r.String(), "p50=5ms,p95=10ms,p99=10ms"; got != want {
+		t.Errorf("String()=%q, want %q", got, want)
+	}
+
+	// Once full, the sample size stays bounded and still covers the range.
+	r = newLatencyReservoir(latencySamples)
+	const n = 100000
+	for i := 1; i <= n; i++ {
+		r.add(time.Duration(i) * time.Microsecond)
+	}
+	if got := len(r.samples); got != latencySamples {
+		t.Errorf("reservoir holds %d samples, want %d", got, latencySamples)
+	}
+	for _, p := range []float64{50, 95, 99} {
+		got := r.percentiles(p)[0]
+		want := time.Duration(p/100*n) * time.Microsecond
+		if diff := got - want; diff < -n/20*time.Microsecond || diff > n/20*time.Microsecond {
+			t.Errorf("percentiles(%v)=%v, want about %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/OlegBabkin/certificate-transparency-go/client"
+	"github.com/google/trillian/crypto/keyspb"
+)
+
+// Names of the strategies for distributing requests across the clients of a
+// pool, as accepted by NewClientPool.
+const (
+	RandomPoolStrategy        = "random"
+	WeightedPoolStrategy      = "weighted"
+	LeastInFlightPoolStrategy = "least_in_flight"
+)
+
+// NewClientPool creates a pool of clients for the log with the given prefix on
+// each of the comma-separated servers, which distributes requests according to
+// strategy. The weights are only used by the weighted strategy, and must hold
+// one positive weight per server.
+func NewClientPool(strategy, servers string, weights []int, pubKey *keyspb.PublicKey, prefix string, auth string) (ClientPool, error) {
+	switch strategy {
+	case RandomPoolStrategy, "":
+		return NewRandomPool(servers, pubKey, prefix, auth)
+	case WeightedPoolStrategy:
+		clients, err := newLogClients(servers, pubKey, prefix, auth, nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewWeightedPool(clients, weights)
+	case LeastInFlightPoolStrategy:
+		pool := &LeastInFlightPool{inFlight: make([]atomic.Int64, len(strings.Split(servers, ",")))}
+		clients, err := newLogClients(servers, pubKey, prefix, auth, func(i int, rt http.RoundTripper) http.RoundTripper {
+			return &inFlightTransport{rt: rt, count: &pool.inFlight[i]}
+		})
+		if err != nil {
+			return nil, err
+		}
+		pool.clients = clients
+		return pool, nil
+	}
+	return nil, fmt.Errorf("unknown client pool strategy %q", strategy)
+}
+
+// WeightedPool is a ClientPool which picks clients at random, in proportion
+// to their weights.
+type WeightedPool struct {
+	clients []*client.LogClient
+	// cumulative[i] is the sum of the weights of clients [0, i].
+	cumulative []int
+	// intn is the source of random choices, or nil for the global one.
+	intn func(n int) int
+}
+
+var _ ClientPool = &WeightedPool{}
+
+// seededPool is a ClientPool whose random choices can be taken from the
+// hammer's seeded source, so that hammer runs are reproducible.
+type seededPool interface {
+	setIntn(intn func(n int) int)
+}
+
+var _ seededPool = &WeightedPool{}
+
+func (p *WeightedPool) setIntn(intn func(n int) int) {
+	p.intn = intn
+}
+
+// NewWeightedPool creates a pool of the given clients, where each client is
+// picked with a probability proportional to the corresponding weight.
+func NewWeightedPool(clients []*client.LogClient, weights []int) (*WeightedPool, error) {
+	if len(clients) != len(weights) {
+		return nil, fmt.Errorf("got %d weights for %d clients", len(weights), len(clients))
+	}
+	pool := &WeightedPool{clients: clients}
+	total := 0
+	for i, w := range weights {
+		if w <= 0 {
+			return nil, fmt.Errorf("weight %d of client %d is not positive", w, i)
+		}
+		total += w
+		pool.cumulative = append(pool.cumulative, total)
+	}
+	return pool, nil
+}
+
+// Next picks a random client from the pool, according to the weights.
+func (p *WeightedPool) Next() *client.LogClient {
+	if len(p.clients) == 0 {
+		return nil
+	}
+	intn := p.intn
+	if intn == nil {
+		intn = rand.Intn
+	}
+	which := intn(p.cumulative[len(p.cumulative)-1])
+	return p.clients[sort.SearchInts(p.cumulative, which+1)]
+}
+
+// LeastInFlightPool is a ClientPool which picks the client with the fewest
+// requests in flight, going round-robin between clients with equally few.
+// Requests are tracked by the HTTP transports of the clients, so the pool
+// must be created by NewClientPool.
+type LeastInFlightPool struct {
+	clients  []*client.LogClient
+	inFlight []atomic.Int64
+	next     atomic.Uint64
+}
+
+var _ ClientPool = &LeastInFlightPool{}
+
+// Next picks the client with the fewest requests in flight.
+func (p *LeastInFlightPool) Next() *client.LogClient {
+	if len(p.clients) == 0 {
+		return nil
+	}
+	start := int(p.next.Add(1) % uint64(len(p.clients)))
+	best := start
+	for i := 1; i < len(p.clients); i++ {
+		idx := (start + i) % len(p.clients)
+		if p.inFlight[idx].Load() < p.inFlight[best].Load() {
+			best = idx
+		}
+	}
+	return p.clients[best]
+}
+
+// inFlightTransport is an http.RoundTripper which counts the requests in
+// flight.
+type inFlightTransport struct {
+	rt    http.RoundTripper
+	count *atomic.Int64
+}
+
+func (t *inFlightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count.Add(1)
+	defer t.count.Add(-1)
+	return t.rt.RoundTrip(req)
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/ctfe/configpb"
+)
+
+const testServers = "ct0.example.com,ct1.example.com,ct2.example.com"
+
+// countPicks calls Next on the pool n times, and returns how often the client
+// for each of the testServers was picked.
+func countPicks(t *testing.T, pool ClientPool, n int) []int {
+	t.Helper()
+	index := map[string]int{
+		"http://ct0.example.com/log": 0,
+		"http://ct1.example.com/log": 1,
+		"http://ct2.example.com/log": 2,
+	}
+	counts := make([]int, len(index))
+	for i := 0; i < n; i++ {
+		idx, ok := index[pool.Next().BaseURI()]
+		if !ok {
+			t.Fatalf("Next() returned unexpected client")
+		}
+		counts[idx]++
+	}
+	return counts
+}
+
+func TestWeightedPool(t *testing.T) {
+	pool, err := NewClientPool(WeightedPoolStrategy, testServers, []int{1, 3, 6}, nil, "log", "")
+	if err != nil {
+		t.Fatalf("NewClientPool()=%v", err)
+	}
+	const n = 50000
+	counts := countPicks(t, pool, n)
+	for i, want := range []float64{0.1, 0.3, 0.6} {
+		if got := float64(counts[i]) / n; math.Abs(got-want) > 0.02 {
+			t.Errorf("client %d picked %.3f of the time, want %.3f", i, got, want)
+		}
+	}
+
+	for _, weights := range [][]int{nil, {1, 2}, {1, 0, 1}, {1, -1, 1}} {
+		if _, err := NewClientPool(WeightedPoolStrategy, testServers, weights, nil, "log", ""); err == nil {
+			t.Errorf("NewClientPool(weights=%v)=nil, want error", weights)
+		}
+	}
+}
+
+func TestWeightedPoolSeeded(t *testing.T) {
+	picks := func(seed int64) []string {
+		p, err := NewClientPool(WeightedPoolStrategy, testServers, []int{1, 3, 6}, nil, "log", "")
+		if err != nil {
+			t.Fatalf("NewClientPool()=%v", err)
+		}
+		if _, err := newHammerState(&HammerConfig{ClientPool: p, Seed: seed, LogCfg: &configpb.LogConfig{}}); err != nil {
+			t.Fatalf("newHammerState()=%v", err)
+		}
+		var ret []string
+		for i := 0; i < 20; i++ {
+			ret = append(ret, p.Next().BaseURI())
+		}
+		return ret
+	}
+	if a, b := picks(42), picks(42); !slices.Equal(a, b) {
+		t.Errorf("picks with the same seed differ: %v vs %v", a, b)
+	}
+}
+
+func TestLeastInFlightPool(t *testing.T) {
+	p, err := NewClientPool(LeastInFlightPoolStrategy, testServers, nil, nil, "log", "")
+	if err != nil {
+		t.Fatalf("NewClientPool()=%v", err)
+	}
+	pool := p.(*LeastInFlightPool)
+
+	// With nothing in flight, clients are picked in turn.
+	if got, want := countPicks(t, pool, 30), []int{10, 10, 10}; !equalInts(got, want) {
+		t.Errorf("idle pool picks=%v, want %v", got, want)
+	}
+
+	// Otherwise the least loaded client is always picked.
+	pool.inFlight[0].Store(2)
+	pool.inFlight[1].Store(1)
+	pool.inFlight[2].Store(3)
+	if got, want := countPicks(t, pool, 30), []int{0, 30, 0}; !equalInts(got, want) {
+		t.Errorf("loaded pool picks=%v, want %v", got, want)
+	}
+
+	// Ties between the least loaded clients are shared between them.
+	pool.inFlight[2].Store(1)
+	if got := countPicks(t, pool, 30); got[0] != 0 || got[1] == 0 || got[2] == 0 {
+		t.Errorf("tied pool picks=%v, want only clients 1 and 2", got)
+	}
+}
+
+func TestInFlightTransport(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+	defer ts.Close()
+
+	var count atomic.Int64
+	hc := &http.Client{Transport: &inFlightTransport{rt: http.DefaultTransport, count: &count}}
+	done := make(chan error)
+	go func() {
+		rsp, err := hc.Get(ts.URL)
+		if err == nil {
+			err = rsp.Body.Close()
+		}
+		done <- err
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(10 * time.Second):
+		t.Fatal("request did not reach server")
+	}
+	if got := count.Load(); got != 1 {
+		t.Errorf("in flight during request=%d, want 1", got)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Get()=%v", err)
+	}
+	if got := count.Load(); got != 0 {
+		t.Errorf("in flight after request=%d, want 0", got)
+	}
+}
+
+func TestNewClientPoolUnknownStrategy(t *testing.T) {
+	if _, err := NewClientPool("round_robin", testServers, nil, nil, "log", ""); err == nil {
+		t.Error("NewClientPool(round_robin)=nil, want error")
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
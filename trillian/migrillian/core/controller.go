@@ -73,6 +73,11 @@ func initMetrics(mf monitoring.MetricFactory) {
 }
 
 // Options holds configuration for a Controller.
+//
+// The entries are fetched by FetcherOptions.ParallelFetch concurrent workers,
+// and submitted to Trillian by Submitters concurrent workers. Since the
+// target is a pre-ordered log, each entry is submitted with its own leaf
+// index, so batches may be fetched and submitted in any order.
 type Options struct {
 	scanner.FetcherOptions
 	Submitters         int
@@ -80,10 +85,15 @@ type Options struct {
 	NoConsistencyCheck bool
 	StartDelay         time.Duration
 	StopAfter          time.Duration
+	// DryRun makes the Controller only check that the migration can be
+	// performed, and report a summary, instead of transferring entries.
+	DryRun bool
 }
 
-// OptionsFromConfig returns Options created from the passed in config.
-func OptionsFromConfig(cfg *configpb.MigrationConfig) Options {
+// OptionsFromConfig returns Options created from the passed in config. If
+// dryRun is true, the Controller only checks that the migration can be
+// performed.
+func OptionsFromConfig(cfg *configpb.MigrationConfig, dryRun bool) Options {
 	opts := Options{
 		FetcherOptions: scanner.FetcherOptions{
 			BatchSize:     int(cfg.BatchSize),
@@ -95,6 +105,7 @@ func OptionsFromConfig(cfg *configpb.MigrationConfig) Options {
 		Submitters:         int(cfg.NumSubmitters),
 		ChannelSize:        int(cfg.ChannelSize),
 		NoConsistencyCheck: cfg.NoConsistencyCheck,
+		DryRun:             dryRun,
 	}
 	if cfg.NumFetchers == 0 {
 		opts.ParallelFetch = 1
@@ -132,10 +143,20 @@ func NewController(
 }
 
 // RunWhenMasterWithRestarts calls RunWhenMaster, and, if the migration is
-// configured with continuous mode, restarts it whenever it returns.
-func (c *Controller) RunWhenMasterWithRestarts(ctx context.Context) {
+// configured with continuous mode, restarts it whenever it returns. In DryRun
+// mode it only runs the DryRun checks, regardless of mastership, and returns
+// their error. Otherwise it always returns nil.
+func (c *Controller) RunWhenMasterWithRestarts(ctx context.Context) error {
 	uri := c.ctClient.BaseURI()
 	treeID := c.plClient.treeID
+	if c.opts.DryRun {
+		res, err := c.DryRun(ctx)
+		if err != nil {
+			return fmt.Errorf("Controller.DryRun(%d<-%q): %v", treeID, uri, err)
+		}
+		klog.Infof("Controller.DryRun(%d<-%q) succeeded: %v", treeID, uri, res)
+		return nil
+	}
 	for run := true; run; run = c.opts.Continuous && ctx.Err() == nil {
 		klog.Infof("Starting migration Controller (%d<-%q)", treeID, uri)
 		if err := c.RunWhenMaster(ctx); err != nil {
@@ -144,6 +165,7 @@ func (c *Controller) RunWhenMasterWithRestarts(ctx context.Context) {
 		}
 		klog.Infof("Controller stopped (%d<-%q)", treeID, uri)
 	}
+	return nil
 }
 
 // RunWhenMaster is a master-elected version of Run method. It executes Run
@@ -268,18 +290,7 @@ func (c *Controller) fetchTail(ctx context.Context, begin uint64) (uint64, error
 		return 0, err
 	}
 
-	fo := c.opts.FetcherOptions
-	if fo.Continuous { // Ignore range parameters in continuous mode.
-		fo.StartIndex, fo.EndIndex = int64(treeSize), 0
-		// Use non-continuous Fetcher, as we implement continuity in Controller.
-		// TODO(pavelkalinnikov): Don't overload Fetcher's Continuous flag.
-		fo.Continuous = false
-	} else if fo.StartIndex < 0 {
-		fo.StartIndex = int64(treeSize)
-	}
-	if int64(begin) > fo.StartIndex {
-		fo.StartIndex = int64(begin)
-	}
+	fo := fetchOptions(c.opts.FetcherOptions, treeSize, begin)
 	klog.Infof("%s: fetching range [%d, %d)", c.label, fo.StartIndex, fo.EndIndex)
 
 	fetcher := scanner.NewFetcher(c.ctClient, &fo)
@@ -292,6 +303,9 @@ func (c *Controller) fetchTail(ctx context.Context, begin uint64) (uint64, error
 	if sth.TreeSize <= begin {
 		return begin, nil
 	}
+	if sth.TreeSize < treeSize {
+		return 0, fmt.Errorf("source log is shorter than Trillian tree: STH size %d < tree size %d", sth.TreeSize, treeSize)
+	}
 
 	if err := c.verifyConsistency(ctx, treeSize, rootHash, sth); err != nil {
 		return 0, err
@@ -334,6 +348,25 @@ func (c *Controller) fetchTail(ctx context.Context, begin uint64) (uint64, error
 	return sth.TreeSize, nil
 }
 
+// fetchOptions returns the Fetcher options for transferring the entries
+// following the passed in minimal position begin, given the current size of
+// the Trillian tree. The entries below the tree size are already integrated,
+// so fetching always starts at or after it.
+func fetchOptions(fo scanner.FetcherOptions, treeSize, begin uint64) scanner.FetcherOptions {
+	if fo.Continuous { // Ignore range parameters in continuous mode.
+		fo.StartIndex, fo.EndIndex = int64(treeSize), 0
+		// Use non-continuous Fetcher, as we implement continuity in Controller.
+		// TODO(pavelkalinnikov): Don't overload Fetcher's Continuous flag.
+		fo.Continuous = false
+	} else if fo.StartIndex < int64(treeSize) {
+		fo.StartIndex = int64(treeSize)
+	}
+	if int64(begin) > fo.StartIndex {
+		fo.StartIndex = int64(begin)
+	}
+	return fo
+}
+
 // verifyConsistency checks that the provided verified Trillian root is
 // consistent with the CT log's STH.
 func (c *Controller) verifyConsistency(ctx context.Context, treeSize uint64, rootHash []byte, sth *ct.SignedTreeHead) error {
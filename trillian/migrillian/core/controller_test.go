@@ -15,10 +15,26 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
 
 	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/client"
+	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
+	"github.com/OlegBabkin/certificate-transparency-go/scanner"
+	"github.com/OlegBabkin/certificate-transparency-go/tls"
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/mockclient"
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc"
 )
 
 func TestVerifyConsistencyEmptyHead(t *testing.T) {
@@ -27,3 +43,158 @@ func TestVerifyConsistencyEmptyHead(t *testing.T) {
 		t.Errorf("verifyConsistency should always succeed given empty root")
 	}
 }
+
+func TestFetchOptions(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		fo        scanner.FetcherOptions
+		treeSize  uint64
+		begin     uint64
+		wantStart int64
+		wantEnd   int64
+	}{
+		{desc: "empty-tree", fo: scanner.FetcherOptions{StartIndex: 0, EndIndex: 100}, wantStart: 0, wantEnd: 100},
+		{desc: "negative-start", fo: scanner.FetcherOptions{StartIndex: -1}, treeSize: 50, wantStart: 50},
+		{desc: "restart-skips-migrated", fo: scanner.FetcherOptions{StartIndex: 0, EndIndex: 100}, treeSize: 50, wantStart: 50, wantEnd: 100},
+		{desc: "start-after-tree", fo: scanner.FetcherOptions{StartIndex: 70, EndIndex: 100}, treeSize: 50, wantStart: 70, wantEnd: 100},
+		{desc: "tree-beyond-end", fo: scanner.FetcherOptions{StartIndex: 0, EndIndex: 100}, treeSize: 120, wantStart: 120, wantEnd: 100},
+		{desc: "begin-after-tree", fo: scanner.FetcherOptions{StartIndex: 0}, treeSize: 50, begin: 80, wantStart: 80},
+		{desc: "continuous", fo: scanner.FetcherOptions{StartIndex: 10, EndIndex: 100, Continuous: true}, treeSize: 50, wantStart: 50},
+		{desc: "continuous-begin", fo: scanner.FetcherOptions{StartIndex: 10, EndIndex: 100, Continuous: true}, treeSize: 50, begin: 60, wantStart: 60},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fo := fetchOptions(tc.fo, tc.treeSize, tc.begin)
+			if got, want := fo.StartIndex, tc.wantStart; got != want {
+				t.Errorf("StartIndex=%d, want %d", got, want)
+			}
+			if got, want := fo.EndIndex, tc.wantEnd; got != want {
+				t.Errorf("EndIndex=%d, want %d", got, want)
+			}
+			if fo.Continuous {
+				t.Error("Continuous=true, want false")
+			}
+		})
+	}
+}
+
+// testLeafEntry returns a distinct X.509 log entry for the given index.
+func testLeafEntry(t *testing.T, index int64) ct.LeafEntry {
+	t.Helper()
+	leaf := ct.MerkleTreeLeaf{
+		Version:  ct.V1,
+		LeafType: ct.TimestampedEntryLeafType,
+		TimestampedEntry: &ct.TimestampedEntry{
+			Timestamp: uint64(index),
+			EntryType: ct.X509LogEntryType,
+			X509Entry: &ct.ASN1Cert{Data: []byte(fmt.Sprintf("cert-%d", index))},
+		},
+	}
+	leafInput, err := tls.Marshal(leaf)
+	if err != nil {
+		t.Fatalf("tls.Marshal(leaf): %v", err)
+	}
+	extraData, err := tls.Marshal(ct.CertificateChain{})
+	if err != nil {
+		t.Fatalf("tls.Marshal(chain): %v", err)
+	}
+	return ct.LeafEntry{LeafInput: leafInput, ExtraData: extraData}
+}
+
+// serveLog returns a test CT log server with treeSize entries, which returns
+// at most maxEntries of them in one get-entries response.
+func serveLog(t *testing.T, treeSize, maxEntries int64) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ct.GetSTHPath:
+			fmt.Fprintf(w, `{"tree_size":%d,"timestamp":1507127718502,"sha256_root_hash":"tncuLXiPAo711IOxjaYTwLmwbSyyE8hEcRhaOXvFb3g=","tree_head_signature":"BAMARjBEAiAi5045/h8Yvs1mNlsYskWvuFbu2A6hO2J45KDFfOR1OwIgZ2jq8iFCwKuTbcIgsBB1ibHEupv97CeAQynK0Dw2PT8="}`, treeSize)
+		case ct.GetEntriesPath:
+			start, err := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			end, err := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			end = min(end, treeSize-1, start+maxEntries-1)
+			var rsp ct.GetEntriesResponse
+			for i := start; i <= end; i++ {
+				rsp.Entries = append(rsp.Entries, testLeafEntry(t, i))
+			}
+			if err := json.NewEncoder(w).Encode(&rsp); err != nil {
+				t.Errorf("Encode(): %v", err)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestRunParallelTransfer(t *testing.T) {
+	const treeSize = 500
+	initMetrics(monitoring.InertMetricFactory{})
+
+	for _, tc := range []struct {
+		desc       string
+		fetchers   int
+		submitters int
+	}{
+		{desc: "sequential", fetchers: 1, submitters: 1},
+		{desc: "parallel-fetch", fetchers: 4, submitters: 1},
+		{desc: "parallel-submit", fetchers: 1, submitters: 4},
+		{desc: "parallel-both", fetchers: 5, submitters: 3},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ts := serveLog(t, treeSize, 7)
+			defer ts.Close()
+			ctClient, err := client.New(ts.URL, http.DefaultClient, jsonclient.Options{})
+			if err != nil {
+				t.Fatalf("client.New(): %v", err)
+			}
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			cli := mockclient.NewMockTrillianLogClient(mockCtrl)
+			cli.EXPECT().GetLatestSignedLogRoot(gomock.Any(), gomock.Any()).Return(signedLogRoot(t, 0), nil)
+
+			var mu sync.Mutex
+			stored := make(map[int64]int)
+			cli.EXPECT().AddSequencedLeaves(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(_ context.Context, req *trillian.AddSequencedLeavesRequest, _ ...grpc.CallOption) (*trillian.AddSequencedLeavesResponse, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					for _, leaf := range req.Leaves {
+						if want := testLeafEntry(t, leaf.LeafIndex).LeafInput; !bytes.Equal(leaf.LeafValue, want) {
+							t.Errorf("Leaf %d has wrong value", leaf.LeafIndex)
+						}
+						stored[leaf.LeafIndex]++
+					}
+					return &trillian.AddSequencedLeavesResponse{}, nil
+				})
+
+			opts := Options{
+				FetcherOptions: scanner.FetcherOptions{BatchSize: 20, ParallelFetch: tc.fetchers},
+				Submitters:     tc.submitters,
+				ChannelSize:    2,
+			}
+			plClient := &PreorderedLogClient{cli: cli, treeID: testTreeID, idFunc: idHashLeafIndex}
+			c := &Controller{opts: opts, ctClient: ctClient, plClient: plClient, label: "test"}
+			if err := c.Run(ctx); err != nil {
+				t.Fatalf("Run(): %v", err)
+			}
+
+			if got, want := len(stored), treeSize; got != want {
+				t.Errorf("Stored %d distinct leaves, want %d", got, want)
+			}
+			for i := int64(0); i < treeSize; i++ {
+				if got := stored[i]; got != 1 {
+					t.Errorf("Leaf %d stored %d times, want once", i, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// DryRunResult summarizes the checks performed by Controller.DryRun.
+type DryRunResult struct {
+	SourceURI      string
+	SourceTreeSize uint64
+	TreeID         int64
+	TargetTreeSize uint64
+	// WriteProbed is false if the target tree is empty, so no write probe
+	// could be made.
+	WriteProbed bool
+}
+
+// String returns a human-readable summary of the dry run.
+func (r *DryRunResult) String() string {
+	ret := fmt.Sprintf("source %q has %d entries, tree %d has %d entries, %d entries to migrate",
+		r.SourceURI, r.SourceTreeSize, r.TreeID, r.TargetTreeSize, r.SourceTreeSize-r.TargetTreeSize)
+	if !r.WriteProbed {
+		ret += ", write probe skipped for empty tree"
+	}
+	return ret
+}
+
+// DryRun checks that the migration can be performed, without transferring any
+// entries. It verifies that the source CT log is reachable and returns an STH
+// signed with the configured public key, that the target Trillian tree is
+// currently an active pre-ordered log whose root can be read, and that it
+// accepts a no-op write (see probeWrite).
+func (c *Controller) DryRun(ctx context.Context) (*DryRunResult, error) {
+	res := &DryRunResult{SourceURI: c.ctClient.BaseURI(), TreeID: c.plClient.treeID}
+
+	sth, err := c.ctClient.GetSTH(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("source log %q: GetSTH(): %v", res.SourceURI, err)
+	}
+	res.SourceTreeSize = sth.TreeSize
+
+	if err := c.plClient.checkTree(ctx); err != nil {
+		return nil, fmt.Errorf("tree %d: %v", res.TreeID, err)
+	}
+
+	treeSize, _, err := c.plClient.getRoot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tree %d: failed to get root: %v", res.TreeID, err)
+	}
+	res.TargetTreeSize = treeSize
+
+	if res.WriteProbed, err = c.plClient.probeWrite(ctx, treeSize); err != nil {
+		return nil, fmt.Errorf("tree %d: write probe failed: %v", res.TreeID, err)
+	}
+
+	if res.SourceTreeSize < res.TargetTreeSize {
+		return nil, fmt.Errorf("source log is shorter than Trillian tree: STH size %d < tree size %d", res.SourceTreeSize, res.TargetTreeSize)
+	}
+	return res, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OlegBabkin/certificate-transparency-go/client"
+	"github.com/OlegBabkin/certificate-transparency-go/jsonclient"
+	"github.com/OlegBabkin/certificate-transparency-go/trillian/mockclient"
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testTreeID = 5
+
+// serveSTH returns a test CT log server which responds to get-sth with an
+// unsigned STH of the given size, or with an error if size is negative.
+func serveSTH(t *testing.T, size int64) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if size < 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"tree_size":%d,"timestamp":1507127718502,"sha256_root_hash":"tncuLXiPAo711IOxjaYTwLmwbSyyE8hEcRhaOXvFb3g=","tree_head_signature":"BAMARjBEAiAi5045/h8Yvs1mNlsYskWvuFbu2A6hO2J45KDFfOR1OwIgZ2jq8iFCwKuTbcIgsBB1ibHEupv97CeAQynK0Dw2PT8="}`, size)
+	}))
+}
+
+func signedLogRoot(t *testing.T, size uint64) *trillian.GetLatestSignedLogRootResponse {
+	t.Helper()
+	root, err := (&types.LogRootV1{TreeSize: size, RootHash: make([]byte, 32)}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): %v", err)
+	}
+	return &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: &trillian.SignedLogRoot{LogRoot: root}}
+}
+
+// fakeAdminClient is a TrillianAdminClient which only implements GetTree.
+type fakeAdminClient struct {
+	trillian.TrillianAdminClient
+	tree *trillian.Tree
+	err  error
+}
+
+func (f *fakeAdminClient) GetTree(context.Context, *trillian.GetTreeRequest, ...grpc.CallOption) (*trillian.Tree, error) {
+	return f.tree, f.err
+}
+
+func TestDryRun(t *testing.T) {
+	activeTree := &trillian.Tree{TreeId: testTreeID, TreeType: trillian.TreeType_PREORDERED_LOG, TreeState: trillian.TreeState_ACTIVE}
+	frozenTree := &trillian.Tree{TreeId: testTreeID, TreeType: trillian.TreeType_PREORDERED_LOG, TreeState: trillian.TreeState_FROZEN}
+	plainTree := &trillian.Tree{TreeId: testTreeID, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE}
+	for _, tc := range []struct {
+		desc       string
+		sourceSize int64
+		tree       *trillian.Tree
+		treeErr    error
+		rootErr    error
+		noRoot     bool
+		treeSize   uint64
+		probe      bool
+		probeErr   error
+		probeCode  codes.Code
+		wantProbed bool
+		wantErr    string
+	}{
+		{desc: "ok", sourceSize: 100, tree: activeTree, treeSize: 40, probe: true, probeCode: codes.AlreadyExists, wantProbed: true},
+		{desc: "ok-empty-tree", sourceSize: 100, tree: activeTree},
+		{desc: "source-unavailable", sourceSize: -1, tree: activeTree, noRoot: true, wantErr: "GetSTH"},
+		{desc: "get-tree-denied", sourceSize: 100, treeErr: status.Error(codes.PermissionDenied, "denied"), noRoot: true, wantErr: "GetTree"},
+		{desc: "frozen", sourceSize: 100, tree: frozenTree, noRoot: true, wantErr: "FROZEN"},
+		{desc: "not-preordered", sourceSize: 100, tree: plainTree, noRoot: true, wantErr: "PREORDERED_LOG"},
+		{desc: "root-error", sourceSize: 100, tree: activeTree, rootErr: status.Error(codes.NotFound, "no tree"), wantErr: "failed to get root"},
+		{desc: "probe-denied", sourceSize: 100, tree: activeTree, treeSize: 40, probe: true, probeErr: status.Error(codes.PermissionDenied, "read only"), wantErr: "write probe failed"},
+		{desc: "probe-leaf-failed", sourceSize: 100, tree: activeTree, treeSize: 40, probe: true, probeCode: codes.FailedPrecondition, wantErr: "leaf status"},
+		{desc: "source-shorter", sourceSize: 10, tree: activeTree, treeSize: 40, probe: true, probeCode: codes.AlreadyExists, wantErr: "shorter"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ts := serveSTH(t, tc.sourceSize)
+			defer ts.Close()
+			ctClient, err := client.New(ts.URL, http.DefaultClient, jsonclient.Options{})
+			if err != nil {
+				t.Fatalf("client.New(): %v", err)
+			}
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			cli := mockclient.NewMockTrillianLogClient(mockCtrl)
+			if !tc.noRoot {
+				var rsp *trillian.GetLatestSignedLogRootResponse
+				if tc.rootErr == nil {
+					rsp = signedLogRoot(t, tc.treeSize)
+				}
+				cli.EXPECT().GetLatestSignedLogRoot(gomock.Any(), gomock.Any()).Return(rsp, tc.rootErr)
+			}
+			if tc.probe {
+				leaf := &trillian.LogLeaf{LeafValue: []byte("leaf"), LeafIndex: int64(tc.treeSize - 1)}
+				cli.EXPECT().GetLeavesByRange(gomock.Any(), &trillian.GetLeavesByRangeRequest{LogId: testTreeID, StartIndex: int64(tc.treeSize - 1), Count: 1}).
+					Return(&trillian.GetLeavesByRangeResponse{Leaves: []*trillian.LogLeaf{leaf}}, nil)
+				var rsp *trillian.AddSequencedLeavesResponse
+				if tc.probeErr == nil {
+					rsp = &trillian.AddSequencedLeavesResponse{Results: []*trillian.QueuedLogLeaf{{Leaf: leaf, Status: status.New(tc.probeCode, "").Proto()}}}
+				}
+				cli.EXPECT().AddSequencedLeaves(gomock.Any(), &trillian.AddSequencedLeavesRequest{LogId: testTreeID, Leaves: []*trillian.LogLeaf{leaf}}).Return(rsp, tc.probeErr)
+			}
+
+			admin := &fakeAdminClient{tree: tc.tree, err: tc.treeErr}
+			plClient := &PreorderedLogClient{cli: cli, admin: admin, treeID: testTreeID}
+			c := &Controller{opts: Options{DryRun: true}, ctClient: ctClient, plClient: plClient}
+			res, err := c.DryRun(ctx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("DryRun()=%v, %v; want error containing %q", res, err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DryRun(): %v", err)
+			}
+			if got, want := res.SourceTreeSize, uint64(tc.sourceSize); got != want {
+				t.Errorf("SourceTreeSize=%d, want %d", got, want)
+			}
+			if got, want := res.TargetTreeSize, tc.treeSize; got != want {
+				t.Errorf("TargetTreeSize=%d, want %d", got, want)
+			}
+			if got, want := res.WriteProbed, tc.wantProbed; got != want {
+				t.Errorf("WriteProbed=%v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRunMigrationDryRunError(t *testing.T) {
+	ts := serveSTH(t, -1)
+	defer ts.Close()
+	ctClient, err := client.New(ts.URL, http.DefaultClient, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("client.New(): %v", err)
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	plClient := &PreorderedLogClient{cli: mockclient.NewMockTrillianLogClient(mockCtrl), treeID: testTreeID}
+	c := &Controller{opts: Options{DryRun: true}, ctClient: ctClient, plClient: plClient}
+
+	if err := RunMigration(context.Background(), []*Controller{c}); err == nil || !strings.Contains(err.Error(), "GetSTH") {
+		t.Errorf("RunMigration()=%v; want error containing %q", err, "GetSTH")
+	}
+}
@@ -40,14 +40,17 @@ var errRetry = errors.New("retry")
 // pre-ordered log tree.
 type PreorderedLogClient struct {
 	cli    trillian.TrillianLogClient
+	admin  trillian.TrillianAdminClient
 	treeID int64
 	idFunc func(int64, *ct.RawLogEntry) []byte
 	prefix string // TODO(pavelkalinnikov): Get rid of this.
 }
 
-// NewPreorderedLogClient creates and initializes a pre-ordered log client.
+// NewPreorderedLogClient creates and initializes a pre-ordered log client. The
+// admin client is used to re-read the tree in dry runs.
 func NewPreorderedLogClient(
 	cli trillian.TrillianLogClient,
+	admin trillian.TrillianAdminClient,
 	tree *trillian.Tree,
 	idFuncType configpb.IdentityFunction,
 	prefix string,
@@ -58,7 +61,7 @@ func NewPreorderedLogClient(
 	if got, want := tree.TreeType, trillian.TreeType_PREORDERED_LOG; got != want {
 		return nil, fmt.Errorf("tree %d is %v, want %v", tree.TreeId, got, want)
 	}
-	ret := PreorderedLogClient{cli: cli, treeID: tree.TreeId, prefix: prefix}
+	ret := PreorderedLogClient{cli: cli, admin: admin, treeID: tree.TreeId, prefix: prefix}
 
 	switch idFuncType {
 	case configpb.IdentityFunction_SHA256_CERT_DATA:
@@ -141,6 +144,57 @@ func (c *PreorderedLogClient) addSequencedLeaves(ctx context.Context, b *scanner
 	return boerr
 }
 
+// checkTree fetches the current state of the tree, and checks that it is an
+// active pre-ordered log.
+func (c *PreorderedLogClient) checkTree(ctx context.Context) error {
+	if c.admin == nil {
+		return errors.New("no admin client")
+	}
+	tree, err := c.admin.GetTree(ctx, &trillian.GetTreeRequest{TreeId: c.treeID})
+	if err != nil {
+		return fmt.Errorf("GetTree(): %v", err)
+	}
+	if got, want := tree.TreeType, trillian.TreeType_PREORDERED_LOG; got != want {
+		return fmt.Errorf("tree is %v, want %v", got, want)
+	}
+	if got, want := tree.TreeState, trillian.TreeState_ACTIVE; got != want {
+		return fmt.Errorf("tree is %v, want %v", got, want)
+	}
+	return nil
+}
+
+// probeWrite checks that the tree accepts AddSequencedLeaves requests,
+// without modifying it. Trillian rejects a request with no leaves before it
+// looks at the tree, so instead the last leaf of the tree is submitted again,
+// at its own index, which Trillian reports as already existing. Nothing can
+// be probed this way in an empty tree, in which case it returns false.
+func (c *PreorderedLogClient) probeWrite(ctx context.Context, treeSize uint64) (bool, error) {
+	if treeSize == 0 {
+		return false, nil
+	}
+	getReq := trillian.GetLeavesByRangeRequest{LogId: c.treeID, StartIndex: int64(treeSize - 1), Count: 1}
+	getRsp, err := c.cli.GetLeavesByRange(ctx, &getReq)
+	if err != nil {
+		return false, fmt.Errorf("GetLeavesByRange(): %v", err)
+	} else if len(getRsp.GetLeaves()) != 1 {
+		return false, fmt.Errorf("GetLeavesByRange() returned %d leaves, want 1", len(getRsp.GetLeaves()))
+	}
+
+	addReq := trillian.AddSequencedLeavesRequest{LogId: c.treeID, Leaves: getRsp.Leaves}
+	addRsp, err := c.cli.AddSequencedLeaves(ctx, &addReq)
+	if err != nil {
+		return false, fmt.Errorf("AddSequencedLeaves(): %v", err)
+	}
+	for _, res := range addRsp.GetResults() {
+		switch code := codes.Code(res.GetStatus().GetCode()); code {
+		case codes.OK, codes.AlreadyExists:
+		default:
+			return false, fmt.Errorf("AddSequencedLeaves() leaf status: %v", res.GetStatus())
+		}
+	}
+	return true, nil
+}
+
 func (c *PreorderedLogClient) buildLogLeaf(index int64, entry *ct.LeafEntry) (*trillian.LogLeaf, error) {
 	rle, err := ct.RawLogEntryFromLeaf(index, entry)
 	if err != nil {
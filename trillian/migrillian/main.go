@@ -45,6 +45,7 @@ var (
 
 	forceMaster = flag.Bool("force_master", false, "If true, assume master for all logs")
 	backend     = flag.String("backend", "", "GRPC endpoint to connect to Trillian logservers")
+	dryRun      = flag.Bool("dry_run", false, "If true, only check that the source logs are reachable and the target trees are active pre-ordered logs accepting a no-op write, without migrating entries")
 
 	metricsEndpoint = flag.String("metrics_endpoint", "localhost:8099", "Endpoint for serving metrics")
 
@@ -107,7 +108,9 @@ func main() {
 	defer cancel()
 	go util.AwaitSignal(cctx, cancel)
 
-	core.RunMigration(cctx, ctrls)
+	if err := core.RunMigration(cctx, ctrls); err != nil {
+		klog.Exitf("Migration failed: %v", err)
+	}
 }
 
 // getController creates a single log migration Controller.
@@ -129,7 +132,7 @@ func getController(
 		return nil, fmt.Errorf("failed to create PreorderedLogClient: %v", err)
 	}
 
-	opts := core.OptionsFromConfig(cfg)
+	opts := core.OptionsFromConfig(cfg, *dryRun)
 	return core.NewController(opts, ctClient, plClient, ef, mf), nil
 }
 
@@ -177,7 +180,7 @@ func newPreorderedLogClient(
 	}
 	log := trillian.NewTrillianLogClient(conn)
 	pref := fmt.Sprintf("%d", cfg.LogId)
-	return core.NewPreorderedLogClient(log, tree, cfg.IdentityFunction, pref)
+	return core.NewPreorderedLogClient(log, admin, tree, cfg.IdentityFunction, pref)
 }
 
 // getElectionFactory returns an election factory based on flags, and a
@@ -20,6 +20,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
@@ -125,7 +126,17 @@ type ASN1Cert struct {
 // LogID holds the hash of the Log's public key (section 3.2).
 // TODO(pphaneuf): Users should be migrated to the one in the logid package.
 type LogID struct {
-	KeyID [sha256.Size]byte
+	KeyID [sha256.Size]byte `tls:"fixed:32"`
+}
+
+// FromBytes populates the LogID with the contents of b, which must hold
+// exactly sha256.Size bytes.
+func (l *LogID) FromBytes(b []byte) error {
+	if len(b) != sha256.Size {
+		return fmt.Errorf("invalid length, expected %d got %d", sha256.Size, len(b))
+	}
+	copy(l.KeyID[:], b)
+	return nil
 }
 
 // PreCert represents a Precertificate (section 3.2).
@@ -139,6 +150,20 @@ type PreCert struct {
 // nolint: revive
 type CTExtensions []byte // tls:"minlen:0,maxlen:65535"`
 
+// CTExtensionType represents the ExtensionType enum from RFC 6962-bis
+// section 4.5:
+//
+//	enum { reserved(65535) } ExtensionType;
+type CTExtensionType tls.Enum // tls:"maxval:65535"
+
+// CTExtension represents a single Extension from RFC 6962-bis section 4.5.
+// The raw bytes of CTExtensions may hold a sequence of these, ordered by
+// ExtensionType; see ParseCTExtensions.
+type CTExtension struct {
+	ExtensionType CTExtensionType `tls:"maxval:65535"`
+	ExtensionData []byte          `tls:"minlen:0,maxlen:65535"`
+}
+
 // MerkleTreeNode represents an internal node in the CT tree.
 type MerkleTreeNode []byte
 
@@ -277,10 +302,19 @@ func (s *SHA256Hash) FromBase64String(b64 string) error {
 	if err != nil {
 		return fmt.Errorf("failed to unbase64 LogID: %v", err)
 	}
-	if len(bs) != sha256.Size {
-		return fmt.Errorf("invalid SHA256 length, expected 32 but got %d", len(bs))
+	if err := s.FromBytes(bs); err != nil {
+		return fmt.Errorf("SHA256 hash is %v", err)
+	}
+	return nil
+}
+
+// FromBytes populates the SHA256Hash with the contents of b, which must hold
+// exactly sha256.Size bytes.
+func (s *SHA256Hash) FromBytes(b []byte) error {
+	if len(b) != sha256.Size {
+		return fmt.Errorf("invalid length, expected %d got %d", sha256.Size, len(b))
 	}
-	copy(s[:], bs)
+	copy(s[:], b)
 	return nil
 }
 
@@ -331,6 +365,18 @@ func (s SignedTreeHead) String() string {
 		logIDStr, s.TreeSize, s.Timestamp, s.SHA256RootHash.Base64String(), sigStr)
 }
 
+// TimestampTime returns the time at which the STH was created, with the
+// millisecond precision of its Timestamp.
+func (s SignedTreeHead) TimestampTime() time.Time {
+	return TimestampToTime(s.Timestamp)
+}
+
+// Age returns how long before now the STH was created. It is negative if the
+// STH's timestamp is later than now.
+func (s SignedTreeHead) Age(now time.Time) time.Duration {
+	return now.Sub(s.TimestampTime())
+}
+
 // TreeHeadSignature holds the data over which the signature in an STH is
 // generated; see section 3.5
 type TreeHeadSignature struct {
@@ -338,7 +384,7 @@ type TreeHeadSignature struct {
 	SignatureType  SignatureType `tls:"maxval:255"` // == TreeHashSignatureType
 	Timestamp      uint64
 	TreeSize       uint64
-	SHA256RootHash SHA256Hash
+	SHA256RootHash SHA256Hash `tls:"fixed:32"`
 }
 
 // SignedCertificateTimestamp represents the structure returned by the
@@ -486,10 +532,9 @@ func (r *AddChainResponse) ToSignedCertificateTimestamp() (*SignedCertificateTim
 		Timestamp:  r.Timestamp,
 	}
 
-	if len(r.ID) != sha256.Size {
-		return nil, fmt.Errorf("id is invalid length, expected %d got %d", sha256.Size, len(r.ID))
+	if err := sct.LogID.FromBytes(r.ID); err != nil {
+		return nil, fmt.Errorf("id is %v", err)
 	}
-	copy(sct.LogID.KeyID[:], r.ID)
 
 	exts, err := base64.StdEncoding.DecodeString(r.Extensions)
 	if err != nil {
@@ -508,6 +553,23 @@ func (r *AddChainResponse) ToSignedCertificateTimestamp() (*SignedCertificateTim
 	return &sct, nil
 }
 
+// ToAddChainResponse creates an AddChainResponse from the
+// SignedCertificateTimestamp, i.e. the JSON form in which a log returns the
+// SCT. This is the inverse of AddChainResponse.ToSignedCertificateTimestamp.
+func (s *SignedCertificateTimestamp) ToAddChainResponse() (*AddChainResponse, error) {
+	sig, err := tls.Marshal(s.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("tls.Marshal(): %s", err)
+	}
+	return &AddChainResponse{
+		SCTVersion: s.SCTVersion,
+		ID:         append([]byte(nil), s.LogID.KeyID[:]...),
+		Timestamp:  s.Timestamp,
+		Extensions: base64.StdEncoding.EncodeToString(s.Extensions),
+		Signature:  sig,
+	}, nil
+}
+
 // AddJSONRequest represents the JSON request body sent to the add-json POST method.
 // The corresponding response re-uses AddChainResponse.
 // This is an experimental addition not covered by RFC6962.
@@ -530,10 +592,9 @@ func (r *GetSTHResponse) ToSignedTreeHead() (*SignedTreeHead, error) {
 		Timestamp: r.Timestamp,
 	}
 
-	if len(r.SHA256RootHash) != sha256.Size {
-		return nil, fmt.Errorf("sha256_root_hash is invalid length, expected %d got %d", sha256.Size, len(r.SHA256RootHash))
+	if err := sth.SHA256RootHash.FromBytes(r.SHA256RootHash); err != nil {
+		return nil, fmt.Errorf("sha256_root_hash is %v", err)
 	}
-	copy(sth.SHA256RootHash[:], r.SHA256RootHash)
 
 	var ds DigitallySigned
 	if rest, err := tls.Unmarshal(r.TreeHeadSignature, &ds); err != nil {
@@ -15,11 +15,15 @@
 package ct
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/OlegBabkin/certificate-transparency-go/tls"
 )
@@ -141,6 +145,57 @@ func TestToSignedCertificateTimestamp(t *testing.T) {
 	}
 }
 
+func TestToAddChainResponseRoundTrip(t *testing.T) {
+	// The same SCT as in TestToSignedCertificateTimestamp.
+	const sctJSON = `{"sct_version":0,"id":"CEEUmABxUywWGQRgvPxH/cJlOvopLHKzf/hjrinMyfA=","timestamp":1512556025588,"extensions":"","signature":"BAMARjBEAiAJAPO7EKykH4eOQ81kTzKCb4IEWzcxTBdbdRCHLFPLFAIgBEoGXDUtcIaF3M5HWI+MxwkCQbvqR9TSGUHDCZoOr3Q="}`
+
+	for _, test := range []struct {
+		desc string
+		exts string
+	}{
+		{desc: "no extensions"},
+		{desc: "extensions", exts: "AQID"},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			var rsp AddChainResponse
+			if err := json.Unmarshal([]byte(sctJSON), &rsp); err != nil {
+				t.Fatalf("json.Unmarshal(): %v", err)
+			}
+			rsp.Extensions = test.exts
+			sct, err := rsp.ToSignedCertificateTimestamp()
+			if err != nil {
+				t.Fatalf("AddChainResponse.ToSignedCertificateTimestamp(): %v", err)
+			}
+
+			got, err := sct.ToAddChainResponse()
+			if err != nil {
+				t.Fatalf("SignedCertificateTimestamp.ToAddChainResponse(): %v", err)
+			}
+			if !reflect.DeepEqual(got, &rsp) {
+				t.Errorf("SignedCertificateTimestamp.ToAddChainResponse() = %+v, want %+v", got, rsp)
+			}
+			if test.exts != "" {
+				return
+			}
+			gotJSON, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("json.Marshal(): %v", err)
+			}
+			if string(gotJSON) != sctJSON {
+				t.Errorf("json.Marshal(ToAddChainResponse()) = %s, want %s", gotJSON, sctJSON)
+			}
+
+			sct2, err := got.ToSignedCertificateTimestamp()
+			if err != nil {
+				t.Fatalf("AddChainResponse.ToSignedCertificateTimestamp(): %v", err)
+			}
+			if !reflect.DeepEqual(sct2, sct) {
+				t.Errorf("round trip SCT = %+v, want %+v", sct2, sct)
+			}
+		})
+	}
+}
+
 const (
 	validRootHash = "708981e91d1487c2a9ea901ab5a8d053c1348585afcdb5e107bf60c0c1d20fc0"
 	longRootHash  = "708981e91d1487c2a9ea901ab5a8d053c1348585afcdb5e107bf60c0c1d20fc000"
@@ -246,3 +301,155 @@ func TestSTHString(t *testing.T) {
 		})
 	}
 }
+
+func TestFixedLengthFieldsRoundTrip(t *testing.T) {
+	var rootHash SHA256Hash
+	var keyID [32]byte
+	for i := range rootHash {
+		rootHash[i] = 0x11
+		keyID[i] = 0x22
+	}
+	for _, test := range []struct {
+		desc string
+		item interface{}
+		want string // hex encoded
+	}{
+		{
+			desc: "TreeHeadSignature",
+			item: &TreeHeadSignature{
+				Version:        V1,
+				SignatureType:  TreeHashSignatureType,
+				Timestamp:      0x0102,
+				TreeSize:       3,
+				SHA256RootHash: rootHash,
+			},
+			want: "0001" + "0000000000000102" + "0000000000000003" + strings.Repeat("11", 32),
+		},
+		{
+			desc: "SignedCertificateTimestamp",
+			item: &SignedCertificateTimestamp{
+				SCTVersion: V1,
+				LogID:      LogID{KeyID: keyID},
+				Timestamp:  0x0102,
+				Extensions: CTExtensions{},
+				Signature: DigitallySigned{
+					Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA},
+					Signature: []byte{0xab, 0xcd},
+				},
+			},
+			want: "00" + strings.Repeat("22", 32) + "0000000000000102" + "0000" + "04030002abcd",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			data, err := tls.Marshal(reflect.ValueOf(test.item).Elem().Interface())
+			if err != nil {
+				t.Fatalf("tls.Marshal()=nil,%v; want no error", err)
+			}
+			if got := hex.EncodeToString(data); got != test.want {
+				t.Errorf("tls.Marshal()=%s; want %s", got, test.want)
+			}
+
+			got := reflect.New(reflect.TypeOf(test.item).Elem()).Interface()
+			if rest, err := tls.Unmarshal(data, got); err != nil {
+				t.Fatalf("tls.Unmarshal()=nil,%v; want no error", err)
+			} else if len(rest) > 0 {
+				t.Errorf("tls.Unmarshal() left %d bytes", len(rest))
+			}
+			if !reflect.DeepEqual(got, test.item) {
+				t.Errorf("tls.Unmarshal()=%+v; want %+v", got, test.item)
+			}
+		})
+	}
+}
+
+func TestSTHTimestampTimeAndAge(t *testing.T) {
+	for _, test := range []struct {
+		desc      string
+		timestamp uint64
+		now       time.Time
+		wantTime  time.Time
+		wantAge   time.Duration
+	}{
+		{
+			desc:      "epoch",
+			timestamp: 0,
+			now:       time.Unix(60, 0),
+			wantTime:  time.Unix(0, 0),
+			wantAge:   time.Minute,
+		},
+		{
+			desc:      "one-millisecond",
+			timestamp: 1,
+			now:       time.Unix(0, 3*int64(time.Millisecond)),
+			wantTime:  time.Unix(0, int64(time.Millisecond)),
+			wantAge:   2 * time.Millisecond,
+		},
+		{
+			desc:      "milliseconds-kept",
+			timestamp: 1527076172068,
+			now:       time.Unix(1527076173, 500*int64(time.Millisecond)),
+			wantTime:  time.Unix(1527076172, 68*int64(time.Millisecond)),
+			wantAge:   1432 * time.Millisecond,
+		},
+		{
+			desc:      "future",
+			timestamp: 1527076172068,
+			now:       time.Unix(1527076172, 0),
+			wantTime:  time.Unix(1527076172, 68*int64(time.Millisecond)),
+			wantAge:   -68 * time.Millisecond,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			sth := SignedTreeHead{Timestamp: test.timestamp}
+			if got := sth.TimestampTime(); !got.Equal(test.wantTime) {
+				t.Errorf("TimestampTime()=%v; want %v", got, test.wantTime)
+			}
+			if got, want := uint64(sth.TimestampTime().UnixMilli()), test.timestamp; got != want {
+				t.Errorf("TimestampTime().UnixMilli()=%d; want %d", got, want)
+			}
+			if got := sth.Age(test.now); got != test.wantAge {
+				t.Errorf("Age(%v)=%v; want %v", test.now, got, test.wantAge)
+			}
+		})
+	}
+}
+
+func TestHashFromBytes(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		data    []byte
+		wantErr string
+	}{
+		{desc: "valid", data: []byte(strings.Repeat("\x11", 32))},
+		{desc: "short", data: []byte(strings.Repeat("\x11", 31)), wantErr: "invalid length, expected 32 got 31"},
+		{desc: "long", data: []byte(strings.Repeat("\x11", 33)), wantErr: "invalid length, expected 32 got 33"},
+		{desc: "empty", wantErr: "invalid length, expected 32 got 0"},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			var logID LogID
+			var hash SHA256Hash
+			for name, err := range map[string]error{
+				"LogID.FromBytes()":      logID.FromBytes(test.data),
+				"SHA256Hash.FromBytes()": hash.FromBytes(test.data),
+			} {
+				if test.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+						t.Errorf("%s=%v; want error containing %q", name, err, test.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s=%v; want nil", name, err)
+				}
+			}
+			if test.wantErr == "" {
+				if !bytes.Equal(logID.KeyID[:], test.data) {
+					t.Errorf("LogID.KeyID=%x; want %x", logID.KeyID, test.data)
+				}
+				if !bytes.Equal(hash[:], test.data) {
+					t.Errorf("SHA256Hash=%x; want %x", hash, test.data)
+				}
+			}
+		})
+	}
+}
@@ -17,10 +17,14 @@ import (
 var initonce sync.Once
 var p192r1 *elliptic.CurveParams
 var p256k1 *ecdsaext.CurveParams
+var bp256r1, bp384r1, bp512r1 *ecdsaext.CurveParams
 
 func initAllCurves() {
 	initSECP192R1()
 	initSecp256k1()
+	initBrainpoolP256r1()
+	initBrainpoolP384r1()
+	initBrainpoolP512r1()
 }
 
 func initSECP192R1() {
@@ -58,3 +62,68 @@ func secp256k1() elliptic.Curve {
 	initonce.Do(initAllCurves)
 	return p256k1
 }
+
+// newBrainpoolCurve builds the parameters for one of the RFC 5639 curves;
+// these have a != -3, so they need the generic ecdsaext implementation.
+func newBrainpoolCurve(name string, bitSize int, p, a, b, gx, gy, n string) *ecdsaext.CurveParams {
+	params := elliptic.CurveParams{Name: name, BitSize: bitSize}
+	params.P, _ = new(big.Int).SetString(p, 16)
+	params.N, _ = new(big.Int).SetString(n, 16)
+	params.B, _ = new(big.Int).SetString(b, 16)
+	params.Gx, _ = new(big.Int).SetString(gx, 16)
+	params.Gy, _ = new(big.Int).SetString(gy, 16)
+	curveA, _ := new(big.Int).SetString(a, 16)
+
+	return &ecdsaext.CurveParams{
+		CurveParams: params,
+		A:           curveA,
+	}
+}
+
+func initBrainpoolP256r1() {
+	// See RFC 5639, section 3.4
+	bp256r1 = newBrainpoolCurve("brainpoolP256r1", 256,
+		"A9FB57DBA1EEA9BC3E660A909D838D726E3BF623D52620282013481D1F6E5377",
+		"7D5A0975FC2C3057EEF67530417AFFE7FB8055C126DC5C6CE94A4B44F330B5D9",
+		"26DC5C6CE94A4B44F330B5D9BBD77CBF958416295CF7E1CE6BCCDC18FF8C07B6",
+		"8BD2AEB9CB7E57CB2C4B482FFC81B7AFB9DE27E1E3BD23C23A4453BD9ACE3262",
+		"547EF835C3DAC4FD97F8461A14611DC9C27745132DED8E545C1D54C72F046997",
+		"A9FB57DBA1EEA9BC3E660A909D838D718C397AA3B561A6F7901E0E82974856A7")
+}
+
+func brainpoolP256r1() elliptic.Curve {
+	initonce.Do(initAllCurves)
+	return bp256r1
+}
+
+func initBrainpoolP384r1() {
+	// See RFC 5639, section 3.6
+	bp384r1 = newBrainpoolCurve("brainpoolP384r1", 384,
+		"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B412B1DA197FB71123ACD3A729901D1A71874700133107EC53",
+		"7BC382C63D8C150C3C72080ACE05AFA0C2BEA28E4FB22787139165EFBA91F90F8AA5814A503AD4EB04A8C7DD22CE2826",
+		"04A8C7DD22CE28268B39B55416F0447C2FB77DE107DCD2A62E880EA53EEB62D57CB4390295DBC9943AB78696FA504C11",
+		"1D1C64F068CF45FFA2A63A81B7C13F6B8847A3E77EF14FE3DB7FCAFE0CBD10E8E826E03436D646AAEF87B2E247D4AF1E",
+		"8ABE1D7520F9C2A45CB1EB8E95CFD55262B70B29FEEC5864E19C054FF99129280E4646217791811142820341263C5315",
+		"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B31F166E6CAC0425A7CF3AB6AF6B7FC3103B883202E9046565")
+}
+
+func brainpoolP384r1() elliptic.Curve {
+	initonce.Do(initAllCurves)
+	return bp384r1
+}
+
+func initBrainpoolP512r1() {
+	// See RFC 5639, section 3.7
+	bp512r1 = newBrainpoolCurve("brainpoolP512r1", 512,
+		"AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA703308717D4D9B009BC66842AECDA12AE6A380E62881FF2F2D82C68528AA6056583A48F3",
+		"7830A3318B603B89E2327145AC234CC594CBDD8D3DF91610A83441CAEA9863BC2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A72BF2C7B9E7C1AC4D77FC94CA",
+		"3DF91610A83441CAEA9863BC2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A72BF2C7B9E7C1AC4D77FC94CADC083E67984050B75EBAE5DD2809BD638016F723",
+		"81AEE4BDD82ED9645A21322E9C4C6A9385ED9F70B5D916C1B43B62EEF4D0098EFF3B1F78E2D0D48D50D1687B93B97D5F7C6D5047406A5E688B352209BCB9F822",
+		"7DDE385D566332ECC0EABFA9CF7822FDF209F70024A57B1AA000C55B881F8111B2DCDE494A5F485E5BCA4BD88A2763AED1CA2B2FA8F0540678CD1E0F3AD80892",
+		"AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA70330870553E5C414CA92619418661197FAC10471DB1D381085DDADDB58796829CA90069")
+}
+
+func brainpoolP512r1() elliptic.Curve {
+	initonce.Do(initAllCurves)
+	return bp512r1
+}
@@ -5,8 +5,14 @@
 package x509
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/pem"
 	"math/big"
+	"os"
 	"testing"
+
+	"github.com/OlegBabkin/certificate-transparency-go/asn1"
 )
 
 func TestSECP192R1(t *testing.T) {
@@ -299,3 +305,78 @@ func TestSECP192R1(t *testing.T) {
 		})
 	}
 }
+
+func TestBrainpoolCertificates(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantName string
+	}{
+		{in: "testdata/brainpool/brainpoolP256r1.pem", wantName: "brainpoolP256r1"},
+		{in: "testdata/brainpool/brainpoolP384r1.pem", wantName: "brainpoolP384r1"},
+		{in: "testdata/brainpool/brainpoolP512r1.pem", wantName: "brainpoolP512r1"},
+	}
+	for _, test := range tests {
+		t.Run(test.wantName, func(t *testing.T) {
+			data, err := os.ReadFile(test.in)
+			if err != nil {
+				t.Fatalf("failed to read test data: %v", err)
+			}
+			block, _ := pem.Decode(data)
+			if block == nil {
+				t.Fatalf("failed to decode PEM from %s", test.in)
+			}
+			cert, err := ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatalf("ParseCertificate()=nil,%v; want _,nil", err)
+			}
+			pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+			if !ok {
+				t.Fatalf("PublicKey is %T, want *ecdsa.PublicKey", cert.PublicKey)
+			}
+			if got := pub.Curve.Params().Name; got != test.wantName {
+				t.Errorf("curve name=%q, want %q", got, test.wantName)
+			}
+			if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+				t.Error("public key point is not on curve")
+			}
+			if _, ok := OIDFromNamedCurve(pub.Curve); !ok {
+				t.Errorf("OIDFromNamedCurve(%s) failed", test.wantName)
+			}
+			// The test certificates are self-signed with the brainpool key.
+			if err := cert.CheckSignatureFrom(cert); err != nil {
+				t.Errorf("CheckSignatureFrom(self)=%v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestNamedCurveFromOID(t *testing.T) {
+	tests := []struct {
+		desc   string
+		oid    asn1.ObjectIdentifier
+		want   elliptic.Curve
+		wantOK bool
+	}{
+		{desc: "P-256", oid: OIDNamedCurveP256, want: elliptic.P256(), wantOK: true},
+		{desc: "secp192r1", oid: OIDNamedCurveP192, want: secp192r1(), wantOK: true},
+		{desc: "secp256k1", oid: OIDNamedCurveP256K1, want: secp256k1(), wantOK: true},
+		{desc: "unknown", oid: asn1.ObjectIdentifier{1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, ok := NamedCurveFromOID(test.oid)
+			if ok != test.wantOK {
+				t.Fatalf("NamedCurveFromOID(%v)=_,%v, want _,%v", test.oid, ok, test.wantOK)
+			}
+			if got != test.want {
+				t.Errorf("NamedCurveFromOID(%v)=%v, want %v", test.oid, got, test.want)
+			}
+			if !ok {
+				return
+			}
+			if oid, ok := OIDFromNamedCurve(got); !ok || !oid.Equal(test.oid) {
+				t.Errorf("OIDFromNamedCurve(NamedCurveFromOID(%v))=%v,%v, want %v,true", test.oid, oid, ok, test.oid)
+			}
+		})
+	}
+}
@@ -623,6 +623,17 @@ func getPublicKeyAlgorithmFromOID(oid asn1.ObjectIdentifier) PublicKeyAlgorithm
 //	    iso(1) member-body(2) us(840) ansi-X9-62(10045) curves(3)
 //	    prime(1) 1 }
 //
+// RFC 5639, 4.1. Object Identifiers
+//
+//	brainpoolP256r1 OBJECT IDENTIFIER ::= {
+//	  iso(1) identified-organization(3) teletrust(36) algorithm(3)
+//	  signatureAlgorithm(3) ecSign(2) ecStdCurvesAndGeneration(8)
+//	  ellipticCurve(1) versionOne(1) 7 }
+//
+//	brainpoolP384r1 OBJECT IDENTIFIER ::= { versionOne 11 }
+//
+//	brainpoolP512r1 OBJECT IDENTIFIER ::= { versionOne 13 }
+//
 // NB: secp256r1 is equivalent to prime256v1,
 // secp192r1 is equivalent to ansix9p192r and prime192v1
 var (
@@ -632,6 +643,10 @@ var (
 	OIDNamedCurveP521   = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
 	OIDNamedCurveP192   = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 1}
 	OIDNamedCurveP256K1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10} // 1.3.132.0.10
+
+	OIDNamedCurveBrainpoolP256r1 = asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 7}
+	OIDNamedCurveBrainpoolP384r1 = asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 11}
+	OIDNamedCurveBrainpoolP512r1 = asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 13}
 )
 
 func namedCurveFromOID(oid asn1.ObjectIdentifier, nfe *NonFatalErrors) elliptic.Curve {
@@ -649,10 +664,25 @@ func namedCurveFromOID(oid asn1.ObjectIdentifier, nfe *NonFatalErrors) elliptic.
 		return secp192r1()
 	case oid.Equal(OIDNamedCurveP256K1):
 		return secp256k1()
+	case oid.Equal(OIDNamedCurveBrainpoolP256r1):
+		return brainpoolP256r1()
+	case oid.Equal(OIDNamedCurveBrainpoolP384r1):
+		return brainpoolP384r1()
+	case oid.Equal(OIDNamedCurveBrainpoolP512r1):
+		return brainpoolP512r1()
 	}
 	return nil
 }
 
+// NamedCurveFromOID returns the elliptic curve identified by the given OID,
+// including the non-standard curves supported by this package, and whether
+// the OID was recognized.
+func NamedCurveFromOID(oid asn1.ObjectIdentifier) (elliptic.Curve, bool) {
+	var nfe NonFatalErrors
+	curve := namedCurveFromOID(oid, &nfe)
+	return curve, curve != nil
+}
+
 // OIDFromNamedCurve returns the OID used to specify the use of the given
 // elliptic curve.
 func OIDFromNamedCurve(curve elliptic.Curve) (asn1.ObjectIdentifier, bool) {
@@ -669,6 +699,12 @@ func OIDFromNamedCurve(curve elliptic.Curve) (asn1.ObjectIdentifier, bool) {
 		return OIDNamedCurveP192, true
 	case secp256k1():
 		return OIDNamedCurveP256K1, true
+	case brainpoolP256r1():
+		return OIDNamedCurveBrainpoolP256r1, true
+	case brainpoolP384r1():
+		return OIDNamedCurveBrainpoolP384r1, true
+	case brainpoolP512r1():
+		return OIDNamedCurveBrainpoolP512r1, true
 	}
 
 	return nil, false
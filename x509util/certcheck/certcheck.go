@@ -17,13 +17,23 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
 
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/ctutil"
+	"github.com/OlegBabkin/certificate-transparency-go/loglist3"
 	"github.com/OlegBabkin/certificate-transparency-go/x509"
 	"github.com/OlegBabkin/certificate-transparency-go/x509util"
 	"k8s.io/klog/v2"
@@ -43,6 +53,10 @@ var (
 	checkNameConstraint      = flag.Bool("check_name_constraint", true, "Check name constraints")
 	checkUnknownCriticalExts = flag.Bool("check_unknown_critical_exts", true, "Check for unknown critical extensions")
 	checkRevoked             = flag.Bool("check_revocation", false, "Check revocation status of certificate")
+	output                   = flag.String("output", "text", "Output format for certificate details: text or json")
+	showSCTs                 = flag.Bool("show_scts", false, "Show the SCTs embedded in the leaf certificate; not supported with --output=json")
+	logList                  = flag.String("log_list", "", "Location of CT log list (URL or filename) to verify the signatures of embedded SCTs against; only used with --show_scts")
+	pin                      = flag.String("pin", "", "Comma-separated list of base64-encoded SHA-256 hashes of SubjectPublicKeyInfo; at least one certificate in a verified chain must match one of them; requires --validate")
 )
 
 func addCerts(filename string, pool *x509.CertPool) {
@@ -67,6 +81,44 @@ func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
 
+	var enc *json.Encoder
+	switch *output {
+	case "text":
+	case "json":
+		enc = json.NewEncoder(os.Stdout)
+	default:
+		klog.Exitf("Unknown --output format %q", *output)
+	}
+	if enc != nil && *showSCTs {
+		// The JSON records have no place for the SCTs yet.
+		klog.Exitf("--show_scts is not supported with --output=json")
+	}
+
+	pins, err := parsePins(*pin)
+	if err != nil {
+		klog.Exitf("Failed to parse --pin: %v", err)
+	}
+	if len(pins) > 0 && !*validate {
+		klog.Exitf("--pin requires --validate")
+	}
+
+	var logsByHash ctutil.LogInfoByHash
+	if *showSCTs && *logList != "" {
+		hc := &http.Client{}
+		llData, err := x509util.ReadFileOrURL(*logList, hc)
+		if err != nil {
+			klog.Exitf("Failed to read log list: %v", err)
+		}
+		ll, err := loglist3.NewFromJSON(llData)
+		if err != nil {
+			klog.Exitf("Failed to parse log list: %v", err)
+		}
+		logsByHash, err = ctutil.LogInfoByKeyHash(ll, hc)
+		if err != nil {
+			klog.Exitf("Failed to build log info map: %v", err)
+		}
+	}
+
 	failed := false
 	for _, target := range flag.Args() {
 		var err error
@@ -85,8 +137,12 @@ func main() {
 		} else if err != nil && *strict {
 			failed = true
 		}
-		for _, cert := range chain {
-			if *verbose {
+		summaries := make([]*x509util.CertificateSummary, len(chain))
+		for i, cert := range chain {
+			if enc != nil {
+				summaries[i] = x509util.SummarizeCertificate(cert)
+				summaries[i].SetParseErrors(err)
+			} else if *verbose {
 				fmt.Print(x509util.CertificateToString(cert))
 			}
 			if *checkRevoked {
@@ -96,6 +152,12 @@ func main() {
 				}
 			}
 		}
+		if *showSCTs && len(chain) > 0 {
+			if err := showEmbeddedSCTs(os.Stdout, chain, logsByHash); err != nil {
+				klog.Errorf("%s: %v", target, err)
+				failed = true
+			}
+		}
 		if *validate && len(chain) > 0 {
 			opts := x509.VerifyOptions{
 				DisableTimeChecks:              !*checkTime,
@@ -105,9 +167,31 @@ func main() {
 				DisablePathLenChecks:           !*checkPathLen,
 				DisableNameConstraintChecks:    !*checkNameConstraint,
 			}
-			if err := validateChain(chain, opts, *root, *intermediate, *useSystemRoots); err != nil {
+			verified, err := validateChain(chain, opts, *root, *intermediate, *useSystemRoots)
+			if err == nil && len(pins) > 0 {
+				err = checkPins(verified, pins)
+			}
+			if err != nil {
 				klog.Errorf("%s: verification error: %v", target, err)
 				failed = true
+				var cie x509.CertificateInvalidError
+				if *verbose && errors.As(err, &cie) && cie.Reason == x509.CANotAuthorizedForThisName {
+					// Keep stdout for the certificate details, which may be JSON.
+					fmt.Fprintf(os.Stderr, "Name constraint violations:\n")
+					for _, v := range nameConstraintViolations(chain) {
+						fmt.Fprintf(os.Stderr, "  %s\n", v)
+					}
+				}
+			}
+			if enc != nil {
+				summaries[0].SetVerifyResult(err)
+			}
+		}
+		if enc != nil {
+			for _, summary := range summaries {
+				if err := enc.Encode(summary); err != nil {
+					klog.Exitf("Failed to write JSON output: %v", err)
+				}
 			}
 		}
 	}
@@ -188,7 +272,9 @@ func chainFromFile(filename string) ([]*x509.Certificate, error) {
 	return chain, nil
 }
 
-func validateChain(chain []*x509.Certificate, opts x509.VerifyOptions, rootsFile, intermediatesFile string, useSystemRoots bool) error {
+// validateChain verifies the leaf of the chain, and returns the verified
+// chains built for it.
+func validateChain(chain []*x509.Certificate, opts x509.VerifyOptions, rootsFile, intermediatesFile string, useSystemRoots bool) ([][]*x509.Certificate, error) {
 	roots := x509.NewCertPool()
 	if useSystemRoots {
 		systemRoots, err := x509.SystemCertPool()
@@ -218,8 +304,225 @@ func validateChain(chain []*x509.Certificate, opts x509.VerifyOptions, rootsFile
 			opts.Intermediates.AddCert(chain[i])
 		}
 	}
-	_, err := chain[0].Verify(opts)
-	return err
+	return chain[0].Verify(opts)
+}
+
+// showEmbeddedSCTs writes the log ID and timestamp of each SCT embedded in the
+// leaf certificate of the chain to w. If logs is non-nil, the SCT signatures
+// are also verified against the known logs, in which case an error is returned
+// if any SCT can't be verified.
+func showEmbeddedSCTs(w io.Writer, chain []*x509.Certificate, logs ctutil.LogInfoByHash) error {
+	leaf := chain[0]
+	fmt.Fprintf(w, "Embedded SCTs: %d\n", len(leaf.SCTList.SCTList))
+	if len(leaf.SCTList.SCTList) == 0 {
+		return nil
+	}
+
+	// Build a Merkle leaf that corresponds to the embedded SCTs.  We can use the same
+	// leaf for all of the SCTs, as long as the timestamp field gets updated.
+	var merkleLeaf *ct.MerkleTreeLeaf
+	var leafErr error
+	if logs != nil {
+		var issuer *x509.Certificate
+		for _, c := range chain[1:] {
+			if bytes.Equal(c.RawSubject, leaf.RawIssuer) && c.CheckSignature(leaf.SignatureAlgorithm, leaf.RawTBSCertificate, leaf.Signature) == nil {
+				issuer = c
+				break
+			}
+		}
+		if issuer == nil {
+			leafErr = errors.New("no issuer in chain")
+		} else {
+			merkleLeaf, leafErr = ct.MerkleTreeLeafForEmbeddedSCT([]*x509.Certificate{leaf, issuer}, 0)
+		}
+	}
+
+	var failures int
+	for i, sctData := range leaf.SCTList.SCTList {
+		sct, err := x509util.ExtractSCT(&sctData)
+		if err != nil {
+			fmt.Fprintf(w, "  SCT[%d]: failed to deserialize: %v\n", i, err)
+			failures++
+			continue
+		}
+		fmt.Fprintf(w, "  SCT[%d]: log ID %x, timestamp %d (%v)\n", i, sct.LogID.KeyID[:], sct.Timestamp, ct.TimestampToTime(sct.Timestamp))
+		if logs == nil {
+			continue
+		}
+		logInfo := logs[sct.LogID.KeyID]
+		switch {
+		case logInfo == nil:
+			fmt.Fprintf(w, "    unknown log, cannot verify signature\n")
+			failures++
+		case leafErr != nil:
+			fmt.Fprintf(w, "    cannot verify signature from log %q: %v\n", logInfo.Description, leafErr)
+			failures++
+		default:
+			if err := logInfo.VerifySCTSignature(*sct, *merkleLeaf); err != nil {
+				fmt.Fprintf(w, "    signature from log %q invalid: %v\n", logInfo.Description, err)
+				failures++
+			} else {
+				fmt.Fprintf(w, "    signature from log %q verified\n", logInfo.Description)
+			}
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d embedded SCTs failed verification", failures, len(leaf.SCTList.SCTList))
+	}
+	return nil
+}
+
+// nameConstraintViolation describes a name in a leaf certificate which is not
+// allowed by the name constraints of a CA certificate in its chain.
+type nameConstraintViolation struct {
+	CA       *x509.Certificate
+	NameType string // "DNS name", "IP address", "email address" or "URI".
+	Name     string
+	// Excluded is the excluded subtree the name falls into. If empty, the
+	// name is outside of all of the Permitted subtrees instead.
+	Excluded  string
+	Permitted []string
+}
+
+func (v nameConstraintViolation) String() string {
+	if v.Excluded != "" {
+		return fmt.Sprintf("%s %q is in excluded subtree %q of CA %q", v.NameType, v.Name, v.Excluded, v.CA.Subject)
+	}
+	return fmt.Sprintf("%s %q is outside of permitted subtrees %q of CA %q", v.NameType, v.Name, v.Permitted, v.CA.Subject)
+}
+
+// nameConstraintViolations walks the name constraints of the CA certificates
+// in the chain, and reports all the names in the leaf certificate which
+// violate them. This is a best-effort explanation of a failed name constraint
+// check, rather than a full re-implementation of the check.
+func nameConstraintViolations(chain []*x509.Certificate) []nameConstraintViolation {
+	if len(chain) == 0 {
+		return nil
+	}
+	leaf := chain[0]
+	var violations []nameConstraintViolation
+	for _, ca := range chain[1:] {
+		check := func(nameType, name string, permitted, excluded []string, match func(constraint string) bool) {
+			for _, constraint := range excluded {
+				if match(constraint) {
+					violations = append(violations, nameConstraintViolation{CA: ca, NameType: nameType, Name: name, Excluded: constraint})
+					return
+				}
+			}
+			if len(permitted) == 0 {
+				return
+			}
+			for _, constraint := range permitted {
+				if match(constraint) {
+					return
+				}
+			}
+			violations = append(violations, nameConstraintViolation{CA: ca, NameType: nameType, Name: name, Permitted: permitted})
+		}
+
+		for _, name := range leaf.DNSNames {
+			check("DNS name", name, ca.PermittedDNSDomains, ca.ExcludedDNSDomains, func(constraint string) bool {
+				return matchDomain(name, constraint)
+			})
+		}
+		for _, email := range leaf.EmailAddresses {
+			check("email address", email, ca.PermittedEmailAddresses, ca.ExcludedEmailAddresses, func(constraint string) bool {
+				return matchEmail(email, constraint)
+			})
+		}
+		for _, uri := range leaf.URIs {
+			check("URI", uri.String(), ca.PermittedURIDomains, ca.ExcludedURIDomains, func(constraint string) bool {
+				return matchDomain(uri.Hostname(), constraint)
+			})
+		}
+		permitted, excluded := ipNetStrings(ca.PermittedIPRanges), ipNetStrings(ca.ExcludedIPRanges)
+		for _, ip := range leaf.IPAddresses {
+			check("IP address", ip.String(), permitted, excluded, func(constraint string) bool {
+				_, ipNet, err := net.ParseCIDR(constraint)
+				return err == nil && ipNet.Contains(ip)
+			})
+		}
+	}
+	return violations
+}
+
+// matchDomain reports whether the domain is within the subtree of the given
+// name constraint. A constraint with a leading period only matches
+// subdomains, and an empty constraint matches everything.
+func matchDomain(domain, constraint string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	constraint = strings.ToLower(constraint)
+	if constraint == "" {
+		return true
+	}
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(domain, constraint)
+	}
+	return domain == constraint || strings.HasSuffix(domain, "."+constraint)
+}
+
+// matchEmail reports whether the email address is within the subtree of the
+// given name constraint. As in RFC 5280 s4.2.1.10, a constraint with an "@"
+// is a single mailbox, a constraint with a leading period matches all
+// mailboxes at subdomains, and any other constraint matches all mailboxes at
+// that exact host.
+func matchEmail(email, constraint string) bool {
+	if strings.Contains(constraint, "@") {
+		return strings.EqualFold(email, constraint)
+	}
+	host := email[strings.LastIndex(email, "@")+1:]
+	if constraint == "" || strings.HasPrefix(constraint, ".") {
+		return matchDomain(host, constraint)
+	}
+	return strings.EqualFold(strings.TrimSuffix(host, "."), constraint)
+}
+
+func ipNetStrings(nets []*net.IPNet) []string {
+	var out []string
+	for _, n := range nets {
+		out = append(out, n.String())
+	}
+	return out
+}
+
+// parsePins parses a comma-separated list of base64-encoded SHA-256 SPKI
+// hashes.
+func parsePins(list string) ([][sha256.Size]byte, error) {
+	var pins [][sha256.Size]byte
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(p)
+		if err != nil {
+			return nil, fmt.Errorf("pin %q is not valid base64: %v", p, err)
+		}
+		if len(data) != sha256.Size {
+			return nil, fmt.Errorf("pin %q has length %d, want %d", p, len(data), sha256.Size)
+		}
+		var pin [sha256.Size]byte
+		copy(pin[:], data)
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// checkPins checks that the SubjectPublicKeyInfo of at least one certificate
+// in one of the verified chains hashes to one of the pins. Certificates which
+// were presented but are not part of a verified chain are not considered.
+func checkPins(chains [][]*x509.Certificate, pins [][sha256.Size]byte) error {
+	for _, chain := range chains {
+		for _, cert := range chain {
+			hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if hash == pin {
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("no certificate in %d verified chain(s) matches any of %d pins", len(chains), len(pins))
 }
 
 func checkRevocation(cert *x509.Certificate, verbose bool) error {
@@ -0,0 +1,330 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/OlegBabkin/certificate-transparency-go"
+	"github.com/OlegBabkin/certificate-transparency-go/ctutil"
+	"github.com/OlegBabkin/certificate-transparency-go/testdata"
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509/pkix"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+)
+
+func mustParseCert(t *testing.T, pemData string) *x509.Certificate {
+	t.Helper()
+	cert, err := x509util.CertificateFromPEM([]byte(pemData))
+	if x509.IsFatal(err) {
+		t.Fatalf("CertificateFromPEM(): %v", err)
+	}
+	return cert
+}
+
+func spkiPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func TestCheckPins(t *testing.T) {
+	leaf := mustParseCert(t, testdata.TestCertPEM)
+	ca := mustParseCert(t, testdata.CACertPEM)
+	otherHash := sha256.Sum256([]byte("some other key"))
+	otherPin := base64.StdEncoding.EncodeToString(otherHash[:])
+	chains := [][]*x509.Certificate{{leaf, ca}}
+
+	for _, tc := range []struct {
+		desc    string
+		pins    []string
+		wantErr bool
+	}{
+		{desc: "leaf-pin", pins: []string{spkiPin(leaf)}},
+		{desc: "ca-pin", pins: []string{spkiPin(ca)}},
+		{desc: "one-of-several", pins: []string{otherPin, spkiPin(ca)}},
+		{desc: "no-match", pins: []string{otherPin}, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			pins, err := parsePins(strings.Join(tc.pins, ", "))
+			if err != nil {
+				t.Fatalf("parsePins(): %v", err)
+			}
+			if len(pins) != len(tc.pins) {
+				t.Fatalf("parsePins() returned %d pins, want %d", len(pins), len(tc.pins))
+			}
+			err = checkPins(chains, pins)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("checkPins()=%v, want err=%v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPinsVerifiedOnly(t *testing.T) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(24 * time.Hour)
+	caTemplate := func(serial int64, name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             notBefore,
+			NotAfter:              notAfter,
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+	}
+	root, rootKey := issueCert(t, caTemplate(1, "Test Root"), nil, nil)
+	stray, _ := issueCert(t, caTemplate(2, "Unrelated Root"), nil, nil)
+	leaf, _ := issueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}, root, rootKey)
+
+	// The unrelated root is presented along with the chain, but is not part
+	// of any chain that verifies.
+	verified, err := validateChain([]*x509.Certificate{leaf, stray, root}, x509.VerifyOptions{}, "", "", false)
+	if err != nil {
+		t.Fatalf("validateChain()=%v, want nil", err)
+	}
+	for _, tc := range []struct {
+		desc    string
+		pin     *x509.Certificate
+		wantErr bool
+	}{
+		{desc: "verified-root", pin: root},
+		{desc: "unverified-stray", pin: stray, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			pins, err := parsePins(spkiPin(tc.pin))
+			if err != nil {
+				t.Fatalf("parsePins(): %v", err)
+			}
+			err = checkPins(verified, pins)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("checkPins()=%v, want err=%v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePinsErrors(t *testing.T) {
+	for _, list := range []string{
+		"not-base64!",
+		base64.StdEncoding.EncodeToString([]byte("too short")),
+	} {
+		if _, err := parsePins(list); err == nil {
+			t.Errorf("parsePins(%q)=nil, want error", list)
+		}
+	}
+}
+
+func TestShowEmbeddedSCTs(t *testing.T) {
+	keyDER, err := base64.StdEncoding.DecodeString(testdata.LogPublicKeyB64)
+	if err != nil {
+		t.Fatalf("failed to decode log key: %v", err)
+	}
+	pk, err := ct.PublicKeyFromB64(testdata.LogPublicKeyB64)
+	if err != nil {
+		t.Fatalf("PublicKeyFromB64(): %v", err)
+	}
+	verifier, err := ct.NewSignatureVerifier(pk)
+	if err != nil {
+		t.Fatalf("NewSignatureVerifier(): %v", err)
+	}
+	known := ctutil.LogInfoByHash{
+		sha256.Sum256(keyDER): {Description: "test log", Verifier: verifier},
+	}
+
+	embedded := mustParseCert(t, testdata.TestEmbeddedCertPEM)
+	invalid := mustParseCert(t, testdata.TestInvalidEmbeddedCertPEM)
+	plain := mustParseCert(t, testdata.TestCertPEM)
+	ca := mustParseCert(t, testdata.CACertPEM)
+
+	for _, tc := range []struct {
+		desc     string
+		chain    []*x509.Certificate
+		logs     ctutil.LogInfoByHash
+		wantErr  bool
+		wantText []string
+	}{
+		{desc: "no-scts", chain: []*x509.Certificate{plain, ca}, logs: known, wantText: []string{"Embedded SCTs: 0"}},
+		{desc: "display-only", chain: []*x509.Certificate{embedded}, wantText: []string{"Embedded SCTs: 1", "SCT[0]: log ID"}},
+		{desc: "verified", chain: []*x509.Certificate{embedded, ca}, logs: known, wantText: []string{`signature from log "test log" verified`}},
+		{desc: "invalid", chain: []*x509.Certificate{invalid, ca}, logs: known, wantErr: true, wantText: []string{"invalid"}},
+		{desc: "unknown-log", chain: []*x509.Certificate{embedded, ca}, logs: ctutil.LogInfoByHash{}, wantErr: true, wantText: []string{"unknown log"}},
+		{desc: "no-issuer", chain: []*x509.Certificate{embedded}, logs: known, wantErr: true, wantText: []string{"no issuer in chain"}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := showEmbeddedSCTs(&buf, tc.chain, tc.logs)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("showEmbeddedSCTs()=%v, want err=%v", err, tc.wantErr)
+			}
+			for _, want := range tc.wantText {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("showEmbeddedSCTs() output %q does not contain %q", buf.String(), want)
+				}
+			}
+		})
+	}
+}
+
+// issueCert creates a certificate from the template, signed by parent (or
+// self-signed if parent is nil), and returns it along with its key.
+func issueCert(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate(): %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate(): %v", err)
+	}
+	return cert, key
+}
+
+func TestNameConstraintViolations(t *testing.T) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(24 * time.Hour)
+	root, rootKey := issueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+	_, ipNet, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseCIDR(): %v", err)
+	}
+	inter, interKey := issueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Constrained Intermediate"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		PermittedDNSDomains:   []string{"example.com"},
+		ExcludedDNSDomains:    []string{"bad.example.com"},
+		PermittedIPRanges:     []*net.IPNet{ipNet},
+	}, root, rootKey)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	good := *leafTemplate
+	good.DNSNames = []string{"www.example.com", "example.com"}
+	good.IPAddresses = []net.IP{net.ParseIP("10.1.2.3")}
+	goodLeaf, _ := issueCert(t, &good, inter, interKey)
+	bad := *leafTemplate
+	bad.DNSNames = []string{"www.example.com", "host.bad.example.com", "www.example.org"}
+	bad.IPAddresses = []net.IP{net.ParseIP("192.168.1.1")}
+	badLeaf, _ := issueCert(t, &bad, inter, interKey)
+
+	opts := x509.VerifyOptions{}
+	if _, err := validateChain([]*x509.Certificate{goodLeaf, inter, root}, opts, "", "", false); err != nil {
+		t.Errorf("validateChain(good)=%v, want nil", err)
+	}
+	if got := nameConstraintViolations([]*x509.Certificate{goodLeaf, inter, root}); len(got) != 0 {
+		t.Errorf("nameConstraintViolations(good)=%v, want none", got)
+	}
+
+	badChain := []*x509.Certificate{badLeaf, inter, root}
+	_, err = validateChain(badChain, opts, "", "", false)
+	var cie x509.CertificateInvalidError
+	if !errors.As(err, &cie) || cie.Reason != x509.CANotAuthorizedForThisName {
+		t.Errorf("validateChain(bad)=%v, want CANotAuthorizedForThisName error", err)
+	}
+	got := nameConstraintViolations(badChain)
+	want := []string{
+		`DNS name "host.bad.example.com" is in excluded subtree "bad.example.com" of CA "CN=Constrained Intermediate"`,
+		`DNS name "www.example.org" is outside of permitted subtrees ["example.com"] of CA "CN=Constrained Intermediate"`,
+		`IP address "192.168.1.1" is outside of permitted subtrees ["10.0.0.0/8"] of CA "CN=Constrained Intermediate"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("nameConstraintViolations(bad)=%v, want %d violations", got, len(want))
+	}
+	for i, v := range got {
+		if v.String() != want[i] {
+			t.Errorf("violation[%d]=%q, want %q", i, v, want[i])
+		}
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	for _, tc := range []struct {
+		domain, constraint string
+		want               bool
+	}{
+		{domain: "example.com", constraint: "example.com", want: true},
+		{domain: "www.Example.com", constraint: "example.COM", want: true},
+		{domain: "wwwexample.com", constraint: "example.com", want: false},
+		{domain: "example.com", constraint: ".example.com", want: false},
+		{domain: "www.example.com", constraint: ".example.com", want: true},
+		{domain: "anything.org", constraint: "", want: true},
+	} {
+		if got := matchDomain(tc.domain, tc.constraint); got != tc.want {
+			t.Errorf("matchDomain(%q, %q)=%v, want %v", tc.domain, tc.constraint, got, tc.want)
+		}
+	}
+}
+
+func TestMatchEmail(t *testing.T) {
+	for _, tc := range []struct {
+		email, constraint string
+		want              bool
+	}{
+		{email: "a@example.com", constraint: "A@Example.com", want: true},
+		{email: "b@example.com", constraint: "a@example.com", want: false},
+		{email: "a@example.com", constraint: "example.com", want: true},
+		{email: "a@Example.com", constraint: "example.COM", want: true},
+		{email: "a@mail.example.com", constraint: "example.com", want: false},
+		{email: "a@mail.example.com", constraint: ".example.com", want: true},
+		{email: "a@example.com", constraint: ".example.com", want: false},
+		{email: "a@anything.org", constraint: "", want: true},
+	} {
+		if got := matchEmail(tc.email, tc.constraint); got != tc.want {
+			t.Errorf("matchEmail(%q, %q)=%v, want %v", tc.email, tc.constraint, got, tc.want)
+		}
+	}
+}
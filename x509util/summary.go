@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package x509util
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+)
+
+// ExtensionSummary describes a single certificate extension.
+type ExtensionSummary struct {
+	OID      string `json:"oid"`
+	Critical bool   `json:"critical"`
+}
+
+// CertificateSummary is a machine-readable description of a certificate,
+// suitable for encoding as JSON.
+type CertificateSummary struct {
+	Subject            string             `json:"subject"`
+	Issuer             string             `json:"issuer"`
+	SerialNumber       string             `json:"serial_number"`
+	NotBefore          time.Time          `json:"not_before"`
+	NotAfter           time.Time          `json:"not_after"`
+	SignatureAlgorithm string             `json:"signature_algorithm"`
+	PublicKeyAlgorithm string             `json:"public_key_algorithm"`
+	DNSNames           []string           `json:"dns_names,omitempty"`
+	EmailAddresses     []string           `json:"email_addresses,omitempty"`
+	IPAddresses        []string           `json:"ip_addresses,omitempty"`
+	URIs               []string           `json:"uris,omitempty"`
+	Extensions         []ExtensionSummary `json:"extensions,omitempty"`
+
+	// ParseErrors holds any non-fatal errors encountered when parsing the
+	// certificate (or the chain it came from).
+	ParseErrors []string `json:"parse_errors,omitempty"`
+	// Verified is set if chain validation was performed, and indicates
+	// whether it succeeded; VerifyError holds the reason for any failure.
+	Verified    *bool  `json:"verified,omitempty"`
+	VerifyError string `json:"verify_error,omitempty"`
+}
+
+// SummarizeCertificate builds a CertificateSummary for the given certificate.
+// The parse error and verification fields are left for the caller to fill in.
+func SummarizeCertificate(cert *x509.Certificate) *CertificateSummary {
+	s := &CertificateSummary{
+		Subject:            NameToString(cert.Subject),
+		Issuer:             NameToString(cert.Issuer),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		PublicKeyAlgorithm: publicKeyAlgorithmToString(cert.PublicKeyAlgorithm),
+		DNSNames:           cert.DNSNames,
+		EmailAddresses:     cert.EmailAddresses,
+	}
+	if cert.SerialNumber != nil {
+		s.SerialNumber = cert.SerialNumber.Text(16)
+	}
+	for _, ip := range cert.IPAddresses {
+		s.IPAddresses = append(s.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		s.URIs = append(s.URIs, uri.String())
+	}
+	for _, ext := range cert.Extensions {
+		s.Extensions = append(s.Extensions, ExtensionSummary{OID: ext.Id.String(), Critical: ext.Critical})
+	}
+	return s
+}
+
+// SetParseErrors records the non-fatal parse errors held in err, if any.
+func (s *CertificateSummary) SetParseErrors(err error) {
+	s.ParseErrors = nil
+	if err == nil {
+		return
+	}
+	if nfe, ok := err.(x509.NonFatalErrors); ok {
+		for _, e := range nfe.Errors {
+			s.ParseErrors = append(s.ParseErrors, e.Error())
+		}
+		return
+	}
+	s.ParseErrors = []string{err.Error()}
+}
+
+// SetVerifyResult records the outcome of chain validation.
+func (s *CertificateSummary) SetVerifyResult(err error) {
+	ok := err == nil
+	s.Verified = &ok
+	s.VerifyError = ""
+	if err != nil {
+		s.VerifyError = err.Error()
+	}
+}
+
+// CertificateToJSON generates a JSON description of the given certificate.
+func CertificateToJSON(cert *x509.Certificate) ([]byte, error) {
+	return json.Marshal(SummarizeCertificate(cert))
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package x509util_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/OlegBabkin/certificate-transparency-go/x509"
+	"github.com/OlegBabkin/certificate-transparency-go/x509util"
+)
+
+func TestCertificateSummaryJSON(t *testing.T) {
+	cert, err := x509util.CertificateFromPEM([]byte(pemCACert))
+	if err != nil {
+		t.Fatalf("CertificateFromPEM()=nil,%v; want _,nil", err)
+	}
+	summary := x509util.SummarizeCertificate(cert)
+	summary.SetParseErrors(x509.NonFatalErrors{Errors: []error{errors.New("parse oops")}})
+	summary.SetVerifyResult(errors.New("verify oops"))
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("json.Marshal()=nil,%v; want _,nil", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal()=%v; want nil", err)
+	}
+
+	for _, field := range []string{"subject", "issuer", "serial_number", "not_before", "not_after", "signature_algorithm", "public_key_algorithm", "extensions", "parse_errors", "verified", "verify_error"} {
+		if _, ok := got[field]; !ok {
+			t.Errorf("JSON output %s missing field %q", data, field)
+		}
+	}
+	if subject, _ := got["subject"].(string); !strings.Contains(subject, "Certificate Transparency CA") {
+		t.Errorf("subject=%q, want to contain %q", subject, "Certificate Transparency CA")
+	}
+	if got, want := got["serial_number"], "0"; got != want {
+		t.Errorf("serial_number=%v, want %q", got, want)
+	}
+	if got, want := got["public_key_algorithm"], "rsaEncryption"; got != want {
+		t.Errorf("public_key_algorithm=%v, want %q", got, want)
+	}
+	if got, want := len(summary.Extensions), len(cert.Extensions); got != want {
+		t.Errorf("len(extensions)=%d, want %d", got, want)
+	}
+	if got, want := got["verified"], false; got != want {
+		t.Errorf("verified=%v, want %v", got, want)
+	}
+	if got, want := got["verify_error"], "verify oops"; got != want {
+		t.Errorf("verify_error=%v, want %q", got, want)
+	}
+	if errs, _ := got["parse_errors"].([]interface{}); len(errs) != 1 || errs[0] != "parse oops" {
+		t.Errorf("parse_errors=%v, want [parse oops]", got["parse_errors"])
+	}
+}
+
+func TestCertificateToJSONOmitsUnsetResults(t *testing.T) {
+	cert, err := x509util.CertificateFromPEM([]byte(pemCACert))
+	if err != nil {
+		t.Fatalf("CertificateFromPEM()=nil,%v; want _,nil", err)
+	}
+	data, err := x509util.CertificateToJSON(cert)
+	if err != nil {
+		t.Fatalf("CertificateToJSON()=nil,%v; want _,nil", err)
+	}
+	for _, field := range []string{"parse_errors", "verified", "verify_error"} {
+		if strings.Contains(string(data), `"`+field+`"`) {
+			t.Errorf("CertificateToJSON()=%s, want no %q field", data, field)
+		}
+	}
+}
@@ -852,13 +852,7 @@ func ExtractSCT(sctData *x509.SerializedSCT) (*ct.SignedCertificateTimestamp, er
 	if sctData == nil {
 		return nil, errors.New("SCT is nil")
 	}
-	var sct ct.SignedCertificateTimestamp
-	if rest, err := tls.Unmarshal(sctData.Val, &sct); err != nil {
-		return nil, fmt.Errorf("error parsing SCT: %s", err)
-	} else if len(rest) > 0 {
-		return nil, fmt.Errorf("extra data (%d bytes) after serialized SCT", len(rest))
-	}
-	return &sct, nil
+	return ct.UnmarshalSCT(sctData.Val)
 }
 
 // MarshalSCTsIntoSCTList serializes SCTs into SCT list.
@@ -869,7 +863,7 @@ func MarshalSCTsIntoSCTList(scts []*ct.SignedCertificateTimestamp) (*x509.Signed
 		if sct == nil {
 			return nil, fmt.Errorf("SCT number %d is nil", i)
 		}
-		encd, err := tls.Marshal(*sct)
+		encd, err := ct.MarshalSCT(*sct)
 		if err != nil {
 			return nil, fmt.Errorf("error serializing SCT number %d: %s", i, err)
 		}